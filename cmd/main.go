@@ -1,15 +1,24 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
+	"time"
+	// users pick the time zone of their emails, the image may not ship one
+	_ "time/tzdata"
 
 	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
 	"github.com/99designs/gqlgen/graphql/playground"
 	"github.com/gin-gonic/gin"
 	"github.com/nurcholisnanda/tigerhall-kittens/config"
 	"github.com/nurcholisnanda/tigerhall-kittens/internal/api/graph"
 	"github.com/nurcholisnanda/tigerhall-kittens/internal/api/graph/directive"
+	"github.com/nurcholisnanda/tigerhall-kittens/internal/api/graph/model"
+	"github.com/nurcholisnanda/tigerhall-kittens/internal/api/handlers"
 	"github.com/nurcholisnanda/tigerhall-kittens/internal/api/middlewares"
 	"github.com/nurcholisnanda/tigerhall-kittens/internal/repository"
 	"github.com/nurcholisnanda/tigerhall-kittens/internal/service"
@@ -23,17 +32,42 @@ func graphqlHandler(
 	userSvc service.UserService,
 	tigerSvc service.TigerService,
 	sightingSvc service.SightingService,
+	notificationSvc service.NotificationService,
+	watchZoneSvc service.WatchZoneService,
+	events service.EventBroker,
+	webhookSvc service.WebhookService,
+	authMiddleware *middlewares.AuthMiddleware,
 ) gin.HandlerFunc {
 	// NewExecutableSchema and Config are in the generated.go file
 	// Resolver is in the resolver.go file
 	c := graph.Config{Resolvers: &graph.Resolver{
-		UserSvc:     userSvc,
-		TigerSvc:    tigerSvc,
-		SightingSvc: sightingSvc,
+		UserSvc:         userSvc,
+		TigerSvc:        tigerSvc,
+		SightingSvc:     sightingSvc,
+		NotificationSvc: notificationSvc,
+		WatchZoneSvc:    watchZoneSvc,
+		Events:          events,
+		WebhookSvc:      webhookSvc,
 	}}
 	c.Directives.Auth = directive.Auth
+	c.Directives.HasRole = directive.HasRole
 
-	h := handler.NewDefaultServer(graph.NewExecutableSchema(c))
+	h := handler.New(graph.NewExecutableSchema(c))
+	// subscriptions are served over graphql-ws and authenticate during
+	// connection init
+	h.AddTransport(transport.Websocket{
+		KeepAlivePingInterval: 10 * time.Second,
+		InitFunc:              authMiddleware.WebsocketInit,
+	})
+	h.AddTransport(transport.Options{})
+	h.AddTransport(transport.GET{})
+	h.AddTransport(transport.POST{})
+	h.AddTransport(transport.MultipartForm{})
+	h.SetQueryCache(lru.New(1000))
+	h.Use(extension.Introspection{})
+	h.Use(extension.AutomaticPersistedQuery{
+		Cache: lru.New(100),
+	})
 
 	return func(c *gin.Context) {
 		h.ServeHTTP(c.Writer, c.Request)
@@ -58,6 +92,22 @@ func main() {
 	db.AutoMigrate() // Automatically migrate database schema
 	gormDB := db.GetDB()
 
+	//setup image storage
+	blobStore, err := config.NewBlobStore()
+	if err != nil {
+		log.Panic(err)
+	}
+	urlSigner, err := config.NewURLSigner()
+	if err != nil {
+		log.Panic(err)
+	}
+
+	//setup notifications
+	notifier, err := config.NewNotifier()
+	if err != nil {
+		log.Panic(err)
+	}
+
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = defaultPort
@@ -67,13 +117,28 @@ func main() {
 	userRepo := repository.NewUserRepoImpl(gormDB)
 	tigerRepo := repository.NewTigerRepositoryImpl(gormDB)
 	sightingRepo := repository.NewSightingRepositoryImpl(gormDB)
+	sightingImageRepo := repository.NewSightingImageRepositoryImpl(gormDB)
+	notificationRepo := repository.NewNotificationRepositoryImpl(gormDB)
+	watchZoneRepo := repository.NewWatchZoneRepositoryImpl(gormDB)
+	webhookRepo := repository.NewWebhookRepositoryImpl(gormDB)
 	JWT := service.NewJWT(os.Getenv("SECRET"))
 	userSvc := service.NewUserService(userRepo, bcrypt.NewBcrypt(), JWT)
-	tigerSvc := service.NewTigerService(tigerRepo)
-	sightingSvc := service.NewSightingService(sightingRepo, tigerRepo)
+	webhookSvc := service.NewWebhookService(webhookRepo, service.NewWebhookClient())
+	webhookSvc.Start(context.Background())
+	tigerSvc := service.NewTigerService(tigerRepo, sightingImageRepo)
+	imageProcessor := service.NewImageProcessor(sightingImageRepo, sightingRepo, blobStore, config.ImageRenditions(), config.ImageWorkers())
+	imageProcessor.Start(context.Background())
+	events := service.NewEventBroker(notificationRepo)
+	sightingSvc := service.NewSightingService(sightingRepo, tigerRepo, sightingImageRepo, blobStore, urlSigner, config.ImageURLTTL(),
+		config.ImageUploadLimits(), imageProcessor, watchZoneRepo, events)
+	watchZoneSvc := service.NewWatchZoneService(watchZoneRepo)
 	authMiddleware := middlewares.NewAuthMiddleware(userSvc, JWT)
-	notificationSvc := service.NewNotificationService(sightingRepo, userRepo)
-	notificationSvc.StartNotificationConsumer()
+	notificationSvc := service.NewNotificationService(notificationRepo, userRepo, sightingRepo, tigerRepo, sightingImageRepo, notifier, urlSigner)
+	notificationSvc.Start(context.Background())
+	exportSvc := service.NewExportService(tigerRepo, sightingRepo, userRepo, urlSigner)
+	exportHandler := handlers.NewExportHandler(sightingSvc, exportSvc)
+	imageHandler := handlers.NewImageHandler(blobStore, urlSigner)
+	unsubscribeHandler := handlers.NewUnsubscribeHandler(userSvc, urlSigner)
 
 	// Setting up Gin
 	r := gin.Default()
@@ -82,8 +147,20 @@ func main() {
 		middlewares.RequestIDMiddleware(),
 		middlewares.LoggerMiddleware(),
 	)
-	r.POST("/query", graphqlHandler(userSvc, tigerSvc, sightingSvc))
+	graphql := graphqlHandler(userSvc, tigerSvc, sightingSvc, notificationSvc, watchZoneSvc, events, webhookSvc, authMiddleware)
+	r.GET("/query", graphql)
+	r.POST("/query", graphql)
 	r.GET("/", playgroundHandler())
+	r.GET("/tigers/:id/track", exportHandler.TigerTrack())
+	r.GET("/images/*key", imageHandler.Serve())
+	r.GET("/unsubscribe/:userID", unsubscribeHandler.Confirm())
+	r.POST("/unsubscribe/:userID", unsubscribeHandler.Unsubscribe())
+	r.GET("/unsubscribe/:userID/tigers/:tigerID", unsubscribeHandler.Confirm())
+	r.POST("/unsubscribe/:userID/tigers/:tigerID", unsubscribeHandler.Unsubscribe())
+	exportGroup := r.Group("/export", middlewares.RequireRole(string(model.RoleResearcher), string(model.RoleAdmin)))
+	exportGroup.GET("/tigers", exportHandler.Tigers())
+	exportGroup.GET("/sightings", exportHandler.Sightings())
+	exportGroup.GET("/dwca", exportHandler.DarwinCore())
 
 	log.Printf("connect to http://localhost:%s/ for GraphQL playground", port)
 	r.Run()
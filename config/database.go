@@ -44,5 +44,7 @@ func (r *database) GetDB() *gorm.DB {
 
 // AutoMigrate performs automatic schema migration for defined models.
 func (r *database) AutoMigrate() error {
-	return r.db.AutoMigrate(&model.User{}, &model.Tiger{}, &model.Sighting{})
+	return r.db.AutoMigrate(&model.User{}, &model.Tiger{}, &model.Sighting{}, &model.SightingImage{},
+		&model.NotificationJob{}, &model.Notification{}, &model.NotificationPreferences{}, &model.MutedTiger{},
+		&model.TigerFollow{}, &model.WatchZone{}, &model.WebhookSubscription{}, &model.WebhookDelivery{})
 }
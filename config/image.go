@@ -0,0 +1,69 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/nurcholisnanda/tigerhall-kittens/pkg/imaging"
+)
+
+const (
+	defaultMaxUploadBytes = 10 << 20
+	defaultMaxImageWidth  = 8000
+	defaultMaxImageHeight = 8000
+	defaultImageWorkers   = 4
+)
+
+// defaultRenditions are the sizes images are scaled to when no
+// IMAGE_<NAME>_SIZE variable overrides them
+var defaultRenditions = []imaging.Rendition{
+	{Name: "thumbnail", MaxWidth: 200, MaxHeight: 200},
+	{Name: "medium", MaxWidth: 800, MaxHeight: 800},
+	{Name: "large", MaxWidth: 1600, MaxHeight: 1600},
+}
+
+// ImageRenditions returns the bounds uploaded images are scaled down to.
+// Each rendition can be overridden with IMAGE_THUMBNAIL_SIZE,
+// IMAGE_MEDIUM_SIZE or IMAGE_LARGE_SIZE set to "<width>x<height>".
+func ImageRenditions() []imaging.Rendition {
+	renditions := make([]imaging.Rendition, 0, len(defaultRenditions))
+	for _, rendition := range defaultRenditions {
+		if bounds := os.Getenv("IMAGE_" + strings.ToUpper(rendition.Name) + "_SIZE"); bounds != "" {
+			if width, height, err := imaging.ParseBounds(bounds); err == nil {
+				rendition.MaxWidth, rendition.MaxHeight = width, height
+			}
+		}
+		renditions = append(renditions, rendition)
+	}
+	return renditions
+}
+
+// ImageUploadLimits returns the limits uploads are checked against before
+// they are decoded. IMAGE_MAX_UPLOAD_BYTES caps the file size and
+// IMAGE_MAX_DIMENSIONS, as "<width>x<height>", caps the pixel dimensions.
+func ImageUploadLimits() imaging.Limits {
+	limits := imaging.Limits{
+		MaxBytes:  defaultMaxUploadBytes,
+		MaxWidth:  defaultMaxImageWidth,
+		MaxHeight: defaultMaxImageHeight,
+	}
+	if maxBytes, err := strconv.ParseInt(os.Getenv("IMAGE_MAX_UPLOAD_BYTES"), 10, 64); err == nil && maxBytes > 0 {
+		limits.MaxBytes = maxBytes
+	}
+	if bounds := os.Getenv("IMAGE_MAX_DIMENSIONS"); bounds != "" {
+		if width, height, err := imaging.ParseBounds(bounds); err == nil {
+			limits.MaxWidth, limits.MaxHeight = width, height
+		}
+	}
+	return limits
+}
+
+// ImageWorkers returns how many images are processed at the same time, set
+// with IMAGE_WORKERS
+func ImageWorkers() int {
+	if workers, err := strconv.Atoi(os.Getenv("IMAGE_WORKERS")); err == nil && workers > 0 {
+		return workers
+	}
+	return defaultImageWorkers
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/nurcholisnanda/tigerhall-kittens/pkg/notifier"
+)
+
+const (
+	defaultNotifierFile = "data/notifications.log"
+	defaultSMTPPort     = 587
+	defaultSMTPFromName = "TigerHall Kittens"
+)
+
+// NewNotifier initializes the notifier selected by NOTIFIER. "log" (the
+// default) writes notifications to stdout, "file" appends them to
+// NOTIFIER_FILE and "smtp" delivers them through the SMTP_* settings.
+func NewNotifier() (notifier.Notifier, error) {
+	switch backend := os.Getenv("NOTIFIER"); backend {
+	case "", "log":
+		return notifier.NewWriterNotifier(os.Stdout), nil
+	case "file":
+		path := os.Getenv("NOTIFIER_FILE")
+		if path == "" {
+			path = defaultNotifierFile
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return nil, fmt.Errorf("error creating notification log directory: %w", err)
+		}
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("error opening notification log: %w", err)
+		}
+		return notifier.NewWriterNotifier(file), nil
+	case "smtp":
+		port := defaultSMTPPort
+		if value := os.Getenv("SMTP_PORT"); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid SMTP_PORT %q", value)
+			}
+			port = parsed
+		}
+		tlsMode := notifier.TLSMode(os.Getenv("SMTP_TLS"))
+		if tlsMode == "" {
+			tlsMode = notifier.TLSStartTLS
+		}
+		fromName := os.Getenv("SMTP_FROM_NAME")
+		if fromName == "" {
+			fromName = defaultSMTPFromName
+		}
+		return notifier.NewSMTPNotifier(notifier.SMTPConfig{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     port,
+			TLSMode:  tlsMode,
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     os.Getenv("SMTP_FROM"),
+			FromName: fromName,
+		})
+	default:
+		return nil, fmt.Errorf("unknown notifier %q", backend)
+	}
+}
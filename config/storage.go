@@ -0,0 +1,70 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/nurcholisnanda/tigerhall-kittens/pkg/storage"
+	"github.com/nurcholisnanda/tigerhall-kittens/pkg/urlsign"
+)
+
+const (
+	defaultStorageDir  = "data/images"
+	defaultPublicURL   = "http://localhost:8080"
+	defaultImageURLTTL = time.Hour
+)
+
+// PublicURL returns the address clients reach the API on
+func PublicURL() string {
+	if url := os.Getenv("PUBLIC_URL"); url != "" {
+		return url
+	}
+	return defaultPublicURL
+}
+
+// StorageDir returns the directory the local image store writes to
+func StorageDir() string {
+	if dir := os.Getenv("STORAGE_LOCAL_DIR"); dir != "" {
+		return dir
+	}
+	return defaultStorageDir
+}
+
+// NewBlobStore initializes the image store selected by STORAGE_BACKEND.
+// "local" (the default) keeps files on disk, "s3" uses an S3 compatible bucket.
+func NewBlobStore() (storage.BlobStore, error) {
+	switch backend := os.Getenv("STORAGE_BACKEND"); backend {
+	case "", "local":
+		return storage.NewLocalStore(StorageDir())
+	case "s3":
+		return storage.NewS3Store(storage.S3Config{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Region:    os.Getenv("S3_REGION"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+		}, nil)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", backend)
+	}
+}
+
+// NewURLSigner initializes the signer for image URLs with
+// IMAGE_SIGNING_SECRET. The key is kept apart from the JWT SECRET and is
+// required, an empty key would let anyone forge links.
+func NewURLSigner() (*urlsign.Signer, error) {
+	secret := os.Getenv("IMAGE_SIGNING_SECRET")
+	if secret == "" {
+		return nil, fmt.Errorf("IMAGE_SIGNING_SECRET is required")
+	}
+	return urlsign.NewSigner(secret, PublicURL()), nil
+}
+
+// ImageURLTTL returns how long signed image URLs stay valid
+func ImageURLTTL() time.Duration {
+	if ttl, err := time.ParseDuration(os.Getenv("IMAGE_URL_TTL")); err == nil && ttl > 0 {
+		return ttl
+	}
+	return defaultImageURLTTL
+}
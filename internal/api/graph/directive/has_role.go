@@ -0,0 +1,31 @@
+package directive
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/nurcholisnanda/tigerhall-kittens/internal/api/graph/model"
+	"github.com/nurcholisnanda/tigerhall-kittens/pkg/helper"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// HasRole only lets users with one of the given roles through
+func HasRole(ctx context.Context, obj interface{}, next graphql.Resolver, roles []model.Role) (interface{}, error) {
+	role, err := helper.GetUserRole(ctx)
+	if err != nil {
+		return nil, &gqlerror.Error{
+			Message: "Access Denied",
+		}
+	}
+	for _, allowed := range roles {
+		if role == string(allowed) {
+			return next(ctx)
+		}
+	}
+	return nil, &gqlerror.Error{
+		Message: "Forbidden",
+		Extensions: map[string]interface{}{
+			"code": helper.FORBIDDEN,
+		},
+	}
+}
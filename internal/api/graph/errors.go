@@ -0,0 +1,36 @@
+package graph
+
+import (
+	"context"
+
+	"github.com/nurcholisnanda/tigerhall-kittens/pkg/helper"
+	"github.com/sirupsen/logrus"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// geoSearchError maps errors returned by the location based searches to
+// GraphQL errors.
+func geoSearchError(ctx context.Context, err error) error {
+	switch err.(type) {
+	case *helper.InvalidCoordinatesError:
+		return &gqlerror.Error{
+			Message: "invalid coordinates",
+			Extensions: map[string]interface{}{
+				"code":    helper.INVALID_INPUT,
+				"details": err.Error(),
+			},
+		}
+	case *helper.InvalidRadiusError:
+		return &gqlerror.Error{
+			Message: "invalid radius",
+			Extensions: map[string]interface{}{
+				"code":    helper.INVALID_INPUT,
+				"details": err.Error(),
+			},
+		}
+	default:
+		// Log the unexpected error for investigation
+		logrus.Error(ctx, "Unexpected error searching by location", "error:", err.Error())
+		return gqlerror.Errorf("Internal Server Error")
+	}
+}
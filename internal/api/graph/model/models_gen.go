@@ -3,6 +3,9 @@
 package model
 
 import (
+	"fmt"
+	"io"
+	"strconv"
 	"time"
 
 	"github.com/99designs/gqlgen/graphql"
@@ -13,9 +16,16 @@ type AuthOps struct {
 	Register interface{} `json:"register"`
 }
 
+type BoundingBox struct {
+	SouthWest *LastSeenCoordinateInput `json:"southWest"`
+	NorthEast *LastSeenCoordinateInput `json:"northEast"`
+}
+
 type CreateOps struct {
-	CreateSighting *Sighting `json:"createSighting"`
-	CreateTiger    *Tiger    `json:"createTiger"`
+	CreateSighting            *Sighting            `json:"createSighting"`
+	CreateTiger               *Tiger               `json:"createTiger"`
+	CreateWatchZone           *WatchZone           `json:"createWatchZone"`
+	CreateWebhookSubscription *WebhookSubscription `json:"createWebhookSubscription"`
 }
 
 type LastSeenCoordinateInput struct {
@@ -24,27 +34,88 @@ type LastSeenCoordinateInput struct {
 }
 
 type ListOps struct {
-	ListTigers    []*Tiger    `json:"listTigers"`
-	ListSightings []*Sighting `json:"listSightings"`
+	ListTigers              []*Tiger                `json:"listTigers"`
+	ListSightings           []*Sighting             `json:"listSightings"`
+	SightingsNear           []*NearbySighting       `json:"sightingsNear"`
+	SightingsInBox          []*NearbySighting       `json:"sightingsInBox"`
+	TigersNear              []*NearbyTiger          `json:"tigersNear"`
+	TigerTrack              *TigerTrack             `json:"tigerTrack"`
+	UnidentifiedSightings   []*Sighting             `json:"unidentifiedSightings"`
+	SuggestTigers           []*TigerSuggestion      `json:"suggestTigers"`
+	Notifications           *NotificationConnection `json:"notifications"`
+	UnreadNotificationCount int                     `json:"unreadNotificationCount"`
+	WatchZones              []*WatchZone            `json:"watchZones"`
+	NotificationJobs        []*NotificationJob      `json:"notificationJobs"`
+	WebhookSubscriptions    []*WebhookSubscription  `json:"webhookSubscriptions"`
+	WebhookDeliveries       []*WebhookDelivery      `json:"webhookDeliveries"`
 }
 
 type Mutation struct {
 }
 
+type NearbySighting struct {
+	Sighting *Sighting `json:"sighting"`
+	Distance float64   `json:"distance"`
+}
+
+type NearbyTiger struct {
+	Tiger       *Tiger  `json:"tiger"`
+	Distance    float64 `json:"distance"`
+	LastSeenAge int     `json:"lastSeenAge"`
+}
+
 type NewUser struct {
 	Name     string `json:"name"`
 	Email    string `json:"email"`
 	Password string `json:"password"`
 }
 
+type NotificationConnection struct {
+	Edges    []*NotificationEdge `json:"edges"`
+	PageInfo *PageInfo           `json:"pageInfo"`
+}
+
+type NotificationEdge struct {
+	Cursor string        `json:"cursor"`
+	Node   *Notification `json:"node"`
+}
+
+type NotificationPreferencesInput struct {
+	EmailEnabled    *bool            `json:"emailEnabled,omitempty"`
+	DigestFrequency *DigestFrequency `json:"digestFrequency,omitempty"`
+	Locale          *Locale          `json:"locale,omitempty"`
+	Timezone        *string          `json:"timezone,omitempty"`
+	MuteTigerIDs    []string         `json:"muteTigerIDs,omitempty"`
+	UnmuteTigerIDs  []string         `json:"unmuteTigerIDs,omitempty"`
+}
+
+type PageInfo struct {
+	EndCursor   *string `json:"endCursor,omitempty"`
+	HasNextPage bool    `json:"hasNextPage"`
+}
+
+type PhotoConnection struct {
+	Edges    []*PhotoEdge `json:"edges"`
+	PageInfo *PageInfo    `json:"pageInfo"`
+}
+
+type PhotoEdge struct {
+	Cursor string         `json:"cursor"`
+	Node   *SightingImage `json:"node"`
+}
+
 type Query struct {
 }
 
 type SightingInput struct {
-	TigerID            string                   `json:"tigerID"`
-	LastSeenTime       time.Time                `json:"lastSeenTime"`
-	LastSeenCoordinate *LastSeenCoordinateInput `json:"lastSeenCoordinate"`
-	Image              *graphql.Upload          `json:"image,omitempty"`
+	TigerID            *string                  `json:"tigerID,omitempty"`
+	LastSeenTime       *time.Time               `json:"lastSeenTime,omitempty"`
+	LastSeenCoordinate *LastSeenCoordinateInput `json:"lastSeenCoordinate,omitempty"`
+	Images             []*graphql.Upload        `json:"images,omitempty"`
+	Captions           []*string                `json:"captions,omitempty"`
+}
+
+type Subscription struct {
 }
 
 type TigerInput struct {
@@ -52,4 +123,483 @@ type TigerInput struct {
 	DateOfBirth        time.Time                `json:"dateOfBirth"`
 	LastSeenTime       time.Time                `json:"lastSeenTime"`
 	LastSeenCoordinate *LastSeenCoordinateInput `json:"lastSeenCoordinate"`
-}
\ No newline at end of file
+	Sensitive          *bool                    `json:"sensitive,omitempty"`
+}
+
+type TigerSuggestion struct {
+	Tiger        *Tiger         `json:"tiger"`
+	Score        float64        `json:"score"`
+	Similarity   float64        `json:"similarity"`
+	Distance     *float64       `json:"distance,omitempty"`
+	MatchedImage *SightingImage `json:"matchedImage"`
+}
+
+type TigerTrack struct {
+	Tiger         *Tiger      `json:"tiger"`
+	Sightings     []*Sighting `json:"sightings"`
+	TotalDistance float64     `json:"totalDistance"`
+}
+
+type TimeRangeInput struct {
+	From *time.Time `json:"from,omitempty"`
+	To   *time.Time `json:"to,omitempty"`
+}
+
+type UpdateOps struct {
+	SetTigerProfilePhoto          *Tiger                   `json:"setTigerProfilePhoto"`
+	AssignSighting                *Sighting                `json:"assignSighting"`
+	FollowTiger                   *Tiger                   `json:"followTiger"`
+	UnfollowTiger                 *Tiger                   `json:"unfollowTiger"`
+	UpdateNotificationPreferences *NotificationPreferences `json:"updateNotificationPreferences"`
+	MarkNotificationRead          *Notification            `json:"markNotificationRead"`
+	MarkAllNotificationsRead      int                      `json:"markAllNotificationsRead"`
+	ReplayNotificationJob         *NotificationJob         `json:"replayNotificationJob"`
+	DeleteWebhookSubscription     *WebhookSubscription     `json:"deleteWebhookSubscription"`
+}
+
+type WatchZoneInput struct {
+	Name         string                     `json:"name"`
+	Polygon      []*LastSeenCoordinateInput `json:"polygon,omitempty"`
+	Center       *LastSeenCoordinateInput   `json:"center,omitempty"`
+	RadiusMeters *float64                   `json:"radiusMeters,omitempty"`
+}
+
+type WebhookSubscriptionInput struct {
+	URL    string         `json:"url"`
+	Events []WebhookEvent `json:"events"`
+	Secret string         `json:"secret"`
+}
+
+type DigestFrequency string
+
+const (
+	DigestFrequencyImmediate DigestFrequency = "IMMEDIATE"
+	DigestFrequencyHourly    DigestFrequency = "HOURLY"
+	DigestFrequencyDaily     DigestFrequency = "DAILY"
+)
+
+var AllDigestFrequency = []DigestFrequency{
+	DigestFrequencyImmediate,
+	DigestFrequencyHourly,
+	DigestFrequencyDaily,
+}
+
+func (e DigestFrequency) IsValid() bool {
+	switch e {
+	case DigestFrequencyImmediate, DigestFrequencyHourly, DigestFrequencyDaily:
+		return true
+	}
+	return false
+}
+
+func (e DigestFrequency) String() string {
+	return string(e)
+}
+
+func (e *DigestFrequency) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = DigestFrequency(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid DigestFrequency", str)
+	}
+	return nil
+}
+
+func (e DigestFrequency) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type ImageSize string
+
+const (
+	ImageSizeThumbnail ImageSize = "THUMBNAIL"
+	ImageSizeMedium    ImageSize = "MEDIUM"
+	ImageSizeLarge     ImageSize = "LARGE"
+	ImageSizeOriginal  ImageSize = "ORIGINAL"
+)
+
+var AllImageSize = []ImageSize{
+	ImageSizeThumbnail,
+	ImageSizeMedium,
+	ImageSizeLarge,
+	ImageSizeOriginal,
+}
+
+func (e ImageSize) IsValid() bool {
+	switch e {
+	case ImageSizeThumbnail, ImageSizeMedium, ImageSizeLarge, ImageSizeOriginal:
+		return true
+	}
+	return false
+}
+
+func (e ImageSize) String() string {
+	return string(e)
+}
+
+func (e *ImageSize) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ImageSize(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ImageSize", str)
+	}
+	return nil
+}
+
+func (e ImageSize) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type ImageStatus string
+
+const (
+	ImageStatusPending    ImageStatus = "PENDING"
+	ImageStatusProcessing ImageStatus = "PROCESSING"
+	ImageStatusReady      ImageStatus = "READY"
+	ImageStatusFailed     ImageStatus = "FAILED"
+)
+
+var AllImageStatus = []ImageStatus{
+	ImageStatusPending,
+	ImageStatusProcessing,
+	ImageStatusReady,
+	ImageStatusFailed,
+}
+
+func (e ImageStatus) IsValid() bool {
+	switch e {
+	case ImageStatusPending, ImageStatusProcessing, ImageStatusReady, ImageStatusFailed:
+		return true
+	}
+	return false
+}
+
+func (e ImageStatus) String() string {
+	return string(e)
+}
+
+func (e *ImageStatus) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ImageStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ImageStatus", str)
+	}
+	return nil
+}
+
+func (e ImageStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type Locale string
+
+const (
+	LocaleEn Locale = "EN"
+	LocaleID Locale = "ID"
+)
+
+var AllLocale = []Locale{
+	LocaleEn,
+	LocaleID,
+}
+
+func (e Locale) IsValid() bool {
+	switch e {
+	case LocaleEn, LocaleID:
+		return true
+	}
+	return false
+}
+
+func (e Locale) String() string {
+	return string(e)
+}
+
+func (e *Locale) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = Locale(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid Locale", str)
+	}
+	return nil
+}
+
+func (e Locale) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type NotificationStatus string
+
+const (
+	NotificationStatusPending   NotificationStatus = "PENDING"
+	NotificationStatusDelivered NotificationStatus = "DELIVERED"
+	NotificationStatusSkipped   NotificationStatus = "SKIPPED"
+	NotificationStatusBatched   NotificationStatus = "BATCHED"
+	NotificationStatusDead      NotificationStatus = "DEAD"
+)
+
+var AllNotificationStatus = []NotificationStatus{
+	NotificationStatusPending,
+	NotificationStatusDelivered,
+	NotificationStatusSkipped,
+	NotificationStatusBatched,
+	NotificationStatusDead,
+}
+
+func (e NotificationStatus) IsValid() bool {
+	switch e {
+	case NotificationStatusPending, NotificationStatusDelivered, NotificationStatusSkipped, NotificationStatusBatched, NotificationStatusDead:
+		return true
+	}
+	return false
+}
+
+func (e NotificationStatus) String() string {
+	return string(e)
+}
+
+func (e *NotificationStatus) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = NotificationStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid NotificationStatus", str)
+	}
+	return nil
+}
+
+func (e NotificationStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type NotificationType string
+
+const (
+	NotificationTypeSighting  NotificationType = "SIGHTING"
+	NotificationTypeWatchZone NotificationType = "WATCH_ZONE"
+)
+
+var AllNotificationType = []NotificationType{
+	NotificationTypeSighting,
+	NotificationTypeWatchZone,
+}
+
+func (e NotificationType) IsValid() bool {
+	switch e {
+	case NotificationTypeSighting, NotificationTypeWatchZone:
+		return true
+	}
+	return false
+}
+
+func (e NotificationType) String() string {
+	return string(e)
+}
+
+func (e *NotificationType) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = NotificationType(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid NotificationType", str)
+	}
+	return nil
+}
+
+func (e NotificationType) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type Role string
+
+const (
+	RoleUser       Role = "USER"
+	RoleResearcher Role = "RESEARCHER"
+	RoleCurator    Role = "CURATOR"
+	RoleAdmin      Role = "ADMIN"
+)
+
+var AllRole = []Role{
+	RoleUser,
+	RoleResearcher,
+	RoleCurator,
+	RoleAdmin,
+}
+
+func (e Role) IsValid() bool {
+	switch e {
+	case RoleUser, RoleResearcher, RoleCurator, RoleAdmin:
+		return true
+	}
+	return false
+}
+
+func (e Role) String() string {
+	return string(e)
+}
+
+func (e *Role) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = Role(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid Role", str)
+	}
+	return nil
+}
+
+func (e Role) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type WatchZoneShape string
+
+const (
+	WatchZoneShapePolygon WatchZoneShape = "POLYGON"
+	WatchZoneShapeCircle  WatchZoneShape = "CIRCLE"
+)
+
+var AllWatchZoneShape = []WatchZoneShape{
+	WatchZoneShapePolygon,
+	WatchZoneShapeCircle,
+}
+
+func (e WatchZoneShape) IsValid() bool {
+	switch e {
+	case WatchZoneShapePolygon, WatchZoneShapeCircle:
+		return true
+	}
+	return false
+}
+
+func (e WatchZoneShape) String() string {
+	return string(e)
+}
+
+func (e *WatchZoneShape) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = WatchZoneShape(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid WatchZoneShape", str)
+	}
+	return nil
+}
+
+func (e WatchZoneShape) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryStatusPending   WebhookDeliveryStatus = "PENDING"
+	WebhookDeliveryStatusDelivered WebhookDeliveryStatus = "DELIVERED"
+	WebhookDeliveryStatusDead      WebhookDeliveryStatus = "DEAD"
+)
+
+var AllWebhookDeliveryStatus = []WebhookDeliveryStatus{
+	WebhookDeliveryStatusPending,
+	WebhookDeliveryStatusDelivered,
+	WebhookDeliveryStatusDead,
+}
+
+func (e WebhookDeliveryStatus) IsValid() bool {
+	switch e {
+	case WebhookDeliveryStatusPending, WebhookDeliveryStatusDelivered, WebhookDeliveryStatusDead:
+		return true
+	}
+	return false
+}
+
+func (e WebhookDeliveryStatus) String() string {
+	return string(e)
+}
+
+func (e *WebhookDeliveryStatus) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = WebhookDeliveryStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid WebhookDeliveryStatus", str)
+	}
+	return nil
+}
+
+func (e WebhookDeliveryStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type WebhookEvent string
+
+const (
+	WebhookEventSightingCreated WebhookEvent = "SIGHTING_CREATED"
+	WebhookEventTigerCreated    WebhookEvent = "TIGER_CREATED"
+	WebhookEventTigerUpdated    WebhookEvent = "TIGER_UPDATED"
+)
+
+var AllWebhookEvent = []WebhookEvent{
+	WebhookEventSightingCreated,
+	WebhookEventTigerCreated,
+	WebhookEventTigerUpdated,
+}
+
+func (e WebhookEvent) IsValid() bool {
+	switch e {
+	case WebhookEventSightingCreated, WebhookEventTigerCreated, WebhookEventTigerUpdated:
+		return true
+	}
+	return false
+}
+
+func (e WebhookEvent) String() string {
+	return string(e)
+}
+
+func (e *WebhookEvent) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = WebhookEvent(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid WebhookEvent", str)
+	}
+	return nil
+}
+
+func (e WebhookEvent) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
@@ -2,8 +2,47 @@ package model // Adjust the package name based on your project structure
 
 import "time"
 
+// NotificationJob is an outbox entry telling one user about a sighting. Jobs
+// are written in the same transaction as the sighting and delivered by the
+// notification dispatcher, so they survive restarts and failed deliveries
+// are retried.
+type NotificationJob struct {
+	ID            string             `json:"id"`
+	UserID        string             `json:"userID" gorm:"not null;index"`
+	Type          NotificationType   `json:"type" gorm:"type:varchar(30);not null;default:SIGHTING"`
+	TigerID       *string            `json:"tigerID"` // nil for a zone alert about an unidentified sighting
+	SightingID    string             `json:"sightingID" gorm:"not null;index"`
+	WatchZoneID   *string            `json:"watchZoneID"`
+	Status        NotificationStatus `json:"status" gorm:"type:varchar(20);not null;default:PENDING;index:,composite:due"`
+	Attempts      int                `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt time.Time          `json:"nextAttemptAt" gorm:"not null;index:,composite:due"`
+	LastError     *string            `json:"lastError"`
+	DeliveredAt   *time.Time         `json:"deliveredAt"`
+	CreatedAt     time.Time          `json:"createdAt"`
+	UpdatedAt     time.Time          `json:"updatedAt"`
+}
+
+// Notification is an entry in a user's in-app inbox. It is written with the
+// notification jobs, so it is there whether or not an email goes out.
 type Notification struct {
-	TigerID    string    `json:"tigerID"`
-	SightingID string    `json:"sightingID"`
-	Timestamp  time.Time `json:"timestamp"`
+	ID          string           `json:"id"`
+	UserID      string           `json:"-" gorm:"not null;index:,composite:inbox"`
+	Type        NotificationType `json:"type" gorm:"type:varchar(30);not null"`
+	TigerID     *string          `json:"tigerID"` // nil for a zone alert about an unidentified sighting
+	SightingID  string           `json:"sightingID" gorm:"not null"`
+	WatchZoneID *string          `json:"watchZoneID"`
+	ReadAt      *time.Time       `json:"readAt"`
+	CreatedAt   time.Time        `json:"createdAt" gorm:"index:,composite:inbox"`
+}
+
+// Read reports whether the user has seen the notification
+func (n *Notification) Read() bool {
+	return n.ReadAt != nil
+}
+
+// NotificationCursor is the position of a notification in an inbox, which is
+// ordered from the newest notification to the oldest
+type NotificationCursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        string    `json:"i"`
 }
@@ -0,0 +1,48 @@
+package model
+
+import "time"
+
+// NotificationPreferences decide how a user hears about sightings. Users who
+// never changed them get DefaultNotificationPreferences.
+type NotificationPreferences struct {
+	UserID          string          `json:"-" gorm:"type:varchar(255);primarykey"`
+	EmailEnabled    bool            `json:"emailEnabled" gorm:"not null"`
+	DigestFrequency DigestFrequency `json:"digestFrequency" gorm:"type:varchar(20);not null"`
+	Locale          Locale          `json:"locale" gorm:"type:varchar(10);not null;default:EN"`
+	// Timezone is the IANA name of the zone times in emails are shown in and
+	// daily digests are sent after midnight of
+	Timezone string `json:"timezone" gorm:"type:varchar(64);not null;default:UTC"`
+	// MutedTigerIDs are stored as MutedTiger rows
+	MutedTigerIDs []string  `json:"mutedTigerIDs" gorm:"-"`
+	UpdatedAt     time.Time `json:"-"`
+}
+
+// DefaultNotificationPreferences emails every notification right away, in
+// English with times in UTC
+func DefaultNotificationPreferences(userID string) *NotificationPreferences {
+	return &NotificationPreferences{
+		UserID:          userID,
+		EmailEnabled:    true,
+		DigestFrequency: DigestFrequencyImmediate,
+		Locale:          LocaleEn,
+		Timezone:        "UTC",
+		MutedTigerIDs:   []string{},
+	}
+}
+
+// Mutes reports whether the user asked not to hear about the tiger
+func (p *NotificationPreferences) Mutes(tigerID string) bool {
+	for _, id := range p.MutedTigerIDs {
+		if id == tigerID {
+			return true
+		}
+	}
+	return false
+}
+
+// MutedTiger is a tiger a user does not want to be notified about
+type MutedTiger struct {
+	UserID    string `gorm:"type:varchar(255);primarykey"`
+	TigerID   string `gorm:"type:varchar(255);primarykey"`
+	CreatedAt time.Time
+}
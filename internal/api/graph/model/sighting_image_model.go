@@ -0,0 +1,60 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SightingImage is one of the photos of a sighting. The tiger and the
+// sighting time are copied from the sighting so a tiger's gallery can be
+// paged without joining sightings. Photos of unidentified sightings have no
+// tiger.
+type SightingImage struct {
+	ID         string      `json:"id"`
+	SightingID string      `json:"sightingID" gorm:"not null;index"`
+	TigerID    *string     `json:"tigerID" gorm:"index:,composite:gallery"`
+	Position   int         `json:"position" gorm:"not null"`
+	Caption    *string     `json:"caption"`
+	TakenAt    time.Time   `json:"takenAt" gorm:"not null;index:,composite:gallery"`
+	Key        string      `json:"-" gorm:"type:varchar(255);not null"`
+	Status     ImageStatus `json:"status" gorm:"type:varchar(20);not null;default:READY;index"`
+	// Attempts counts the processing runs, ClaimedAt is when the latest one
+	// started and NextAttemptAt is when a failed image is retried. A failed
+	// image without NextAttemptAt has given up.
+	Attempts      int        `json:"-" gorm:"not null;default:0"`
+	ClaimedAt     *time.Time `json:"-"`
+	NextAttemptAt *time.Time `json:"-"`
+	// Hash is the perceptual hash of the photo, stored as the signed
+	// equivalent of its 64 bits. It is computed with the renditions, images
+	// stored before hashing have none. HashBlock0 to HashBlock3 are its 16 bit
+	// blocks, see imaging.SplitHash, indexed so similar hashes are found
+	// without comparing every stored hash.
+	Hash       *int64 `json:"-"`
+	HashBlock0 *int   `json:"-" gorm:"index"`
+	HashBlock1 *int   `json:"-" gorm:"index"`
+	HashBlock2 *int   `json:"-" gorm:"index"`
+	HashBlock3 *int   `json:"-" gorm:"index"`
+	// Features describe the stripe pattern, see imaging.StripeFeatures. They
+	// are computed with the renditions.
+	Features  []byte `json:"-" gorm:"type:bytea"`
+	CreatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+// PhotoCursor is the position of a photo in a tiger's gallery, which is
+// ordered from the newest sighting to the oldest and by position within a
+// sighting
+type PhotoCursor struct {
+	TakenAt    time.Time `json:"t"`
+	SightingID string    `json:"s"`
+	Position   int       `json:"p"`
+}
+
+// ImageMatch is a stored image whose perceptual hash is close to the one
+// looked for
+type ImageMatch struct {
+	ImageID    string
+	SightingID string
+	Distance   int
+}
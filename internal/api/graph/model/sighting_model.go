@@ -7,10 +7,13 @@ import (
 )
 
 type Sighting struct {
-	ID                  string    `json:"id"`
-	TigerID             string    `json:"tigerID" gorm:"not null"`
-	LastSeenTime        time.Time `json:"lastSeenTime" gorm:"not null"`
-	Image               *string   `json:"image" gorm:"type:text"`
+	ID                  string           `json:"id"`
+	TigerID             *string          `json:"tigerID" gorm:"index"` // nil until the sighting is identified
+	LastSeenTime        time.Time        `json:"lastSeenTime" gorm:"not null"`
+	ImageKey            string           `json:"-" gorm:"type:varchar(255)"` // key of the first image
+	Images              []*SightingImage `json:"-" gorm:"foreignKey:SightingID"`
+	Flagged             bool             `json:"flagged" gorm:"not null;default:false"`
+	FlagReason          *string          `json:"flagReason"`
 	*LastSeenCoordinate `json:"lastSeenCoordinate"`
 	CreatedAt           time.Time
 	CreatedBy           string `gorm:"index"`
@@ -19,3 +22,8 @@ type Sighting struct {
 	DeletedAt           gorm.DeletedAt `gorm:"index"`
 	DeletedBy           string
 }
+
+// Identified reports whether the sighting has been assigned to a tiger
+func (s *Sighting) Identified() bool {
+	return s.TigerID != nil
+}
@@ -8,8 +8,8 @@ import (
 
 // LastSeenCoordinate represents a latitude and longitude pair where the tiger last seen
 type LastSeenCoordinate struct {
-	Latitude  float64 `json:"latitude" gorm:"not null"`
-	Longitude float64 `json:"longitude" gorm:"not null"`
+	Latitude  float64 `json:"latitude" gorm:"not null;index:,composite:coordinate"`
+	Longitude float64 `json:"longitude" gorm:"not null;index:,composite:coordinate"`
 }
 
 type Tiger struct {
@@ -18,6 +18,8 @@ type Tiger struct {
 	DateOfBirth         time.Time `json:"dateOfBirth" gorm:"not null"`
 	LastSeenTime        time.Time `json:"lastSeenTime" gorm:"not null"`
 	*LastSeenCoordinate `json:"lastSeenCoordinate"`
+	Sensitive           bool    `json:"sensitive" gorm:"not null;default:false"`
+	ProfileImageID      *string `json:"-" gorm:"type:varchar(255)"`
 	CreatedAt           time.Time
 	CreatedBy           string
 	UpdatedAt           time.Time
@@ -25,3 +27,10 @@ type Tiger struct {
 	DeletedAt           gorm.DeletedAt `gorm:"index"`
 	DeletedBy           string
 }
+
+// TigerFollow is a tiger a user wants to hear about without having sighted it
+type TigerFollow struct {
+	UserID    string `gorm:"type:varchar(255);primarykey"`
+	TigerID   string `gorm:"type:varchar(255);primarykey;index"`
+	CreatedAt time.Time
+}
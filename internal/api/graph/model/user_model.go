@@ -5,4 +5,5 @@ type User struct {
 	Name     string `json:"name" gorm:"type:varchar(100);not null"`
 	Email    string `json:"email" gorm:"type:varchar(100);not null;unique;index"`
 	Password string `json:"password" gorm:"type:varchar(100);not null"`
+	Role     Role   `json:"role" gorm:"type:varchar(20);not null;default:USER"`
 }
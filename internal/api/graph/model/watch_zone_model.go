@@ -0,0 +1,38 @@
+package model
+
+import "time"
+
+// WatchZone is an area a user wants to hear about every tiger sighted in,
+// either a polygon or a circle around a center
+type WatchZone struct {
+	ID     string         `json:"id"`
+	UserID string         `json:"-" gorm:"type:varchar(255);not null;index"`
+	Name   string         `json:"name" gorm:"type:varchar(100);not null"`
+	Shape  WatchZoneShape `json:"shape" gorm:"type:varchar(20);not null"`
+	// Polygon holds the vertices of a polygon zone in order, the last one
+	// connects back to the first
+	Polygon         []*LastSeenCoordinate `json:"polygon" gorm:"type:text;serializer:json"`
+	CenterLatitude  *float64              `json:"-"`
+	CenterLongitude *float64              `json:"-"`
+	RadiusMeters    *float64              `json:"radiusMeters"`
+	// The bounding box of the zone. When it crosses the antimeridian
+	// MinLongitude is greater than MaxLongitude.
+	MinLatitude  float64 `json:"-" gorm:"not null"`
+	MaxLatitude  float64 `json:"-" gorm:"not null"`
+	MinLongitude float64 `json:"-" gorm:"not null"`
+	MaxLongitude float64 `json:"-" gorm:"not null"`
+	// Bounds is the bounding box as a Postgres box kept up to date by the
+	// database, its GiST index finds the candidate zones of a point before
+	// the exact test. A box crossing the antimeridian extends past 180
+	// degrees of longitude.
+	Bounds    string    `json:"-" gorm:"->;type:box GENERATED ALWAYS AS (box(point(min_longitude, min_latitude), point(CASE WHEN min_longitude > max_longitude THEN max_longitude + 360 ELSE max_longitude END, max_latitude))) STORED;index:idx_watch_zones_box,type:gist"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Center returns the center of a circle zone, nil for a polygon
+func (z *WatchZone) Center() *LastSeenCoordinate {
+	if z.CenterLatitude == nil || z.CenterLongitude == nil {
+		return nil
+	}
+	return &LastSeenCoordinate{Latitude: *z.CenterLatitude, Longitude: *z.CenterLongitude}
+}
@@ -0,0 +1,63 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// WebhookSubscription is a partner system that is sent the events it
+// subscribed to, signed with its secret
+type WebhookSubscription struct {
+	ID        string         `json:"id"`
+	URL       string         `json:"url" gorm:"type:varchar(2048);not null"`
+	Events    []WebhookEvent `json:"events" gorm:"type:text;not null;serializer:json"`
+	Secret    string         `json:"-" gorm:"type:varchar(255);not null"`
+	CreatedAt time.Time      `json:"createdAt"`
+	CreatedBy string         `json:"-"`
+	UpdatedAt time.Time      `json:"-"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// WebhookMessage is an event with its encoded payload. It is queued as a
+// delivery to every subscription to the event, in the transaction storing
+// the change it is about.
+type WebhookMessage struct {
+	Event     WebhookEvent
+	EventID   string
+	Payload   string
+	CreatedAt time.Time
+}
+
+// WebhookDelivery is an event waiting to be, or that was, sent to a
+// subscription. Deliveries are retried like notification jobs.
+type WebhookDelivery struct {
+	ID             string                `json:"id"`
+	SubscriptionID string                `json:"subscriptionID" gorm:"not null;index"`
+	Subscription   *WebhookSubscription  `json:"-"`
+	Event          WebhookEvent          `json:"event" gorm:"type:varchar(30);not null"`
+	EventID        string                `json:"eventID" gorm:"not null"`
+	Payload        string                `json:"payload" gorm:"type:text;not null"`
+	Status         WebhookDeliveryStatus `json:"status" gorm:"type:varchar(20);not null;default:PENDING;index:,composite:due"`
+	Attempts       int                   `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt  time.Time             `json:"nextAttemptAt" gorm:"not null;index:,composite:due"`
+	ResponseStatus *int                  `json:"responseStatus"`
+	LastError      *string               `json:"lastError"`
+	DeliveredAt    *time.Time            `json:"deliveredAt"`
+	CreatedAt      time.Time             `json:"createdAt"`
+	UpdatedAt      time.Time             `json:"updatedAt"`
+}
+
+// Name returns the name the event is sent as, e.g. "sighting.created"
+func (e WebhookEvent) Name() string {
+	switch e {
+	case WebhookEventSightingCreated:
+		return "sighting.created"
+	case WebhookEventTigerCreated:
+		return "tiger.created"
+	case WebhookEventTigerUpdated:
+		return "tiger.updated"
+	default:
+		return string(e)
+	}
+}
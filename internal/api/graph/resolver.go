@@ -9,7 +9,11 @@ import "github.com/nurcholisnanda/tigerhall-kittens/internal/service"
 // It serves as dependency injection for your app, add any dependencies you require here.
 
 type Resolver struct {
-	UserSvc     service.UserService
-	TigerSvc    service.TigerService
-	SightingSvc service.SightingService
+	UserSvc         service.UserService
+	TigerSvc        service.TigerService
+	SightingSvc     service.SightingService
+	NotificationSvc service.NotificationService
+	WatchZoneSvc    service.WatchZoneService
+	Events          service.EventBroker
+	WebhookSvc      service.WebhookService
 }
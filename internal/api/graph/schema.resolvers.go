@@ -7,7 +7,9 @@ package graph
 import (
 	"context"
 	"fmt"
+	"time"
 
+	"github.com/99designs/gqlgen/graphql"
 	"github.com/nurcholisnanda/tigerhall-kittens/internal/api/graph/model"
 	"github.com/nurcholisnanda/tigerhall-kittens/pkg/helper"
 	"github.com/nurcholisnanda/tigerhall-kittens/pkg/logger"
@@ -63,6 +65,14 @@ func (r *createOpsResolver) CreateSighting(ctx context.Context, obj *model.Creat
 					"details": err.Error(),
 				},
 			}
+		case *helper.InvalidImageError:
+			return nil, &gqlerror.Error{
+				Message: "invalid image",
+				Extensions: map[string]interface{}{
+					"code":    helper.INVALID_IMAGE,
+					"details": err.Error(),
+				},
+			}
 		default:
 			// Log the unexpected error for investigation
 			logger.Logger(ctx).Error(ctx, "Unexpected error creating sighting", "error", err)
@@ -120,6 +130,64 @@ func (r *createOpsResolver) CreateTiger(ctx context.Context, obj *model.CreateOp
 	return tiger, nil
 }
 
+// CreateWatchZone is the resolver for the createWatchZone field.
+func (r *createOpsResolver) CreateWatchZone(ctx context.Context, obj *model.CreateOps, input model.WatchZoneInput) (*model.WatchZone, error) {
+	userID, err := helper.GetUserID(ctx)
+	if err != nil {
+		return nil, &gqlerror.Error{
+			Message: "Access Denied",
+		}
+	}
+	zone, err := r.WatchZoneSvc.CreateWatchZone(ctx, userID, &input)
+	if err != nil {
+		switch err.(type) {
+		case *helper.InvalidWatchZoneError:
+			return nil, &gqlerror.Error{
+				Message: "invalid watch zone",
+				Extensions: map[string]interface{}{
+					"code":    helper.INVALID_INPUT,
+					"details": err.Error(),
+				},
+			}
+		case *helper.InvalidCoordinatesError, *helper.InvalidRadiusError:
+			return nil, geoSearchError(ctx, err)
+		default:
+			// Log the unexpected error for investigation
+			logrus.Error(ctx, "Unexpected error creating watch zone", "error:", err.Error())
+			return nil, gqlerror.Errorf("Internal Server Error")
+		}
+	}
+	return zone, nil
+}
+
+// CreateWebhookSubscription is the resolver for the createWebhookSubscription field.
+func (r *createOpsResolver) CreateWebhookSubscription(ctx context.Context, obj *model.CreateOps, input model.WebhookSubscriptionInput) (*model.WebhookSubscription, error) {
+	userID, err := helper.GetUserID(ctx)
+	if err != nil {
+		return nil, &gqlerror.Error{
+			Message: "Access Denied",
+		}
+	}
+	subscription, err := r.WebhookSvc.CreateWebhookSubscription(ctx, userID, &input)
+	if err != nil {
+		switch err.(type) {
+		case *helper.InvalidWebhookError:
+			return nil, &gqlerror.Error{
+				Message: "invalid webhook subscription",
+				Extensions: map[string]interface{}{
+					"code":    helper.INVALID_INPUT,
+					"details": err.Error(),
+				},
+			}
+		default:
+			// Log the unexpected error for investigation
+			logrus.Error(ctx, "Unexpected error creating webhook subscription", "error:", err.Error())
+			return nil, gqlerror.Errorf("Internal Server Error")
+		}
+	}
+	return subscription, nil
+}
+
 // ListTigers is the resolver for the ListTigers field.
 func (r *listOpsResolver) ListTigers(ctx context.Context, obj *model.ListOps, limit int, offset int) ([]*model.Tiger, error) {
 	// Call your tiger service to fetch tigers with pagination
@@ -143,6 +211,200 @@ func (r *listOpsResolver) ListSightings(ctx context.Context, obj *model.ListOps,
 	return sightings, nil
 }
 
+// SightingsNear is the resolver for the sightingsNear field.
+func (r *listOpsResolver) SightingsNear(ctx context.Context, obj *model.ListOps, point model.LastSeenCoordinateInput, radiusMeters float64, timeRange *model.TimeRangeInput, limit int) ([]*model.NearbySighting, error) {
+	sightings, err := r.SightingSvc.ListSightingsNear(ctx, &point, radiusMeters, timeRange, limit)
+	if err != nil {
+		return nil, geoSearchError(ctx, err)
+	}
+	return sightings, nil
+}
+
+// SightingsInBox is the resolver for the sightingsInBox field.
+func (r *listOpsResolver) SightingsInBox(ctx context.Context, obj *model.ListOps, southWest model.LastSeenCoordinateInput, northEast model.LastSeenCoordinateInput, timeRange *model.TimeRangeInput, limit int) ([]*model.NearbySighting, error) {
+	box := &model.BoundingBox{SouthWest: &southWest, NorthEast: &northEast}
+	sightings, err := r.SightingSvc.ListSightingsInBox(ctx, box, timeRange, limit)
+	if err != nil {
+		return nil, geoSearchError(ctx, err)
+	}
+	return sightings, nil
+}
+
+// TigersNear is the resolver for the tigersNear field.
+func (r *listOpsResolver) TigersNear(ctx context.Context, obj *model.ListOps, point model.LastSeenCoordinateInput, radiusMeters float64, seenSince *time.Time, limit int) ([]*model.NearbyTiger, error) {
+	tigers, err := r.TigerSvc.ListTigersNear(ctx, &point, radiusMeters, seenSince, limit)
+	if err != nil {
+		return nil, geoSearchError(ctx, err)
+	}
+	return tigers, nil
+}
+
+// TigerTrack is the resolver for the tigerTrack field.
+func (r *listOpsResolver) TigerTrack(ctx context.Context, obj *model.ListOps, id string, from *time.Time, to *time.Time) (*model.TigerTrack, error) {
+	track, err := r.SightingSvc.GetTigerTrack(ctx, id, &model.TimeRangeInput{From: from, To: to})
+	if err != nil {
+		switch err.(type) {
+		case *helper.TigerNotFound:
+			return nil, &gqlerror.Error{
+				Message: "tiger not found",
+				Extensions: map[string]interface{}{
+					"code":    helper.NOT_FOUND,
+					"details": err.Error(),
+				},
+			}
+		default:
+			// Log the unexpected error for investigation
+			logrus.Error(ctx, "Unexpected error getting tiger track", "error:", err.Error())
+			return nil, gqlerror.Errorf("Internal Server Error")
+		}
+	}
+	return track, nil
+}
+
+// UnidentifiedSightings is the resolver for the unidentifiedSightings field.
+func (r *listOpsResolver) UnidentifiedSightings(ctx context.Context, obj *model.ListOps, limit int, offset int) ([]*model.Sighting, error) {
+	sightings, err := r.SightingSvc.ListUnidentifiedSightings(ctx, limit, offset)
+	if err != nil {
+		// Log the unexpected error for investigation
+		logrus.Error(ctx, "Unexpected error getting unidentified sighting list", "error:", err.Error())
+		return nil, gqlerror.Errorf("Internal Server Error")
+	}
+	return sightings, nil
+}
+
+// SuggestTigers is the resolver for the suggestTigers field.
+func (r *listOpsResolver) SuggestTigers(ctx context.Context, obj *model.ListOps, image graphql.Upload, near *model.LastSeenCoordinateInput, limit int) ([]*model.TigerSuggestion, error) {
+	suggestions, err := r.SightingSvc.SuggestTigers(ctx, image, near, limit)
+	if err != nil {
+		switch err.(type) {
+		case *helper.InvalidPaginationError:
+			return nil, &gqlerror.Error{
+				Message: "invalid limit",
+				Extensions: map[string]interface{}{
+					"code":    helper.INVALID_INPUT,
+					"details": err.Error(),
+				},
+			}
+		case *helper.InvalidCoordinatesError:
+			return nil, &gqlerror.Error{
+				Message: "invalid coordinates",
+				Extensions: map[string]interface{}{
+					"code":    helper.INVALID_INPUT,
+					"details": err.Error(),
+				},
+			}
+		case *helper.InvalidImageError:
+			return nil, &gqlerror.Error{
+				Message: "invalid image",
+				Extensions: map[string]interface{}{
+					"code":    helper.INVALID_IMAGE,
+					"details": err.Error(),
+				},
+			}
+		default:
+			// Log the unexpected error for investigation
+			logrus.Error(ctx, "Unexpected error suggesting tigers", "error:", err.Error())
+			return nil, gqlerror.Errorf("Internal Server Error")
+		}
+	}
+	return suggestions, nil
+}
+
+// Notifications is the resolver for the notifications field.
+func (r *listOpsResolver) Notifications(ctx context.Context, obj *model.ListOps, unreadOnly bool, first int, after *string) (*model.NotificationConnection, error) {
+	userID, err := helper.GetUserID(ctx)
+	if err != nil {
+		return nil, &gqlerror.Error{
+			Message: "Access Denied",
+		}
+	}
+	notifications, err := r.NotificationSvc.ListNotifications(ctx, userID, unreadOnly, first, after)
+	if err != nil {
+		switch err.(type) {
+		case *helper.InvalidPaginationError:
+			return nil, &gqlerror.Error{
+				Message: "invalid pagination",
+				Extensions: map[string]interface{}{
+					"code":    helper.INVALID_INPUT,
+					"details": err.Error(),
+				},
+			}
+		default:
+			// Log the unexpected error for investigation
+			logrus.Error(ctx, "Unexpected error listing notifications", "error:", err.Error())
+			return nil, gqlerror.Errorf("Internal Server Error")
+		}
+	}
+	return notifications, nil
+}
+
+// UnreadNotificationCount is the resolver for the unreadNotificationCount field.
+func (r *listOpsResolver) UnreadNotificationCount(ctx context.Context, obj *model.ListOps) (int, error) {
+	userID, err := helper.GetUserID(ctx)
+	if err != nil {
+		return 0, &gqlerror.Error{
+			Message: "Access Denied",
+		}
+	}
+	count, err := r.NotificationSvc.UnreadNotificationCount(ctx, userID)
+	if err != nil {
+		// Log the unexpected error for investigation
+		logrus.Error(ctx, "Unexpected error counting unread notifications", "error:", err.Error())
+		return 0, gqlerror.Errorf("Internal Server Error")
+	}
+	return count, nil
+}
+
+// WatchZones is the resolver for the watchZones field.
+func (r *listOpsResolver) WatchZones(ctx context.Context, obj *model.ListOps) ([]*model.WatchZone, error) {
+	userID, err := helper.GetUserID(ctx)
+	if err != nil {
+		return nil, &gqlerror.Error{
+			Message: "Access Denied",
+		}
+	}
+	zones, err := r.WatchZoneSvc.ListWatchZones(ctx, userID)
+	if err != nil {
+		// Log the unexpected error for investigation
+		logrus.Error(ctx, "Unexpected error getting watch zone list", "error:", err.Error())
+		return nil, gqlerror.Errorf("Internal Server Error")
+	}
+	return zones, nil
+}
+
+// NotificationJobs is the resolver for the notificationJobs field.
+func (r *listOpsResolver) NotificationJobs(ctx context.Context, obj *model.ListOps, status *model.NotificationStatus, limit int, offset int) ([]*model.NotificationJob, error) {
+	jobs, err := r.NotificationSvc.ListNotificationJobs(ctx, status, limit, offset)
+	if err != nil {
+		// Log the unexpected error for investigation
+		logrus.Error(ctx, "Unexpected error getting notification job list", "error:", err.Error())
+		return nil, gqlerror.Errorf("Internal Server Error")
+	}
+	return jobs, nil
+}
+
+// WebhookSubscriptions is the resolver for the webhookSubscriptions field.
+func (r *listOpsResolver) WebhookSubscriptions(ctx context.Context, obj *model.ListOps) ([]*model.WebhookSubscription, error) {
+	subscriptions, err := r.WebhookSvc.ListWebhookSubscriptions(ctx)
+	if err != nil {
+		// Log the unexpected error for investigation
+		logrus.Error(ctx, "Unexpected error getting webhook subscription list", "error:", err.Error())
+		return nil, gqlerror.Errorf("Internal Server Error")
+	}
+	return subscriptions, nil
+}
+
+// WebhookDeliveries is the resolver for the webhookDeliveries field.
+func (r *listOpsResolver) WebhookDeliveries(ctx context.Context, obj *model.ListOps, subscriptionID *string, status *model.WebhookDeliveryStatus, limit int, offset int) ([]*model.WebhookDelivery, error) {
+	deliveries, err := r.WebhookSvc.ListWebhookDeliveries(ctx, subscriptionID, status, limit, offset)
+	if err != nil {
+		// Log the unexpected error for investigation
+		logrus.Error(ctx, "Unexpected error getting webhook delivery list", "error:", err.Error())
+		return nil, gqlerror.Errorf("Internal Server Error")
+	}
+	return deliveries, nil
+}
+
 // Auth is the resolver for the auth field.
 func (r *mutationResolver) Auth(ctx context.Context) (*model.AuthOps, error) {
 	return &model.AuthOps{}, nil
@@ -153,6 +415,11 @@ func (r *mutationResolver) Create(ctx context.Context) (*model.CreateOps, error)
 	return &model.CreateOps{}, nil
 }
 
+// Update is the resolver for the update field.
+func (r *mutationResolver) Update(ctx context.Context) (*model.UpdateOps, error) {
+	return &model.UpdateOps{}, nil
+}
+
 // User is the resolver for the user field.
 func (r *queryResolver) User(ctx context.Context, id string) (*model.User, error) {
 	return r.UserSvc.GetUserByID(ctx, id)
@@ -163,6 +430,402 @@ func (r *queryResolver) List(ctx context.Context) (*model.ListOps, error) {
 	return &model.ListOps{}, nil
 }
 
+// Image is the resolver for the image field.
+func (r *sightingResolver) Image(ctx context.Context, obj *model.Sighting, size *model.ImageSize) (*string, error) {
+	imageSize := model.ImageSizeMedium
+	if size != nil {
+		imageSize = *size
+	}
+	url, err := r.SightingSvc.CoverImageURL(ctx, obj, imageSize)
+	if err != nil {
+		// Log the unexpected error for investigation
+		logrus.Error(ctx, "Unexpected error getting sighting image", "error:", err.Error())
+		return nil, gqlerror.Errorf("Internal Server Error")
+	}
+	return url, nil
+}
+
+// Images is the resolver for the images field.
+func (r *sightingResolver) Images(ctx context.Context, obj *model.Sighting) ([]*model.SightingImage, error) {
+	images, err := r.SightingSvc.ListSightingImages(ctx, obj)
+	if err != nil {
+		// Log the unexpected error for investigation
+		logrus.Error(ctx, "Unexpected error listing sighting images", "error:", err.Error())
+		return nil, gqlerror.Errorf("Internal Server Error")
+	}
+	return images, nil
+}
+
+// ImageStatus is the resolver for the imageStatus field.
+func (r *sightingResolver) ImageStatus(ctx context.Context, obj *model.Sighting) (*model.ImageStatus, error) {
+	status, err := r.SightingSvc.ImageStatus(ctx, obj)
+	if err != nil {
+		// Log the unexpected error for investigation
+		logrus.Error(ctx, "Unexpected error getting sighting image status", "error:", err.Error())
+		return nil, gqlerror.Errorf("Internal Server Error")
+	}
+	return status, nil
+}
+
+// URL is the resolver for the url field.
+func (r *sightingImageResolver) URL(ctx context.Context, obj *model.SightingImage, size *model.ImageSize) (string, error) {
+	imageSize := model.ImageSizeMedium
+	if size != nil {
+		imageSize = *size
+	}
+	return r.SightingSvc.SightingImageURL(obj, imageSize), nil
+}
+
+// SightingCreated is the resolver for the sightingCreated field.
+func (r *subscriptionResolver) SightingCreated(ctx context.Context, tigerID *string, area *model.BoundingBox) (<-chan *model.Sighting, error) {
+	sightings, err := r.Events.SubscribeSightings(ctx, tigerID, area)
+	if err != nil {
+		return nil, geoSearchError(ctx, err)
+	}
+	return sightings, nil
+}
+
+// NotificationReceived is the resolver for the notificationReceived field.
+func (r *subscriptionResolver) NotificationReceived(ctx context.Context) (<-chan *model.Notification, error) {
+	userID, err := helper.GetUserID(ctx)
+	if err != nil {
+		return nil, &gqlerror.Error{
+			Message: "Access Denied",
+		}
+	}
+	return r.Events.SubscribeNotifications(ctx, userID), nil
+}
+
+// ProfilePhoto is the resolver for the profilePhoto field.
+func (r *tigerResolver) ProfilePhoto(ctx context.Context, obj *model.Tiger) (*model.SightingImage, error) {
+	image, err := r.TigerSvc.GetProfilePhoto(ctx, obj)
+	if err != nil {
+		// Log the unexpected error for investigation
+		logrus.Error(ctx, "Unexpected error getting profile photo", "error:", err.Error())
+		return nil, gqlerror.Errorf("Internal Server Error")
+	}
+	return image, nil
+}
+
+// Photos is the resolver for the photos field.
+func (r *tigerResolver) Photos(ctx context.Context, obj *model.Tiger, first int, after *string) (*model.PhotoConnection, error) {
+	photos, err := r.SightingSvc.ListTigerPhotos(ctx, obj.ID, first, after)
+	if err != nil {
+		switch err.(type) {
+		case *helper.InvalidPaginationError:
+			return nil, &gqlerror.Error{
+				Message: "invalid pagination",
+				Extensions: map[string]interface{}{
+					"code":    helper.INVALID_INPUT,
+					"details": err.Error(),
+				},
+			}
+		default:
+			// Log the unexpected error for investigation
+			logrus.Error(ctx, "Unexpected error listing tiger photos", "error:", err.Error())
+			return nil, gqlerror.Errorf("Internal Server Error")
+		}
+	}
+	return photos, nil
+}
+
+// SetTigerProfilePhoto is the resolver for the setTigerProfilePhoto field.
+func (r *updateOpsResolver) SetTigerProfilePhoto(ctx context.Context, obj *model.UpdateOps, tigerID string, imageID string) (*model.Tiger, error) {
+	tiger, err := r.TigerSvc.SetProfilePhoto(ctx, tigerID, imageID)
+	if err != nil {
+		switch err.(type) {
+		case *helper.TigerNotFound:
+			return nil, &gqlerror.Error{
+				Message: "tiger not found",
+				Extensions: map[string]interface{}{
+					"code":    helper.NOT_FOUND,
+					"details": err.Error(),
+				},
+			}
+		case *helper.ImageNotFoundError:
+			return nil, &gqlerror.Error{
+				Message: "image not found",
+				Extensions: map[string]interface{}{
+					"code":    helper.NOT_FOUND,
+					"details": err.Error(),
+				},
+			}
+		default:
+			// Log the unexpected error for investigation
+			logrus.Error(ctx, "Unexpected error setting profile photo", "error:", err.Error())
+			return nil, gqlerror.Errorf("Internal Server Error")
+		}
+	}
+	return tiger, nil
+}
+
+// AssignSighting is the resolver for the assignSighting field.
+func (r *updateOpsResolver) AssignSighting(ctx context.Context, obj *model.UpdateOps, sightingID string, tigerID string) (*model.Sighting, error) {
+	sighting, err := r.SightingSvc.AssignSighting(ctx, sightingID, tigerID)
+	if err != nil {
+		switch err.(type) {
+		case *helper.SightingNotFoundError:
+			return nil, &gqlerror.Error{
+				Message: "sighting not found",
+				Extensions: map[string]interface{}{
+					"code":    helper.NOT_FOUND,
+					"details": err.Error(),
+				},
+			}
+		case *helper.TigerNotFound:
+			return nil, &gqlerror.Error{
+				Message: "tiger not found",
+				Extensions: map[string]interface{}{
+					"code":    helper.NOT_FOUND,
+					"details": err.Error(),
+				},
+			}
+		case *helper.SightingAlreadyIdentifiedError:
+			return nil, &gqlerror.Error{
+				Message: "sighting already identified",
+				Extensions: map[string]interface{}{
+					"code":    helper.CONFLICT,
+					"details": err.Error(),
+				},
+			}
+		case *helper.InvalidLastSeenTimeError:
+			return nil, &gqlerror.Error{
+				Message: "invalid last seen time",
+				Extensions: map[string]interface{}{
+					"code":    helper.INVALID_INPUT,
+					"details": err.Error(),
+				},
+			}
+		case *helper.SightingTooCloseError:
+			return nil, &gqlerror.Error{
+				Message: "sighting too close",
+				Extensions: map[string]interface{}{
+					"code":    helper.CONFLICT,
+					"details": err.Error(),
+				},
+			}
+		default:
+			// Log the unexpected error for investigation
+			logrus.Error(ctx, "Unexpected error assigning sighting", "error:", err.Error())
+			return nil, gqlerror.Errorf("Internal Server Error")
+		}
+	}
+	return sighting, nil
+}
+
+// FollowTiger is the resolver for the followTiger field.
+func (r *updateOpsResolver) FollowTiger(ctx context.Context, obj *model.UpdateOps, tigerID string) (*model.Tiger, error) {
+	userID, err := helper.GetUserID(ctx)
+	if err != nil {
+		return nil, &gqlerror.Error{
+			Message: "Access Denied",
+		}
+	}
+	tiger, err := r.TigerSvc.FollowTiger(ctx, userID, tigerID)
+	if err != nil {
+		switch err.(type) {
+		case *helper.TigerNotFound:
+			return nil, &gqlerror.Error{
+				Message: "tiger not found",
+				Extensions: map[string]interface{}{
+					"code":    helper.NOT_FOUND,
+					"details": err.Error(),
+				},
+			}
+		default:
+			// Log the unexpected error for investigation
+			logrus.Error(ctx, "Unexpected error following tiger", "error:", err.Error())
+			return nil, gqlerror.Errorf("Internal Server Error")
+		}
+	}
+	return tiger, nil
+}
+
+// UnfollowTiger is the resolver for the unfollowTiger field.
+func (r *updateOpsResolver) UnfollowTiger(ctx context.Context, obj *model.UpdateOps, tigerID string) (*model.Tiger, error) {
+	userID, err := helper.GetUserID(ctx)
+	if err != nil {
+		return nil, &gqlerror.Error{
+			Message: "Access Denied",
+		}
+	}
+	tiger, err := r.TigerSvc.UnfollowTiger(ctx, userID, tigerID)
+	if err != nil {
+		switch err.(type) {
+		case *helper.TigerNotFound:
+			return nil, &gqlerror.Error{
+				Message: "tiger not found",
+				Extensions: map[string]interface{}{
+					"code":    helper.NOT_FOUND,
+					"details": err.Error(),
+				},
+			}
+		default:
+			// Log the unexpected error for investigation
+			logrus.Error(ctx, "Unexpected error unfollowing tiger", "error:", err.Error())
+			return nil, gqlerror.Errorf("Internal Server Error")
+		}
+	}
+	return tiger, nil
+}
+
+// UpdateNotificationPreferences is the resolver for the updateNotificationPreferences field.
+func (r *updateOpsResolver) UpdateNotificationPreferences(ctx context.Context, obj *model.UpdateOps, input model.NotificationPreferencesInput) (*model.NotificationPreferences, error) {
+	userID, err := helper.GetUserID(ctx)
+	if err != nil {
+		return nil, &gqlerror.Error{
+			Message: "Access Denied",
+		}
+	}
+	preferences, err := r.UserSvc.UpdateNotificationPreferences(ctx, userID, &input)
+	if err != nil {
+		switch err.(type) {
+		case *helper.InvalidTimezoneError:
+			return nil, &gqlerror.Error{
+				Message: "invalid time zone",
+				Extensions: map[string]interface{}{
+					"code":    helper.INVALID_INPUT,
+					"details": err.Error(),
+				},
+			}
+		default:
+			// Log the unexpected error for investigation
+			logrus.Error(ctx, "Unexpected error updating notification preferences", "error:", err.Error())
+			return nil, gqlerror.Errorf("Internal Server Error")
+		}
+	}
+	return preferences, nil
+}
+
+// MarkNotificationRead is the resolver for the markNotificationRead field.
+func (r *updateOpsResolver) MarkNotificationRead(ctx context.Context, obj *model.UpdateOps, id string) (*model.Notification, error) {
+	userID, err := helper.GetUserID(ctx)
+	if err != nil {
+		return nil, &gqlerror.Error{
+			Message: "Access Denied",
+		}
+	}
+	notification, err := r.NotificationSvc.MarkNotificationRead(ctx, userID, id)
+	if err != nil {
+		switch err.(type) {
+		case *helper.NotificationNotFoundError:
+			return nil, &gqlerror.Error{
+				Message: "notification not found",
+				Extensions: map[string]interface{}{
+					"code":    helper.NOT_FOUND,
+					"details": err.Error(),
+				},
+			}
+		default:
+			// Log the unexpected error for investigation
+			logrus.Error(ctx, "Unexpected error marking notification read", "error:", err.Error())
+			return nil, gqlerror.Errorf("Internal Server Error")
+		}
+	}
+	return notification, nil
+}
+
+// MarkAllNotificationsRead is the resolver for the markAllNotificationsRead field.
+func (r *updateOpsResolver) MarkAllNotificationsRead(ctx context.Context, obj *model.UpdateOps) (int, error) {
+	userID, err := helper.GetUserID(ctx)
+	if err != nil {
+		return 0, &gqlerror.Error{
+			Message: "Access Denied",
+		}
+	}
+	count, err := r.NotificationSvc.MarkAllNotificationsRead(ctx, userID)
+	if err != nil {
+		// Log the unexpected error for investigation
+		logrus.Error(ctx, "Unexpected error marking notifications read", "error:", err.Error())
+		return 0, gqlerror.Errorf("Internal Server Error")
+	}
+	return count, nil
+}
+
+// ReplayNotificationJob is the resolver for the replayNotificationJob field.
+func (r *updateOpsResolver) ReplayNotificationJob(ctx context.Context, obj *model.UpdateOps, id string) (*model.NotificationJob, error) {
+	job, err := r.NotificationSvc.ReplayNotificationJob(ctx, id)
+	if err != nil {
+		switch err.(type) {
+		case *helper.NotificationJobNotFoundError:
+			return nil, &gqlerror.Error{
+				Message: "notification job not found",
+				Extensions: map[string]interface{}{
+					"code":    helper.NOT_FOUND,
+					"details": err.Error(),
+				},
+			}
+		case *helper.NotificationJobNotDeadError:
+			return nil, &gqlerror.Error{
+				Message: "notification job is not dead",
+				Extensions: map[string]interface{}{
+					"code":    helper.CONFLICT,
+					"details": err.Error(),
+				},
+			}
+		default:
+			// Log the unexpected error for investigation
+			logrus.Error(ctx, "Unexpected error replaying notification job", "error:", err.Error())
+			return nil, gqlerror.Errorf("Internal Server Error")
+		}
+	}
+	return job, nil
+}
+
+// DeleteWebhookSubscription is the resolver for the deleteWebhookSubscription field.
+func (r *updateOpsResolver) DeleteWebhookSubscription(ctx context.Context, obj *model.UpdateOps, id string) (*model.WebhookSubscription, error) {
+	subscription, err := r.WebhookSvc.DeleteWebhookSubscription(ctx, id)
+	if err != nil {
+		switch err.(type) {
+		case *helper.WebhookSubscriptionNotFoundError:
+			return nil, &gqlerror.Error{
+				Message: "webhook subscription not found",
+				Extensions: map[string]interface{}{
+					"code":    helper.NOT_FOUND,
+					"details": err.Error(),
+				},
+			}
+		default:
+			// Log the unexpected error for investigation
+			logrus.Error(ctx, "Unexpected error deleting webhook subscription", "error:", err.Error())
+			return nil, gqlerror.Errorf("Internal Server Error")
+		}
+	}
+	return subscription, nil
+}
+
+// NotificationPreferences is the resolver for the notificationPreferences field.
+func (r *userResolver) NotificationPreferences(ctx context.Context, obj *model.User) (*model.NotificationPreferences, error) {
+	// preferences are private to their user
+	userID, err := helper.GetUserID(ctx)
+	if err != nil || userID != obj.ID {
+		return nil, nil
+	}
+	preferences, err := r.UserSvc.GetNotificationPreferences(ctx, userID)
+	if err != nil {
+		// Log the unexpected error for investigation
+		logrus.Error(ctx, "Unexpected error getting notification preferences", "error:", err.Error())
+		return nil, gqlerror.Errorf("Internal Server Error")
+	}
+	return preferences, nil
+}
+
+// FollowedTigers is the resolver for the followedTigers field.
+func (r *userResolver) FollowedTigers(ctx context.Context, obj *model.User) ([]*model.Tiger, error) {
+	// who a user follows is private to them
+	userID, err := helper.GetUserID(ctx)
+	if err != nil || userID != obj.ID {
+		return nil, nil
+	}
+	tigers, err := r.TigerSvc.ListFollowedTigers(ctx, userID)
+	if err != nil {
+		// Log the unexpected error for investigation
+		logrus.Error(ctx, "Unexpected error getting followed tigers", "error:", err.Error())
+		return nil, gqlerror.Errorf("Internal Server Error")
+	}
+	return tigers, nil
+}
+
 // AuthOps returns AuthOpsResolver implementation.
 func (r *Resolver) AuthOps() AuthOpsResolver { return &authOpsResolver{r} }
 
@@ -178,8 +841,32 @@ func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
 // Query returns QueryResolver implementation.
 func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
 
+// Sighting returns SightingResolver implementation.
+func (r *Resolver) Sighting() SightingResolver { return &sightingResolver{r} }
+
+// SightingImage returns SightingImageResolver implementation.
+func (r *Resolver) SightingImage() SightingImageResolver { return &sightingImageResolver{r} }
+
+// Subscription returns SubscriptionResolver implementation.
+func (r *Resolver) Subscription() SubscriptionResolver { return &subscriptionResolver{r} }
+
+// Tiger returns TigerResolver implementation.
+func (r *Resolver) Tiger() TigerResolver { return &tigerResolver{r} }
+
+// UpdateOps returns UpdateOpsResolver implementation.
+func (r *Resolver) UpdateOps() UpdateOpsResolver { return &updateOpsResolver{r} }
+
+// User returns UserResolver implementation.
+func (r *Resolver) User() UserResolver { return &userResolver{r} }
+
 type authOpsResolver struct{ *Resolver }
 type createOpsResolver struct{ *Resolver }
 type listOpsResolver struct{ *Resolver }
 type mutationResolver struct{ *Resolver }
 type queryResolver struct{ *Resolver }
+type sightingResolver struct{ *Resolver }
+type sightingImageResolver struct{ *Resolver }
+type subscriptionResolver struct{ *Resolver }
+type tigerResolver struct{ *Resolver }
+type updateOpsResolver struct{ *Resolver }
+type userResolver struct{ *Resolver }
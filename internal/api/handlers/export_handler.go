@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nurcholisnanda/tigerhall-kittens/internal/api/graph/model"
+	"github.com/nurcholisnanda/tigerhall-kittens/internal/export"
+	"github.com/nurcholisnanda/tigerhall-kittens/internal/service"
+	"github.com/nurcholisnanda/tigerhall-kittens/pkg/helper"
+	"github.com/nurcholisnanda/tigerhall-kittens/pkg/logger"
+)
+
+type ExportHandler struct {
+	sightingSvc service.SightingService
+	exportSvc   service.ExportService
+}
+
+func NewExportHandler(sightingSvc service.SightingService, exportSvc service.ExportService) *ExportHandler {
+	return &ExportHandler{
+		sightingSvc: sightingSvc,
+		exportSvc:   exportSvc,
+	}
+}
+
+// TigerTrack serves the ordered sightings of a tiger as a GeoJSON, KML or GPX
+// track. The optional from and to query parameters are RFC 3339 timestamps.
+func (h *ExportHandler) TigerTrack() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		format := export.TrackFormat(c.DefaultQuery("format", string(export.GeoJSON)))
+		if !format.IsValid() {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "format must be one of geojson, kml or gpx"})
+			return
+		}
+
+		timeRange, err := parseTimeRange(c)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		track, err := h.sightingSvc.GetTigerTrack(ctx, c.Param("id"), timeRange)
+		if err != nil {
+			if _, ok := err.(*helper.TigerNotFound); ok {
+				c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+			return
+		}
+
+		c.Header("Content-Type", format.ContentType())
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="tiger-%s.%s"`, track.Tiger.ID, format))
+		c.Status(http.StatusOK)
+		if err := export.WriteTrack(c.Writer, format, track); err != nil {
+			logger.Logger(ctx).Error("Failed to write tiger track:", err)
+		}
+	}
+}
+
+// Tigers streams every tiger as CSV, newline-delimited JSON or GeoJSON
+func (h *ExportHandler) Tigers() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		format, err := export.ParseBulkFormat(c.Query("format"))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		startDownload(c, "tigers", format)
+		if err := h.exportSvc.ExportTigers(c.Request.Context(), c.Writer, format); err != nil {
+			// The status line is already sent, so the truncated body is all we can signal
+			logger.Logger(c.Request.Context()).Error("Failed to export tigers:", err)
+		}
+	}
+}
+
+// Sightings streams sightings as CSV, newline-delimited JSON or GeoJSON. Like
+// listSightings it can be filtered by tigerID, and by the from and to times.
+func (h *ExportHandler) Sightings() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		format, err := export.ParseBulkFormat(c.Query("format"))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		timeRange, err := parseTimeRange(c)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		startDownload(c, "sightings", format)
+		if err := h.exportSvc.ExportSightings(c.Request.Context(), c.Writer, format, c.Query("tigerID"), timeRange); err != nil {
+			// The status line is already sent, so the truncated body is all we can signal
+			logger.Logger(c.Request.Context()).Error("Failed to export sightings:", err)
+		}
+	}
+}
+
+// DarwinCore streams every sighting as a Darwin Core Archive zip
+func (h *ExportHandler) DarwinCore() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Content-Type", "application/zip")
+		c.Header("Content-Disposition", `attachment; filename="dwca-sightings.zip"`)
+		c.Status(http.StatusOK)
+		if err := h.exportSvc.ExportDarwinCore(c.Request.Context(), c.Writer); err != nil {
+			// The status line is already sent, so the truncated body is all we can signal
+			logger.Logger(c.Request.Context()).Error("Failed to export darwin core archive:", err)
+		}
+	}
+}
+
+func startDownload(c *gin.Context, name string, format export.BulkFormat) {
+	c.Header("Content-Type", format.ContentType())
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, name, format))
+	c.Status(http.StatusOK)
+}
+
+// parseTimeRange reads the optional from and to query parameters
+func parseTimeRange(c *gin.Context) (*model.TimeRangeInput, error) {
+	timeRange := &model.TimeRangeInput{}
+	for param, target := range map[string]**time.Time{"from": &timeRange.From, "to": &timeRange.To} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be an RFC 3339 timestamp", param)
+		}
+		*target = &parsed
+	}
+	return timeRange, nil
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nurcholisnanda/tigerhall-kittens/internal/service"
+	"github.com/nurcholisnanda/tigerhall-kittens/pkg/logger"
+	"github.com/nurcholisnanda/tigerhall-kittens/pkg/storage"
+	"github.com/nurcholisnanda/tigerhall-kittens/pkg/urlsign"
+)
+
+type ImageHandler struct {
+	blobStore storage.BlobStore
+	urlSigner *urlsign.Signer
+}
+
+func NewImageHandler(blobStore storage.BlobStore, urlSigner *urlsign.Signer) *ImageHandler {
+	return &ImageHandler{
+		blobStore: blobStore,
+		urlSigner: urlSigner,
+	}
+}
+
+// Serve streams a stored image to clients holding a valid signed URL. It
+// sets Content-Type, ETag and Cache-Control and answers Range and
+// conditional requests.
+func (h *ImageHandler) Serve() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		key := strings.TrimPrefix(c.Param("key"), "/")
+		expiry, err := h.urlSigner.Verify(service.ImagePath(key), c.Query("expires"), c.Query("signature"))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+
+		object, info, err := h.blobStore.Get(ctx, key)
+		if err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "image not found"})
+				return
+			}
+			logger.Logger(ctx).Error("Failed to open image:", err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+			return
+		}
+		defer object.Close()
+
+		// http.ServeContent needs to seek to honour Range requests. Local
+		// files can, remote objects are small enough to buffer.
+		content, ok := object.(io.ReadSeeker)
+		if !ok {
+			data, err := io.ReadAll(object)
+			if err != nil {
+				logger.Logger(ctx).Error("Failed to read image:", err)
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+				return
+			}
+			content = bytes.NewReader(data)
+		}
+
+		maxAge := int(time.Until(expiry).Seconds())
+		c.Header("Content-Type", info.ContentType)
+		c.Header("Cache-Control", fmt.Sprintf("private, max-age=%d", maxAge))
+		if info.ETag != "" {
+			c.Header("ETag", info.ETag)
+		}
+		http.ServeContent(c.Writer, c.Request, key, info.ModTime, content)
+	}
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"html/template"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nurcholisnanda/tigerhall-kittens/internal/service"
+	"github.com/nurcholisnanda/tigerhall-kittens/pkg/urlsign"
+)
+
+// confirmUnsubscribePage asks for a POST, so link scanners and prefetching
+// mail clients opening the link do not unsubscribe anyone
+var confirmUnsubscribePage = template.Must(template.New("unsubscribe").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Unsubscribe</title></head>
+<body>
+<form method="post" action="{{.Action}}">
+<p>{{.Question}}</p>
+<button type="submit">Unsubscribe</button>
+</form>
+</body>
+</html>
+`))
+
+type UnsubscribeHandler struct {
+	userSvc   service.UserService
+	urlSigner *urlsign.Signer
+}
+
+func NewUnsubscribeHandler(userSvc service.UserService, urlSigner *urlsign.Signer) *UnsubscribeHandler {
+	return &UnsubscribeHandler{
+		userSvc:   userSvc,
+		urlSigner: urlSigner,
+	}
+}
+
+// Confirm serves the signed links in notification emails opened in a
+// browser. It only checks the signature and renders a form that posts back
+// to the same link.
+func (h *UnsubscribeHandler) Confirm() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, tigerID := c.Param("userID"), c.Param("tigerID")
+		if !h.verify(c, userID, tigerID) {
+			return
+		}
+
+		question := "Stop receiving notification emails?"
+		if tigerID != "" {
+			question = "Stop being notified about this tiger?"
+		}
+		c.Status(http.StatusOK)
+		c.Header("Content-Type", "text/html; charset=utf-8")
+		if err := confirmUnsubscribePage.Execute(c.Writer, gin.H{
+			"Action":   c.Request.URL.RequestURI(),
+			"Question": question,
+		}); err != nil {
+			c.Error(err)
+		}
+	}
+}
+
+// Unsubscribe serves the confirmation form and one-click unsubscribe from
+// mail clients (RFC 8058). It turns emails off or mutes the tiger in the
+// path.
+func (h *UnsubscribeHandler) Unsubscribe() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		userID, tigerID := c.Param("userID"), c.Param("tigerID")
+		if !h.verify(c, userID, tigerID) {
+			return
+		}
+
+		if err := h.userSvc.Unsubscribe(ctx, userID, tigerID); err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+			return
+		}
+
+		if tigerID != "" {
+			c.String(http.StatusOK, "You will no longer be notified about this tiger.")
+			return
+		}
+		c.String(http.StatusOK, "You have been unsubscribed from notification emails.")
+	}
+}
+
+// verify checks the signature of an unsubscribe link and aborts the request
+// when it is invalid
+func (h *UnsubscribeHandler) verify(c *gin.Context, userID, tigerID string) bool {
+	if _, err := h.urlSigner.Verify(service.UnsubscribePath(userID, tigerID), c.Query("expires"), c.Query("signature")); err != nil {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
+}
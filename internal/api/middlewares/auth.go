@@ -2,15 +2,16 @@ package middlewares
 
 import (
 	"context"
+	"errors"
 	"net/http"
+	"strings"
 
+	"github.com/99designs/gqlgen/graphql/handler/transport"
 	"github.com/gin-gonic/gin"
 	"github.com/nurcholisnanda/tigerhall-kittens/internal/service"
 	"github.com/nurcholisnanda/tigerhall-kittens/pkg/helper"
 )
 
-type ContextKey string
-
 type AuthMiddleware struct {
 	userSvc service.UserService
 	jwtSvc  service.JWT
@@ -35,25 +36,71 @@ func (m *AuthMiddleware) Authenticate() gin.HandlerFunc {
 			return
 		}
 
-		tokenString := authHeader[len("Bearer "):]
-
-		// Validate and parse the JWT token
-		claims, err := m.jwtSvc.ValidateToken(ctx, tokenString)
-		if err != nil {
+		if err := m.authenticate(ctx, c, authHeader); err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
 			return
 		}
-		customClaim, _ := claims.Claims.(*helper.JwtCustomClaim)
-
-		if _, err := m.userSvc.GetUserByID(ctx, customClaim.ID); err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
-			return
-		}
-
-		c.Set("auth", customClaim)
 		ctx = helper.SetContext(c.Request.Context(), "ContextKey", c)
 		c.Request = c.Request.WithContext(ctx)
 		// Continue to next middleware or handler
 		c.Next()
 	}
 }
+
+// WebsocketInit authenticates a GraphQL subscription connection with the
+// token sent in the connection init payload, browsers cannot set headers on
+// a websocket. A connection without a token stays anonymous.
+func (m *AuthMiddleware) WebsocketInit(ctx context.Context, payload transport.InitPayload) (context.Context, *transport.InitPayload, error) {
+	authHeader := payload.Authorization()
+	if authHeader == "" {
+		return ctx, &payload, nil
+	}
+	c, err := helper.RetrieveGinContext(ctx, "ContextKey")
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := m.authenticate(ctx, c, authHeader); err != nil {
+		return nil, nil, errors.New("Invalid token")
+	}
+	return ctx, &payload, nil
+}
+
+// authenticate validates a bearer token and stores the claims and role of
+// its user on the gin context
+func (m *AuthMiddleware) authenticate(ctx context.Context, c *gin.Context, authHeader string) error {
+	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+
+	// Validate and parse the JWT token
+	claims, err := m.jwtSvc.ValidateToken(ctx, tokenString)
+	if err != nil {
+		return err
+	}
+	customClaim, _ := claims.Claims.(*helper.JwtCustomClaim)
+
+	user, err := m.userSvc.GetUserByID(ctx, customClaim.ID)
+	if err != nil {
+		return err
+	}
+
+	c.Set("auth", customClaim)
+	c.Set("role", string(user.Role))
+	return nil
+}
+
+// RequireRole only lets authenticated users holding one of the roles through
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := c.Get("auth"); !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header missing"})
+			return
+		}
+		role := c.GetString("role")
+		for _, allowed := range roles {
+			if role == allowed {
+				c.Next()
+				return
+			}
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Access Denied"})
+	}
+}
@@ -0,0 +1,109 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/websocket"
+	"github.com/nurcholisnanda/tigerhall-kittens/internal/api/graph/model"
+	mockSvc "github.com/nurcholisnanda/tigerhall-kittens/internal/service/mock"
+	"github.com/nurcholisnanda/tigerhall-kittens/pkg/helper"
+	"go.uber.org/mock/gomock"
+)
+
+func TestAuthMiddleware_WebsocketInit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctrl := gomock.NewController(t)
+	userSvc := mockSvc.NewMockUserService(ctrl)
+	jwtSvc := mockSvc.NewMockJWT(ctrl)
+	m := NewAuthMiddleware(userSvc, jwtSvc)
+
+	tests := []struct {
+		name       string
+		payload    map[string]any
+		mocks      []*gomock.Call
+		wantType   string
+		wantUserID string
+	}{
+		{
+			name:    "token in the init payload",
+			payload: map[string]any{"Authorization": "Bearer valid"},
+			mocks: []*gomock.Call{
+				jwtSvc.EXPECT().ValidateToken(gomock.Any(), "valid").
+					Return(&jwt.Token{Claims: &helper.JwtCustomClaim{ID: "user-1"}}, nil),
+				userSvc.EXPECT().GetUserByID(gomock.Any(), "user-1").
+					Return(&model.User{ID: "user-1", Role: model.RoleUser}, nil),
+			},
+			wantType:   "connection_ack",
+			wantUserID: "user-1",
+		},
+		{
+			name:     "anonymous",
+			payload:  map[string]any{},
+			wantType: "connection_ack",
+		},
+		{
+			name:    "invalid token",
+			payload: map[string]any{"Authorization": "Bearer invalid"},
+			mocks: []*gomock.Call{
+				jwtSvc.EXPECT().ValidateToken(gomock.Any(), "invalid").Return(nil, errors.New("token expired")),
+			},
+			// graphql-transport-ws closes the connection instead of acking it
+			wantType: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userIDs := make(chan string, 1)
+			r := gin.New()
+			r.Use(RequestIDMiddleware(), m.Authenticate())
+			r.GET("/query", func(c *gin.Context) {
+				ws := transport.Websocket{
+					InitFunc: func(ctx context.Context, payload transport.InitPayload) (context.Context, *transport.InitPayload, error) {
+						ctx, ack, err := m.WebsocketInit(ctx, payload)
+						if err == nil {
+							userID, _ := helper.GetUserID(ctx)
+							userIDs <- userID
+						}
+						return ctx, ack, err
+					},
+				}
+				ws.Do(c.Writer, c.Request, nil)
+			})
+			server := httptest.NewServer(r)
+			defer server.Close()
+
+			// browsers cannot set an Authorization header on the upgrade
+			dialer := websocket.Dialer{Subprotocols: []string{"graphql-transport-ws"}}
+			conn, _, err := dialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/query", nil)
+			if err != nil {
+				t.Fatalf("Dial() error = %v", err)
+			}
+			defer conn.Close()
+
+			if err := conn.WriteJSON(map[string]any{"type": "connection_init", "payload": tt.payload}); err != nil {
+				t.Fatalf("WriteJSON() error = %v", err)
+			}
+			var msg struct {
+				Type string `json:"type"`
+			}
+			if err := conn.ReadJSON(&msg); err != nil && !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+				t.Fatalf("ReadJSON() error = %v", err)
+			}
+			if msg.Type != tt.wantType {
+				t.Errorf("WebsocketInit() message = %v, want %v", msg.Type, tt.wantType)
+			}
+			if tt.wantType == "connection_ack" {
+				if userID := <-userIDs; userID != tt.wantUserID {
+					t.Errorf("WebsocketInit() user = %v, want %v", userID, tt.wantUserID)
+				}
+			}
+		})
+	}
+}
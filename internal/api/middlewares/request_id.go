@@ -1,17 +1,18 @@
 package middlewares
 
 import (
-	"context"
-
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/nurcholisnanda/tigerhall-kittens/pkg/helper"
 )
 
 func RequestIDMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		requestID := uuid.NewString()
 		c.Set("requestID", requestID)
-		ctx := context.WithValue(c.Request.Context(), ContextKey("ContextKey"), c)
+		// the gin context is read back with helper.RetrieveGinContext, also
+		// by requests without an Authorization header such as websockets
+		ctx := helper.SetContext(c.Request.Context(), "ContextKey", c)
 		c.Request = c.Request.WithContext(ctx)
 		c.Writer.Header().Set("X-Request-ID", requestID) // Add the request ID to the response header
 		c.Next()
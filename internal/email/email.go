@@ -0,0 +1,192 @@
+package email
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/nurcholisnanda/tigerhall-kittens/internal/api/graph/model"
+)
+
+// templateFS holds a directory per locale with a text and an HTML template
+// per kind of email. Each kind defines "subject" in its text template and
+// "content" in both, which the locale's layout wraps.
+//
+//go:embed templates
+var templateFS embed.FS
+
+const defaultLocale = model.LocaleEn
+
+// kinds of emails, named after their template files
+const (
+	kindSighting  = "sighting"
+	kindWatchZone = "watch_zone"
+	kindDigest    = "digest"
+)
+
+// Email is a rendered email
+type Email struct {
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Recipient is who an email is written for
+type Recipient struct {
+	Name   string
+	Locale model.Locale
+	// Location is the time zone sighting times are shown in
+	Location *time.Location
+}
+
+// Tiger is a tiger and its sightings in an email. Unidentified sightings
+// belong to a tiger without ID.
+type Tiger struct {
+	ID       string
+	Name     string
+	TrackURL string
+	MuteURL  string
+
+	Sightings []*Sighting
+}
+
+type Sighting struct {
+	SeenAt       time.Time
+	Latitude     float64
+	Longitude    float64
+	PhotoURL     string
+	ThumbnailURL string
+}
+
+// data is what the templates are executed with
+type data struct {
+	Recipient      Recipient
+	Subject        string
+	UnsubscribeURL string
+
+	// Tiger and Sighting are set for a notification
+	Tiger    *Tiger
+	Sighting *Sighting
+
+	// Tigers, Frequency and Sightings are set for a digest
+	Tigers    []*Tiger
+	Frequency model.DigestFrequency
+	Sightings int
+}
+
+type templates struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+// parsed holds the templates by locale and kind, they are embedded so a
+// broken template fails at startup
+var parsed = mustParse(templateFS)
+
+// RenderNotification renders the email about a single sighting of tiger,
+// which holds that sighting
+func RenderNotification(recipient Recipient, notificationType model.NotificationType, tiger *Tiger,
+	unsubscribeURL string) (*Email, error) {
+	if len(tiger.Sightings) != 1 {
+		return nil, fmt.Errorf("a notification is about one sighting, got %d", len(tiger.Sightings))
+	}
+	kind := kindSighting
+	if notificationType == model.NotificationTypeWatchZone {
+		kind = kindWatchZone
+	}
+	tigers := inLocation([]*Tiger{tiger}, recipient.Location)
+	return render(recipient, kind, &data{
+		Recipient:      recipient,
+		UnsubscribeURL: unsubscribeURL,
+		Tiger:          tigers[0],
+		Sighting:       tigers[0].Sightings[0],
+	})
+}
+
+// RenderDigest renders the summary of the sightings of tigers
+func RenderDigest(recipient Recipient, frequency model.DigestFrequency, tigers []*Tiger,
+	unsubscribeURL string) (*Email, error) {
+	d := &data{
+		Recipient:      recipient,
+		UnsubscribeURL: unsubscribeURL,
+		Tigers:         inLocation(tigers, recipient.Location),
+		Frequency:      frequency,
+	}
+	for _, tiger := range tigers {
+		d.Sightings += len(tiger.Sightings)
+	}
+	return render(recipient, kindDigest, d)
+}
+
+func render(recipient Recipient, kind string, d *data) (*Email, error) {
+	byKind, ok := parsed[recipient.Locale]
+	if !ok {
+		byKind = parsed[defaultLocale]
+	}
+	t := byKind[kind]
+
+	var subject, text, html bytes.Buffer
+	if err := t.text.ExecuteTemplate(&subject, "subject", d); err != nil {
+		return nil, fmt.Errorf("error rendering %s subject: %w", kind, err)
+	}
+	d.Subject = subject.String()
+	if err := t.text.ExecuteTemplate(&text, "layout", d); err != nil {
+		return nil, fmt.Errorf("error rendering %s text: %w", kind, err)
+	}
+	if err := t.html.ExecuteTemplate(&html, "layout", d); err != nil {
+		return nil, fmt.Errorf("error rendering %s html: %w", kind, err)
+	}
+	return &Email{Subject: d.Subject, Text: text.String(), HTML: html.String()}, nil
+}
+
+// inLocation copies tigers with their sighting times in loc, UTC when loc
+// is nil
+func inLocation(tigers []*Tiger, loc *time.Location) []*Tiger {
+	if loc == nil {
+		loc = time.UTC
+	}
+	converted := make([]*Tiger, 0, len(tigers))
+	for _, tiger := range tigers {
+		copied := *tiger
+		copied.Sightings = make([]*Sighting, 0, len(tiger.Sightings))
+		for _, sighting := range tiger.Sightings {
+			s := *sighting
+			s.SeenAt = s.SeenAt.In(loc)
+			copied.Sightings = append(copied.Sightings, &s)
+		}
+		converted = append(converted, &copied)
+	}
+	return converted
+}
+
+func mustParse(fsys fs.FS) map[model.Locale]map[string]*templates {
+	parsed := make(map[model.Locale]map[string]*templates, len(model.AllLocale))
+	for _, locale := range model.AllLocale {
+		dir := "templates/" + localeDir(locale)
+		funcs := formatFuncs(locale)
+		parsed[locale] = make(map[string]*templates)
+		for _, kind := range []string{kindSighting, kindWatchZone, kindDigest} {
+			text := texttemplate.Must(texttemplate.New(kind).Funcs(texttemplate.FuncMap(funcs)).
+				ParseFS(fsys, dir+"/layout.txt", dir+"/"+kind+".txt"))
+			html := htmltemplate.Must(htmltemplate.New(kind).Funcs(htmltemplate.FuncMap(funcs)).
+				ParseFS(fsys, dir+"/layout.html", dir+"/"+kind+".html"))
+			parsed[locale][kind] = &templates{text: text, html: html}
+		}
+	}
+	return parsed
+}
+
+// localeDir returns the directory of a locale's templates, named after its
+// language code
+func localeDir(locale model.Locale) string {
+	switch locale {
+	case model.LocaleID:
+		return "id"
+	default:
+		return "en"
+	}
+}
@@ -0,0 +1,231 @@
+package email
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nurcholisnanda/tigerhall-kittens/internal/api/graph/model"
+)
+
+func Test_formatTime(t *testing.T) {
+	jakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		t.Fatalf("time.LoadLocation() error = %v", err)
+	}
+	seenAt := time.Date(2024, 8, 17, 1, 5, 0, 0, time.UTC).In(jakarta)
+	tests := []struct {
+		locale model.Locale
+		want   string
+	}{
+		{locale: model.LocaleEn, want: "Saturday, 17 August 2024 at 08:05 WIB"},
+		{locale: model.LocaleID, want: "Sabtu, 17 Agustus 2024 pukul 08.05 WIB"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.locale), func(t *testing.T) {
+			if got := formatTime(tt.locale, seenAt); got != tt.want {
+				t.Errorf("formatTime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_formatLocation(t *testing.T) {
+	tests := []struct {
+		name      string
+		locale    model.Locale
+		latitude  float64
+		longitude float64
+		want      string
+	}{
+		{name: "south east", locale: model.LocaleEn, latitude: -1.23456, longitude: 101.45678, want: "1.23°S, 101.46°E"},
+		{name: "north west", locale: model.LocaleEn, latitude: 2, longitude: -3.004, want: "2.00°N, 3.00°W"},
+		{name: "rounded to zero", locale: model.LocaleEn, latitude: -0.001, longitude: 0, want: "0.00°N, 0.00°E"},
+		{name: "indonesian", locale: model.LocaleID, latitude: -1.23456, longitude: 101.45678, want: "1,23° LS; 101,46° BT"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatLocation(tt.locale, tt.latitude, tt.longitude); got != tt.want {
+				t.Errorf("formatLocation() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderNotification(t *testing.T) {
+	jakarta, _ := time.LoadLocation("Asia/Jakarta")
+	sighting := &Sighting{
+		SeenAt:       time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC),
+		Latitude:     -1.23456,
+		Longitude:    101.45678,
+		PhotoURL:     "http://localhost:8080/images/a.jpg?expires=1&signature=s",
+		ThumbnailURL: "http://localhost:8080/images/a_thumbnail.jpg?expires=1&signature=s",
+	}
+	stripes := &Tiger{
+		ID:        "t1",
+		Name:      "Stripes <3",
+		TrackURL:  "http://localhost:8080/tigers/t1/track",
+		MuteURL:   "http://localhost:8080/unsubscribe/u1/tigers/t1",
+		Sightings: []*Sighting{sighting},
+	}
+	unidentified := &Tiger{Sightings: []*Sighting{sighting}}
+	tests := []struct {
+		name             string
+		recipient        Recipient
+		notificationType model.NotificationType
+		tiger            *Tiger
+		wantSubject      string
+		wantText         []string
+		wantHTML         []string
+		notWantText      []string
+	}{
+		{
+			name:             "sighting in English",
+			recipient:        Recipient{Name: "Siti", Locale: model.LocaleEn, Location: jakarta},
+			notificationType: model.NotificationTypeSighting,
+			tiger:            stripes,
+			wantSubject:      "New sighting of Stripes <3",
+			wantText: []string{
+				"Hello Siti,",
+				"Friday, 1 March 2024 at 17:00 WIB, near 1.23°S, 101.46°E",
+				"Photo: " + sighting.PhotoURL,
+				"Follow Stripes <3's track: " + stripes.TrackURL,
+				"Stop notifications about Stripes <3: " + stripes.MuteURL,
+				"Unsubscribe from all notification emails: http://localhost:8080/unsubscribe/u1",
+			},
+			wantHTML: []string{
+				`<html lang="en">`,
+				"<strong>Stripes &lt;3</strong>",
+				`<img src="http://localhost:8080/images/a_thumbnail.jpg?expires=1&amp;signature=s"`,
+				`href="http://localhost:8080/unsubscribe/u1"`,
+			},
+		},
+		{
+			name:             "sighting in Indonesian",
+			recipient:        Recipient{Name: "Siti", Locale: model.LocaleID, Location: jakarta},
+			notificationType: model.NotificationTypeSighting,
+			tiger:            stripes,
+			wantSubject:      "Penampakan baru Stripes <3",
+			wantText: []string{
+				"Halo Siti,",
+				"Jumat, 1 Maret 2024 pukul 17.00 WIB, di sekitar 1,23° LS; 101,46° BT",
+				"Berhenti menerima notifikasi tentang Stripes <3: " + stripes.MuteURL,
+			},
+			wantHTML: []string{`<html lang="id">`, `alt="Foto penampakan"`},
+		},
+		{
+			name:             "watch zone alert about an unidentified tiger",
+			recipient:        Recipient{Name: "Siti", Locale: model.LocaleEn},
+			notificationType: model.NotificationTypeWatchZone,
+			tiger:            unidentified,
+			wantSubject:      "A tiger sighted in your watch zone",
+			wantText:         []string{"An unidentified tiger has been sighted", "at 10:00 UTC"},
+			notWantText:      []string{"track", "Stop notifications about"},
+		},
+		{
+			name:             "unknown locales fall back to English",
+			recipient:        Recipient{Name: "Siti", Locale: model.Locale("FR")},
+			notificationType: model.NotificationTypeWatchZone,
+			tiger:            stripes,
+			wantSubject:      "Stripes <3 sighted in your watch zone",
+			wantText:         []string{"Follow Stripes <3's track"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RenderNotification(tt.recipient, tt.notificationType, tt.tiger, "http://localhost:8080/unsubscribe/u1")
+			if err != nil {
+				t.Fatalf("RenderNotification() error = %v", err)
+			}
+			if got.Subject != tt.wantSubject {
+				t.Errorf("RenderNotification() subject = %v, want %v", got.Subject, tt.wantSubject)
+			}
+			for _, want := range tt.wantText {
+				if !strings.Contains(got.Text, want) {
+					t.Errorf("RenderNotification() text does not contain %q:\n%s", want, got.Text)
+				}
+			}
+			for _, notWant := range tt.notWantText {
+				if strings.Contains(got.Text, notWant) {
+					t.Errorf("RenderNotification() text contains %q:\n%s", notWant, got.Text)
+				}
+			}
+			for _, want := range tt.wantHTML {
+				if !strings.Contains(got.HTML, want) {
+					t.Errorf("RenderNotification() HTML does not contain %q:\n%s", want, got.HTML)
+				}
+			}
+		})
+	}
+
+	// the times of the caller's sightings are left alone
+	if sighting.SeenAt.Location() != time.UTC {
+		t.Errorf("RenderNotification() changed the sighting time to %v", sighting.SeenAt.Location())
+	}
+	if _, err := RenderNotification(Recipient{}, model.NotificationTypeSighting, &Tiger{}, ""); err == nil {
+		t.Errorf("RenderNotification() without a sighting error = nil, want an error")
+	}
+}
+
+func TestRenderDigest(t *testing.T) {
+	seenAt := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	tigers := []*Tiger{
+		{
+			ID:       "t1",
+			Name:     "Stripes",
+			TrackURL: "http://localhost:8080/tigers/t1/track",
+			MuteURL:  "http://localhost:8080/unsubscribe/u1/tigers/t1",
+			Sightings: []*Sighting{
+				{SeenAt: seenAt, Latitude: 1, Longitude: 2},
+				{SeenAt: seenAt.Add(time.Hour), Latitude: 1, Longitude: 2},
+			},
+		},
+		{Sightings: []*Sighting{{SeenAt: seenAt, Latitude: 3, Longitude: 4}}},
+	}
+	tests := []struct {
+		name        string
+		locale      model.Locale
+		frequency   model.DigestFrequency
+		wantSubject string
+		wantText    []string
+	}{
+		{
+			name:        "hourly in English",
+			locale:      model.LocaleEn,
+			frequency:   model.DigestFrequencyHourly,
+			wantSubject: "Your hourly tiger sightings summary",
+			wantText: []string{
+				"Here is your hourly summary of 3 tiger sightings.",
+				"Stripes (2)\n- Friday, 1 March 2024 at 10:00 UTC",
+				"Track: http://localhost:8080/tigers/t1/track",
+				"Unidentified tiger (1)",
+			},
+		},
+		{
+			name:        "daily in Indonesian",
+			locale:      model.LocaleID,
+			frequency:   model.DigestFrequencyDaily,
+			wantSubject: "Ringkasan harian penampakan harimau Anda",
+			wantText:    []string{"berisi 3 penampakan harimau", "Harimau tak dikenal (1)"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RenderDigest(Recipient{Name: "Siti", Locale: tt.locale}, tt.frequency, tigers, "http://localhost:8080/unsubscribe/u1")
+			if err != nil {
+				t.Fatalf("RenderDigest() error = %v", err)
+			}
+			if got.Subject != tt.wantSubject {
+				t.Errorf("RenderDigest() subject = %v, want %v", got.Subject, tt.wantSubject)
+			}
+			for _, want := range tt.wantText {
+				if !strings.Contains(got.Text, want) {
+					t.Errorf("RenderDigest() text does not contain %q:\n%s", want, got.Text)
+				}
+			}
+			if strings.Count(got.HTML, "<h3") != len(tigers) {
+				t.Errorf("RenderDigest() HTML has %d tiger headings, want %d", strings.Count(got.HTML, "<h3"), len(tigers))
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package email
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/nurcholisnanda/tigerhall-kittens/internal/api/graph/model"
+)
+
+var (
+	indonesianDays   = [...]string{"Minggu", "Senin", "Selasa", "Rabu", "Kamis", "Jumat", "Sabtu"}
+	indonesianMonths = [...]string{"Januari", "Februari", "Maret", "April", "Mei", "Juni", "Juli",
+		"Agustus", "September", "Oktober", "November", "Desember"}
+)
+
+// formatFuncs returns the template functions formatting values for locale
+func formatFuncs(locale model.Locale) map[string]any {
+	return map[string]any{
+		"datetime": func(t time.Time) string { return formatTime(locale, t) },
+		"location": func(latitude, longitude float64) string { return formatLocation(locale, latitude, longitude) },
+	}
+}
+
+// formatTime writes out a time in its own time zone
+func formatTime(locale model.Locale, t time.Time) string {
+	zone, _ := t.Zone()
+	if locale == model.LocaleID {
+		return fmt.Sprintf("%s, %d %s %d pukul %02d.%02d %s", indonesianDays[t.Weekday()], t.Day(),
+			indonesianMonths[t.Month()-1], t.Year(), t.Hour(), t.Minute(), zone)
+	}
+	return t.Format("Monday, 2 January 2006 at 15:04 ") + zone
+}
+
+// formatLocation rounds coordinates to two decimals, about a kilometre, so
+// an email does not point at the exact spot a tiger was seen
+func formatLocation(locale model.Locale, latitude, longitude float64) string {
+	latitude = math.Round(latitude*100) / 100
+	longitude = math.Round(longitude*100) / 100
+	north, south, east, west := "N", "S", "E", "W"
+	if locale == model.LocaleID {
+		north, south, east, west = " LU", " LS", " BT", " BB"
+	}
+	latHemisphere, lonHemisphere := north, east
+	if latitude < 0 {
+		latHemisphere = south
+	}
+	if longitude < 0 {
+		lonHemisphere = west
+	}
+	formatted := fmt.Sprintf("%.2f°%s, %.2f°%s", math.Abs(latitude), latHemisphere, math.Abs(longitude), lonHemisphere)
+	if locale == model.LocaleID {
+		// Indonesian writes decimals with a comma, the separator becomes a
+		// semicolon to keep the two apart
+		formatted = strings.NewReplacer(".", ",", ", ", "; ").Replace(formatted)
+	}
+	return formatted
+}
@@ -0,0 +1,216 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/nurcholisnanda/tigerhall-kittens/internal/api/graph/model"
+)
+
+// BulkFormat is an output format supported by the bulk exporters
+type BulkFormat string
+
+const (
+	CSV           BulkFormat = "csv"
+	NDJSON        BulkFormat = "ndjson"
+	BulkGeoJSON   BulkFormat = "geojson"
+	defaultFormat            = CSV
+)
+
+// ContentType returns the MIME type served for the format
+func (f BulkFormat) ContentType() string {
+	switch f {
+	case NDJSON:
+		return "application/x-ndjson"
+	case BulkGeoJSON:
+		return "application/geo+json"
+	default:
+		return "text/csv; charset=utf-8"
+	}
+}
+
+// IsValid reports whether the format has a bulk exporter
+func (f BulkFormat) IsValid() bool {
+	return f == CSV || f == NDJSON || f == BulkGeoJSON
+}
+
+// ParseBulkFormat returns the format named by value, defaulting to CSV
+func ParseBulkFormat(value string) (BulkFormat, error) {
+	if value == "" {
+		return defaultFormat, nil
+	}
+	format := BulkFormat(value)
+	if !format.IsValid() {
+		return "", fmt.Errorf("format must be one of csv, ndjson or geojson")
+	}
+	return format, nil
+}
+
+// Record is one flat exported row. Values are ordered like the columns the
+// RecordWriter was created with.
+type Record struct {
+	Values     []interface{}
+	Coordinate *model.LastSeenCoordinate
+}
+
+// RecordWriter streams records one at a time. Close must be called once every
+// record is written so formats with a footer stay valid.
+type RecordWriter interface {
+	Write(record *Record) error
+	Close() error
+}
+
+var (
+	TigerColumns    = []string{"id", "name", "dateOfBirth", "lastSeenTime", "latitude", "longitude"}
+	SightingColumns = []string{"id", "tigerID", "lastSeenTime", "latitude", "longitude", "createdAt"}
+)
+
+// TigerRecord flattens a tiger using TigerColumns
+func TigerRecord(tiger *model.Tiger) *Record {
+	return &Record{
+		Values: []interface{}{
+			tiger.ID,
+			tiger.Name,
+			formatTime(tiger.DateOfBirth),
+			formatTime(tiger.LastSeenTime),
+			tiger.Latitude,
+			tiger.Longitude,
+		},
+		Coordinate: tiger.LastSeenCoordinate,
+	}
+}
+
+// SightingRecord flattens a sighting using SightingColumns
+func SightingRecord(sighting *model.Sighting) *Record {
+	return &Record{
+		Values: []interface{}{
+			sighting.ID,
+			sightingTigerID(sighting),
+			formatTime(sighting.LastSeenTime),
+			sighting.Latitude,
+			sighting.Longitude,
+			formatTime(sighting.CreatedAt),
+		},
+		Coordinate: sighting.LastSeenCoordinate,
+	}
+}
+
+// NewRecordWriter returns a streaming writer for the format
+func NewRecordWriter(w io.Writer, format BulkFormat, columns []string) (RecordWriter, error) {
+	switch format {
+	case CSV:
+		return newCSVWriter(w, columns)
+	case NDJSON:
+		return &ndjsonWriter{encoder: json.NewEncoder(w), columns: columns}, nil
+	case BulkGeoJSON:
+		return &geoJSONWriter{w: w, columns: columns}, nil
+	default:
+		return nil, fmt.Errorf("unsupported bulk format %q", format)
+	}
+}
+
+type csvWriter struct {
+	writer *csv.Writer
+	row    []string
+}
+
+func newCSVWriter(w io.Writer, columns []string) (*csvWriter, error) {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(columns); err != nil {
+		return nil, err
+	}
+	return &csvWriter{writer: writer, row: make([]string, len(columns))}, nil
+}
+
+func (c *csvWriter) Write(record *Record) error {
+	for i, value := range record.Values {
+		switch v := value.(type) {
+		case float64:
+			c.row[i] = strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			c.row[i] = fmt.Sprint(v)
+		}
+	}
+	return c.writer.Write(c.row)
+}
+
+func (c *csvWriter) Close() error {
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+type ndjsonWriter struct {
+	encoder *json.Encoder
+	columns []string
+}
+
+func (n *ndjsonWriter) Write(record *Record) error {
+	return n.encoder.Encode(recordProperties(n.columns, record))
+}
+
+func (n *ndjsonWriter) Close() error {
+	return nil
+}
+
+// geoJSONWriter writes a FeatureCollection feature by feature instead of
+// encoding the whole collection at once.
+type geoJSONWriter struct {
+	w       io.Writer
+	columns []string
+	count   int
+}
+
+func (g *geoJSONWriter) Write(record *Record) error {
+	prefix := ","
+	if g.count == 0 {
+		prefix = `{"type":"FeatureCollection","features":[`
+	}
+	feature, err := json.Marshal(&geoJSONFeature{
+		Type: "Feature",
+		Geometry: geoJSONGeometry{
+			Type:        "Point",
+			Coordinates: [2]float64{record.Coordinate.Longitude, record.Coordinate.Latitude},
+		},
+		Properties: recordProperties(g.columns, record),
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(g.w, prefix); err != nil {
+		return err
+	}
+	if _, err := g.w.Write(append(feature, '\n')); err != nil {
+		return err
+	}
+	g.count++
+	return nil
+}
+
+func (g *geoJSONWriter) Close() error {
+	footer := "]}\n"
+	if g.count == 0 {
+		footer = `{"type":"FeatureCollection","features":[]}` + "\n"
+	}
+	_, err := io.WriteString(g.w, footer)
+	return err
+}
+
+func recordProperties(columns []string, record *Record) map[string]interface{} {
+	properties := make(map[string]interface{}, len(columns))
+	for i, column := range columns {
+		properties[column] = record.Values[i]
+	}
+	return properties
+}
+
+// sightingTigerID returns the tiger of a sighting, empty while it is
+// unidentified
+func sightingTigerID(sighting *model.Sighting) string {
+	if sighting.TigerID == nil {
+		return ""
+	}
+	return *sighting.TigerID
+}
@@ -0,0 +1,77 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/nurcholisnanda/tigerhall-kittens/internal/api/graph/model"
+)
+
+func TestParseBulkFormat(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    BulkFormat
+		wantErr bool
+	}{
+		{value: "", want: CSV},
+		{value: "ndjson", want: NDJSON},
+		{value: "geojson", want: BulkGeoJSON},
+		{value: "xlsx", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseBulkFormat(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseBulkFormat() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ParseBulkFormat() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGeoJSONRecordWriter(t *testing.T) {
+	tests := []struct {
+		name     string
+		records  []*Record
+		features int
+	}{
+		{name: "empty collection"},
+		{
+			name: "two features",
+			records: []*Record{
+				{Values: []interface{}{"a"}, Coordinate: &model.LastSeenCoordinate{Latitude: 1, Longitude: 2}},
+				{Values: []interface{}{"b"}, Coordinate: &model.LastSeenCoordinate{Latitude: 3, Longitude: 4}},
+			},
+			features: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			writer, err := NewRecordWriter(&buf, BulkGeoJSON, []string{"id"})
+			if err != nil {
+				t.Fatalf("NewRecordWriter() error = %v", err)
+			}
+			for _, record := range tt.records {
+				if err := writer.Write(record); err != nil {
+					t.Fatalf("Write() error = %v", err)
+				}
+			}
+			if err := writer.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+
+			var got geoJSONFeatureCollection
+			if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+				t.Fatalf("writer produced invalid JSON %s: %v", buf.String(), err)
+			}
+			if got.Type != "FeatureCollection" || len(got.Features) != tt.features {
+				t.Errorf("writer = %s, want %d features", buf.String(), tt.features)
+			}
+		})
+	}
+}
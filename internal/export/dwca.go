@@ -0,0 +1,270 @@
+package export
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/xml"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/nurcholisnanda/tigerhall-kittens/internal/api/graph/model"
+)
+
+const (
+	dwcOccurrenceRowType = "http://rs.tdwg.org/dwc/terms/Occurrence"
+	dwcTermPrefix        = "http://rs.tdwg.org/dwc/terms/"
+	dcTermPrefix         = "http://purl.org/dc/terms/"
+	scientificName       = "Panthera tigris"
+	// sensitiveCellsPerDegree sets the grid sensitive coordinates are snapped
+	// to, 0.1 degree or about 11 km at the equator
+	sensitiveCellsPerDegree = 10
+	// sensitiveUncertainty is the worst case error introduced by snapping
+	sensitiveUncertainty = 7900
+)
+
+// occurrenceTerms lists the columns of occurrence.txt. The first column is
+// the core id and every column is described in meta.xml.
+var occurrenceTerms = []struct {
+	Name string
+	URI  string
+}{
+	{"occurrenceID", dwcTermPrefix + "occurrenceID"},
+	{"basisOfRecord", dwcTermPrefix + "basisOfRecord"},
+	{"eventDate", dwcTermPrefix + "eventDate"},
+	{"scientificName", dwcTermPrefix + "scientificName"},
+	{"kingdom", dwcTermPrefix + "kingdom"},
+	{"occurrenceStatus", dwcTermPrefix + "occurrenceStatus"},
+	{"individualCount", dwcTermPrefix + "individualCount"},
+	{"organismID", dwcTermPrefix + "organismID"},
+	{"organismName", dwcTermPrefix + "organismName"},
+	{"recordedBy", dwcTermPrefix + "recordedBy"},
+	{"decimalLatitude", dwcTermPrefix + "decimalLatitude"},
+	{"decimalLongitude", dwcTermPrefix + "decimalLongitude"},
+	{"geodeticDatum", dwcTermPrefix + "geodeticDatum"},
+	{"coordinateUncertaintyInMeters", dwcTermPrefix + "coordinateUncertaintyInMeters"},
+	{"dataGeneralizations", dwcTermPrefix + "dataGeneralizations"},
+	{"associatedMedia", dwcTermPrefix + "associatedMedia"},
+	{"modified", dcTermPrefix + "modified"},
+}
+
+// Occurrence is a sighting mapped to Darwin Core occurrence terms
+type Occurrence struct {
+	Tiger           *model.Tiger
+	Sighting        *model.Sighting
+	RecordedBy      string
+	AssociatedMedia string
+}
+
+// DatasetMetadata describes the dataset in eml.xml
+type DatasetMetadata struct {
+	Title     string
+	Publisher string
+	Abstract  string
+	PubDate   time.Time
+}
+
+// DwCAWriter writes a Darwin Core Archive zip. Occurrences are streamed into
+// occurrence.txt and the descriptor files are added on Close.
+type DwCAWriter struct {
+	zip      *zip.Writer
+	csv      *csv.Writer
+	metadata DatasetMetadata
+	row      []string
+}
+
+// NewDwCAWriter starts an archive on w
+func NewDwCAWriter(w io.Writer, metadata DatasetMetadata) (*DwCAWriter, error) {
+	archive := zip.NewWriter(w)
+	file, err := archive.Create("occurrence.txt")
+	if err != nil {
+		return nil, err
+	}
+	writer := csv.NewWriter(file)
+	writer.Comma = '\t'
+
+	header := make([]string, len(occurrenceTerms))
+	for i, term := range occurrenceTerms {
+		header[i] = term.Name
+	}
+	if err := writer.Write(header); err != nil {
+		return nil, err
+	}
+
+	return &DwCAWriter{
+		zip:      archive,
+		csv:      writer,
+		metadata: metadata,
+		row:      make([]string, len(occurrenceTerms)),
+	}, nil
+}
+
+// Write adds one occurrence record. Sightings of sensitive tigers have their
+// coordinates snapped to a coarse grid and the generalization documented.
+func (d *DwCAWriter) Write(occurrence *Occurrence) error {
+	sighting := occurrence.Sighting
+	latitude, longitude := sighting.Latitude, sighting.Longitude
+	uncertainty, generalizations := "", ""
+	if occurrence.Tiger != nil && occurrence.Tiger.Sensitive {
+		latitude, longitude = coarsen(latitude), coarsen(longitude)
+		uncertainty = strconv.Itoa(sensitiveUncertainty)
+		generalizations = "Coordinates rounded to 0.1 degree to protect a sensitive individual"
+	}
+
+	organismName := ""
+	if occurrence.Tiger != nil {
+		organismName = occurrence.Tiger.Name
+	}
+
+	d.row = d.row[:0]
+	d.row = append(d.row,
+		sighting.ID,
+		"HumanObservation",
+		formatTime(sighting.LastSeenTime),
+		scientificName,
+		"Animalia",
+		"present",
+		"1",
+		sightingTigerID(sighting),
+		organismName,
+		occurrence.RecordedBy,
+		strconv.FormatFloat(latitude, 'f', -1, 64),
+		strconv.FormatFloat(longitude, 'f', -1, 64),
+		"WGS84",
+		uncertainty,
+		generalizations,
+		occurrence.AssociatedMedia,
+		formatTime(sighting.UpdatedAt),
+	)
+	return d.csv.Write(d.row)
+}
+
+// Close finishes occurrence.txt, adds meta.xml and eml.xml and closes the zip
+func (d *DwCAWriter) Close() error {
+	d.csv.Flush()
+	if err := d.csv.Error(); err != nil {
+		return err
+	}
+	if err := d.writeXMLFile("meta.xml", newArchiveMeta()); err != nil {
+		return err
+	}
+	if err := d.writeXMLFile("eml.xml", newEML(d.metadata)); err != nil {
+		return err
+	}
+	return d.zip.Close()
+}
+
+func (d *DwCAWriter) writeXMLFile(name string, doc interface{}) error {
+	file, err := d.zip.Create(name)
+	if err != nil {
+		return err
+	}
+	return writeXML(file, doc)
+}
+
+func coarsen(degrees float64) float64 {
+	return math.Round(degrees*sensitiveCellsPerDegree) / sensitiveCellsPerDegree
+}
+
+// CoarsenCoordinate returns a copy of the coordinate snapped to the grid
+// sensitive tigers are published on, or nil for a nil coordinate
+func CoarsenCoordinate(coordinate *model.LastSeenCoordinate) *model.LastSeenCoordinate {
+	if coordinate == nil {
+		return nil
+	}
+	return &model.LastSeenCoordinate{
+		Latitude:  coarsen(coordinate.Latitude),
+		Longitude: coarsen(coordinate.Longitude),
+	}
+}
+
+type archiveMeta struct {
+	XMLName  xml.Name     `xml:"archive"`
+	XMLNS    string       `xml:"xmlns,attr"`
+	Metadata string       `xml:"metadata,attr"`
+	Core     archiveTable `xml:"core"`
+}
+
+type archiveTable struct {
+	Encoding           string         `xml:"encoding,attr"`
+	FieldsTerminatedBy string         `xml:"fieldsTerminatedBy,attr"`
+	LinesTerminatedBy  string         `xml:"linesTerminatedBy,attr"`
+	FieldsEnclosedBy   string         `xml:"fieldsEnclosedBy,attr"`
+	IgnoreHeaderLines  int            `xml:"ignoreHeaderLines,attr"`
+	RowType            string         `xml:"rowType,attr"`
+	Location           string         `xml:"files>location"`
+	ID                 archiveID      `xml:"id"`
+	Fields             []archiveField `xml:"field"`
+}
+
+type archiveID struct {
+	Index int `xml:"index,attr"`
+}
+
+type archiveField struct {
+	Index int    `xml:"index,attr"`
+	Term  string `xml:"term,attr"`
+}
+
+func newArchiveMeta() *archiveMeta {
+	fields := make([]archiveField, len(occurrenceTerms))
+	for i, term := range occurrenceTerms {
+		fields[i] = archiveField{Index: i, Term: term.URI}
+	}
+	return &archiveMeta{
+		XMLNS:    "http://rs.tdwg.org/dwc/text/",
+		Metadata: "eml.xml",
+		Core: archiveTable{
+			Encoding:           "UTF-8",
+			FieldsTerminatedBy: `\t`,
+			LinesTerminatedBy:  `\n`,
+			FieldsEnclosedBy:   `"`,
+			IgnoreHeaderLines:  1,
+			RowType:            dwcOccurrenceRowType,
+			Location:           "occurrence.txt",
+			ID:                 archiveID{Index: 0},
+			Fields:             fields,
+		},
+	}
+}
+
+type emlDocument struct {
+	XMLName   xml.Name   `xml:"eml:eml"`
+	XMLNSEML  string     `xml:"xmlns:eml,attr"`
+	PackageID string     `xml:"packageId,attr"`
+	System    string     `xml:"system,attr"`
+	Dataset   emlDataset `xml:"dataset"`
+}
+
+type emlDataset struct {
+	Title     string   `xml:"title"`
+	Creator   emlParty `xml:"creator"`
+	Publisher emlParty `xml:"publisher"`
+	PubDate   string   `xml:"pubDate"`
+	Language  string   `xml:"language"`
+	Abstract  string   `xml:"abstract>para"`
+	Contact   emlParty `xml:"contact"`
+}
+
+type emlParty struct {
+	OrganizationName string `xml:"organizationName"`
+}
+
+func newEML(metadata DatasetMetadata) *emlDocument {
+	party := emlParty{OrganizationName: metadata.Publisher}
+	return &emlDocument{
+		XMLNSEML:  "eml://ecoinformatics.org/eml-2.1.1",
+		PackageID: "tigerhall-kittens-sightings",
+		System:    "http://gbif.org",
+		Dataset: emlDataset{
+			Title:     metadata.Title,
+			Creator:   party,
+			Publisher: party,
+			PubDate:   metadata.PubDate.UTC().Format("2006-01-02"),
+			Language:  "en",
+			Abstract:  metadata.Abstract,
+			Contact:   party,
+		},
+	}
+}
@@ -0,0 +1,190 @@
+package export
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/nurcholisnanda/tigerhall-kittens/internal/api/graph/model"
+)
+
+// TrackFormat is an output format supported by the track exporters
+type TrackFormat string
+
+const (
+	GeoJSON TrackFormat = "geojson"
+	KML     TrackFormat = "kml"
+	GPX     TrackFormat = "gpx"
+)
+
+// ContentType returns the MIME type served for the format
+func (f TrackFormat) ContentType() string {
+	switch f {
+	case KML:
+		return "application/vnd.google-earth.kml+xml"
+	case GPX:
+		return "application/gpx+xml"
+	default:
+		return "application/geo+json"
+	}
+}
+
+// IsValid reports whether the format has a track exporter
+func (f TrackFormat) IsValid() bool {
+	return f == GeoJSON || f == KML || f == GPX
+}
+
+// WriteTrack encodes the track of a tiger in the requested format
+func WriteTrack(w io.Writer, format TrackFormat, track *model.TigerTrack) error {
+	switch format {
+	case GeoJSON:
+		return WriteTrackGeoJSON(w, track)
+	case KML:
+		return WriteTrackKML(w, track)
+	case GPX:
+		return WriteTrackGPX(w, track)
+	default:
+		return fmt.Errorf("unsupported track format %q", format)
+	}
+}
+
+type geoJSONGeometry struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
+
+type geoJSONFeature struct {
+	Type       string                 `json:"type"`
+	Geometry   geoJSONGeometry        `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type geoJSONFeatureCollection struct {
+	Type     string            `json:"type"`
+	Features []*geoJSONFeature `json:"features"`
+}
+
+// WriteTrackGeoJSON writes the track as a FeatureCollection holding one
+// LineString for the whole trajectory followed by one Point per sighting.
+// GeoJSON positions are ordered longitude first.
+func WriteTrackGeoJSON(w io.Writer, track *model.TigerTrack) error {
+	line := make([][2]float64, 0, len(track.Sightings))
+	times := make([]string, 0, len(track.Sightings))
+	features := make([]*geoJSONFeature, 0, len(track.Sightings)+1)
+	for _, sighting := range track.Sightings {
+		line = append(line, [2]float64{sighting.Longitude, sighting.Latitude})
+		times = append(times, formatTime(sighting.LastSeenTime))
+	}
+
+	features = append(features, &geoJSONFeature{
+		Type:     "Feature",
+		Geometry: geoJSONGeometry{Type: "LineString", Coordinates: line},
+		Properties: map[string]interface{}{
+			"tigerID":       track.Tiger.ID,
+			"name":          track.Tiger.Name,
+			"totalDistance": track.TotalDistance,
+			"coordTimes":    times,
+		},
+	})
+	for i, sighting := range track.Sightings {
+		features = append(features, &geoJSONFeature{
+			Type:     "Feature",
+			Geometry: geoJSONGeometry{Type: "Point", Coordinates: line[i]},
+			Properties: map[string]interface{}{
+				"sightingID": sighting.ID,
+				"tigerID":    track.Tiger.ID,
+				"time":       times[i],
+			},
+		})
+	}
+
+	return json.NewEncoder(w).Encode(&geoJSONFeatureCollection{
+		Type:     "FeatureCollection",
+		Features: features,
+	})
+}
+
+type kmlDocument struct {
+	XMLName  xml.Name     `xml:"kml"`
+	XMLNS    string       `xml:"xmlns,attr"`
+	XMLNSGX  string       `xml:"xmlns:gx,attr"`
+	Document kmlPlacemark `xml:"Document>Placemark"`
+}
+
+type kmlPlacemark struct {
+	Name        string   `xml:"name"`
+	Description string   `xml:"description,omitempty"`
+	When        []string `xml:"gx:Track>when"`
+	Coords      []string `xml:"gx:Track>gx:coord"`
+}
+
+// WriteTrackKML writes the track as a KML gx:Track so every position keeps
+// its timestamp when loaded into a GIS tool.
+func WriteTrackKML(w io.Writer, track *model.TigerTrack) error {
+	placemark := kmlPlacemark{
+		Name:        track.Tiger.Name,
+		Description: fmt.Sprintf("Track of tiger %s (%.0f m)", track.Tiger.ID, track.TotalDistance),
+	}
+	for _, sighting := range track.Sightings {
+		placemark.When = append(placemark.When, formatTime(sighting.LastSeenTime))
+		placemark.Coords = append(placemark.Coords, fmt.Sprintf("%f %f 0", sighting.Longitude, sighting.Latitude))
+	}
+
+	return writeXML(w, &kmlDocument{
+		XMLNS:    "http://www.opengis.net/kml/2.2",
+		XMLNSGX:  "http://www.google.com/kml/ext/2.2",
+		Document: placemark,
+	})
+}
+
+type gpxDocument struct {
+	XMLName xml.Name `xml:"gpx"`
+	XMLNS   string   `xml:"xmlns,attr"`
+	Version string   `xml:"version,attr"`
+	Creator string   `xml:"creator,attr"`
+	Track   gpxTrack `xml:"trk"`
+}
+
+type gpxTrack struct {
+	Name   string     `xml:"name"`
+	Points []gpxPoint `xml:"trkseg>trkpt"`
+}
+
+type gpxPoint struct {
+	Latitude  float64 `xml:"lat,attr"`
+	Longitude float64 `xml:"lon,attr"`
+	Time      string  `xml:"time"`
+}
+
+// WriteTrackGPX writes the track as a single GPX track segment
+func WriteTrackGPX(w io.Writer, track *model.TigerTrack) error {
+	doc := &gpxDocument{
+		XMLNS:   "http://www.topografix.com/GPX/1/1",
+		Version: "1.1",
+		Creator: "TigerHall Kittens",
+		Track:   gpxTrack{Name: track.Tiger.Name},
+	}
+	for _, sighting := range track.Sightings {
+		doc.Track.Points = append(doc.Track.Points, gpxPoint{
+			Latitude:  sighting.Latitude,
+			Longitude: sighting.Longitude,
+			Time:      formatTime(sighting.LastSeenTime),
+		})
+	}
+	return writeXML(w, doc)
+}
+
+func writeXML(w io.Writer, doc interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	return encoder.Encode(doc)
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,88 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nurcholisnanda/tigerhall-kittens/internal/api/graph/model"
+)
+
+func testTrack() *model.TigerTrack {
+	start := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	return &model.TigerTrack{
+		Tiger: &model.Tiger{ID: "tiger-1", Name: "Raja"},
+		Sightings: []*model.Sighting{
+			{ID: "s1", LastSeenTime: start, LastSeenCoordinate: &model.LastSeenCoordinate{Latitude: 1.5, Longitude: 103.5}},
+			{ID: "s2", LastSeenTime: start.Add(time.Hour), LastSeenCoordinate: &model.LastSeenCoordinate{Latitude: 1.6, Longitude: 103.7}},
+		},
+		TotalDistance: 24800,
+	}
+}
+
+func TestWriteTrackGeoJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteTrack(&buf, GeoJSON, testTrack()); err != nil {
+		t.Fatalf("WriteTrack() error = %v", err)
+	}
+
+	var got struct {
+		Type     string `json:"type"`
+		Features []struct {
+			Geometry struct {
+				Type        string          `json:"type"`
+				Coordinates json.RawMessage `json:"coordinates"`
+			} `json:"geometry"`
+			Properties map[string]interface{} `json:"properties"`
+		} `json:"features"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("WriteTrack() produced invalid JSON: %v", err)
+	}
+	if got.Type != "FeatureCollection" || len(got.Features) != 3 {
+		t.Fatalf("WriteTrack() = %s, want a FeatureCollection with a line and two points", buf.String())
+	}
+	if got.Features[0].Geometry.Type != "LineString" || string(got.Features[0].Geometry.Coordinates) != "[[103.5,1.5],[103.7,1.6]]" {
+		t.Errorf("WriteTrack() line = %s", got.Features[0].Geometry.Coordinates)
+	}
+	if got.Features[2].Properties["time"] != "2024-05-01T09:00:00Z" {
+		t.Errorf("WriteTrack() point time = %v, want 2024-05-01T09:00:00Z", got.Features[2].Properties["time"])
+	}
+}
+
+func TestWriteTrackKML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteTrack(&buf, KML, testTrack()); err != nil {
+		t.Fatalf("WriteTrack() error = %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"<when>2024-05-01T08:00:00Z</when>", "<gx:coord>103.700000 1.600000 0</gx:coord>", "<name>Raja</name>"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("WriteTrack() = %s, want it to contain %s", out, want)
+		}
+	}
+}
+
+func TestWriteTrackGPX(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteTrack(&buf, GPX, testTrack()); err != nil {
+		t.Fatalf("WriteTrack() error = %v", err)
+	}
+
+	var got gpxDocument
+	if err := xml.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("WriteTrack() produced invalid XML: %v", err)
+	}
+	if len(got.Track.Points) != 2 || got.Track.Points[1].Latitude != 1.6 || got.Track.Points[1].Time != "2024-05-01T09:00:00Z" {
+		t.Errorf("WriteTrack() = %+v", got.Track.Points)
+	}
+}
+
+func TestWriteTrackUnsupportedFormat(t *testing.T) {
+	if err := WriteTrack(&bytes.Buffer{}, TrackFormat("shp"), testTrack()); err == nil {
+		t.Errorf("WriteTrack() error = nil, want unsupported format error")
+	}
+}
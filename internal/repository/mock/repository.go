@@ -12,6 +12,7 @@ package mock
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	model "github.com/nurcholisnanda/tigerhall-kittens/internal/api/graph/model"
 	gomock "go.uber.org/mock/gomock"
@@ -54,6 +55,21 @@ func (mr *MockUserRepositoryMockRecorder) CreateUser(ctx, user any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockUserRepository)(nil).CreateUser), ctx, user)
 }
 
+// GetNotificationPreferences mocks base method.
+func (m *MockUserRepository) GetNotificationPreferences(ctx context.Context, userID string) (*model.NotificationPreferences, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNotificationPreferences", ctx, userID)
+	ret0, _ := ret[0].(*model.NotificationPreferences)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNotificationPreferences indicates an expected call of GetNotificationPreferences.
+func (mr *MockUserRepositoryMockRecorder) GetNotificationPreferences(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNotificationPreferences", reflect.TypeOf((*MockUserRepository)(nil).GetNotificationPreferences), ctx, userID)
+}
+
 // GetUserByEmail mocks base method.
 func (m *MockUserRepository) GetUserByEmail(ctx context.Context, email string) (*model.User, error) {
 	m.ctrl.T.Helper()
@@ -84,6 +100,20 @@ func (mr *MockUserRepositoryMockRecorder) GetUserByID(ctx, id any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByID", reflect.TypeOf((*MockUserRepository)(nil).GetUserByID), ctx, id)
 }
 
+// SaveNotificationPreferences mocks base method.
+func (m *MockUserRepository) SaveNotificationPreferences(ctx context.Context, preferences *model.NotificationPreferences) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveNotificationPreferences", ctx, preferences)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveNotificationPreferences indicates an expected call of SaveNotificationPreferences.
+func (mr *MockUserRepositoryMockRecorder) SaveNotificationPreferences(ctx, preferences any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveNotificationPreferences", reflect.TypeOf((*MockUserRepository)(nil).SaveNotificationPreferences), ctx, preferences)
+}
+
 // MockTigerRepository is a mock of TigerRepository interface.
 type MockTigerRepository struct {
 	ctrl     *gomock.Controller
@@ -108,17 +138,31 @@ func (m *MockTigerRepository) EXPECT() *MockTigerRepositoryMockRecorder {
 }
 
 // Create mocks base method.
-func (m *MockTigerRepository) Create(ctx context.Context, tiger *model.Tiger) error {
+func (m *MockTigerRepository) Create(ctx context.Context, tiger *model.Tiger, webhook *model.WebhookMessage) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Create", ctx, tiger)
+	ret := m.ctrl.Call(m, "Create", ctx, tiger, webhook)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Create indicates an expected call of Create.
-func (mr *MockTigerRepositoryMockRecorder) Create(ctx, tiger any) *gomock.Call {
+func (mr *MockTigerRepositoryMockRecorder) Create(ctx, tiger, webhook any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockTigerRepository)(nil).Create), ctx, tiger)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockTigerRepository)(nil).Create), ctx, tiger, webhook)
+}
+
+// FollowTiger mocks base method.
+func (m *MockTigerRepository) FollowTiger(ctx context.Context, userID, tigerID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FollowTiger", ctx, userID, tigerID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FollowTiger indicates an expected call of FollowTiger.
+func (mr *MockTigerRepositoryMockRecorder) FollowTiger(ctx, userID, tigerID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FollowTiger", reflect.TypeOf((*MockTigerRepository)(nil).FollowTiger), ctx, userID, tigerID)
 }
 
 // GetTigerByID mocks base method.
@@ -136,6 +180,21 @@ func (mr *MockTigerRepositoryMockRecorder) GetTigerByID(ctx, id any) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTigerByID", reflect.TypeOf((*MockTigerRepository)(nil).GetTigerByID), ctx, id)
 }
 
+// ListFollowedTigers mocks base method.
+func (m *MockTigerRepository) ListFollowedTigers(ctx context.Context, userID string) ([]*model.Tiger, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListFollowedTigers", ctx, userID)
+	ret0, _ := ret[0].([]*model.Tiger)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListFollowedTigers indicates an expected call of ListFollowedTigers.
+func (mr *MockTigerRepositoryMockRecorder) ListFollowedTigers(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFollowedTigers", reflect.TypeOf((*MockTigerRepository)(nil).ListFollowedTigers), ctx, userID)
+}
+
 // ListTigers mocks base method.
 func (m *MockTigerRepository) ListTigers(ctx context.Context, limit, offset int) ([]*model.Tiger, error) {
 	m.ctrl.T.Helper()
@@ -151,6 +210,78 @@ func (mr *MockTigerRepositoryMockRecorder) ListTigers(ctx, limit, offset any) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTigers", reflect.TypeOf((*MockTigerRepository)(nil).ListTigers), ctx, limit, offset)
 }
 
+// ListTigersByIDs mocks base method.
+func (m *MockTigerRepository) ListTigersByIDs(ctx context.Context, ids []string) ([]*model.Tiger, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTigersByIDs", ctx, ids)
+	ret0, _ := ret[0].([]*model.Tiger)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTigersByIDs indicates an expected call of ListTigersByIDs.
+func (mr *MockTigerRepositoryMockRecorder) ListTigersByIDs(ctx, ids any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTigersByIDs", reflect.TypeOf((*MockTigerRepository)(nil).ListTigersByIDs), ctx, ids)
+}
+
+// ListTigersInBox mocks base method.
+func (m *MockTigerRepository) ListTigersInBox(ctx context.Context, box *model.BoundingBox, seenSince *time.Time) ([]*model.Tiger, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTigersInBox", ctx, box, seenSince)
+	ret0, _ := ret[0].([]*model.Tiger)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTigersInBox indicates an expected call of ListTigersInBox.
+func (mr *MockTigerRepositoryMockRecorder) ListTigersInBox(ctx, box, seenSince any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTigersInBox", reflect.TypeOf((*MockTigerRepository)(nil).ListTigersInBox), ctx, box, seenSince)
+}
+
+// SetProfileImage mocks base method.
+func (m *MockTigerRepository) SetProfileImage(ctx context.Context, tigerID, imageID string, webhook *model.WebhookMessage) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetProfileImage", ctx, tigerID, imageID, webhook)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetProfileImage indicates an expected call of SetProfileImage.
+func (mr *MockTigerRepositoryMockRecorder) SetProfileImage(ctx, tigerID, imageID, webhook any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetProfileImage", reflect.TypeOf((*MockTigerRepository)(nil).SetProfileImage), ctx, tigerID, imageID, webhook)
+}
+
+// StreamTigers mocks base method.
+func (m *MockTigerRepository) StreamTigers(ctx context.Context, fn func(*model.Tiger) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamTigers", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StreamTigers indicates an expected call of StreamTigers.
+func (mr *MockTigerRepositoryMockRecorder) StreamTigers(ctx, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamTigers", reflect.TypeOf((*MockTigerRepository)(nil).StreamTigers), ctx, fn)
+}
+
+// UnfollowTiger mocks base method.
+func (m *MockTigerRepository) UnfollowTiger(ctx context.Context, userID, tigerID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnfollowTiger", ctx, userID, tigerID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnfollowTiger indicates an expected call of UnfollowTiger.
+func (mr *MockTigerRepositoryMockRecorder) UnfollowTiger(ctx, userID, tigerID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnfollowTiger", reflect.TypeOf((*MockTigerRepository)(nil).UnfollowTiger), ctx, userID, tigerID)
+}
+
 // MockSightingRepository is a mock of SightingRepository interface.
 type MockSightingRepository struct {
 	ctrl     *gomock.Controller
@@ -174,18 +305,46 @@ func (m *MockSightingRepository) EXPECT() *MockSightingRepositoryMockRecorder {
 	return m.recorder
 }
 
+// AssignSighting mocks base method.
+func (m *MockSightingRepository) AssignSighting(ctx context.Context, sighting *model.Sighting, tigerID string, webhook *model.WebhookMessage) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AssignSighting", ctx, sighting, tigerID, webhook)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AssignSighting indicates an expected call of AssignSighting.
+func (mr *MockSightingRepositoryMockRecorder) AssignSighting(ctx, sighting, tigerID, webhook any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AssignSighting", reflect.TypeOf((*MockSightingRepository)(nil).AssignSighting), ctx, sighting, tigerID, webhook)
+}
+
 // CreateSighting mocks base method.
-func (m *MockSightingRepository) CreateSighting(ctx context.Context, sighting *model.Sighting) error {
+func (m *MockSightingRepository) CreateSighting(ctx context.Context, sighting *model.Sighting, watchZones []*model.WatchZone, webhooks []*model.WebhookMessage) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateSighting", ctx, sighting)
+	ret := m.ctrl.Call(m, "CreateSighting", ctx, sighting, watchZones, webhooks)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // CreateSighting indicates an expected call of CreateSighting.
-func (mr *MockSightingRepositoryMockRecorder) CreateSighting(ctx, sighting any) *gomock.Call {
+func (mr *MockSightingRepositoryMockRecorder) CreateSighting(ctx, sighting, watchZones, webhooks any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSighting", reflect.TypeOf((*MockSightingRepository)(nil).CreateSighting), ctx, sighting, watchZones, webhooks)
+}
+
+// FlagSighting mocks base method.
+func (m *MockSightingRepository) FlagSighting(ctx context.Context, id, reason string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FlagSighting", ctx, id, reason)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FlagSighting indicates an expected call of FlagSighting.
+func (mr *MockSightingRepositoryMockRecorder) FlagSighting(ctx, id, reason any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSighting", reflect.TypeOf((*MockSightingRepository)(nil).CreateSighting), ctx, sighting)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlagSighting", reflect.TypeOf((*MockSightingRepository)(nil).FlagSighting), ctx, id, reason)
 }
 
 // GetLatestSightingByTigerID mocks base method.
@@ -203,6 +362,21 @@ func (mr *MockSightingRepositoryMockRecorder) GetLatestSightingByTigerID(ctx, ti
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLatestSightingByTigerID", reflect.TypeOf((*MockSightingRepository)(nil).GetLatestSightingByTigerID), ctx, tigerID)
 }
 
+// GetSightingByID mocks base method.
+func (m *MockSightingRepository) GetSightingByID(ctx context.Context, id string) (*model.Sighting, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSightingByID", ctx, id)
+	ret0, _ := ret[0].(*model.Sighting)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSightingByID indicates an expected call of GetSightingByID.
+func (mr *MockSightingRepositoryMockRecorder) GetSightingByID(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSightingByID", reflect.TypeOf((*MockSightingRepository)(nil).GetSightingByID), ctx, id)
+}
+
 // GetSightingsByTigerID mocks base method.
 func (m *MockSightingRepository) GetSightingsByTigerID(ctx context.Context, tigerID string, limit, offset int) ([]*model.Sighting, error) {
 	m.ctrl.T.Helper()
@@ -218,17 +392,768 @@ func (mr *MockSightingRepositoryMockRecorder) GetSightingsByTigerID(ctx, tigerID
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSightingsByTigerID", reflect.TypeOf((*MockSightingRepository)(nil).GetSightingsByTigerID), ctx, tigerID, limit, offset)
 }
 
-// ListUserCreatedSightingByTigerID mocks base method.
-func (m *MockSightingRepository) ListUserCreatedSightingByTigerID(ctx context.Context, tigerID string) ([]string, error) {
+// ListSightingsByIDs mocks base method.
+func (m *MockSightingRepository) ListSightingsByIDs(ctx context.Context, ids []string) ([]*model.Sighting, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSightingsByIDs", ctx, ids)
+	ret0, _ := ret[0].([]*model.Sighting)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSightingsByIDs indicates an expected call of ListSightingsByIDs.
+func (mr *MockSightingRepositoryMockRecorder) ListSightingsByIDs(ctx, ids any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSightingsByIDs", reflect.TypeOf((*MockSightingRepository)(nil).ListSightingsByIDs), ctx, ids)
+}
+
+// ListSightingsForTrack mocks base method.
+func (m *MockSightingRepository) ListSightingsForTrack(ctx context.Context, tigerID string, timeRange *model.TimeRangeInput) ([]*model.Sighting, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSightingsForTrack", ctx, tigerID, timeRange)
+	ret0, _ := ret[0].([]*model.Sighting)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSightingsForTrack indicates an expected call of ListSightingsForTrack.
+func (mr *MockSightingRepositoryMockRecorder) ListSightingsForTrack(ctx, tigerID, timeRange any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSightingsForTrack", reflect.TypeOf((*MockSightingRepository)(nil).ListSightingsForTrack), ctx, tigerID, timeRange)
+}
+
+// ListSightingsInBox mocks base method.
+func (m *MockSightingRepository) ListSightingsInBox(ctx context.Context, box *model.BoundingBox, center *model.LastSeenCoordinate, timeRange *model.TimeRangeInput, limit int) ([]*model.Sighting, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSightingsInBox", ctx, box, center, timeRange, limit)
+	ret0, _ := ret[0].([]*model.Sighting)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSightingsInBox indicates an expected call of ListSightingsInBox.
+func (mr *MockSightingRepositoryMockRecorder) ListSightingsInBox(ctx, box, center, timeRange, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSightingsInBox", reflect.TypeOf((*MockSightingRepository)(nil).ListSightingsInBox), ctx, box, center, timeRange, limit)
+}
+
+// ListUnidentifiedSightings mocks base method.
+func (m *MockSightingRepository) ListUnidentifiedSightings(ctx context.Context, limit, offset int) ([]*model.Sighting, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUnidentifiedSightings", ctx, limit, offset)
+	ret0, _ := ret[0].([]*model.Sighting)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUnidentifiedSightings indicates an expected call of ListUnidentifiedSightings.
+func (mr *MockSightingRepositoryMockRecorder) ListUnidentifiedSightings(ctx, limit, offset any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUnidentifiedSightings", reflect.TypeOf((*MockSightingRepository)(nil).ListUnidentifiedSightings), ctx, limit, offset)
+}
+
+// StreamSightings mocks base method.
+func (m *MockSightingRepository) StreamSightings(ctx context.Context, tigerID string, timeRange *model.TimeRangeInput, fn func(*model.Sighting) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamSightings", ctx, tigerID, timeRange, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StreamSightings indicates an expected call of StreamSightings.
+func (mr *MockSightingRepositoryMockRecorder) StreamSightings(ctx, tigerID, timeRange, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamSightings", reflect.TypeOf((*MockSightingRepository)(nil).StreamSightings), ctx, tigerID, timeRange, fn)
+}
+
+// MockSightingImageRepository is a mock of SightingImageRepository interface.
+type MockSightingImageRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockSightingImageRepositoryMockRecorder
+}
+
+// MockSightingImageRepositoryMockRecorder is the mock recorder for MockSightingImageRepository.
+type MockSightingImageRepositoryMockRecorder struct {
+	mock *MockSightingImageRepository
+}
+
+// NewMockSightingImageRepository creates a new mock instance.
+func NewMockSightingImageRepository(ctrl *gomock.Controller) *MockSightingImageRepository {
+	mock := &MockSightingImageRepository{ctrl: ctrl}
+	mock.recorder = &MockSightingImageRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSightingImageRepository) EXPECT() *MockSightingImageRepositoryMockRecorder {
+	return m.recorder
+}
+
+// ClaimSightingImage mocks base method.
+func (m *MockSightingImageRepository) ClaimSightingImage(ctx context.Context, id string, now time.Time, lease time.Duration) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimSightingImage", ctx, id, now, lease)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimSightingImage indicates an expected call of ClaimSightingImage.
+func (mr *MockSightingImageRepositoryMockRecorder) ClaimSightingImage(ctx, id, now, lease any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimSightingImage", reflect.TypeOf((*MockSightingImageRepository)(nil).ClaimSightingImage), ctx, id, now, lease)
+}
+
+// FindSimilarImage mocks base method.
+func (m *MockSightingImageRepository) FindSimilarImage(ctx context.Context, hash int64, maxDistance int, excludeSightingID string) (*model.ImageMatch, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindSimilarImage", ctx, hash, maxDistance, excludeSightingID)
+	ret0, _ := ret[0].(*model.ImageMatch)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindSimilarImage indicates an expected call of FindSimilarImage.
+func (mr *MockSightingImageRepositoryMockRecorder) FindSimilarImage(ctx, hash, maxDistance, excludeSightingID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindSimilarImage", reflect.TypeOf((*MockSightingImageRepository)(nil).FindSimilarImage), ctx, hash, maxDistance, excludeSightingID)
+}
+
+// GetSightingImageByID mocks base method.
+func (m *MockSightingImageRepository) GetSightingImageByID(ctx context.Context, id string) (*model.SightingImage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSightingImageByID", ctx, id)
+	ret0, _ := ret[0].(*model.SightingImage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSightingImageByID indicates an expected call of GetSightingImageByID.
+func (mr *MockSightingImageRepositoryMockRecorder) GetSightingImageByID(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSightingImageByID", reflect.TypeOf((*MockSightingImageRepository)(nil).GetSightingImageByID), ctx, id)
+}
+
+// ListDueSightingImageIDs mocks base method.
+func (m *MockSightingImageRepository) ListDueSightingImageIDs(ctx context.Context, now time.Time, lease time.Duration) ([]string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListUserCreatedSightingByTigerID", ctx, tigerID)
+	ret := m.ctrl.Call(m, "ListDueSightingImageIDs", ctx, now, lease)
 	ret0, _ := ret[0].([]string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ListUserCreatedSightingByTigerID indicates an expected call of ListUserCreatedSightingByTigerID.
-func (mr *MockSightingRepositoryMockRecorder) ListUserCreatedSightingByTigerID(ctx, tigerID any) *gomock.Call {
+// ListDueSightingImageIDs indicates an expected call of ListDueSightingImageIDs.
+func (mr *MockSightingImageRepositoryMockRecorder) ListDueSightingImageIDs(ctx, now, lease any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDueSightingImageIDs", reflect.TypeOf((*MockSightingImageRepository)(nil).ListDueSightingImageIDs), ctx, now, lease)
+}
+
+// ListImageFeatures mocks base method.
+func (m *MockSightingImageRepository) ListImageFeatures(ctx context.Context, box *model.BoundingBox, perTiger, limit int) ([]*model.SightingImage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListImageFeatures", ctx, box, perTiger, limit)
+	ret0, _ := ret[0].([]*model.SightingImage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListImageFeatures indicates an expected call of ListImageFeatures.
+func (mr *MockSightingImageRepositoryMockRecorder) ListImageFeatures(ctx, box, perTiger, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListImageFeatures", reflect.TypeOf((*MockSightingImageRepository)(nil).ListImageFeatures), ctx, box, perTiger, limit)
+}
+
+// ListImagesWithoutFeatures mocks base method.
+func (m *MockSightingImageRepository) ListImagesWithoutFeatures(ctx context.Context, afterID string, limit int) ([]*model.SightingImage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListImagesWithoutFeatures", ctx, afterID, limit)
+	ret0, _ := ret[0].([]*model.SightingImage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListImagesWithoutFeatures indicates an expected call of ListImagesWithoutFeatures.
+func (mr *MockSightingImageRepositoryMockRecorder) ListImagesWithoutFeatures(ctx, afterID, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListImagesWithoutFeatures", reflect.TypeOf((*MockSightingImageRepository)(nil).ListImagesWithoutFeatures), ctx, afterID, limit)
+}
+
+// ListSightingImages mocks base method.
+func (m *MockSightingImageRepository) ListSightingImages(ctx context.Context, sightingID string) ([]*model.SightingImage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSightingImages", ctx, sightingID)
+	ret0, _ := ret[0].([]*model.SightingImage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSightingImages indicates an expected call of ListSightingImages.
+func (mr *MockSightingImageRepositoryMockRecorder) ListSightingImages(ctx, sightingID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSightingImages", reflect.TypeOf((*MockSightingImageRepository)(nil).ListSightingImages), ctx, sightingID)
+}
+
+// ListTigerPhotos mocks base method.
+func (m *MockSightingImageRepository) ListTigerPhotos(ctx context.Context, tigerID string, after *model.PhotoCursor, limit int) ([]*model.SightingImage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTigerPhotos", ctx, tigerID, after, limit)
+	ret0, _ := ret[0].([]*model.SightingImage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTigerPhotos indicates an expected call of ListTigerPhotos.
+func (mr *MockSightingImageRepositoryMockRecorder) ListTigerPhotos(ctx, tigerID, after, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTigerPhotos", reflect.TypeOf((*MockSightingImageRepository)(nil).ListTigerPhotos), ctx, tigerID, after, limit)
+}
+
+// MarkSightingImageFailed mocks base method.
+func (m *MockSightingImageRepository) MarkSightingImageFailed(ctx context.Context, id string, nextAttemptAt *time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkSightingImageFailed", ctx, id, nextAttemptAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkSightingImageFailed indicates an expected call of MarkSightingImageFailed.
+func (mr *MockSightingImageRepositoryMockRecorder) MarkSightingImageFailed(ctx, id, nextAttemptAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkSightingImageFailed", reflect.TypeOf((*MockSightingImageRepository)(nil).MarkSightingImageFailed), ctx, id, nextAttemptAt)
+}
+
+// UpdateSightingImageFeatures mocks base method.
+func (m *MockSightingImageRepository) UpdateSightingImageFeatures(ctx context.Context, id string, features []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSightingImageFeatures", ctx, id, features)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateSightingImageFeatures indicates an expected call of UpdateSightingImageFeatures.
+func (mr *MockSightingImageRepositoryMockRecorder) UpdateSightingImageFeatures(ctx, id, features any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSightingImageFeatures", reflect.TypeOf((*MockSightingImageRepository)(nil).UpdateSightingImageFeatures), ctx, id, features)
+}
+
+// UpdateSightingImageHash mocks base method.
+func (m *MockSightingImageRepository) UpdateSightingImageHash(ctx context.Context, id string, hash int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSightingImageHash", ctx, id, hash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateSightingImageHash indicates an expected call of UpdateSightingImageHash.
+func (mr *MockSightingImageRepositoryMockRecorder) UpdateSightingImageHash(ctx, id, hash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSightingImageHash", reflect.TypeOf((*MockSightingImageRepository)(nil).UpdateSightingImageHash), ctx, id, hash)
+}
+
+// UpdateSightingImageStatus mocks base method.
+func (m *MockSightingImageRepository) UpdateSightingImageStatus(ctx context.Context, id string, status model.ImageStatus) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSightingImageStatus", ctx, id, status)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateSightingImageStatus indicates an expected call of UpdateSightingImageStatus.
+func (mr *MockSightingImageRepositoryMockRecorder) UpdateSightingImageStatus(ctx, id, status any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSightingImageStatus", reflect.TypeOf((*MockSightingImageRepository)(nil).UpdateSightingImageStatus), ctx, id, status)
+}
+
+// MockNotificationRepository is a mock of NotificationRepository interface.
+type MockNotificationRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockNotificationRepositoryMockRecorder
+}
+
+// MockNotificationRepositoryMockRecorder is the mock recorder for MockNotificationRepository.
+type MockNotificationRepositoryMockRecorder struct {
+	mock *MockNotificationRepository
+}
+
+// NewMockNotificationRepository creates a new mock instance.
+func NewMockNotificationRepository(ctrl *gomock.Controller) *MockNotificationRepository {
+	mock := &MockNotificationRepository{ctrl: ctrl}
+	mock.recorder = &MockNotificationRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockNotificationRepository) EXPECT() *MockNotificationRepositoryMockRecorder {
+	return m.recorder
+}
+
+// ClaimDigestJobs mocks base method.
+func (m *MockNotificationRepository) ClaimDigestJobs(ctx context.Context, userID string, now time.Time, lease time.Duration) ([]*model.NotificationJob, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimDigestJobs", ctx, userID, now, lease)
+	ret0, _ := ret[0].([]*model.NotificationJob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimDigestJobs indicates an expected call of ClaimDigestJobs.
+func (mr *MockNotificationRepositoryMockRecorder) ClaimDigestJobs(ctx, userID, now, lease any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimDigestJobs", reflect.TypeOf((*MockNotificationRepository)(nil).ClaimDigestJobs), ctx, userID, now, lease)
+}
+
+// ClaimDueNotificationJobs mocks base method.
+func (m *MockNotificationRepository) ClaimDueNotificationJobs(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*model.NotificationJob, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimDueNotificationJobs", ctx, now, lease, limit)
+	ret0, _ := ret[0].([]*model.NotificationJob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimDueNotificationJobs indicates an expected call of ClaimDueNotificationJobs.
+func (mr *MockNotificationRepositoryMockRecorder) ClaimDueNotificationJobs(ctx, now, lease, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimDueNotificationJobs", reflect.TypeOf((*MockNotificationRepository)(nil).ClaimDueNotificationJobs), ctx, now, lease, limit)
+}
+
+// CountUnreadNotifications mocks base method.
+func (m *MockNotificationRepository) CountUnreadNotifications(ctx context.Context, userID string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountUnreadNotifications", ctx, userID)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountUnreadNotifications indicates an expected call of CountUnreadNotifications.
+func (mr *MockNotificationRepositoryMockRecorder) CountUnreadNotifications(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountUnreadNotifications", reflect.TypeOf((*MockNotificationRepository)(nil).CountUnreadNotifications), ctx, userID)
+}
+
+// GetNotification mocks base method.
+func (m *MockNotificationRepository) GetNotification(ctx context.Context, userID, id string) (*model.Notification, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNotification", ctx, userID, id)
+	ret0, _ := ret[0].(*model.Notification)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNotification indicates an expected call of GetNotification.
+func (mr *MockNotificationRepositoryMockRecorder) GetNotification(ctx, userID, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNotification", reflect.TypeOf((*MockNotificationRepository)(nil).GetNotification), ctx, userID, id)
+}
+
+// GetNotificationJobByID mocks base method.
+func (m *MockNotificationRepository) GetNotificationJobByID(ctx context.Context, id string) (*model.NotificationJob, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNotificationJobByID", ctx, id)
+	ret0, _ := ret[0].(*model.NotificationJob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNotificationJobByID indicates an expected call of GetNotificationJobByID.
+func (mr *MockNotificationRepositoryMockRecorder) GetNotificationJobByID(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNotificationJobByID", reflect.TypeOf((*MockNotificationRepository)(nil).GetNotificationJobByID), ctx, id)
+}
+
+// ListDigestRecipients mocks base method.
+func (m *MockNotificationRepository) ListDigestRecipients(ctx context.Context, now, hourlyBefore time.Time, dailyBefore map[string]time.Time, limit int) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDigestRecipients", ctx, now, hourlyBefore, dailyBefore, limit)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDigestRecipients indicates an expected call of ListDigestRecipients.
+func (mr *MockNotificationRepositoryMockRecorder) ListDigestRecipients(ctx, now, hourlyBefore, dailyBefore, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDigestRecipients", reflect.TypeOf((*MockNotificationRepository)(nil).ListDigestRecipients), ctx, now, hourlyBefore, dailyBefore, limit)
+}
+
+// ListDigestTimezones mocks base method.
+func (m *MockNotificationRepository) ListDigestTimezones(ctx context.Context, now time.Time) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDigestTimezones", ctx, now)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDigestTimezones indicates an expected call of ListDigestTimezones.
+func (mr *MockNotificationRepositoryMockRecorder) ListDigestTimezones(ctx, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDigestTimezones", reflect.TypeOf((*MockNotificationRepository)(nil).ListDigestTimezones), ctx, now)
+}
+
+// ListNotificationJobs mocks base method.
+func (m *MockNotificationRepository) ListNotificationJobs(ctx context.Context, status *model.NotificationStatus, limit, offset int) ([]*model.NotificationJob, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListNotificationJobs", ctx, status, limit, offset)
+	ret0, _ := ret[0].([]*model.NotificationJob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListNotificationJobs indicates an expected call of ListNotificationJobs.
+func (mr *MockNotificationRepositoryMockRecorder) ListNotificationJobs(ctx, status, limit, offset any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListNotificationJobs", reflect.TypeOf((*MockNotificationRepository)(nil).ListNotificationJobs), ctx, status, limit, offset)
+}
+
+// ListNotifications mocks base method.
+func (m *MockNotificationRepository) ListNotifications(ctx context.Context, userID string, unreadOnly bool, after *model.NotificationCursor, limit int) ([]*model.Notification, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListNotifications", ctx, userID, unreadOnly, after, limit)
+	ret0, _ := ret[0].([]*model.Notification)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListNotifications indicates an expected call of ListNotifications.
+func (mr *MockNotificationRepositoryMockRecorder) ListNotifications(ctx, userID, unreadOnly, after, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListNotifications", reflect.TypeOf((*MockNotificationRepository)(nil).ListNotifications), ctx, userID, unreadOnly, after, limit)
+}
+
+// ListNotificationsBySighting mocks base method.
+func (m *MockNotificationRepository) ListNotificationsBySighting(ctx context.Context, sightingID string) ([]*model.Notification, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListNotificationsBySighting", ctx, sightingID)
+	ret0, _ := ret[0].([]*model.Notification)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListNotificationsBySighting indicates an expected call of ListNotificationsBySighting.
+func (mr *MockNotificationRepositoryMockRecorder) ListNotificationsBySighting(ctx, sightingID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListNotificationsBySighting", reflect.TypeOf((*MockNotificationRepository)(nil).ListNotificationsBySighting), ctx, sightingID)
+}
+
+// MarkAllNotificationsRead mocks base method.
+func (m *MockNotificationRepository) MarkAllNotificationsRead(ctx context.Context, userID string, readAt time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkAllNotificationsRead", ctx, userID, readAt)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkAllNotificationsRead indicates an expected call of MarkAllNotificationsRead.
+func (mr *MockNotificationRepositoryMockRecorder) MarkAllNotificationsRead(ctx, userID, readAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkAllNotificationsRead", reflect.TypeOf((*MockNotificationRepository)(nil).MarkAllNotificationsRead), ctx, userID, readAt)
+}
+
+// MarkNotificationJobBatched mocks base method.
+func (m *MockNotificationRepository) MarkNotificationJobBatched(ctx context.Context, id string, now time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkNotificationJobBatched", ctx, id, now)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkNotificationJobBatched indicates an expected call of MarkNotificationJobBatched.
+func (mr *MockNotificationRepositoryMockRecorder) MarkNotificationJobBatched(ctx, id, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkNotificationJobBatched", reflect.TypeOf((*MockNotificationRepository)(nil).MarkNotificationJobBatched), ctx, id, now)
+}
+
+// MarkNotificationJobDelivered mocks base method.
+func (m *MockNotificationRepository) MarkNotificationJobDelivered(ctx context.Context, id string, deliveredAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkNotificationJobDelivered", ctx, id, deliveredAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkNotificationJobDelivered indicates an expected call of MarkNotificationJobDelivered.
+func (mr *MockNotificationRepositoryMockRecorder) MarkNotificationJobDelivered(ctx, id, deliveredAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkNotificationJobDelivered", reflect.TypeOf((*MockNotificationRepository)(nil).MarkNotificationJobDelivered), ctx, id, deliveredAt)
+}
+
+// MarkNotificationJobSkipped mocks base method.
+func (m *MockNotificationRepository) MarkNotificationJobSkipped(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkNotificationJobSkipped", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkNotificationJobSkipped indicates an expected call of MarkNotificationJobSkipped.
+func (mr *MockNotificationRepositoryMockRecorder) MarkNotificationJobSkipped(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkNotificationJobSkipped", reflect.TypeOf((*MockNotificationRepository)(nil).MarkNotificationJobSkipped), ctx, id)
+}
+
+// MarkNotificationJobsDelivered mocks base method.
+func (m *MockNotificationRepository) MarkNotificationJobsDelivered(ctx context.Context, ids []string, deliveredAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkNotificationJobsDelivered", ctx, ids, deliveredAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkNotificationJobsDelivered indicates an expected call of MarkNotificationJobsDelivered.
+func (mr *MockNotificationRepositoryMockRecorder) MarkNotificationJobsDelivered(ctx, ids, deliveredAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkNotificationJobsDelivered", reflect.TypeOf((*MockNotificationRepository)(nil).MarkNotificationJobsDelivered), ctx, ids, deliveredAt)
+}
+
+// MarkNotificationRead mocks base method.
+func (m *MockNotificationRepository) MarkNotificationRead(ctx context.Context, userID, id string, readAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkNotificationRead", ctx, userID, id, readAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkNotificationRead indicates an expected call of MarkNotificationRead.
+func (mr *MockNotificationRepositoryMockRecorder) MarkNotificationRead(ctx, userID, id, readAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkNotificationRead", reflect.TypeOf((*MockNotificationRepository)(nil).MarkNotificationRead), ctx, userID, id, readAt)
+}
+
+// RecordNotificationJobFailure mocks base method.
+func (m *MockNotificationRepository) RecordNotificationJobFailure(ctx context.Context, job *model.NotificationJob) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordNotificationJobFailure", ctx, job)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordNotificationJobFailure indicates an expected call of RecordNotificationJobFailure.
+func (mr *MockNotificationRepositoryMockRecorder) RecordNotificationJobFailure(ctx, job any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordNotificationJobFailure", reflect.TypeOf((*MockNotificationRepository)(nil).RecordNotificationJobFailure), ctx, job)
+}
+
+// ReplayNotificationJob mocks base method.
+func (m *MockNotificationRepository) ReplayNotificationJob(ctx context.Context, id string, now time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplayNotificationJob", ctx, id, now)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReplayNotificationJob indicates an expected call of ReplayNotificationJob.
+func (mr *MockNotificationRepositoryMockRecorder) ReplayNotificationJob(ctx, id, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplayNotificationJob", reflect.TypeOf((*MockNotificationRepository)(nil).ReplayNotificationJob), ctx, id, now)
+}
+
+// MockWatchZoneRepository is a mock of WatchZoneRepository interface.
+type MockWatchZoneRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockWatchZoneRepositoryMockRecorder
+}
+
+// MockWatchZoneRepositoryMockRecorder is the mock recorder for MockWatchZoneRepository.
+type MockWatchZoneRepositoryMockRecorder struct {
+	mock *MockWatchZoneRepository
+}
+
+// NewMockWatchZoneRepository creates a new mock instance.
+func NewMockWatchZoneRepository(ctrl *gomock.Controller) *MockWatchZoneRepository {
+	mock := &MockWatchZoneRepository{ctrl: ctrl}
+	mock.recorder = &MockWatchZoneRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWatchZoneRepository) EXPECT() *MockWatchZoneRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateWatchZone mocks base method.
+func (m *MockWatchZoneRepository) CreateWatchZone(ctx context.Context, zone *model.WatchZone, limit int) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWatchZone", ctx, zone, limit)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWatchZone indicates an expected call of CreateWatchZone.
+func (mr *MockWatchZoneRepositoryMockRecorder) CreateWatchZone(ctx, zone, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWatchZone", reflect.TypeOf((*MockWatchZoneRepository)(nil).CreateWatchZone), ctx, zone, limit)
+}
+
+// ListWatchZones mocks base method.
+func (m *MockWatchZoneRepository) ListWatchZones(ctx context.Context, userID string) ([]*model.WatchZone, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWatchZones", ctx, userID)
+	ret0, _ := ret[0].([]*model.WatchZone)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWatchZones indicates an expected call of ListWatchZones.
+func (mr *MockWatchZoneRepositoryMockRecorder) ListWatchZones(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWatchZones", reflect.TypeOf((*MockWatchZoneRepository)(nil).ListWatchZones), ctx, userID)
+}
+
+// ListWatchZonesAround mocks base method.
+func (m *MockWatchZoneRepository) ListWatchZonesAround(ctx context.Context, point *model.LastSeenCoordinate) ([]*model.WatchZone, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWatchZonesAround", ctx, point)
+	ret0, _ := ret[0].([]*model.WatchZone)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWatchZonesAround indicates an expected call of ListWatchZonesAround.
+func (mr *MockWatchZoneRepositoryMockRecorder) ListWatchZonesAround(ctx, point any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWatchZonesAround", reflect.TypeOf((*MockWatchZoneRepository)(nil).ListWatchZonesAround), ctx, point)
+}
+
+// MockWebhookRepository is a mock of WebhookRepository interface.
+type MockWebhookRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockWebhookRepositoryMockRecorder
+}
+
+// MockWebhookRepositoryMockRecorder is the mock recorder for MockWebhookRepository.
+type MockWebhookRepositoryMockRecorder struct {
+	mock *MockWebhookRepository
+}
+
+// NewMockWebhookRepository creates a new mock instance.
+func NewMockWebhookRepository(ctrl *gomock.Controller) *MockWebhookRepository {
+	mock := &MockWebhookRepository{ctrl: ctrl}
+	mock.recorder = &MockWebhookRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWebhookRepository) EXPECT() *MockWebhookRepositoryMockRecorder {
+	return m.recorder
+}
+
+// ClaimDueWebhookDeliveries mocks base method.
+func (m *MockWebhookRepository) ClaimDueWebhookDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*model.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimDueWebhookDeliveries", ctx, now, lease, limit)
+	ret0, _ := ret[0].([]*model.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimDueWebhookDeliveries indicates an expected call of ClaimDueWebhookDeliveries.
+func (mr *MockWebhookRepositoryMockRecorder) ClaimDueWebhookDeliveries(ctx, now, lease, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimDueWebhookDeliveries", reflect.TypeOf((*MockWebhookRepository)(nil).ClaimDueWebhookDeliveries), ctx, now, lease, limit)
+}
+
+// CreateWebhookSubscription mocks base method.
+func (m *MockWebhookRepository) CreateWebhookSubscription(ctx context.Context, subscription *model.WebhookSubscription) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebhookSubscription", ctx, subscription)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateWebhookSubscription indicates an expected call of CreateWebhookSubscription.
+func (mr *MockWebhookRepositoryMockRecorder) CreateWebhookSubscription(ctx, subscription any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhookSubscription", reflect.TypeOf((*MockWebhookRepository)(nil).CreateWebhookSubscription), ctx, subscription)
+}
+
+// DeleteWebhookSubscription mocks base method.
+func (m *MockWebhookRepository) DeleteWebhookSubscription(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebhookSubscription", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWebhookSubscription indicates an expected call of DeleteWebhookSubscription.
+func (mr *MockWebhookRepositoryMockRecorder) DeleteWebhookSubscription(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebhookSubscription", reflect.TypeOf((*MockWebhookRepository)(nil).DeleteWebhookSubscription), ctx, id)
+}
+
+// GetWebhookSubscription mocks base method.
+func (m *MockWebhookRepository) GetWebhookSubscription(ctx context.Context, id string) (*model.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhookSubscription", ctx, id)
+	ret0, _ := ret[0].(*model.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhookSubscription indicates an expected call of GetWebhookSubscription.
+func (mr *MockWebhookRepositoryMockRecorder) GetWebhookSubscription(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhookSubscription", reflect.TypeOf((*MockWebhookRepository)(nil).GetWebhookSubscription), ctx, id)
+}
+
+// ListWebhookDeliveries mocks base method.
+func (m *MockWebhookRepository) ListWebhookDeliveries(ctx context.Context, subscriptionID *string, status *model.WebhookDeliveryStatus, limit, offset int) ([]*model.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebhookDeliveries", ctx, subscriptionID, status, limit, offset)
+	ret0, _ := ret[0].([]*model.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebhookDeliveries indicates an expected call of ListWebhookDeliveries.
+func (mr *MockWebhookRepositoryMockRecorder) ListWebhookDeliveries(ctx, subscriptionID, status, limit, offset any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhookDeliveries", reflect.TypeOf((*MockWebhookRepository)(nil).ListWebhookDeliveries), ctx, subscriptionID, status, limit, offset)
+}
+
+// ListWebhookSubscriptions mocks base method.
+func (m *MockWebhookRepository) ListWebhookSubscriptions(ctx context.Context) ([]*model.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebhookSubscriptions", ctx)
+	ret0, _ := ret[0].([]*model.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebhookSubscriptions indicates an expected call of ListWebhookSubscriptions.
+func (mr *MockWebhookRepositoryMockRecorder) ListWebhookSubscriptions(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhookSubscriptions", reflect.TypeOf((*MockWebhookRepository)(nil).ListWebhookSubscriptions), ctx)
+}
+
+// MarkWebhookDeliveryDelivered mocks base method.
+func (m *MockWebhookRepository) MarkWebhookDeliveryDelivered(ctx context.Context, id string, responseStatus int, deliveredAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkWebhookDeliveryDelivered", ctx, id, responseStatus, deliveredAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkWebhookDeliveryDelivered indicates an expected call of MarkWebhookDeliveryDelivered.
+func (mr *MockWebhookRepositoryMockRecorder) MarkWebhookDeliveryDelivered(ctx, id, responseStatus, deliveredAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkWebhookDeliveryDelivered", reflect.TypeOf((*MockWebhookRepository)(nil).MarkWebhookDeliveryDelivered), ctx, id, responseStatus, deliveredAt)
+}
+
+// RecordWebhookDeliveryFailure mocks base method.
+func (m *MockWebhookRepository) RecordWebhookDeliveryFailure(ctx context.Context, delivery *model.WebhookDelivery) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordWebhookDeliveryFailure", ctx, delivery)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordWebhookDeliveryFailure indicates an expected call of RecordWebhookDeliveryFailure.
+func (mr *MockWebhookRepositoryMockRecorder) RecordWebhookDeliveryFailure(ctx, delivery any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUserCreatedSightingByTigerID", reflect.TypeOf((*MockSightingRepository)(nil).ListUserCreatedSightingByTigerID), ctx, tigerID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordWebhookDeliveryFailure", reflect.TypeOf((*MockWebhookRepository)(nil).RecordWebhookDeliveryFailure), ctx, delivery)
 }
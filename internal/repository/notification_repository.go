@@ -0,0 +1,358 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nurcholisnanda/tigerhall-kittens/internal/api/graph/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type NotificationRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepositoryImpl(db *gorm.DB) NotificationRepository {
+	return &NotificationRepositoryImpl{db: db}
+}
+
+// ClaimDueNotificationJobs returns up to limit pending jobs that are due and
+// pushes their next attempt lease into the future, so other dispatchers skip
+// them and a job whose dispatcher dies is picked up again once the lease
+// ends.
+func (r *NotificationRepositoryImpl) ClaimDueNotificationJobs(ctx context.Context, now time.Time, lease time.Duration,
+	limit int) ([]*model.NotificationJob, error) {
+	var jobs []*model.NotificationJob
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", model.NotificationStatusPending, now).
+			Order("next_attempt_at asc").Limit(limit).Find(&jobs).Error; err != nil {
+			return err
+		}
+		if len(jobs) == 0 {
+			return nil
+		}
+		ids := make([]string, 0, len(jobs))
+		for _, job := range jobs {
+			ids = append(ids, job.ID)
+		}
+		return tx.Model(&model.NotificationJob{}).Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(lease)).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+func (r *NotificationRepositoryImpl) MarkNotificationJobDelivered(ctx context.Context, id string, deliveredAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&model.NotificationJob{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":       model.NotificationStatusDelivered,
+		"delivered_at": deliveredAt,
+		"last_error":   nil,
+	}).Error
+}
+
+// MarkNotificationJobSkipped settles a job the recipient does not want
+// delivered
+func (r *NotificationRepositoryImpl) MarkNotificationJobSkipped(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Model(&model.NotificationJob{}).Where("id = ?", id).
+		Update("status", model.NotificationStatusSkipped).Error
+}
+
+// MarkNotificationJobBatched holds a job back for the recipient's next
+// digest
+func (r *NotificationRepositoryImpl) MarkNotificationJobBatched(ctx context.Context, id string, now time.Time) error {
+	return r.db.WithContext(ctx).Model(&model.NotificationJob{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":          model.NotificationStatusBatched,
+		"next_attempt_at": now,
+	}).Error
+}
+
+func (r *NotificationRepositoryImpl) MarkNotificationJobsDelivered(ctx context.Context, ids []string, deliveredAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&model.NotificationJob{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+		"status":       model.NotificationStatusDelivered,
+		"delivered_at": deliveredAt,
+		"last_error":   nil,
+	}).Error
+}
+
+// ListDigestTimezones returns the timezones of the users with batched jobs
+// for a daily digest
+func (r *NotificationRepositoryImpl) ListDigestTimezones(ctx context.Context, now time.Time) ([]string, error) {
+	var timezones []string
+	if err := r.db.WithContext(ctx).Model(&model.NotificationJob{}).
+		Joins("JOIN notification_preferences ON notification_preferences.user_id = notification_jobs.user_id").
+		Where("notification_jobs.status = ? AND notification_jobs.next_attempt_at <= ?", model.NotificationStatusBatched, now).
+		Where("notification_preferences.digest_frequency = ?", model.DigestFrequencyDaily).
+		Distinct().Pluck("notification_preferences.timezone", &timezones).Error; err != nil {
+		return nil, err
+	}
+	return timezones, nil
+}
+
+// ListDigestRecipients returns up to limit users with batched jobs whose
+// digest is due: hourly digests include jobs created before hourlyBefore,
+// daily ones jobs created before the cutoff of the user's timezone in
+// dailyBefore. Users who went back to immediate emails get what is left
+// batched right away.
+func (r *NotificationRepositoryImpl) ListDigestRecipients(ctx context.Context, now time.Time, hourlyBefore time.Time,
+	dailyBefore map[string]time.Time, limit int) ([]string, error) {
+	// the cutoffs come from Go, Postgres never has to know the zone names
+	timezones := make([]string, 0, len(dailyBefore))
+	for timezone := range dailyBefore {
+		timezones = append(timezones, timezone)
+	}
+	sort.Strings(timezones)
+	daily := r.db.Where("FALSE")
+	for _, timezone := range timezones {
+		daily = daily.Or("notification_preferences.timezone = ? AND notification_jobs.created_at < ?",
+			timezone, dailyBefore[timezone])
+	}
+
+	var userIDs []string
+	if err := r.db.WithContext(ctx).Model(&model.NotificationJob{}).
+		Joins("LEFT JOIN notification_preferences ON notification_preferences.user_id = notification_jobs.user_id").
+		Where("notification_jobs.status = ? AND notification_jobs.next_attempt_at <= ?", model.NotificationStatusBatched, now).
+		Where(r.db.Where("notification_preferences.digest_frequency = ? AND notification_jobs.created_at < ?",
+			model.DigestFrequencyHourly, hourlyBefore).
+			Or(r.db.Where("notification_preferences.digest_frequency = ?", model.DigestFrequencyDaily).Where(daily)).
+			Or("notification_preferences.digest_frequency IS NULL OR notification_preferences.digest_frequency = ?",
+				model.DigestFrequencyImmediate)).
+		Distinct().Limit(limit).Pluck("notification_jobs.user_id", &userIDs).Error; err != nil {
+		return nil, err
+	}
+	return userIDs, nil
+}
+
+// ClaimDigestJobs returns the batched jobs of a user that are due and leases
+// them the same way ClaimDueNotificationJobs does
+func (r *NotificationRepositoryImpl) ClaimDigestJobs(ctx context.Context, userID string, now time.Time,
+	lease time.Duration) ([]*model.NotificationJob, error) {
+	var jobs []*model.NotificationJob
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("user_id = ? AND status = ? AND next_attempt_at <= ?", userID, model.NotificationStatusBatched, now).
+			Order("created_at asc").Find(&jobs).Error; err != nil {
+			return err
+		}
+		if len(jobs) == 0 {
+			return nil
+		}
+		ids := make([]string, 0, len(jobs))
+		for _, job := range jobs {
+			ids = append(ids, job.ID)
+		}
+		return tx.Model(&model.NotificationJob{}).Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(lease)).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// RecordNotificationJobFailure stores the outcome of a failed attempt: the
+// attempt count, the error and either the next attempt or the dead status
+func (r *NotificationRepositoryImpl) RecordNotificationJobFailure(ctx context.Context, job *model.NotificationJob) error {
+	return r.db.WithContext(ctx).Model(&model.NotificationJob{}).Where("id = ?", job.ID).Updates(map[string]interface{}{
+		"status":          job.Status,
+		"attempts":        job.Attempts,
+		"next_attempt_at": job.NextAttemptAt,
+		"last_error":      job.LastError,
+	}).Error
+}
+
+func (r *NotificationRepositoryImpl) GetNotificationJobByID(ctx context.Context, id string) (*model.NotificationJob, error) {
+	var job *model.NotificationJob
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&job).Error; err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+func (r *NotificationRepositoryImpl) ListNotificationJobs(ctx context.Context, status *model.NotificationStatus,
+	limit int, offset int) ([]*model.NotificationJob, error) {
+	var jobs []*model.NotificationJob
+	query := r.db.WithContext(ctx)
+	if status != nil {
+		query = query.Where("status = ?", *status)
+	}
+	if err := query.Order("updated_at desc").Offset(offset).Limit(limit).Find(&jobs).Error; err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// ReplayNotificationJob queues a dead job again with its attempts reset. It
+// returns gorm.ErrRecordNotFound when the job is not dead.
+func (r *NotificationRepositoryImpl) ReplayNotificationJob(ctx context.Context, id string, now time.Time) error {
+	result := r.db.WithContext(ctx).Model(&model.NotificationJob{}).
+		Where("id = ? AND status = ?", id, model.NotificationStatusDead).Updates(map[string]interface{}{
+		"status":          model.NotificationStatusPending,
+		"attempts":        0,
+		"next_attempt_at": now,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *NotificationRepositoryImpl) ListNotifications(ctx context.Context, userID string, unreadOnly bool,
+	after *model.NotificationCursor, limit int) ([]*model.Notification, error) {
+	var notifications []*model.Notification
+	query := r.db.WithContext(ctx).Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+	if after != nil {
+		query = query.Where("created_at < ? OR (created_at = ? AND id > ?)", after.CreatedAt, after.CreatedAt, after.ID)
+	}
+	if err := query.Order("created_at desc, id asc").Limit(limit).Find(&notifications).Error; err != nil {
+		return nil, err
+	}
+	return notifications, nil
+}
+
+func (r *NotificationRepositoryImpl) CountUnreadNotifications(ctx context.Context, userID string) (int, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&model.Notification{}).Where("user_id = ? AND read_at IS NULL", userID).
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return int(count), nil
+}
+
+// GetNotification returns a notification from the inbox of a user, other
+// users' notifications are not found
+func (r *NotificationRepositoryImpl) GetNotification(ctx context.Context, userID string, id string) (*model.Notification, error) {
+	var notification *model.Notification
+	if err := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).First(&notification).Error; err != nil {
+		return nil, err
+	}
+	return notification, nil
+}
+
+// ListNotificationsBySighting returns the inbox notifications created for a
+// sighting, one per recipient
+func (r *NotificationRepositoryImpl) ListNotificationsBySighting(ctx context.Context, sightingID string) ([]*model.Notification, error) {
+	var notifications []*model.Notification
+	if err := r.db.WithContext(ctx).Where("sighting_id = ?", sightingID).Find(&notifications).Error; err != nil {
+		return nil, err
+	}
+	return notifications, nil
+}
+
+// MarkNotificationRead keeps the time a notification was first read
+func (r *NotificationRepositoryImpl) MarkNotificationRead(ctx context.Context, userID string, id string, readAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&model.Notification{}).
+		Where("id = ? AND user_id = ? AND read_at IS NULL", id, userID).Update("read_at", readAt).Error
+}
+
+func (r *NotificationRepositoryImpl) MarkAllNotificationsRead(ctx context.Context, userID string, readAt time.Time) (int, error) {
+	result := r.db.WithContext(ctx).Model(&model.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).Update("read_at", readAt)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return int(result.RowsAffected), nil
+}
+
+// enqueueSightingNotifications adds a notification to the inbox of every
+// other user who reported a sighting of the tiger or follows it and queues
+// the email, as part of the transaction storing the sighting. The reporter of
+// the sighting and users who muted the tiger are not told about it. It
+// returns the users notified.
+func enqueueSightingNotifications(tx *gorm.DB, tigerID string, sighting *model.Sighting) ([]string, error) {
+	// UNION drops users who both sighted and follow the tiger
+	var userIDs []string
+	if err := tx.Raw(`SELECT user_id FROM (
+			SELECT created_by AS user_id FROM sightings WHERE tiger_id = ? AND deleted_at IS NULL
+			UNION
+			SELECT user_id FROM tiger_follows WHERE tiger_id = ?
+		) recipients
+		WHERE user_id <> '' AND user_id <> ?
+		AND user_id NOT IN (SELECT user_id FROM muted_tigers WHERE tiger_id = ?)`,
+		tigerID, tigerID, sighting.CreatedBy, tigerID).Scan(&userIDs).Error; err != nil {
+		return nil, err
+	}
+	recipients := make(map[string]*string, len(userIDs))
+	for _, userID := range userIDs {
+		recipients[userID] = nil
+	}
+	return userIDs, createNotifications(tx, model.NotificationTypeSighting, &tigerID, sighting.ID, recipients)
+}
+
+// enqueueWatchZoneNotifications alerts the owners of the watch zones a new
+// sighting is inside of, as part of the transaction storing the sighting.
+// Users in notified already heard about the sighting, and a user with several
+// matching zones is alerted once. The reporter and users who muted the tiger
+// are not told about it.
+func enqueueWatchZoneNotifications(tx *gorm.DB, sighting *model.Sighting, zones []*model.WatchZone, notified []string) error {
+	skip := map[string]bool{sighting.CreatedBy: true}
+	for _, userID := range notified {
+		skip[userID] = true
+	}
+	if sighting.Identified() {
+		var muted []string
+		if err := tx.Model(&model.MutedTiger{}).Where("tiger_id = ?", *sighting.TigerID).
+			Pluck("user_id", &muted).Error; err != nil {
+			return err
+		}
+		for _, userID := range muted {
+			skip[userID] = true
+		}
+	}
+
+	recipients := map[string]*string{}
+	for _, zone := range zones {
+		if _, ok := recipients[zone.UserID]; ok || skip[zone.UserID] {
+			continue
+		}
+		recipients[zone.UserID] = &zone.ID
+	}
+	return createNotifications(tx, model.NotificationTypeWatchZone, sighting.TigerID, sighting.ID, recipients)
+}
+
+// createNotifications adds an inbox notification and an outbox job about a
+// sighting for every recipient, mapped to the watch zone it concerns if any
+func createNotifications(tx *gorm.DB, notificationType model.NotificationType, tigerID *string, sightingID string,
+	recipients map[string]*string) error {
+	if len(recipients) == 0 {
+		return nil
+	}
+	now := time.Now()
+	notifications := make([]*model.Notification, 0, len(recipients))
+	jobs := make([]*model.NotificationJob, 0, len(recipients))
+	for userID, watchZoneID := range recipients {
+		notifications = append(notifications, &model.Notification{
+			ID:          uuid.NewString(),
+			UserID:      userID,
+			Type:        notificationType,
+			TigerID:     tigerID,
+			SightingID:  sightingID,
+			WatchZoneID: watchZoneID,
+		})
+		jobs = append(jobs, &model.NotificationJob{
+			ID:            uuid.NewString(),
+			UserID:        userID,
+			Type:          notificationType,
+			TigerID:       tigerID,
+			SightingID:    sightingID,
+			WatchZoneID:   watchZoneID,
+			Status:        model.NotificationStatusPending,
+			NextAttemptAt: now,
+		})
+	}
+	if err := tx.Create(&notifications).Error; err != nil {
+		return err
+	}
+	return tx.Create(&jobs).Error
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/nurcholisnanda/tigerhall-kittens/internal/api/graph/model"
 )
@@ -11,19 +12,94 @@ type UserRepository interface {
 	CreateUser(ctx context.Context, user *model.User) error
 	GetUserByID(ctx context.Context, id string) (*model.User, error)
 	GetUserByEmail(ctx context.Context, email string) (*model.User, error)
+	GetNotificationPreferences(ctx context.Context, userID string) (*model.NotificationPreferences, error)
+	SaveNotificationPreferences(ctx context.Context, preferences *model.NotificationPreferences) error
 }
 
 //go:generate mockgen -source=repository.go -destination=mock/repository.go -package=mock
 type TigerRepository interface {
-	Create(ctx context.Context, tiger *model.Tiger) error
+	Create(ctx context.Context, tiger *model.Tiger, webhook *model.WebhookMessage) error
 	GetTigerByID(ctx context.Context, id string) (*model.Tiger, error)
 	ListTigers(ctx context.Context, limit int, offset int) ([]*model.Tiger, error)
+	ListTigersInBox(ctx context.Context, box *model.BoundingBox, seenSince *time.Time) ([]*model.Tiger, error)
+	StreamTigers(ctx context.Context, fn func(tiger *model.Tiger) error) error
+	SetProfileImage(ctx context.Context, tigerID string, imageID string, webhook *model.WebhookMessage) error
+	ListTigersByIDs(ctx context.Context, ids []string) ([]*model.Tiger, error)
+	FollowTiger(ctx context.Context, userID string, tigerID string) error
+	UnfollowTiger(ctx context.Context, userID string, tigerID string) error
+	ListFollowedTigers(ctx context.Context, userID string) ([]*model.Tiger, error)
 }
 
 //go:generate mockgen -source=repository.go -destination=mock/repository.go -package=mock
 type SightingRepository interface {
 	GetSightingsByTigerID(ctx context.Context, tigerID string, limit int, offset int) ([]*model.Sighting, error)
-	CreateSighting(ctx context.Context, sighting *model.Sighting) error
+	CreateSighting(ctx context.Context, sighting *model.Sighting, watchZones []*model.WatchZone, webhooks []*model.WebhookMessage) error
+	GetSightingByID(ctx context.Context, id string) (*model.Sighting, error)
+	ListUnidentifiedSightings(ctx context.Context, limit int, offset int) ([]*model.Sighting, error)
+	FlagSighting(ctx context.Context, id string, reason string) error
+	AssignSighting(ctx context.Context, sighting *model.Sighting, tigerID string, webhook *model.WebhookMessage) error
 	GetLatestSightingByTigerID(ctx context.Context, tigerID string) (*model.Sighting, error)
-	ListUserCreatedSightingByTigerID(ctx context.Context, tigerID string) ([]string, error)
+	ListSightingsByIDs(ctx context.Context, ids []string) ([]*model.Sighting, error)
+	ListSightingsInBox(ctx context.Context, box *model.BoundingBox, center *model.LastSeenCoordinate, timeRange *model.TimeRangeInput,
+		limit int) ([]*model.Sighting, error)
+	ListSightingsForTrack(ctx context.Context, tigerID string, timeRange *model.TimeRangeInput) ([]*model.Sighting, error)
+	StreamSightings(ctx context.Context, tigerID string, timeRange *model.TimeRangeInput, fn func(sighting *model.Sighting) error) error
+}
+
+//go:generate mockgen -source=repository.go -destination=mock/repository.go -package=mock
+type SightingImageRepository interface {
+	GetSightingImageByID(ctx context.Context, id string) (*model.SightingImage, error)
+	ListSightingImages(ctx context.Context, sightingID string) ([]*model.SightingImage, error)
+	ListTigerPhotos(ctx context.Context, tigerID string, after *model.PhotoCursor, limit int) ([]*model.SightingImage, error)
+	ListDueSightingImageIDs(ctx context.Context, now time.Time, lease time.Duration) ([]string, error)
+	ClaimSightingImage(ctx context.Context, id string, now time.Time, lease time.Duration) (bool, error)
+	UpdateSightingImageStatus(ctx context.Context, id string, status model.ImageStatus) error
+	MarkSightingImageFailed(ctx context.Context, id string, nextAttemptAt *time.Time) error
+	FindSimilarImage(ctx context.Context, hash int64, maxDistance int, excludeSightingID string) (*model.ImageMatch, error)
+	UpdateSightingImageHash(ctx context.Context, id string, hash int64) error
+	UpdateSightingImageFeatures(ctx context.Context, id string, features []byte) error
+	ListImageFeatures(ctx context.Context, box *model.BoundingBox, perTiger int, limit int) ([]*model.SightingImage, error)
+	ListImagesWithoutFeatures(ctx context.Context, afterID string, limit int) ([]*model.SightingImage, error)
+}
+
+//go:generate mockgen -source=repository.go -destination=mock/repository.go -package=mock
+type NotificationRepository interface {
+	ClaimDueNotificationJobs(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*model.NotificationJob, error)
+	MarkNotificationJobDelivered(ctx context.Context, id string, deliveredAt time.Time) error
+	MarkNotificationJobSkipped(ctx context.Context, id string) error
+	MarkNotificationJobBatched(ctx context.Context, id string, now time.Time) error
+	MarkNotificationJobsDelivered(ctx context.Context, ids []string, deliveredAt time.Time) error
+	ListDigestTimezones(ctx context.Context, now time.Time) ([]string, error)
+	ListDigestRecipients(ctx context.Context, now time.Time, hourlyBefore time.Time, dailyBefore map[string]time.Time,
+		limit int) ([]string, error)
+	ClaimDigestJobs(ctx context.Context, userID string, now time.Time, lease time.Duration) ([]*model.NotificationJob, error)
+	RecordNotificationJobFailure(ctx context.Context, job *model.NotificationJob) error
+	GetNotificationJobByID(ctx context.Context, id string) (*model.NotificationJob, error)
+	ListNotificationJobs(ctx context.Context, status *model.NotificationStatus, limit int, offset int) ([]*model.NotificationJob, error)
+	ReplayNotificationJob(ctx context.Context, id string, now time.Time) error
+	ListNotifications(ctx context.Context, userID string, unreadOnly bool, after *model.NotificationCursor, limit int) ([]*model.Notification, error)
+	CountUnreadNotifications(ctx context.Context, userID string) (int, error)
+	GetNotification(ctx context.Context, userID string, id string) (*model.Notification, error)
+	ListNotificationsBySighting(ctx context.Context, sightingID string) ([]*model.Notification, error)
+	MarkNotificationRead(ctx context.Context, userID string, id string, readAt time.Time) error
+	MarkAllNotificationsRead(ctx context.Context, userID string, readAt time.Time) (int, error)
+}
+
+//go:generate mockgen -source=repository.go -destination=mock/repository.go -package=mock
+type WatchZoneRepository interface {
+	CreateWatchZone(ctx context.Context, zone *model.WatchZone, limit int) (bool, error)
+	ListWatchZones(ctx context.Context, userID string) ([]*model.WatchZone, error)
+	ListWatchZonesAround(ctx context.Context, point *model.LastSeenCoordinate) ([]*model.WatchZone, error)
+}
+
+//go:generate mockgen -source=repository.go -destination=mock/repository.go -package=mock
+type WebhookRepository interface {
+	CreateWebhookSubscription(ctx context.Context, subscription *model.WebhookSubscription) error
+	GetWebhookSubscription(ctx context.Context, id string) (*model.WebhookSubscription, error)
+	ListWebhookSubscriptions(ctx context.Context) ([]*model.WebhookSubscription, error)
+	DeleteWebhookSubscription(ctx context.Context, id string) error
+	ClaimDueWebhookDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*model.WebhookDelivery, error)
+	MarkWebhookDeliveryDelivered(ctx context.Context, id string, responseStatus int, deliveredAt time.Time) error
+	RecordWebhookDeliveryFailure(ctx context.Context, delivery *model.WebhookDelivery) error
+	ListWebhookDeliveries(ctx context.Context, subscriptionID *string, status *model.WebhookDeliveryStatus, limit int, offset int) ([]*model.WebhookDelivery, error)
 }
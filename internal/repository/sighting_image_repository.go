@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nurcholisnanda/tigerhall-kittens/internal/api/graph/model"
+	"github.com/nurcholisnanda/tigerhall-kittens/pkg/imaging"
+	"gorm.io/gorm"
+)
+
+type SightingImageRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewSightingImageRepositoryImpl(db *gorm.DB) SightingImageRepository {
+	return &SightingImageRepositoryImpl{db: db}
+}
+
+func (r *SightingImageRepositoryImpl) GetSightingImageByID(ctx context.Context, id string) (*model.SightingImage, error) {
+	var image *model.SightingImage
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&image).Error; err != nil {
+		return nil, err
+	}
+	return image, nil
+}
+
+func (r *SightingImageRepositoryImpl) ListSightingImages(ctx context.Context, sightingID string) ([]*model.SightingImage, error) {
+	var images []*model.SightingImage
+	if err := r.db.WithContext(ctx).Where("sighting_id = ?", sightingID).Order("position asc").
+		Find(&images).Error; err != nil {
+		return nil, err
+	}
+	return images, nil
+}
+
+// ListTigerPhotos returns up to limit photos of a tiger that come after the
+// cursor in gallery order, newest sighting first
+func (r *SightingImageRepositoryImpl) ListTigerPhotos(ctx context.Context, tigerID string, after *model.PhotoCursor,
+	limit int) ([]*model.SightingImage, error) {
+	var images []*model.SightingImage
+	query := r.db.WithContext(ctx).Where("tiger_id = ?", tigerID)
+	if after != nil {
+		query = query.Where("taken_at < ? OR (taken_at = ? AND sighting_id > ?) OR (taken_at = ? AND sighting_id = ? AND position > ?)",
+			after.TakenAt, after.TakenAt, after.SightingID, after.TakenAt, after.SightingID, after.Position)
+	}
+	if err := query.Order("taken_at desc, sighting_id asc, position asc").Limit(limit).
+		Find(&images).Error; err != nil {
+		return nil, err
+	}
+	return images, nil
+}
+
+// ListDueSightingImageIDs returns the images waiting to be processed: pending
+// ones, failed ones whose retry is due and processing ones whose lease ran
+// out because their worker stopped
+func (r *SightingImageRepositoryImpl) ListDueSightingImageIDs(ctx context.Context, now time.Time, lease time.Duration) ([]string, error) {
+	var ids []string
+	if err := r.db.WithContext(ctx).Model(&model.SightingImage{}).Scopes(dueImages(now, lease)).
+		Order("created_at asc").Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// ClaimSightingImage moves a due image to processing and leases it to the
+// caller, the same way ClaimDueNotificationJobs leases jobs. It reports false
+// when the image is not due anymore, e.g. because another worker took it.
+func (r *SightingImageRepositoryImpl) ClaimSightingImage(ctx context.Context, id string, now time.Time,
+	lease time.Duration) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&model.SightingImage{}).
+		Where("id = ?", id).Scopes(dueImages(now, lease)).
+		Updates(map[string]interface{}{
+			"status":          model.ImageStatusProcessing,
+			"attempts":        gorm.Expr("attempts + 1"),
+			"claimed_at":      now,
+			"next_attempt_at": nil,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *SightingImageRepositoryImpl) UpdateSightingImageStatus(ctx context.Context, id string, status model.ImageStatus) error {
+	return r.db.WithContext(ctx).Model(&model.SightingImage{}).Where("id = ?", id).
+		Update("status", status).Error
+}
+
+// MarkSightingImageFailed marks an image failed and schedules its next
+// attempt, a nil nextAttemptAt gives up on it
+func (r *SightingImageRepositoryImpl) MarkSightingImageFailed(ctx context.Context, id string, nextAttemptAt *time.Time) error {
+	return r.db.WithContext(ctx).Model(&model.SightingImage{}).Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":          model.ImageStatusFailed,
+			"next_attempt_at": nextAttemptAt,
+		}).Error
+}
+
+// dueImages restricts a query to the images a worker may claim at now
+func dueImages(now time.Time, lease time.Duration) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("status = ? OR (status = ? AND claimed_at <= ?) OR (status = ? AND next_attempt_at <= ?)",
+			model.ImageStatusPending, model.ImageStatusProcessing, now.Add(-lease), model.ImageStatusFailed, now)
+	}
+}
+
+// hammingDistanceSQL counts the bits that differ between the stored hash and
+// the one bound to the placeholder
+const hammingDistanceSQL = "length(replace(((hash # ?)::bit(64))::text, '0', ''))"
+
+// FindSimilarImage returns the image of another sighting whose hash is
+// closest to hash, if it is at most maxDistance bits away. Only the images
+// sharing a nearby block with hash are compared, see imaging.HashBlocks.
+func (r *SightingImageRepositoryImpl) FindSimilarImage(ctx context.Context, hash int64, maxDistance int,
+	excludeSightingID string) (*model.ImageMatch, error) {
+	radius := maxDistance / imaging.HashBlocks
+	candidates := r.db
+	for i, block := range imaging.SplitHash(uint64(hash)) {
+		near := imaging.BlocksWithin(block, radius)
+		values := make([]int, 0, len(near))
+		for _, value := range near {
+			values = append(values, int(value))
+		}
+		candidates = candidates.Or(fmt.Sprintf("hash_block%d IN ?", i), values)
+	}
+
+	var matches []*model.ImageMatch
+	if err := r.db.WithContext(ctx).Model(&model.SightingImage{}).
+		Select("id AS image_id, sighting_id, "+hammingDistanceSQL+" AS distance", hash).
+		Where(candidates).Where("sighting_id <> ?", excludeSightingID).Where(hammingDistanceSQL+" <= ?", hash, maxDistance).
+		Order("distance asc").Limit(1).Scan(&matches).Error; err != nil {
+		return nil, err
+	}
+	if len(matches) == 0 {
+		return nil, nil
+	}
+	return matches[0], nil
+}
+
+// UpdateSightingImageHash stores the perceptual hash of an image with its
+// blocks
+func (r *SightingImageRepositoryImpl) UpdateSightingImageHash(ctx context.Context, id string, hash int64) error {
+	blocks := imaging.SplitHash(uint64(hash))
+	return r.db.WithContext(ctx).Model(&model.SightingImage{}).Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"hash":        hash,
+			"hash_block0": int(blocks[0]),
+			"hash_block1": int(blocks[1]),
+			"hash_block2": int(blocks[2]),
+			"hash_block3": int(blocks[3]),
+		}).Error
+}
+
+func (r *SightingImageRepositoryImpl) UpdateSightingImageFeatures(ctx context.Context, id string, features []byte) error {
+	return r.db.WithContext(ctx).Model(&model.SightingImage{}).Where("id = ?", id).
+		Update("features", features).Error
+}
+
+// ListImageFeatures returns the newest images with stripe features of
+// identified sightings, at most perTiger per tiger and limit in total, with
+// only what is needed to compare them loaded. A box keeps the tigers last
+// seen inside it. The photos are ranked on the gallery index before any
+// features are read.
+func (r *SightingImageRepositoryImpl) ListImageFeatures(ctx context.Context, box *model.BoundingBox, perTiger int,
+	limit int) ([]*model.SightingImage, error) {
+	ranked := r.db.Model(&model.SightingImage{}).
+		Select("sighting_images.id, sighting_images.taken_at, " +
+			"ROW_NUMBER() OVER (PARTITION BY sighting_images.tiger_id ORDER BY sighting_images.taken_at DESC) AS rank").
+		Where("sighting_images.features IS NOT NULL AND sighting_images.tiger_id IS NOT NULL")
+	if box != nil {
+		ranked = ranked.Joins("JOIN tigers ON tigers.id = sighting_images.tiger_id").Scopes(withinBox(box))
+	}
+	newest := r.db.Table("(?) AS ranked", ranked).Select("id").Where("rank <= ?", perTiger).
+		Order("taken_at desc").Limit(limit)
+
+	var images []*model.SightingImage
+	if err := r.db.WithContext(ctx).Select("id", "sighting_id", "tiger_id", "position", "taken_at", "key", "status", "features").
+		Where("id IN (?)", newest).Find(&images).Error; err != nil {
+		return nil, err
+	}
+	return images, nil
+}
+
+// ListImagesWithoutFeatures returns up to limit ready images, ordered by id
+// and after afterID, that were processed before stripe features or hashes
+// were extracted
+func (r *SightingImageRepositoryImpl) ListImagesWithoutFeatures(ctx context.Context, afterID string,
+	limit int) ([]*model.SightingImage, error) {
+	var images []*model.SightingImage
+	if err := r.db.WithContext(ctx).Where("status = ? AND (features IS NULL OR hash IS NULL) AND id > ?",
+		model.ImageStatusReady, afterID).Order("id asc").Limit(limit).Find(&images).Error; err != nil {
+		return nil, err
+	}
+	return images, nil
+}
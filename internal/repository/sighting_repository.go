@@ -7,6 +7,7 @@ import (
 	"github.com/nurcholisnanda/tigerhall-kittens/pkg/helper"
 	"github.com/nurcholisnanda/tigerhall-kittens/pkg/logger"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type SightingRepositoryImpl struct {
@@ -26,13 +27,113 @@ func (r *SightingRepositoryImpl) GetSightingsByTigerID(ctx context.Context, tige
 	return sightings, nil
 }
 
-func (r *SightingRepositoryImpl) CreateSighting(ctx context.Context, sighting *model.Sighting) error {
+// CreateSighting stores a sighting and queues the notifications about it,
+// including alerts for the watch zones it is inside of, and its webhook
+// messages
+func (r *SightingRepositoryImpl) CreateSighting(ctx context.Context, sighting *model.Sighting, watchZones []*model.WatchZone,
+	webhooks []*model.WebhookMessage) error {
 	userId, err := helper.GetUserID(ctx)
 	if err != nil {
 		logger.Logger(ctx).Error("failed to get user id")
 	}
 	sighting.CreatedBy = userId
-	return r.db.WithContext(ctx).Create(sighting).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(sighting).Error; err != nil {
+			return err
+		}
+		var notified []string
+		if sighting.Identified() {
+			if err := updateTigerLastSeen(tx, *sighting.TigerID, sighting); err != nil {
+				return err
+			}
+			if notified, err = enqueueSightingNotifications(tx, *sighting.TigerID, sighting); err != nil {
+				return err
+			}
+		}
+		if err := enqueueWatchZoneNotifications(tx, sighting, watchZones, notified); err != nil {
+			return err
+		}
+		return enqueueWebhookDeliveries(tx, webhooks...)
+	})
+}
+
+func (r *SightingRepositoryImpl) GetSightingByID(ctx context.Context, id string) (*model.Sighting, error) {
+	var sighting *model.Sighting
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&sighting).Error; err != nil {
+		return nil, err
+	}
+	return sighting, nil
+}
+
+func (r *SightingRepositoryImpl) ListSightingsByIDs(ctx context.Context, ids []string) ([]*model.Sighting, error) {
+	var sightings []*model.Sighting
+	if err := r.db.WithContext(ctx).Where("id IN ?", ids).Order("last_seen_time asc").Find(&sightings).Error; err != nil {
+		return nil, err
+	}
+	return sightings, nil
+}
+
+func (r *SightingRepositoryImpl) ListUnidentifiedSightings(ctx context.Context, limit int, offset int) ([]*model.Sighting, error) {
+	var sightings []*model.Sighting
+	if err := r.db.WithContext(ctx).Where("tiger_id IS NULL").Order("created_at asc").
+		Offset(offset).Limit(limit).Find(&sightings).Error; err != nil {
+		return nil, err
+	}
+	return sightings, nil
+}
+
+// FlagSighting flags a sighting and adds reason to the reasons it is flagged
+// for
+func (r *SightingRepositoryImpl) FlagSighting(ctx context.Context, id string, reason string) error {
+	return r.db.WithContext(ctx).Model(&model.Sighting{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"flagged": true,
+		"flag_reason": gorm.Expr("CASE WHEN flag_reason IS NULL OR flag_reason = '' THEN ? ELSE flag_reason || '; ' || ? END",
+			reason, reason),
+	}).Error
+}
+
+// AssignSighting sets the tiger of an unidentified sighting and its images
+// and queues the webhook message about the tiger. It returns
+// gorm.ErrRecordNotFound when the sighting is not unidentified anymore.
+func (r *SightingRepositoryImpl) AssignSighting(ctx context.Context, sighting *model.Sighting, tigerID string,
+	webhook *model.WebhookMessage) error {
+	userId, err := helper.GetUserID(ctx)
+	if err != nil {
+		logger.Logger(ctx).Error("failed to get user id")
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.Sighting{}).Where("id = ? AND tiger_id IS NULL", sighting.ID).Updates(map[string]interface{}{
+			"tiger_id":   tigerID,
+			"updated_by": userId,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if err := tx.Model(&model.SightingImage{}).Where("sighting_id = ?", sighting.ID).
+			Update("tiger_id", tigerID).Error; err != nil {
+			return err
+		}
+		if err := updateTigerLastSeen(tx, tigerID, sighting); err != nil {
+			return err
+		}
+		if _, err := enqueueSightingNotifications(tx, tigerID, sighting); err != nil {
+			return err
+		}
+		return enqueueWebhookDeliveries(tx, webhook)
+	})
+}
+
+// updateTigerLastSeen keeps the tiger's last known position in sync with its
+// latest sighting
+func updateTigerLastSeen(tx *gorm.DB, tigerID string, sighting *model.Sighting) error {
+	return tx.Model(&model.Tiger{}).Where("id = ?", tigerID).Updates(map[string]interface{}{
+		"last_seen_time": sighting.LastSeenTime,
+		"latitude":       sighting.Latitude,
+		"longitude":      sighting.Longitude,
+	}).Error
 }
 
 func (r *SightingRepositoryImpl) GetLatestSightingByTigerID(ctx context.Context, tigerID string) (*model.Sighting, error) {
@@ -43,12 +144,95 @@ func (r *SightingRepositoryImpl) GetLatestSightingByTigerID(ctx context.Context,
 	return sighting, nil
 }
 
-func (r *SightingRepositoryImpl) ListUserCreatedSightingByTigerID(ctx context.Context, tigerID string) ([]string, error) {
-	var createdBy []string
+// ListSightingsInBox returns up to limit sightings inside the box, nearest to
+// center first. A negative limit returns every sighting in the box.
+func (r *SightingRepositoryImpl) ListSightingsInBox(ctx context.Context, box *model.BoundingBox, center *model.LastSeenCoordinate,
+	timeRange *model.TimeRangeInput, limit int) ([]*model.Sighting, error) {
+	var sightings []*model.Sighting
+	if err := r.db.WithContext(ctx).Scopes(withinBox(box), withinTimeRange(timeRange), nearestFirst(center)).
+		Limit(limit).Find(&sightings).Error; err != nil {
+		return nil, err
+	}
+	return sightings, nil
+}
 
-	if err := r.db.WithContext(ctx).Table("sightings").Where("tiger_id = ?", tigerID).
-		Pluck("created_by", &createdBy).Error; err != nil {
+func (r *SightingRepositoryImpl) ListSightingsForTrack(ctx context.Context, tigerID string, timeRange *model.TimeRangeInput) ([]*model.Sighting, error) {
+	var sightings []*model.Sighting
+	if err := r.db.WithContext(ctx).Where("tiger_id = ?", tigerID).Scopes(withinTimeRange(timeRange)).
+		Order("last_seen_time asc").Find(&sightings).Error; err != nil {
 		return nil, err
 	}
-	return createdBy, nil
+	return sightings, nil
+}
+
+// StreamSightings calls fn for every sighting matching the filters one row at
+// a time so callers can export millions of rows without loading them into
+// memory. An empty tigerID matches every tiger. Unidentified sightings are
+// left out, whether their tiger is sensitive is not known.
+func (r *SightingRepositoryImpl) StreamSightings(ctx context.Context, tigerID string, timeRange *model.TimeRangeInput,
+	fn func(sighting *model.Sighting) error) error {
+	db := r.db.WithContext(ctx)
+	query := db.Model(&model.Sighting{}).Where("tiger_id IS NOT NULL").Scopes(withinTimeRange(timeRange))
+	if tigerID != "" {
+		query = query.Where("tiger_id = ?", tigerID)
+	}
+	rows, err := query.Order("last_seen_time asc").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var sighting model.Sighting
+		if err := db.ScanRows(rows, &sighting); err != nil {
+			return err
+		}
+		if err := fn(&sighting); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// withinTimeRange restricts a query to rows last seen inside the optional
+// time range. Both ends of the range are inclusive.
+func withinTimeRange(timeRange *model.TimeRangeInput) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if timeRange == nil {
+			return db
+		}
+		if timeRange.From != nil {
+			db = db.Where("last_seen_time >= ?", *timeRange.From)
+		}
+		if timeRange.To != nil {
+			db = db.Where("last_seen_time <= ?", *timeRange.To)
+		}
+		return db
+	}
+}
+
+// withinBox restricts a query to rows whose coordinate lies inside the box so
+// the lookup can use the composite coordinate index. A box whose south-west
+// longitude is greater than its north-east longitude crosses the antimeridian.
+func withinBox(box *model.BoundingBox) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		db = db.Where("latitude BETWEEN ? AND ?", box.SouthWest.Latitude, box.NorthEast.Latitude)
+		if box.SouthWest.Longitude <= box.NorthEast.Longitude {
+			return db.Where("longitude BETWEEN ? AND ?", box.SouthWest.Longitude, box.NorthEast.Longitude)
+		}
+		return db.Where("(longitude >= ? OR longitude <= ?)", box.SouthWest.Longitude, box.NorthEast.Longitude)
+	}
+}
+
+// nearestFirst orders rows by their great-circle distance to center. It sorts
+// on the haversine term, which grows with the distance, so the database can
+// apply the limit without computing the distance itself.
+func nearestFirst(center *model.LastSeenCoordinate) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Order(clause.OrderBy{Expression: clause.Expr{
+			SQL: "POWER(SIN(RADIANS(latitude - ?) / 2), 2) + COS(RADIANS(?)) * COS(RADIANS(latitude)) * " +
+				"POWER(SIN(RADIANS(longitude - ?) / 2), 2)",
+			Vars: []interface{}{center.Latitude, center.Latitude, center.Longitude},
+		}})
+	}
 }
@@ -2,11 +2,13 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/nurcholisnanda/tigerhall-kittens/internal/api/graph/model"
 	"github.com/nurcholisnanda/tigerhall-kittens/pkg/helper"
 	"github.com/nurcholisnanda/tigerhall-kittens/pkg/logger"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type TigerRepositoryImpl struct {
@@ -27,13 +29,46 @@ func (r *TigerRepositoryImpl) GetTigerByID(ctx context.Context, id string) (*mod
 	return tiger, nil
 }
 
-func (r *TigerRepositoryImpl) Create(ctx context.Context, tiger *model.Tiger) error {
+func (r *TigerRepositoryImpl) ListTigersByIDs(ctx context.Context, ids []string) ([]*model.Tiger, error) {
+	var tigers []*model.Tiger
+	if err := r.db.WithContext(ctx).Where("id IN ?", ids).Find(&tigers).Error; err != nil {
+		return nil, err
+	}
+	return tigers, nil
+}
+
+// Create stores a tiger and queues the webhook message about it
+func (r *TigerRepositoryImpl) Create(ctx context.Context, tiger *model.Tiger, webhook *model.WebhookMessage) error {
 	userId, err := helper.GetUserID(ctx)
 	if err != nil {
 		logger.Logger(ctx).Error("failed to get user id")
 	}
 	tiger.CreatedBy = userId
-	return r.db.WithContext(ctx).Create(tiger).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(tiger).Error; err != nil {
+			return err
+		}
+		return enqueueWebhookDeliveries(tx, webhook)
+	})
+}
+
+// SetProfileImage sets the profile image of a tiger and queues the webhook
+// message about it
+func (r *TigerRepositoryImpl) SetProfileImage(ctx context.Context, tigerID string, imageID string,
+	webhook *model.WebhookMessage) error {
+	userId, err := helper.GetUserID(ctx)
+	if err != nil {
+		logger.Logger(ctx).Error("failed to get user id")
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.Tiger{}).Where("id = ?", tigerID).Updates(map[string]interface{}{
+			"profile_image_id": imageID,
+			"updated_by":       userId,
+		}).Error; err != nil {
+			return err
+		}
+		return enqueueWebhookDeliveries(tx, webhook)
+	})
 }
 
 func (r *TigerRepositoryImpl) ListTigers(ctx context.Context, limit int, offset int) ([]*model.Tiger, error) {
@@ -43,3 +78,58 @@ func (r *TigerRepositoryImpl) ListTigers(ctx context.Context, limit int, offset
 	}
 	return tigers, nil
 }
+
+func (r *TigerRepositoryImpl) ListTigersInBox(ctx context.Context, box *model.BoundingBox, seenSince *time.Time) ([]*model.Tiger, error) {
+	var tigers []*model.Tiger
+	query := r.db.WithContext(ctx).Scopes(withinBox(box))
+	if seenSince != nil {
+		query = query.Where("last_seen_time >= ?", *seenSince)
+	}
+	if err := query.Find(&tigers).Error; err != nil {
+		return nil, err
+	}
+	return tigers, nil
+}
+
+// FollowTiger is a no-op when the user already follows the tiger
+func (r *TigerRepositoryImpl) FollowTiger(ctx context.Context, userID string, tigerID string) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&model.TigerFollow{UserID: userID, TigerID: tigerID}).Error
+}
+
+func (r *TigerRepositoryImpl) UnfollowTiger(ctx context.Context, userID string, tigerID string) error {
+	return r.db.WithContext(ctx).Where("user_id = ? AND tiger_id = ?", userID, tigerID).Delete(&model.TigerFollow{}).Error
+}
+
+// ListFollowedTigers returns the tigers a user follows, in the order they
+// were followed
+func (r *TigerRepositoryImpl) ListFollowedTigers(ctx context.Context, userID string) ([]*model.Tiger, error) {
+	var tigers []*model.Tiger
+	if err := r.db.WithContext(ctx).Joins("JOIN tiger_follows ON tiger_follows.tiger_id = tigers.id").
+		Where("tiger_follows.user_id = ?", userID).Order("tiger_follows.created_at asc").Find(&tigers).Error; err != nil {
+		return nil, err
+	}
+	return tigers, nil
+}
+
+// StreamTigers calls fn for every tiger one row at a time so callers can
+// export the whole table without loading it into memory.
+func (r *TigerRepositoryImpl) StreamTigers(ctx context.Context, fn func(tiger *model.Tiger) error) error {
+	db := r.db.WithContext(ctx)
+	rows, err := db.Model(&model.Tiger{}).Order("created_at asc").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var tiger model.Tiger
+		if err := db.ScanRows(rows, &tiger); err != nil {
+			return err
+		}
+		if err := fn(&tiger); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -2,9 +2,11 @@ package repository
 
 import (
 	"context"
+	"errors"
 
 	"github.com/nurcholisnanda/tigerhall-kittens/internal/api/graph/model"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type userRepoImpl struct {
@@ -36,3 +38,46 @@ func (r *userRepoImpl) GetUserByEmail(ctx context.Context, email string) (*model
 	}
 	return user, nil
 }
+
+// GetNotificationPreferences returns the stored preferences of a user, or
+// the defaults when the user never changed them
+func (r *userRepoImpl) GetNotificationPreferences(ctx context.Context, userID string) (*model.NotificationPreferences, error) {
+	db := r.db.WithContext(ctx)
+	var preferences *model.NotificationPreferences
+	if err := db.Where("user_id = ?", userID).First(&preferences).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		preferences = model.DefaultNotificationPreferences(userID)
+	}
+	preferences.MutedTigerIDs = []string{}
+	if err := db.Model(&model.MutedTiger{}).Where("user_id = ?", userID).Order("created_at asc").
+		Pluck("tiger_id", &preferences.MutedTigerIDs).Error; err != nil {
+		return nil, err
+	}
+	return preferences, nil
+}
+
+// SaveNotificationPreferences stores preferences, replacing the muted tigers
+// with preferences.MutedTigerIDs
+func (r *userRepoImpl) SaveNotificationPreferences(ctx context.Context, preferences *model.NotificationPreferences) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"email_enabled", "digest_frequency", "locale", "timezone", "updated_at"}),
+		}).Create(preferences).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", preferences.UserID).Delete(&model.MutedTiger{}).Error; err != nil {
+			return err
+		}
+		if len(preferences.MutedTigerIDs) == 0 {
+			return nil
+		}
+		muted := make([]*model.MutedTiger, 0, len(preferences.MutedTigerIDs))
+		for _, tigerID := range preferences.MutedTigerIDs {
+			muted = append(muted, &model.MutedTiger{UserID: preferences.UserID, TigerID: tigerID})
+		}
+		return tx.Create(&muted).Error
+	})
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/nurcholisnanda/tigerhall-kittens/internal/api/graph/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WatchZoneRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewWatchZoneRepositoryImpl(db *gorm.DB) WatchZoneRepository {
+	return &WatchZoneRepositoryImpl{
+		db: db,
+	}
+}
+
+// CreateWatchZone stores a zone unless its user already has limit zones, it
+// reports whether the zone was created. The user is locked while counting so
+// concurrent creates cannot pass the limit together.
+func (r *WatchZoneRepositoryImpl) CreateWatchZone(ctx context.Context, zone *model.WatchZone, limit int) (bool, error) {
+	created := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id").Where("id = ?", zone.UserID).Take(&model.User{}).Error; err != nil {
+			return err
+		}
+		var count int64
+		if err := tx.Model(&model.WatchZone{}).Where("user_id = ?", zone.UserID).Count(&count).Error; err != nil {
+			return err
+		}
+		if int(count) >= limit {
+			return nil
+		}
+		if err := tx.Create(zone).Error; err != nil {
+			return err
+		}
+		created = true
+		return nil
+	})
+	return created, err
+}
+
+func (r *WatchZoneRepositoryImpl) ListWatchZones(ctx context.Context, userID string) ([]*model.WatchZone, error) {
+	var zones []*model.WatchZone
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at asc").Find(&zones).Error; err != nil {
+		return nil, err
+	}
+	return zones, nil
+}
+
+// ListWatchZonesAround returns the zones whose bounding box contains the
+// point. The caller still has to check the point against their shape.
+func (r *WatchZoneRepositoryImpl) ListWatchZonesAround(ctx context.Context, point *model.LastSeenCoordinate) ([]*model.WatchZone, error) {
+	var zones []*model.WatchZone
+	// boxes crossing the antimeridian extend past 180, so the point is also
+	// looked up one turn east
+	if err := r.db.WithContext(ctx).
+		Where("bounds @> box(point(?, ?), point(?, ?)) OR bounds @> box(point(?, ?), point(?, ?))",
+			point.Longitude, point.Latitude, point.Longitude, point.Latitude,
+			point.Longitude+360, point.Latitude, point.Longitude+360, point.Latitude).
+		Find(&zones).Error; err != nil {
+		return nil, err
+	}
+	return zones, nil
+}
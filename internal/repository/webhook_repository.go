@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nurcholisnanda/tigerhall-kittens/internal/api/graph/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WebhookRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepositoryImpl(db *gorm.DB) WebhookRepository {
+	return &WebhookRepositoryImpl{db: db}
+}
+
+func (r *WebhookRepositoryImpl) CreateWebhookSubscription(ctx context.Context, subscription *model.WebhookSubscription) error {
+	return r.db.WithContext(ctx).Create(subscription).Error
+}
+
+func (r *WebhookRepositoryImpl) GetWebhookSubscription(ctx context.Context, id string) (*model.WebhookSubscription, error) {
+	var subscription *model.WebhookSubscription
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&subscription).Error; err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+func (r *WebhookRepositoryImpl) ListWebhookSubscriptions(ctx context.Context) ([]*model.WebhookSubscription, error) {
+	var subscriptions []*model.WebhookSubscription
+	if err := r.db.WithContext(ctx).Order("created_at asc").Find(&subscriptions).Error; err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+// DeleteWebhookSubscription soft deletes a subscription and gives up its
+// pending deliveries, the delivery log is kept
+func (r *WebhookRepositoryImpl) DeleteWebhookSubscription(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ?", id).Delete(&model.WebhookSubscription{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Model(&model.WebhookDelivery{}).
+			Where("subscription_id = ? AND status = ?", id, model.WebhookDeliveryStatusPending).
+			Updates(map[string]interface{}{
+				"status":     model.WebhookDeliveryStatusDead,
+				"last_error": "subscription deleted",
+			}).Error
+	})
+}
+
+// ClaimDueWebhookDeliveries returns up to limit pending deliveries that are
+// due with their subscription, and leases them the same way
+// ClaimDueNotificationJobs does. The subscription of a delivery is nil once
+// it was deleted.
+func (r *WebhookRepositoryImpl) ClaimDueWebhookDeliveries(ctx context.Context, now time.Time, lease time.Duration,
+	limit int) ([]*model.WebhookDelivery, error) {
+	var deliveries []*model.WebhookDelivery
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", model.WebhookDeliveryStatusPending, now).
+			Order("next_attempt_at asc").Limit(limit).Find(&deliveries).Error; err != nil {
+			return err
+		}
+		if len(deliveries) == 0 {
+			return nil
+		}
+		ids := make([]string, 0, len(deliveries))
+		for _, delivery := range deliveries {
+			ids = append(ids, delivery.ID)
+		}
+		return tx.Model(&model.WebhookDelivery{}).Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(lease)).Error
+	})
+	if err != nil || len(deliveries) == 0 {
+		return nil, err
+	}
+
+	subscriptionIDs := make([]string, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		subscriptionIDs = append(subscriptionIDs, delivery.SubscriptionID)
+	}
+	var subscriptions []*model.WebhookSubscription
+	if err := r.db.WithContext(ctx).Where("id IN ?", subscriptionIDs).Find(&subscriptions).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[string]*model.WebhookSubscription, len(subscriptions))
+	for _, subscription := range subscriptions {
+		byID[subscription.ID] = subscription
+	}
+	for _, delivery := range deliveries {
+		delivery.Subscription = byID[delivery.SubscriptionID]
+	}
+	return deliveries, nil
+}
+
+func (r *WebhookRepositoryImpl) MarkWebhookDeliveryDelivered(ctx context.Context, id string, responseStatus int,
+	deliveredAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&model.WebhookDelivery{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":          model.WebhookDeliveryStatusDelivered,
+		"response_status": responseStatus,
+		"delivered_at":    deliveredAt,
+		"last_error":      nil,
+	}).Error
+}
+
+// RecordWebhookDeliveryFailure stores the outcome of a failed attempt: the
+// attempt count, the response status and error and either the next attempt
+// or the dead status
+func (r *WebhookRepositoryImpl) RecordWebhookDeliveryFailure(ctx context.Context, delivery *model.WebhookDelivery) error {
+	return r.db.WithContext(ctx).Model(&model.WebhookDelivery{}).Where("id = ?", delivery.ID).Updates(map[string]interface{}{
+		"status":          delivery.Status,
+		"attempts":        delivery.Attempts,
+		"next_attempt_at": delivery.NextAttemptAt,
+		"response_status": delivery.ResponseStatus,
+		"last_error":      delivery.LastError,
+	}).Error
+}
+
+func (r *WebhookRepositoryImpl) ListWebhookDeliveries(ctx context.Context, subscriptionID *string,
+	status *model.WebhookDeliveryStatus, limit int, offset int) ([]*model.WebhookDelivery, error) {
+	var deliveries []*model.WebhookDelivery
+	query := r.db.WithContext(ctx)
+	if subscriptionID != nil {
+		query = query.Where("subscription_id = ?", *subscriptionID)
+	}
+	if status != nil {
+		query = query.Where("status = ?", *status)
+	}
+	if err := query.Order("updated_at desc").Offset(offset).Limit(limit).Find(&deliveries).Error; err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// enqueueWebhookDeliveries queues a delivery of every message to each
+// subscription to its event, as part of the transaction storing the change
+// the messages are about
+func enqueueWebhookDeliveries(tx *gorm.DB, messages ...*model.WebhookMessage) error {
+	for _, message := range messages {
+		if message == nil {
+			continue
+		}
+		event, err := json.Marshal([]model.WebhookEvent{message.Event})
+		if err != nil {
+			return err
+		}
+		var subscriptionIDs []string
+		if err := tx.Model(&model.WebhookSubscription{}).Where("CAST(events AS jsonb) @> CAST(? AS jsonb)", string(event)).
+			Order("created_at asc").Pluck("id", &subscriptionIDs).Error; err != nil {
+			return err
+		}
+		if len(subscriptionIDs) == 0 {
+			continue
+		}
+		deliveries := make([]*model.WebhookDelivery, 0, len(subscriptionIDs))
+		for _, subscriptionID := range subscriptionIDs {
+			deliveries = append(deliveries, &model.WebhookDelivery{
+				ID:             uuid.NewString(),
+				SubscriptionID: subscriptionID,
+				Event:          message.Event,
+				EventID:        message.EventID,
+				Payload:        message.Payload,
+				Status:         model.WebhookDeliveryStatusPending,
+				NextAttemptAt:  message.CreatedAt,
+			})
+		}
+		if err := tx.Create(deliveries).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"sync"
+
+	"github.com/nurcholisnanda/tigerhall-kittens/internal/api/graph/model"
+	"github.com/nurcholisnanda/tigerhall-kittens/internal/repository"
+	"github.com/nurcholisnanda/tigerhall-kittens/pkg/helper"
+	"github.com/nurcholisnanda/tigerhall-kittens/pkg/logger"
+)
+
+// subscriberBuffer is how many events a subscriber can fall behind before
+// further events are dropped for it, a slow client never holds up a
+// publisher
+const subscriberBuffer = 16
+
+type sightingSubscriber struct {
+	tigerID *string
+	area    *model.BoundingBox
+	events  chan *model.Sighting
+}
+
+// matches reports whether a new sighting is one the subscriber asked for. A
+// tiger filter only matches identified sightings.
+func (s *sightingSubscriber) matches(sighting *model.Sighting) bool {
+	if s.tigerID != nil && (sighting.TigerID == nil || *sighting.TigerID != *s.tigerID) {
+		return false
+	}
+	if s.area != nil && (sighting.LastSeenCoordinate == nil || !boxContains(s.area, sighting.LastSeenCoordinate)) {
+		return false
+	}
+	return true
+}
+
+// eventBroker fans events out to the subscriptions of this instance. It
+// lives in memory, subscribers only receive what happens while they are
+// connected.
+type eventBroker struct {
+	notificationRepo repository.NotificationRepository
+
+	mu               sync.RWMutex
+	nextID           int
+	sightingSubs     map[int]*sightingSubscriber
+	notificationSubs map[string]map[int]chan *model.Notification
+}
+
+func NewEventBroker(notificationRepo repository.NotificationRepository) EventBroker {
+	return &eventBroker{
+		notificationRepo: notificationRepo,
+		sightingSubs:     map[int]*sightingSubscriber{},
+		notificationSubs: map[string]map[int]chan *model.Notification{},
+	}
+}
+
+// SubscribeSightings streams new sightings, optionally only those of a tiger
+// or inside an area, until ctx is done
+func (b *eventBroker) SubscribeSightings(ctx context.Context, tigerID *string, area *model.BoundingBox) (<-chan *model.Sighting, error) {
+	if area != nil && !isValidBoundingBox(area) {
+		return nil, &helper.InvalidCoordinatesError{
+			Message: "area must have valid south-west and north-east corners, south of each other",
+		}
+	}
+	subscriber := &sightingSubscriber{
+		tigerID: tigerID,
+		area:    area,
+		events:  make(chan *model.Sighting, subscriberBuffer),
+	}
+
+	b.mu.Lock()
+	id := b.nextID
+	b.nextID++
+	b.sightingSubs[id] = subscriber
+	b.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		b.mu.Lock()
+		delete(b.sightingSubs, id)
+		close(subscriber.events)
+		b.mu.Unlock()
+	}()
+	return subscriber.events, nil
+}
+
+// SubscribeNotifications streams the inbox notifications a user receives
+// until ctx is done
+func (b *eventBroker) SubscribeNotifications(ctx context.Context, userID string) <-chan *model.Notification {
+	events := make(chan *model.Notification, subscriberBuffer)
+
+	b.mu.Lock()
+	id := b.nextID
+	b.nextID++
+	if b.notificationSubs[userID] == nil {
+		b.notificationSubs[userID] = map[int]chan *model.Notification{}
+	}
+	b.notificationSubs[userID][id] = events
+	b.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		b.mu.Lock()
+		delete(b.notificationSubs[userID], id)
+		if len(b.notificationSubs[userID]) == 0 {
+			delete(b.notificationSubs, userID)
+		}
+		close(events)
+		b.mu.Unlock()
+	}()
+	return events
+}
+
+// PublishSighting sends a new sighting to every subscriber it matches
+func (b *eventBroker) PublishSighting(ctx context.Context, sighting *model.Sighting) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, subscriber := range b.sightingSubs {
+		if !subscriber.matches(sighting) {
+			continue
+		}
+		select {
+		case subscriber.events <- sighting:
+		default:
+			logger.Logger(ctx).Warn("Dropped sighting event for a slow subscriber")
+		}
+	}
+}
+
+// PublishSightingNotifications sends the inbox notifications created for a
+// sighting to their connected recipients. Nothing is loaded while nobody is
+// subscribed.
+func (b *eventBroker) PublishSightingNotifications(ctx context.Context, sightingID string) {
+	b.mu.RLock()
+	subscribed := len(b.notificationSubs) > 0
+	b.mu.RUnlock()
+	if !subscribed {
+		return
+	}
+
+	notifications, err := b.notificationRepo.ListNotificationsBySighting(ctx, sightingID)
+	if err != nil {
+		logger.Logger(ctx).Error("Failed to list notifications of sighting:", err)
+		return
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, notification := range notifications {
+		for _, events := range b.notificationSubs[notification.UserID] {
+			select {
+			case events <- notification:
+			default:
+				logger.Logger(ctx).Warn("Dropped notification event for a slow subscriber")
+			}
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/nurcholisnanda/tigerhall-kittens/internal/api/graph/model"
+	"github.com/nurcholisnanda/tigerhall-kittens/internal/repository/mock"
+	"github.com/nurcholisnanda/tigerhall-kittens/pkg/helper"
+	"go.uber.org/mock/gomock"
+)
+
+func Test_eventBroker_SubscribeSightings(t *testing.T) {
+	sumatra := &model.BoundingBox{
+		SouthWest: &model.LastSeenCoordinateInput{Latitude: -6, Longitude: 95},
+		NorthEast: &model.LastSeenCoordinateInput{Latitude: 6, Longitude: 106},
+	}
+	inSumatra := &model.LastSeenCoordinate{Latitude: 0, Longitude: 100}
+	inJava := &model.LastSeenCoordinate{Latitude: -7, Longitude: 110}
+	tests := []struct {
+		name     string
+		tigerID  *string
+		area     *model.BoundingBox
+		sighting *model.Sighting
+		want     bool
+		wantErr  error
+	}{
+		{
+			name:     "without filters every sighting is received",
+			sighting: &model.Sighting{ID: "s1", LastSeenCoordinate: inJava},
+			want:     true,
+		},
+		{
+			name:     "sighting of the tiger is received",
+			tigerID:  ptr("t1"),
+			sighting: &model.Sighting{ID: "s1", TigerID: ptr("t1"), LastSeenCoordinate: inJava},
+			want:     true,
+		},
+		{
+			name:     "sighting of another tiger is not received",
+			tigerID:  ptr("t1"),
+			sighting: &model.Sighting{ID: "s1", TigerID: ptr("t2"), LastSeenCoordinate: inJava},
+		},
+		{
+			name:     "unidentified sighting does not match a tiger",
+			tigerID:  ptr("t1"),
+			sighting: &model.Sighting{ID: "s1", LastSeenCoordinate: inJava},
+		},
+		{
+			name:     "sighting inside the area is received",
+			area:     sumatra,
+			sighting: &model.Sighting{ID: "s1", LastSeenCoordinate: inSumatra},
+			want:     true,
+		},
+		{
+			name:     "sighting outside the area is not received",
+			area:     sumatra,
+			sighting: &model.Sighting{ID: "s1", LastSeenCoordinate: inJava},
+		},
+		{
+			name: "should return error if the area is invalid",
+			area: &model.BoundingBox{
+				SouthWest: &model.LastSeenCoordinateInput{Latitude: 6, Longitude: 95},
+				NorthEast: &model.LastSeenCoordinateInput{Latitude: -6, Longitude: 106},
+			},
+			wantErr: &helper.InvalidCoordinatesError{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewEventBroker(nil)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			events, err := b.SubscribeSightings(ctx, tt.tigerID, tt.area)
+			if tt.wantErr != nil {
+				if reflect.TypeOf(err) != reflect.TypeOf(tt.wantErr) {
+					t.Errorf("eventBroker.SubscribeSightings() error = %v, want %T", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("eventBroker.SubscribeSightings() error = %v", err)
+			}
+
+			b.PublishSighting(context.Background(), tt.sighting)
+			select {
+			case got := <-events:
+				if !tt.want {
+					t.Errorf("eventBroker.PublishSighting() delivered %v, want nothing", got.ID)
+				}
+			default:
+				if tt.want {
+					t.Errorf("eventBroker.PublishSighting() delivered nothing, want %v", tt.sighting.ID)
+				}
+			}
+		})
+	}
+}
+
+func Test_eventBroker_unsubscribe(t *testing.T) {
+	b := NewEventBroker(nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	sightings, _ := b.SubscribeSightings(ctx, nil, nil)
+	notifications := b.SubscribeNotifications(ctx, "u1")
+	cancel()
+
+	// the channels are closed once the subscription ends
+	for range sightings {
+	}
+	for range notifications {
+	}
+	broker := b.(*eventBroker)
+	if len(broker.sightingSubs) != 0 || len(broker.notificationSubs) != 0 {
+		t.Errorf("eventBroker kept %d sighting and %d notification subscribers, want none",
+			len(broker.sightingSubs), len(broker.notificationSubs))
+	}
+}
+
+func Test_eventBroker_PublishSightingNotifications(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	notificationRepo := mock.NewMockNotificationRepository(ctrl)
+
+	t.Run("nothing is loaded without subscribers", func(t *testing.T) {
+		b := NewEventBroker(notificationRepo)
+		b.PublishSightingNotifications(context.Background(), "s1")
+	})
+
+	t.Run("notifications reach their recipients only", func(t *testing.T) {
+		b := NewEventBroker(notificationRepo)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		first := b.SubscribeNotifications(ctx, "u1")
+		second := b.SubscribeNotifications(ctx, "u1")
+		other := b.SubscribeNotifications(ctx, "u3")
+
+		notificationRepo.EXPECT().ListNotificationsBySighting(gomock.Any(), "s1").Return([]*model.Notification{
+			{ID: "n1", UserID: "u1", SightingID: "s1"},
+			{ID: "n2", UserID: "u2", SightingID: "s1"},
+		}, nil)
+		b.PublishSightingNotifications(context.Background(), "s1")
+
+		for _, events := range []<-chan *model.Notification{first, second} {
+			select {
+			case got := <-events:
+				if got.ID != "n1" {
+					t.Errorf("eventBroker.PublishSightingNotifications() delivered %v, want n1", got.ID)
+				}
+			default:
+				t.Errorf("eventBroker.PublishSightingNotifications() delivered nothing, want n1")
+			}
+		}
+		select {
+		case got := <-other:
+			t.Errorf("eventBroker.PublishSightingNotifications() delivered %v to another user", got.ID)
+		default:
+		}
+	})
+
+	t.Run("a failing lookup delivers nothing", func(t *testing.T) {
+		b := NewEventBroker(notificationRepo)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		events := b.SubscribeNotifications(ctx, "u1")
+
+		notificationRepo.EXPECT().ListNotificationsBySighting(gomock.Any(), "s1").Return(nil, errors.New("any error"))
+		b.PublishSightingNotifications(context.Background(), "s1")
+
+		select {
+		case got := <-events:
+			t.Errorf("eventBroker.PublishSightingNotifications() delivered %v, want nothing", got.ID)
+		default:
+		}
+	})
+}
+
+func Test_eventBroker_slowSubscriber(t *testing.T) {
+	b := NewEventBroker(nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, _ := b.SubscribeSightings(ctx, nil, nil)
+
+	// publishing never blocks, events beyond the buffer are dropped
+	for i := 0; i < subscriberBuffer+5; i++ {
+		b.PublishSighting(context.Background(), &model.Sighting{ID: "s1"})
+	}
+	if len(events) != subscriberBuffer {
+		t.Errorf("eventBroker buffered %d events, want %d", len(events), subscriberBuffer)
+	}
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/nurcholisnanda/tigerhall-kittens/internal/api/graph/model"
+	"github.com/nurcholisnanda/tigerhall-kittens/internal/export"
+	"github.com/nurcholisnanda/tigerhall-kittens/internal/repository"
+	"github.com/nurcholisnanda/tigerhall-kittens/pkg/logger"
+	"github.com/nurcholisnanda/tigerhall-kittens/pkg/urlsign"
+	"gorm.io/gorm"
+)
+
+// dwcaMediaURLTTL is how long image links in Darwin Core Archives stay valid
+const dwcaMediaURLTTL = 365 * 24 * time.Hour
+
+type exportService struct {
+	tigerRepo    repository.TigerRepository
+	sightingRepo repository.SightingRepository
+	userRepo     repository.UserRepository
+	urlSigner    *urlsign.Signer
+}
+
+func NewExportService(tigerRepo repository.TigerRepository, sightingRepo repository.SightingRepository,
+	userRepo repository.UserRepository, urlSigner *urlsign.Signer) ExportService {
+	return &exportService{
+		tigerRepo:    tigerRepo,
+		sightingRepo: sightingRepo,
+		userRepo:     userRepo,
+		urlSigner:    urlSigner,
+	}
+}
+
+// ExportTigers streams every tiger to w. Rows are written as they are read
+// from the database so memory use does not grow with the table size.
+func (s *exportService) ExportTigers(ctx context.Context, w io.Writer, format export.BulkFormat) error {
+	writer, err := export.NewRecordWriter(w, format, export.TigerColumns)
+	if err != nil {
+		return err
+	}
+	if err := s.tigerRepo.StreamTigers(ctx, func(tiger *model.Tiger) error {
+		return writer.Write(export.TigerRecord(tiger))
+	}); err != nil {
+		logger.Logger(ctx).Error("failed to export tigers", err)
+		return err
+	}
+	return writer.Close()
+}
+
+// ExportSightings streams the sightings matching the filters to w. An empty
+// tigerID exports the sightings of every tiger.
+func (s *exportService) ExportSightings(ctx context.Context, w io.Writer, format export.BulkFormat, tigerID string,
+	timeRange *model.TimeRangeInput) error {
+	writer, err := export.NewRecordWriter(w, format, export.SightingColumns)
+	if err != nil {
+		return err
+	}
+	if err := s.sightingRepo.StreamSightings(ctx, tigerID, timeRange, func(sighting *model.Sighting) error {
+		return writer.Write(export.SightingRecord(sighting))
+	}); err != nil {
+		logger.Logger(ctx).Error("failed to export sightings", err)
+		return err
+	}
+	return writer.Close()
+}
+
+// ExportDarwinCore writes every sighting as a Darwin Core Archive for
+// biodiversity data portals. Tigers and reporters are looked up once and
+// cached, since many sightings share them.
+func (s *exportService) ExportDarwinCore(ctx context.Context, w io.Writer) error {
+	writer, err := export.NewDwCAWriter(w, export.DatasetMetadata{
+		Title:     "TigerHall Kittens tiger sightings",
+		Publisher: "TigerHall Kittens",
+		Abstract:  "Sightings of individually identified wild tigers (Panthera tigris) reported by the TigerHall Kittens community.",
+		PubDate:   time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	tigers := map[string]*model.Tiger{}
+	reporters := map[string]string{}
+	if err := s.sightingRepo.StreamSightings(ctx, "", nil, func(sighting *model.Sighting) error {
+		// only identified sightings are streamed
+		tigerID := *sighting.TigerID
+		tiger, ok := tigers[tigerID]
+		if !ok {
+			tiger, err = s.tigerRepo.GetTigerByID(ctx, tigerID)
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			tigers[tigerID] = tiger
+		}
+
+		reporter, ok := reporters[sighting.CreatedBy]
+		if !ok {
+			if user, err := s.userRepo.GetUserByID(ctx, sighting.CreatedBy); err == nil {
+				reporter = user.Name
+			} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			reporters[sighting.CreatedBy] = reporter
+		}
+
+		// Images of sensitive tigers stay private, the others get long lived
+		// links since published archives are harvested long after export
+		var media string
+		if sighting.ImageKey != "" && (tiger == nil || !tiger.Sensitive) {
+			media = s.urlSigner.SignedURL(ImagePath(sighting.ImageKey), dwcaMediaURLTTL)
+		}
+		return writer.Write(&export.Occurrence{
+			Tiger:           tiger,
+			Sighting:        sighting,
+			RecordedBy:      reporter,
+			AssociatedMedia: media,
+		})
+	}); err != nil {
+		logger.Logger(ctx).Error("failed to export darwin core archive", err)
+		return err
+	}
+	return writer.Close()
+}
@@ -0,0 +1,266 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nurcholisnanda/tigerhall-kittens/internal/api/graph/model"
+	"github.com/nurcholisnanda/tigerhall-kittens/internal/export"
+	"github.com/nurcholisnanda/tigerhall-kittens/internal/repository"
+	mockRepo "github.com/nurcholisnanda/tigerhall-kittens/internal/repository/mock"
+	"github.com/nurcholisnanda/tigerhall-kittens/pkg/urlsign"
+	"go.uber.org/mock/gomock"
+)
+
+func TestNewExportService(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	tigerRepo := mockRepo.NewMockTigerRepository(ctrl)
+	sightingRepo := mockRepo.NewMockSightingRepository(ctrl)
+	userRepo := mockRepo.NewMockUserRepository(ctrl)
+	urlSigner := urlsign.NewSigner("secret", "http://localhost:8080")
+	type args struct {
+		tigerRepo    repository.TigerRepository
+		sightingRepo repository.SightingRepository
+		userRepo     repository.UserRepository
+		urlSigner    *urlsign.Signer
+	}
+	tests := []struct {
+		name string
+		args args
+		want ExportService
+	}{
+		{
+			name: "success",
+			args: args{
+				tigerRepo:    tigerRepo,
+				sightingRepo: sightingRepo,
+				userRepo:     userRepo,
+				urlSigner:    urlSigner,
+			},
+			want: NewExportService(tigerRepo, sightingRepo, userRepo, urlSigner),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewExportService(tt.args.tigerRepo, tt.args.sightingRepo, tt.args.userRepo, tt.args.urlSigner); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewExportService() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_exportService_ExportTigers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	tigerRepo := mockRepo.NewMockTigerRepository(ctrl)
+	sightingRepo := mockRepo.NewMockSightingRepository(ctrl)
+	tiger := &model.Tiger{
+		ID:                 "tiger-1",
+		Name:               "Raja",
+		DateOfBirth:        time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		LastSeenTime:       time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC),
+		LastSeenCoordinate: &model.LastSeenCoordinate{Latitude: 1.5, Longitude: 103.25},
+	}
+	tests := []struct {
+		name    string
+		format  export.BulkFormat
+		want    string
+		wantErr bool
+		mocks   []*gomock.Call
+	}{
+		{
+			name:    "should return error if database returning error",
+			format:  export.CSV,
+			wantErr: true,
+			mocks: []*gomock.Call{
+				tigerRepo.EXPECT().StreamTigers(gomock.Any(), gomock.Any()).Return(errors.New("any error")),
+			},
+		},
+		{
+			name:   "success writing csv",
+			format: export.CSV,
+			want: "id,name,dateOfBirth,lastSeenTime,latitude,longitude\n" +
+				"tiger-1,Raja,2020-01-01T00:00:00Z,2024-05-01T08:00:00Z,1.5,103.25\n",
+			mocks: []*gomock.Call{
+				tigerRepo.EXPECT().StreamTigers(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(ctx context.Context, fn func(tiger *model.Tiger) error) error {
+						return fn(tiger)
+					}),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &exportService{
+				tigerRepo:    tigerRepo,
+				sightingRepo: sightingRepo,
+			}
+			var buf bytes.Buffer
+			err := s.ExportTigers(context.Background(), &buf, tt.format)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("exportService.ExportTigers() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && buf.String() != tt.want {
+				t.Errorf("exportService.ExportTigers() = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func Test_exportService_ExportSightings(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	tigerRepo := mockRepo.NewMockTigerRepository(ctrl)
+	sightingRepo := mockRepo.NewMockSightingRepository(ctrl)
+	sighting := &model.Sighting{
+		ID:                 "sighting-1",
+		TigerID:            ptr("tiger-1"),
+		LastSeenTime:       time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC),
+		LastSeenCoordinate: &model.LastSeenCoordinate{Latitude: 1.5, Longitude: 103.25},
+		CreatedAt:          time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC),
+	}
+	tests := []struct {
+		name    string
+		format  export.BulkFormat
+		want    string
+		wantErr bool
+		mocks   []*gomock.Call
+	}{
+		{
+			name:    "should return error if database returning error",
+			format:  export.NDJSON,
+			wantErr: true,
+			mocks: []*gomock.Call{
+				sightingRepo.EXPECT().StreamSightings(gomock.Any(), "tiger-1", gomock.Any(), gomock.Any()).Return(errors.New("any error")),
+			},
+		},
+		{
+			name:   "success writing newline-delimited json",
+			format: export.NDJSON,
+			want: `{"createdAt":"2024-05-01T09:00:00Z","id":"sighting-1","lastSeenTime":"2024-05-01T08:00:00Z",` +
+				`"latitude":1.5,"longitude":103.25,"tigerID":"tiger-1"}` + "\n",
+			mocks: []*gomock.Call{
+				sightingRepo.EXPECT().StreamSightings(gomock.Any(), "tiger-1", gomock.Any(), gomock.Any()).DoAndReturn(
+					func(ctx context.Context, tigerID string, timeRange *model.TimeRangeInput, fn func(sighting *model.Sighting) error) error {
+						return fn(sighting)
+					}),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &exportService{
+				tigerRepo:    tigerRepo,
+				sightingRepo: sightingRepo,
+			}
+			var buf bytes.Buffer
+			err := s.ExportSightings(context.Background(), &buf, tt.format, "tiger-1", nil)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("exportService.ExportSightings() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && buf.String() != tt.want {
+				t.Errorf("exportService.ExportSightings() = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func Test_exportService_ExportDarwinCore(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	tigerRepo := mockRepo.NewMockTigerRepository(ctrl)
+	sightingRepo := mockRepo.NewMockSightingRepository(ctrl)
+	userRepo := mockRepo.NewMockUserRepository(ctrl)
+	sensitive := &model.Tiger{ID: "tiger-1", Name: "Raja", Sensitive: true}
+	public := &model.Tiger{ID: "tiger-2", Name: "Bima"}
+	sightings := []*model.Sighting{
+		{ID: "s1", TigerID: ptr("tiger-1"), CreatedBy: "user-1", ImageKey: "sightings/s1.jpg",
+			LastSeenCoordinate: &model.LastSeenCoordinate{Latitude: 1.2345, Longitude: 103.8765}},
+		{ID: "s2", TigerID: ptr("tiger-1"), CreatedBy: "user-1", LastSeenCoordinate: &model.LastSeenCoordinate{Latitude: 1.5, Longitude: 103.5}},
+		{ID: "s3", TigerID: ptr("tiger-2"), CreatedBy: "user-1", ImageKey: "sightings/s3.jpg",
+			LastSeenCoordinate: &model.LastSeenCoordinate{Latitude: 1.2345, Longitude: 103.8765}},
+	}
+	stream := func(ctx context.Context, tigerID string, timeRange *model.TimeRangeInput, fn func(sighting *model.Sighting) error) error {
+		for _, sighting := range sightings {
+			if err := fn(sighting); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	tests := []struct {
+		name     string
+		wantErr  bool
+		contains []string
+		mocks    []*gomock.Call
+	}{
+		{
+			name:    "should return error if database returning error",
+			wantErr: true,
+			mocks: []*gomock.Call{
+				sightingRepo.EXPECT().StreamSightings(gomock.Any(), "", gomock.Any(), gomock.Any()).Return(errors.New("any error")),
+			},
+		},
+		{
+			name: "success coarsening sensitive coordinates and caching lookups",
+			contains: []string{
+				"s1\tHumanObservation",
+				"tiger-1\tRaja\tAnna\t1.2\t103.9\tWGS84\t7900\tCoordinates rounded to 0.1 degree to protect a sensitive individual\t\t",
+				"tiger-2\tBima\tAnna\t1.2345\t103.8765\tWGS84\t\t\thttp://localhost:8080/images/sightings/s3.jpg?expires=",
+			},
+			mocks: []*gomock.Call{
+				sightingRepo.EXPECT().StreamSightings(gomock.Any(), "", gomock.Any(), gomock.Any()).DoAndReturn(stream),
+				tigerRepo.EXPECT().GetTigerByID(gomock.Any(), "tiger-1").Return(sensitive, nil).Times(1),
+				tigerRepo.EXPECT().GetTigerByID(gomock.Any(), "tiger-2").Return(public, nil).Times(1),
+				userRepo.EXPECT().GetUserByID(gomock.Any(), "user-1").Return(&model.User{Name: "Anna"}, nil).Times(1),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &exportService{
+				tigerRepo:    tigerRepo,
+				sightingRepo: sightingRepo,
+				userRepo:     userRepo,
+				urlSigner:    urlsign.NewSigner("secret", "http://localhost:8080"),
+			}
+			var buf bytes.Buffer
+			err := s.ExportDarwinCore(context.Background(), &buf)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("exportService.ExportDarwinCore() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+
+			archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+			if err != nil {
+				t.Fatalf("exportService.ExportDarwinCore() wrote an invalid zip: %v", err)
+			}
+			files := map[string]string{}
+			for _, file := range archive.File {
+				rc, _ := file.Open()
+				content, _ := io.ReadAll(rc)
+				rc.Close()
+				files[file.Name] = string(content)
+			}
+			for _, name := range []string{"occurrence.txt", "meta.xml", "eml.xml"} {
+				if _, ok := files[name]; !ok {
+					t.Errorf("exportService.ExportDarwinCore() archive is missing %s", name)
+				}
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(files["occurrence.txt"], want) {
+					t.Errorf("occurrence.txt = %q, want it to contain %q", files["occurrence.txt"], want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"math"
+
+	"github.com/nurcholisnanda/tigerhall-kittens/internal/api/graph/model"
+)
+
+const (
+	// earthRadius is the mean Earth radius in meters
+	earthRadius = 6371000.0
+	// maxSearchRadius caps radius searches so a single query stays bounded
+	maxSearchRadius = 500000.0
+)
+
+// boundingBoxAround returns the smallest latitude/longitude box that contains
+// every point within radius meters of center. When the box crosses the
+// antimeridian its south-west longitude is greater than its north-east one.
+func boundingBoxAround(center *model.LastSeenCoordinate, radius float64) *model.BoundingBox {
+	angular := radius / earthRadius
+	lat := degreesToRadians(center.Latitude)
+	lon := degreesToRadians(center.Longitude)
+
+	minLat := lat - angular
+	maxLat := lat + angular
+
+	var minLon, maxLon float64
+	if minLat > -math.Pi/2 && maxLat < math.Pi/2 {
+		deltaLon := math.Asin(math.Sin(angular) / math.Cos(lat))
+		minLon = lon - deltaLon
+		maxLon = lon + deltaLon
+		if minLon < -math.Pi {
+			minLon += 2 * math.Pi
+		}
+		if maxLon > math.Pi {
+			maxLon -= 2 * math.Pi
+		}
+	} else {
+		// A pole is inside the circle so every longitude is reachable
+		minLat = math.Max(minLat, -math.Pi/2)
+		maxLat = math.Min(maxLat, math.Pi/2)
+		minLon = -math.Pi
+		maxLon = math.Pi
+	}
+
+	return &model.BoundingBox{
+		SouthWest: &model.LastSeenCoordinateInput{
+			Latitude:  radiansToDegrees(minLat),
+			Longitude: radiansToDegrees(minLon),
+		},
+		NorthEast: &model.LastSeenCoordinateInput{
+			Latitude:  radiansToDegrees(maxLat),
+			Longitude: radiansToDegrees(maxLon),
+		},
+	}
+}
+
+// boxCenter returns the midpoint of a bounding box, taking antimeridian
+// crossing into account.
+func boxCenter(box *model.BoundingBox) *model.LastSeenCoordinate {
+	west := box.SouthWest.Longitude
+	east := box.NorthEast.Longitude
+	if west > east {
+		east += 360
+	}
+	lon := (west + east) / 2
+	if lon > 180 {
+		lon -= 360
+	}
+	return &model.LastSeenCoordinate{
+		Latitude:  (box.SouthWest.Latitude + box.NorthEast.Latitude) / 2,
+		Longitude: lon,
+	}
+}
+
+// boxContains reports whether a point lies inside a bounding box, taking
+// antimeridian crossing into account.
+func boxContains(box *model.BoundingBox, point *model.LastSeenCoordinate) bool {
+	if point.Latitude < box.SouthWest.Latitude || point.Latitude > box.NorthEast.Latitude {
+		return false
+	}
+	west := box.SouthWest.Longitude
+	east := box.NorthEast.Longitude
+	if west > east {
+		return point.Longitude >= west || point.Longitude <= east
+	}
+	return point.Longitude >= west && point.Longitude <= east
+}
+
+// polygonContains reports whether a point lies inside a polygon by casting
+// a ray east of it and counting the edges it crosses. Coordinates are
+// treated as planar, which holds for polygons that do not cross the
+// antimeridian or contain a pole.
+func polygonContains(polygon []*model.LastSeenCoordinate, point *model.LastSeenCoordinate) bool {
+	inside := false
+	for i, j := 0, len(polygon)-1; i < len(polygon); j, i = i, i+1 {
+		a, b := polygon[i], polygon[j]
+		if (a.Latitude > point.Latitude) == (b.Latitude > point.Latitude) {
+			continue
+		}
+		crossing := a.Longitude + (point.Latitude-a.Latitude)/(b.Latitude-a.Latitude)*(b.Longitude-a.Longitude)
+		if point.Longitude < crossing {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// watchZoneContains reports whether a point lies inside a watch zone
+func watchZoneContains(zone *model.WatchZone, point *model.LastSeenCoordinate) bool {
+	switch zone.Shape {
+	case model.WatchZoneShapePolygon:
+		return polygonContains(zone.Polygon, point)
+	case model.WatchZoneShapeCircle:
+		return zone.RadiusMeters != nil && zone.Center() != nil && calculateDistance(zone.Center(), point) <= *zone.RadiusMeters
+	}
+	return false
+}
+
+func isValidBoundingBox(box *model.BoundingBox) bool {
+	if box == nil || box.SouthWest == nil || box.NorthEast == nil {
+		return false
+	}
+	for _, corner := range []*model.LastSeenCoordinateInput{box.SouthWest, box.NorthEast} {
+		if !isValidLatitude(corner.Latitude) || !isValidLongitude(corner.Longitude) {
+			return false
+		}
+	}
+	return box.SouthWest.Latitude <= box.NorthEast.Latitude
+}
+
+// Helper function to convert radians to degrees
+func radiansToDegrees(radians float64) float64 {
+	return radians * 180 / math.Pi
+}
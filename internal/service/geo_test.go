@@ -0,0 +1,190 @@
+package service
+
+import (
+	"math"
+	"testing"
+
+	"github.com/nurcholisnanda/tigerhall-kittens/internal/api/graph/model"
+)
+
+func Test_boundingBoxAround(t *testing.T) {
+	tests := []struct {
+		name   string
+		center *model.LastSeenCoordinate
+		radius float64
+		want   *model.BoundingBox
+	}{
+		{
+			name:   "box around the equator",
+			center: &model.LastSeenCoordinate{Latitude: 0, Longitude: 0},
+			radius: 111195,
+			want: &model.BoundingBox{
+				SouthWest: &model.LastSeenCoordinateInput{Latitude: -1, Longitude: -1},
+				NorthEast: &model.LastSeenCoordinateInput{Latitude: 1, Longitude: 1},
+			},
+		},
+		{
+			name:   "box crossing the antimeridian",
+			center: &model.LastSeenCoordinate{Latitude: 0, Longitude: 179.5},
+			radius: 111195,
+			want: &model.BoundingBox{
+				SouthWest: &model.LastSeenCoordinateInput{Latitude: -1, Longitude: 178.5},
+				NorthEast: &model.LastSeenCoordinateInput{Latitude: 1, Longitude: -179.5},
+			},
+		},
+		{
+			name:   "box containing a pole",
+			center: &model.LastSeenCoordinate{Latitude: 89.5, Longitude: 20},
+			radius: 111195,
+			want: &model.BoundingBox{
+				SouthWest: &model.LastSeenCoordinateInput{Latitude: 88.5, Longitude: -180},
+				NorthEast: &model.LastSeenCoordinateInput{Latitude: 90, Longitude: 180},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := boundingBoxAround(tt.center, tt.radius)
+			if !closeCoordinate(got.SouthWest, tt.want.SouthWest) || !closeCoordinate(got.NorthEast, tt.want.NorthEast) {
+				t.Errorf("boundingBoxAround() = %v %v, want %v %v", got.SouthWest, got.NorthEast, tt.want.SouthWest, tt.want.NorthEast)
+			}
+		})
+	}
+}
+
+func Test_boxCenter(t *testing.T) {
+	tests := []struct {
+		name string
+		box  *model.BoundingBox
+		want *model.LastSeenCoordinate
+	}{
+		{
+			name: "regular box",
+			box: &model.BoundingBox{
+				SouthWest: &model.LastSeenCoordinateInput{Latitude: 0, Longitude: 100},
+				NorthEast: &model.LastSeenCoordinateInput{Latitude: 2, Longitude: 104},
+			},
+			want: &model.LastSeenCoordinate{Latitude: 1, Longitude: 102},
+		},
+		{
+			name: "box crossing the antimeridian",
+			box: &model.BoundingBox{
+				SouthWest: &model.LastSeenCoordinateInput{Latitude: 0, Longitude: 178},
+				NorthEast: &model.LastSeenCoordinateInput{Latitude: 2, Longitude: -176},
+			},
+			want: &model.LastSeenCoordinate{Latitude: 1, Longitude: -179},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := boxCenter(tt.box)
+			if math.Abs(got.Latitude-tt.want.Latitude) > 1e-9 || math.Abs(got.Longitude-tt.want.Longitude) > 1e-9 {
+				t.Errorf("boxCenter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_boxContains(t *testing.T) {
+	crossing := &model.BoundingBox{
+		SouthWest: &model.LastSeenCoordinateInput{Latitude: -2, Longitude: 178},
+		NorthEast: &model.LastSeenCoordinateInput{Latitude: 2, Longitude: -178},
+	}
+	regular := &model.BoundingBox{
+		SouthWest: &model.LastSeenCoordinateInput{Latitude: 0, Longitude: 100},
+		NorthEast: &model.LastSeenCoordinateInput{Latitude: 2, Longitude: 104},
+	}
+	tests := []struct {
+		name  string
+		box   *model.BoundingBox
+		point *model.LastSeenCoordinate
+		want  bool
+	}{
+		{name: "inside a regular box", box: regular, point: &model.LastSeenCoordinate{Latitude: 1, Longitude: 102}, want: true},
+		{name: "on the edge of a regular box", box: regular, point: &model.LastSeenCoordinate{Latitude: 2, Longitude: 100}, want: true},
+		{name: "east of a regular box", box: regular, point: &model.LastSeenCoordinate{Latitude: 1, Longitude: 105}},
+		{name: "north of a regular box", box: regular, point: &model.LastSeenCoordinate{Latitude: 3, Longitude: 102}},
+		{name: "west of the antimeridian", box: crossing, point: &model.LastSeenCoordinate{Latitude: 0, Longitude: 179}, want: true},
+		{name: "east of the antimeridian", box: crossing, point: &model.LastSeenCoordinate{Latitude: 0, Longitude: -179}, want: true},
+		{name: "outside a box crossing the antimeridian", box: crossing, point: &model.LastSeenCoordinate{Latitude: 0, Longitude: 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := boxContains(tt.box, tt.point); got != tt.want {
+				t.Errorf("boxContains() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_polygonContains(t *testing.T) {
+	// a concave, U shaped polygon open to the north
+	polygon := []*model.LastSeenCoordinate{
+		{Latitude: 0, Longitude: 0},
+		{Latitude: 0, Longitude: 3},
+		{Latitude: 3, Longitude: 3},
+		{Latitude: 3, Longitude: 2},
+		{Latitude: 1, Longitude: 2},
+		{Latitude: 1, Longitude: 1},
+		{Latitude: 3, Longitude: 1},
+		{Latitude: 3, Longitude: 0},
+	}
+	tests := []struct {
+		name  string
+		point *model.LastSeenCoordinate
+		want  bool
+	}{
+		{name: "inside the base", point: &model.LastSeenCoordinate{Latitude: 0.5, Longitude: 1.5}, want: true},
+		{name: "inside an arm", point: &model.LastSeenCoordinate{Latitude: 2, Longitude: 0.5}, want: true},
+		{name: "in the gap between the arms", point: &model.LastSeenCoordinate{Latitude: 2, Longitude: 1.5}},
+		{name: "outside", point: &model.LastSeenCoordinate{Latitude: -1, Longitude: 1.5}},
+		{name: "east of the polygon", point: &model.LastSeenCoordinate{Latitude: 2, Longitude: 4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := polygonContains(polygon, tt.point); got != tt.want {
+				t.Errorf("polygonContains() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_watchZoneContains(t *testing.T) {
+	circle := &model.WatchZone{
+		Shape:           model.WatchZoneShapeCircle,
+		CenterLatitude:  ptr(0.0),
+		CenterLongitude: ptr(179.9),
+		RadiusMeters:    ptr(50000.0),
+	}
+	triangle := &model.WatchZone{
+		Shape: model.WatchZoneShapePolygon,
+		Polygon: []*model.LastSeenCoordinate{
+			{Latitude: 0, Longitude: 100},
+			{Latitude: 0, Longitude: 102},
+			{Latitude: 2, Longitude: 101},
+		},
+	}
+	tests := []struct {
+		name  string
+		zone  *model.WatchZone
+		point *model.LastSeenCoordinate
+		want  bool
+	}{
+		{name: "inside a circle", zone: circle, point: &model.LastSeenCoordinate{Latitude: 0.1, Longitude: 179.9}, want: true},
+		{name: "inside a circle across the antimeridian", zone: circle, point: &model.LastSeenCoordinate{Latitude: 0, Longitude: -179.9}, want: true},
+		{name: "outside a circle", zone: circle, point: &model.LastSeenCoordinate{Latitude: 1, Longitude: 179.9}},
+		{name: "inside a polygon", zone: triangle, point: &model.LastSeenCoordinate{Latitude: 1, Longitude: 101}, want: true},
+		{name: "outside a polygon", zone: triangle, point: &model.LastSeenCoordinate{Latitude: 1.5, Longitude: 100.2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := watchZoneContains(tt.zone, tt.point); got != tt.want {
+				t.Errorf("watchZoneContains() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func closeCoordinate(got, want *model.LastSeenCoordinateInput) bool {
+	return math.Abs(got.Latitude-want.Latitude) < 0.01 && math.Abs(got.Longitude-want.Longitude) < 0.01
+}
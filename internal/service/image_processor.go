@@ -0,0 +1,271 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	goimage "image"
+	"image/jpeg"
+	"io"
+	"time"
+
+	"github.com/nurcholisnanda/tigerhall-kittens/internal/api/graph/model"
+	"github.com/nurcholisnanda/tigerhall-kittens/internal/repository"
+	"github.com/nurcholisnanda/tigerhall-kittens/pkg/imaging"
+	"github.com/nurcholisnanda/tigerhall-kittens/pkg/logger"
+	"github.com/nurcholisnanda/tigerhall-kittens/pkg/storage"
+)
+
+const (
+	// imageQueueSize is how many images can wait for a worker before Enqueue
+	// leaves them to the next sweep
+	imageQueueSize = 256
+	// imageSweepInterval is how often pending images that never made it into
+	// the queue are picked up
+	imageSweepInterval = time.Minute
+	// imageLease is how long a claimed image is left to its worker, an image
+	// whose worker stopped is processed again after it
+	imageLease = 10 * time.Minute
+	// failed images are retried with the backoff of notification jobs
+	imageBaseBackoff = 30 * time.Second
+	imageMaxBackoff  = 6 * time.Hour
+	maxImageAttempts = 8
+	// imageBackfillBatchSize is how many images the backfill reads at once
+	imageBackfillBatchSize = 100
+
+	// duplicateImageDistance is how many bits the perceptual hash of a photo
+	// may differ from the photo of another sighting and still count as the
+	// same photo. Up to similarImageDistance the photo counts as similar.
+	// Either flags the sighting.
+	duplicateImageDistance = 4
+	similarImageDistance   = 10
+)
+
+// imageProcessor renders sighting images in the background. The status
+// stored with every image is the source of truth, the queue only speeds
+// things up, so pending work survives a restart.
+type imageProcessor struct {
+	sightingImageRepo repository.SightingImageRepository
+	sightingRepo      repository.SightingRepository
+	blobStore         storage.BlobStore
+	renditions        []imaging.Rendition
+	workers           int
+	jobs              chan string
+}
+
+// NewImageProcessor creates an ImageProcessor running the given number of
+// workers
+func NewImageProcessor(sightingImageRepo repository.SightingImageRepository, sightingRepo repository.SightingRepository,
+	blobStore storage.BlobStore, renditions []imaging.Rendition, workers int) ImageProcessor {
+	if workers < 1 {
+		workers = 1
+	}
+	return &imageProcessor{
+		sightingImageRepo: sightingImageRepo,
+		sightingRepo:      sightingRepo,
+		blobStore:         blobStore,
+		renditions:        renditions,
+		workers:           workers,
+		jobs:              make(chan string, imageQueueSize),
+	}
+}
+
+// Start starts the workers, the sweep that queues due images and the
+// backfill of images processed before features were extracted
+func (p *imageProcessor) Start(ctx context.Context) {
+	go p.backfill(ctx)
+	for i := 0; i < p.workers; i++ {
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case imageID := <-p.jobs:
+					if err := p.ProcessImage(ctx, imageID); err != nil {
+						logger.Logger(ctx).Error("Failed to process image:", err)
+					}
+				}
+			}
+		}()
+	}
+	go func() {
+		ticker := time.NewTicker(imageSweepInterval)
+		defer ticker.Stop()
+		for {
+			p.sweep(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Enqueue queues an image for processing. It never blocks, an image that
+// does not fit in the queue is picked up by the next sweep.
+func (p *imageProcessor) Enqueue(imageID string) {
+	select {
+	case p.jobs <- imageID:
+	default:
+	}
+}
+
+// ProcessImage renders the renditions of a due image, checks it for
+// duplicates and extracts the stripe features used to suggest which tiger it
+// shows. Images another
+// worker already claimed are skipped. A failure marks the image as failed
+// and retries it with exponential backoff, its original stays available.
+func (p *imageProcessor) ProcessImage(ctx context.Context, imageID string) error {
+	claimed, err := p.sightingImageRepo.ClaimSightingImage(ctx, imageID, time.Now(), imageLease)
+	if err != nil {
+		return fmt.Errorf("error claiming image %s: %w", imageID, err)
+	}
+	if !claimed {
+		return nil
+	}
+	image, err := p.sightingImageRepo.GetSightingImageByID(ctx, imageID)
+	if err != nil {
+		// the lease runs out and the image is claimed again
+		return fmt.Errorf("error getting image %s: %w", imageID, err)
+	}
+
+	if renderErr := p.render(ctx, image); renderErr != nil {
+		var nextAttemptAt *time.Time
+		if image.Attempts < maxImageAttempts {
+			next := time.Now().Add(exponentialBackoff(image.Attempts, imageBaseBackoff, imageMaxBackoff))
+			nextAttemptAt = &next
+		}
+		if err := p.sightingImageRepo.MarkSightingImageFailed(ctx, imageID, nextAttemptAt); err != nil {
+			return fmt.Errorf("error updating status of image %s: %w", imageID, err)
+		}
+		return renderErr
+	}
+	if err := p.sightingImageRepo.UpdateSightingImageStatus(ctx, imageID, model.ImageStatusReady); err != nil {
+		return fmt.Errorf("error updating status of image %s: %w", imageID, err)
+	}
+	return nil
+}
+
+func (p *imageProcessor) render(ctx context.Context, image *model.SightingImage) error {
+	upright, err := p.load(ctx, image)
+	if err != nil {
+		return err
+	}
+	if err := p.analyze(ctx, image, upright); err != nil {
+		return err
+	}
+	for _, rendition := range p.renditions {
+		buf := new(bytes.Buffer)
+		if err := jpeg.Encode(buf, rendition.Fit(upright), &jpeg.Options{Quality: renditionQuality}); err != nil {
+			return fmt.Errorf("error encoding %s rendition of image %s: %w", rendition.Name, image.ID, err)
+		}
+		if err := p.blobStore.Put(ctx, renditionKey(image.Key, rendition.Name), buf, int64(buf.Len()), "image/jpeg"); err != nil {
+			return fmt.Errorf("error storing %s rendition of image %s: %w", rendition.Name, image.ID, err)
+		}
+	}
+	return nil
+}
+
+// load reads and decodes the original of an image. The stored original
+// keeps its orientation tag, the returned image is upright.
+func (p *imageProcessor) load(ctx context.Context, image *model.SightingImage) (goimage.Image, error) {
+	reader, _, err := p.blobStore.Get(ctx, image.Key)
+	if err != nil {
+		return nil, fmt.Errorf("error reading image %s: %w", image.ID, err)
+	}
+	data, err := io.ReadAll(reader)
+	reader.Close()
+	if err != nil {
+		return nil, fmt.Errorf("error reading image %s: %w", image.ID, err)
+	}
+
+	img, err := (&imaging.Upload{Data: data}).Decode()
+	if err != nil {
+		return nil, fmt.Errorf("error decoding image %s: %w", image.ID, err)
+	}
+	return imaging.Orient(img, imaging.ReadExif(data).Orientation), nil
+}
+
+// analyze checks an image for duplicates unless it was hashed already and
+// stores its stripe features
+func (p *imageProcessor) analyze(ctx context.Context, image *model.SightingImage, upright goimage.Image) error {
+	if image.Hash == nil {
+		if err := p.checkDuplicate(ctx, image, int64(imaging.DHash(upright))); err != nil {
+			return err
+		}
+	}
+	features := imaging.EncodeFeatures(imaging.StripeFeatures(upright))
+	if err := p.sightingImageRepo.UpdateSightingImageFeatures(ctx, image.ID, features); err != nil {
+		return fmt.Errorf("error storing features of image %s: %w", image.ID, err)
+	}
+	return nil
+}
+
+// backfill analyzes the ready images processed before features or hashes
+// were extracted. Their renditions are kept, images stored before
+// renditions existed share one key for every size. Running it again only
+// picks up what is still missing.
+func (p *imageProcessor) backfill(ctx context.Context) {
+	afterID := ""
+	for {
+		images, err := p.sightingImageRepo.ListImagesWithoutFeatures(ctx, afterID, imageBackfillBatchSize)
+		if err != nil {
+			logger.Logger(ctx).Error("Failed to list images to backfill:", err)
+			return
+		}
+		for _, image := range images {
+			afterID = image.ID
+			upright, err := p.load(ctx, image)
+			if err == nil {
+				err = p.analyze(ctx, image, upright)
+			}
+			if err != nil {
+				logger.Logger(ctx).Error("Failed to backfill image:", err)
+			}
+		}
+		if len(images) < imageBackfillBatchSize || ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// checkDuplicate compares the perceptual hash of an image with the photos of
+// other sightings and flags its sighting when one is close, then stores the
+// hash. Uploads are not held up by the lookup, so a re-uploaded photo is
+// flagged rather than rejected.
+func (p *imageProcessor) checkDuplicate(ctx context.Context, image *model.SightingImage, hash int64) error {
+	match, err := p.sightingImageRepo.FindSimilarImage(ctx, hash, similarImageDistance, image.SightingID)
+	if err != nil {
+		return fmt.Errorf("error looking up images similar to %s: %w", image.ID, err)
+	}
+	if match != nil {
+		reason := fmt.Sprintf("image is similar to a photo of sighting %s (hash distance %d)", match.SightingID, match.Distance)
+		if match.Distance <= duplicateImageDistance {
+			reason = fmt.Sprintf("image is a duplicate of a photo of sighting %s", match.SightingID)
+		}
+		if err := p.sightingRepo.FlagSighting(ctx, image.SightingID, reason); err != nil {
+			return fmt.Errorf("error flagging sighting %s: %w", image.SightingID, err)
+		}
+	}
+	if err := p.sightingImageRepo.UpdateSightingImageHash(ctx, image.ID, hash); err != nil {
+		return fmt.Errorf("error storing hash of image %s: %w", image.ID, err)
+	}
+	return nil
+}
+
+// sweep queues every due image
+func (p *imageProcessor) sweep(ctx context.Context) {
+	ids, err := p.sightingImageRepo.ListDueSightingImageIDs(ctx, time.Now(), imageLease)
+	if err != nil {
+		logger.Logger(ctx).Error("Failed to list due images:", err)
+		return
+	}
+	for _, id := range ids {
+		select {
+		case p.jobs <- id:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
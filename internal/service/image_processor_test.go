@@ -0,0 +1,356 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/nurcholisnanda/tigerhall-kittens/internal/api/graph/model"
+	mockRepo "github.com/nurcholisnanda/tigerhall-kittens/internal/repository/mock"
+	"github.com/nurcholisnanda/tigerhall-kittens/pkg/imaging"
+	mockStorage "github.com/nurcholisnanda/tigerhall-kittens/pkg/storage/mock"
+	"go.uber.org/mock/gomock"
+)
+
+var testRenditions = []imaging.Rendition{
+	{Name: "thumbnail", MaxWidth: 200, MaxHeight: 200},
+	{Name: "large", MaxWidth: 1600, MaxHeight: 1600},
+}
+
+func TestNewImageProcessor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	sightingImageRepo := mockRepo.NewMockSightingImageRepository(ctrl)
+	sightingRepo := mockRepo.NewMockSightingRepository(ctrl)
+	blobStore := mockStorage.NewMockBlobStore(ctrl)
+	tests := []struct {
+		name        string
+		workers     int
+		wantWorkers int
+	}{
+		{
+			name:        "should keep the number of workers",
+			workers:     3,
+			wantWorkers: 3,
+		},
+		{
+			name:        "should run at least one worker",
+			workers:     0,
+			wantWorkers: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewImageProcessor(sightingImageRepo, sightingRepo, blobStore, testRenditions, tt.workers).(*imageProcessor)
+			if got.workers != tt.wantWorkers {
+				t.Errorf("NewImageProcessor() workers = %v, want %v", got.workers, tt.wantWorkers)
+			}
+		})
+	}
+}
+
+func Test_imageProcessor_Enqueue(t *testing.T) {
+	p := &imageProcessor{jobs: make(chan string, 1)}
+	p.Enqueue("a")
+	// the queue is full, the image is left to the sweep instead of blocking
+	p.Enqueue("b")
+	if got := <-p.jobs; got != "a" {
+		t.Errorf("imageProcessor.Enqueue() queued %v, want a", got)
+	}
+	if len(p.jobs) != 0 {
+		t.Errorf("imageProcessor.Enqueue() queued %d images past the queue size", len(p.jobs))
+	}
+}
+
+func Test_imageProcessor_ProcessImage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	sightingImageRepo := mockRepo.NewMockSightingImageRepository(ctrl)
+	sightingRepo := mockRepo.NewMockSightingRepository(ctrl)
+	blobStore := mockStorage.NewMockBlobStore(ctrl)
+	prefix := "sightings/s1/i1/"
+	stored := &model.SightingImage{ID: "i1", SightingID: "s1", Key: prefix + "original.png", Status: model.ImageStatusProcessing,
+		Attempts: 1, Hash: ptr(int64(42))}
+	unhashed := &model.SightingImage{ID: "i1", SightingID: "s1", Key: prefix + "original.png", Status: model.ImageStatusProcessing,
+		Attempts: 1}
+	exhausted := &model.SightingImage{ID: "i1", SightingID: "s1", Key: prefix + "original.png", Status: model.ImageStatusProcessing,
+		Attempts: maxImageAttempts}
+	original := testPNG(t, 500, 400)
+	// storedBounds records the size of every rendition written to the store
+	storedBounds := map[string]image.Point{}
+	storeRendition := func(_ context.Context, key string, r io.Reader, _ int64, _ string) error {
+		img, _, err := image.Decode(r)
+		if err != nil {
+			return err
+		}
+		storedBounds[key] = img.Bounds().Size()
+		return nil
+	}
+	tests := []struct {
+		name       string
+		wantBounds map[string]image.Point
+		wantErr    bool
+		mocks      []*gomock.Call
+	}{
+		{
+			name:    "should return error if the image cannot be claimed",
+			wantErr: true,
+			mocks: []*gomock.Call{
+				sightingImageRepo.EXPECT().ClaimSightingImage(gomock.Any(), "i1", gomock.Any(), imageLease).Return(false, errors.New("any error")),
+			},
+		},
+		{
+			name: "should skip an image another worker claimed",
+			mocks: []*gomock.Call{
+				sightingImageRepo.EXPECT().ClaimSightingImage(gomock.Any(), "i1", gomock.Any(), imageLease).Return(false, nil),
+			},
+		},
+		{
+			name:    "should mark the image failed if the original cannot be read",
+			wantErr: true,
+			mocks: []*gomock.Call{
+				sightingImageRepo.EXPECT().ClaimSightingImage(gomock.Any(), "i1", gomock.Any(), imageLease).Return(true, nil),
+				sightingImageRepo.EXPECT().GetSightingImageByID(gomock.Any(), "i1").Return(stored, nil),
+				blobStore.EXPECT().Get(gomock.Any(), stored.Key).Return(nil, nil, errors.New("any error")),
+				sightingImageRepo.EXPECT().MarkSightingImageFailed(gomock.Any(), "i1", gomock.Not(gomock.Nil())).Return(nil),
+			},
+		},
+		{
+			name:    "should return error if the image cannot be loaded",
+			wantErr: true,
+			mocks: []*gomock.Call{
+				sightingImageRepo.EXPECT().ClaimSightingImage(gomock.Any(), "i1", gomock.Any(), imageLease).Return(true, nil),
+				sightingImageRepo.EXPECT().GetSightingImageByID(gomock.Any(), "i1").Return(nil, errors.New("any error")),
+			},
+		},
+		{
+			name:    "should give up on the image once it failed too often",
+			wantErr: true,
+			mocks: []*gomock.Call{
+				sightingImageRepo.EXPECT().ClaimSightingImage(gomock.Any(), "i1", gomock.Any(), imageLease).Return(true, nil),
+				sightingImageRepo.EXPECT().GetSightingImageByID(gomock.Any(), "i1").Return(exhausted, nil),
+				blobStore.EXPECT().Get(gomock.Any(), exhausted.Key).Return(nil, nil, errors.New("any error")),
+				sightingImageRepo.EXPECT().MarkSightingImageFailed(gomock.Any(), "i1", gomock.Nil()).Return(nil),
+			},
+		},
+		{
+			name:    "should mark the image failed if the original is corrupt",
+			wantErr: true,
+			mocks: []*gomock.Call{
+				sightingImageRepo.EXPECT().ClaimSightingImage(gomock.Any(), "i1", gomock.Any(), imageLease).Return(true, nil),
+				sightingImageRepo.EXPECT().GetSightingImageByID(gomock.Any(), "i1").Return(stored, nil),
+				blobStore.EXPECT().Get(gomock.Any(), stored.Key).Return(io.NopCloser(bytes.NewReader([]byte("not an image"))), nil, nil),
+				sightingImageRepo.EXPECT().MarkSightingImageFailed(gomock.Any(), "i1", gomock.Not(gomock.Nil())).Return(nil),
+			},
+		},
+		{
+			name:    "should mark the image failed if it cannot be checked for duplicates",
+			wantErr: true,
+			mocks: []*gomock.Call{
+				sightingImageRepo.EXPECT().ClaimSightingImage(gomock.Any(), "i1", gomock.Any(), imageLease).Return(true, nil),
+				sightingImageRepo.EXPECT().GetSightingImageByID(gomock.Any(), "i1").Return(unhashed, nil),
+				blobStore.EXPECT().Get(gomock.Any(), unhashed.Key).Return(io.NopCloser(bytes.NewReader(original)), nil, nil),
+				sightingImageRepo.EXPECT().FindSimilarImage(gomock.Any(), gomock.Any(), similarImageDistance, "s1").Return(nil, errors.New("any error")),
+				sightingImageRepo.EXPECT().MarkSightingImageFailed(gomock.Any(), "i1", gomock.Not(gomock.Nil())).Return(nil),
+			},
+		},
+		{
+			name:    "should mark the image failed if storing its features fails",
+			wantErr: true,
+			mocks: []*gomock.Call{
+				sightingImageRepo.EXPECT().ClaimSightingImage(gomock.Any(), "i1", gomock.Any(), imageLease).Return(true, nil),
+				sightingImageRepo.EXPECT().GetSightingImageByID(gomock.Any(), "i1").Return(stored, nil),
+				blobStore.EXPECT().Get(gomock.Any(), stored.Key).Return(io.NopCloser(bytes.NewReader(original)), nil, nil),
+				sightingImageRepo.EXPECT().UpdateSightingImageFeatures(gomock.Any(), "i1", gomock.Any()).Return(errors.New("any error")),
+				sightingImageRepo.EXPECT().MarkSightingImageFailed(gomock.Any(), "i1", gomock.Not(gomock.Nil())).Return(nil),
+			},
+		},
+		{
+			name:    "should mark the image failed if storing a rendition fails",
+			wantErr: true,
+			mocks: []*gomock.Call{
+				sightingImageRepo.EXPECT().ClaimSightingImage(gomock.Any(), "i1", gomock.Any(), imageLease).Return(true, nil),
+				sightingImageRepo.EXPECT().GetSightingImageByID(gomock.Any(), "i1").Return(stored, nil),
+				blobStore.EXPECT().Get(gomock.Any(), stored.Key).Return(io.NopCloser(bytes.NewReader(original)), nil, nil),
+				sightingImageRepo.EXPECT().UpdateSightingImageFeatures(gomock.Any(), "i1", gomock.Any()).Return(nil),
+				blobStore.EXPECT().Put(gomock.Any(), prefix+"thumbnail.jpg", gomock.Any(), gomock.Any(), "image/jpeg").Return(errors.New("any error")),
+				sightingImageRepo.EXPECT().MarkSightingImageFailed(gomock.Any(), "i1", gomock.Not(gomock.Nil())).Return(nil),
+			},
+		},
+		{
+			name:    "should return error if the status cannot be updated",
+			wantErr: true,
+			mocks: []*gomock.Call{
+				sightingImageRepo.EXPECT().ClaimSightingImage(gomock.Any(), "i1", gomock.Any(), imageLease).Return(true, nil),
+				sightingImageRepo.EXPECT().GetSightingImageByID(gomock.Any(), "i1").Return(stored, nil),
+				blobStore.EXPECT().Get(gomock.Any(), stored.Key).Return(io.NopCloser(bytes.NewReader(original)), nil, nil),
+				sightingImageRepo.EXPECT().UpdateSightingImageFeatures(gomock.Any(), "i1", gomock.Any()).Return(nil),
+				blobStore.EXPECT().Put(gomock.Any(), prefix+"thumbnail.jpg", gomock.Any(), gomock.Any(), "image/jpeg").Return(nil),
+				blobStore.EXPECT().Put(gomock.Any(), prefix+"large.jpg", gomock.Any(), gomock.Any(), "image/jpeg").Return(nil),
+				sightingImageRepo.EXPECT().UpdateSightingImageStatus(gomock.Any(), "i1", model.ImageStatusReady).Return(errors.New("any error")),
+			},
+		},
+		{
+			name: "success stores features, scales renditions with the aspect ratio kept and marks the image ready",
+			wantBounds: map[string]image.Point{
+				prefix + "thumbnail.jpg": {X: 200, Y: 160},
+				prefix + "large.jpg":     {X: 500, Y: 400},
+			},
+			mocks: []*gomock.Call{
+				sightingImageRepo.EXPECT().ClaimSightingImage(gomock.Any(), "i1", gomock.Any(), imageLease).Return(true, nil),
+				sightingImageRepo.EXPECT().GetSightingImageByID(gomock.Any(), "i1").Return(stored, nil),
+				blobStore.EXPECT().Get(gomock.Any(), stored.Key).Return(io.NopCloser(bytes.NewReader(original)), nil, nil),
+				sightingImageRepo.EXPECT().UpdateSightingImageFeatures(gomock.Any(), "i1", gomock.Any()).Return(nil),
+				blobStore.EXPECT().Put(gomock.Any(), prefix+"thumbnail.jpg", gomock.Any(), gomock.Any(), "image/jpeg").DoAndReturn(storeRendition),
+				blobStore.EXPECT().Put(gomock.Any(), prefix+"large.jpg", gomock.Any(), gomock.Any(), "image/jpeg").DoAndReturn(storeRendition),
+				sightingImageRepo.EXPECT().UpdateSightingImageStatus(gomock.Any(), "i1", model.ImageStatusReady).Return(nil),
+			},
+		},
+		{
+			name: "success hashes an image stored before hashing",
+			wantBounds: map[string]image.Point{
+				prefix + "thumbnail.jpg": {X: 200, Y: 160},
+				prefix + "large.jpg":     {X: 500, Y: 400},
+			},
+			mocks: []*gomock.Call{
+				sightingImageRepo.EXPECT().ClaimSightingImage(gomock.Any(), "i1", gomock.Any(), imageLease).Return(true, nil),
+				sightingImageRepo.EXPECT().GetSightingImageByID(gomock.Any(), "i1").Return(unhashed, nil),
+				blobStore.EXPECT().Get(gomock.Any(), unhashed.Key).Return(io.NopCloser(bytes.NewReader(original)), nil, nil),
+				sightingImageRepo.EXPECT().FindSimilarImage(gomock.Any(), gomock.Any(), similarImageDistance, "s1").Return(nil, nil),
+				sightingImageRepo.EXPECT().UpdateSightingImageHash(gomock.Any(), "i1", gomock.Any()).Return(nil),
+				sightingImageRepo.EXPECT().UpdateSightingImageFeatures(gomock.Any(), "i1", gomock.Any()).Return(nil),
+				blobStore.EXPECT().Put(gomock.Any(), prefix+"thumbnail.jpg", gomock.Any(), gomock.Any(), "image/jpeg").DoAndReturn(storeRendition),
+				blobStore.EXPECT().Put(gomock.Any(), prefix+"large.jpg", gomock.Any(), gomock.Any(), "image/jpeg").DoAndReturn(storeRendition),
+				sightingImageRepo.EXPECT().UpdateSightingImageStatus(gomock.Any(), "i1", model.ImageStatusReady).Return(nil),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &imageProcessor{
+				sightingImageRepo: sightingImageRepo,
+				sightingRepo:      sightingRepo,
+				blobStore:         blobStore,
+				renditions:        testRenditions,
+			}
+			err := p.ProcessImage(context.Background(), "i1")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("imageProcessor.ProcessImage() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantBounds != nil && !reflect.DeepEqual(storedBounds, tt.wantBounds) {
+				t.Errorf("imageProcessor.ProcessImage() stored %v, want %v", storedBounds, tt.wantBounds)
+			}
+		})
+	}
+}
+
+func Test_imageProcessor_checkDuplicate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	sightingImageRepo := mockRepo.NewMockSightingImageRepository(ctrl)
+	sightingRepo := mockRepo.NewMockSightingRepository(ctrl)
+	stored := &model.SightingImage{ID: "i2", SightingID: "s2"}
+	hash := int64(0x0f0f0f0f)
+	tests := []struct {
+		name    string
+		wantErr bool
+		mocks   []*gomock.Call
+	}{
+		{
+			name:    "should return error if looking up similar images fails",
+			wantErr: true,
+			mocks: []*gomock.Call{
+				sightingImageRepo.EXPECT().FindSimilarImage(gomock.Any(), hash, similarImageDistance, "s2").Return(nil, errors.New("any error")),
+			},
+		},
+		{
+			name:    "should return error if the sighting cannot be flagged",
+			wantErr: true,
+			mocks: []*gomock.Call{
+				sightingImageRepo.EXPECT().FindSimilarImage(gomock.Any(), hash, similarImageDistance, "s2").
+					Return(&model.ImageMatch{ImageID: "i1", SightingID: "s1", Distance: 8}, nil),
+				sightingRepo.EXPECT().FlagSighting(gomock.Any(), "s2", gomock.Any()).Return(errors.New("any error")),
+			},
+		},
+		{
+			name:    "should return error if the hash cannot be stored",
+			wantErr: true,
+			mocks: []*gomock.Call{
+				sightingImageRepo.EXPECT().FindSimilarImage(gomock.Any(), hash, similarImageDistance, "s2").Return(nil, nil),
+				sightingImageRepo.EXPECT().UpdateSightingImageHash(gomock.Any(), "i2", hash).Return(errors.New("any error")),
+			},
+		},
+		{
+			name: "should flag a near-identical photo as a duplicate",
+			mocks: []*gomock.Call{
+				sightingImageRepo.EXPECT().FindSimilarImage(gomock.Any(), hash, similarImageDistance, "s2").
+					Return(&model.ImageMatch{ImageID: "i1", SightingID: "s1", Distance: 2}, nil),
+				sightingRepo.EXPECT().FlagSighting(gomock.Any(), "s2", "image is a duplicate of a photo of sighting s1").Return(nil),
+				sightingImageRepo.EXPECT().UpdateSightingImageHash(gomock.Any(), "i2", hash).Return(nil),
+			},
+		},
+		{
+			name: "should flag a similar photo",
+			mocks: []*gomock.Call{
+				sightingImageRepo.EXPECT().FindSimilarImage(gomock.Any(), hash, similarImageDistance, "s2").
+					Return(&model.ImageMatch{ImageID: "i1", SightingID: "s1", Distance: 8}, nil),
+				sightingRepo.EXPECT().FlagSighting(gomock.Any(), "s2", "image is similar to a photo of sighting s1 (hash distance 8)").Return(nil),
+				sightingImageRepo.EXPECT().UpdateSightingImageHash(gomock.Any(), "i2", hash).Return(nil),
+			},
+		},
+		{
+			name: "success",
+			mocks: []*gomock.Call{
+				sightingImageRepo.EXPECT().FindSimilarImage(gomock.Any(), hash, similarImageDistance, "s2").Return(nil, nil),
+				sightingImageRepo.EXPECT().UpdateSightingImageHash(gomock.Any(), "i2", hash).Return(nil),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &imageProcessor{
+				sightingImageRepo: sightingImageRepo,
+				sightingRepo:      sightingRepo,
+			}
+			if err := p.checkDuplicate(context.Background(), stored, hash); (err != nil) != tt.wantErr {
+				t.Errorf("imageProcessor.checkDuplicate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_imageProcessor_backfill(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	sightingImageRepo := mockRepo.NewMockSightingImageRepository(ctrl)
+	sightingRepo := mockRepo.NewMockSightingRepository(ctrl)
+	blobStore := mockStorage.NewMockBlobStore(ctrl)
+	missing := &model.SightingImage{ID: "a", SightingID: "s1", Key: "sightings/s1/a.png", Status: model.ImageStatusReady}
+	hashed := &model.SightingImage{ID: "b", SightingID: "s2", Key: "sightings/s2/b.png", Status: model.ImageStatusReady,
+		Hash: ptr(int64(42))}
+	original := testPNG(t, 300, 200)
+	gomock.InOrder(
+		sightingImageRepo.EXPECT().ListImagesWithoutFeatures(gomock.Any(), "", imageBackfillBatchSize).
+			Return([]*model.SightingImage{missing, hashed}, nil),
+		// a failing image does not stop the backfill
+		blobStore.EXPECT().Get(gomock.Any(), missing.Key).Return(nil, nil, errors.New("any error")),
+		blobStore.EXPECT().Get(gomock.Any(), hashed.Key).Return(io.NopCloser(bytes.NewReader(original)), nil, nil),
+		sightingImageRepo.EXPECT().UpdateSightingImageFeatures(gomock.Any(), "b", gomock.Any()).Return(nil),
+	)
+
+	p := &imageProcessor{sightingImageRepo: sightingImageRepo, sightingRepo: sightingRepo, blobStore: blobStore}
+	p.backfill(context.Background())
+}
+
+func Test_imageProcessor_sweep(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	sightingImageRepo := mockRepo.NewMockSightingImageRepository(ctrl)
+	sightingImageRepo.EXPECT().ListDueSightingImageIDs(gomock.Any(), gomock.Any(), imageLease).Return([]string{"a", "b"}, nil)
+
+	p := &imageProcessor{sightingImageRepo: sightingImageRepo, jobs: make(chan string, 2)}
+	p.sweep(context.Background())
+	if got := []string{<-p.jobs, <-p.jobs}; !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("imageProcessor.sweep() queued %v, want [a b]", got)
+	}
+}
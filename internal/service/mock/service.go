@@ -11,11 +11,15 @@ package mock
 
 import (
 	context "context"
+	io "io"
 	reflect "reflect"
+	time "time"
 
 	graphql "github.com/99designs/gqlgen/graphql"
 	jwt "github.com/golang-jwt/jwt/v5"
 	model "github.com/nurcholisnanda/tigerhall-kittens/internal/api/graph/model"
+	export "github.com/nurcholisnanda/tigerhall-kittens/internal/export"
+	imaging "github.com/nurcholisnanda/tigerhall-kittens/pkg/imaging"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -95,6 +99,21 @@ func (m *MockUserService) EXPECT() *MockUserServiceMockRecorder {
 	return m.recorder
 }
 
+// GetNotificationPreferences mocks base method.
+func (m *MockUserService) GetNotificationPreferences(ctx context.Context, userID string) (*model.NotificationPreferences, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNotificationPreferences", ctx, userID)
+	ret0, _ := ret[0].(*model.NotificationPreferences)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNotificationPreferences indicates an expected call of GetNotificationPreferences.
+func (mr *MockUserServiceMockRecorder) GetNotificationPreferences(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNotificationPreferences", reflect.TypeOf((*MockUserService)(nil).GetNotificationPreferences), ctx, userID)
+}
+
 // GetUserByID mocks base method.
 func (m *MockUserService) GetUserByID(ctx context.Context, id string) (*model.User, error) {
 	m.ctrl.T.Helper()
@@ -140,6 +159,35 @@ func (mr *MockUserServiceMockRecorder) Register(ctx, input any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Register", reflect.TypeOf((*MockUserService)(nil).Register), ctx, input)
 }
 
+// Unsubscribe mocks base method.
+func (m *MockUserService) Unsubscribe(ctx context.Context, userID, tigerID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unsubscribe", ctx, userID, tigerID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Unsubscribe indicates an expected call of Unsubscribe.
+func (mr *MockUserServiceMockRecorder) Unsubscribe(ctx, userID, tigerID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unsubscribe", reflect.TypeOf((*MockUserService)(nil).Unsubscribe), ctx, userID, tigerID)
+}
+
+// UpdateNotificationPreferences mocks base method.
+func (m *MockUserService) UpdateNotificationPreferences(ctx context.Context, userID string, input *model.NotificationPreferencesInput) (*model.NotificationPreferences, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateNotificationPreferences", ctx, userID, input)
+	ret0, _ := ret[0].(*model.NotificationPreferences)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateNotificationPreferences indicates an expected call of UpdateNotificationPreferences.
+func (mr *MockUserServiceMockRecorder) UpdateNotificationPreferences(ctx, userID, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateNotificationPreferences", reflect.TypeOf((*MockUserService)(nil).UpdateNotificationPreferences), ctx, userID, input)
+}
+
 // MockTigerService is a mock of TigerService interface.
 type MockTigerService struct {
 	ctrl     *gomock.Controller
@@ -178,6 +226,51 @@ func (mr *MockTigerServiceMockRecorder) CreateTiger(ctx, input any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTiger", reflect.TypeOf((*MockTigerService)(nil).CreateTiger), ctx, input)
 }
 
+// FollowTiger mocks base method.
+func (m *MockTigerService) FollowTiger(ctx context.Context, userID, tigerID string) (*model.Tiger, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FollowTiger", ctx, userID, tigerID)
+	ret0, _ := ret[0].(*model.Tiger)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FollowTiger indicates an expected call of FollowTiger.
+func (mr *MockTigerServiceMockRecorder) FollowTiger(ctx, userID, tigerID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FollowTiger", reflect.TypeOf((*MockTigerService)(nil).FollowTiger), ctx, userID, tigerID)
+}
+
+// GetProfilePhoto mocks base method.
+func (m *MockTigerService) GetProfilePhoto(ctx context.Context, tiger *model.Tiger) (*model.SightingImage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProfilePhoto", ctx, tiger)
+	ret0, _ := ret[0].(*model.SightingImage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProfilePhoto indicates an expected call of GetProfilePhoto.
+func (mr *MockTigerServiceMockRecorder) GetProfilePhoto(ctx, tiger any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProfilePhoto", reflect.TypeOf((*MockTigerService)(nil).GetProfilePhoto), ctx, tiger)
+}
+
+// ListFollowedTigers mocks base method.
+func (m *MockTigerService) ListFollowedTigers(ctx context.Context, userID string) ([]*model.Tiger, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListFollowedTigers", ctx, userID)
+	ret0, _ := ret[0].([]*model.Tiger)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListFollowedTigers indicates an expected call of ListFollowedTigers.
+func (mr *MockTigerServiceMockRecorder) ListFollowedTigers(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFollowedTigers", reflect.TypeOf((*MockTigerService)(nil).ListFollowedTigers), ctx, userID)
+}
+
 // ListTigers mocks base method.
 func (m *MockTigerService) ListTigers(ctx context.Context, limit, offset int) ([]*model.Tiger, error) {
 	m.ctrl.T.Helper()
@@ -193,6 +286,51 @@ func (mr *MockTigerServiceMockRecorder) ListTigers(ctx, limit, offset any) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTigers", reflect.TypeOf((*MockTigerService)(nil).ListTigers), ctx, limit, offset)
 }
 
+// ListTigersNear mocks base method.
+func (m *MockTigerService) ListTigersNear(ctx context.Context, point *model.LastSeenCoordinateInput, radiusMeters float64, seenSince *time.Time, limit int) ([]*model.NearbyTiger, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTigersNear", ctx, point, radiusMeters, seenSince, limit)
+	ret0, _ := ret[0].([]*model.NearbyTiger)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTigersNear indicates an expected call of ListTigersNear.
+func (mr *MockTigerServiceMockRecorder) ListTigersNear(ctx, point, radiusMeters, seenSince, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTigersNear", reflect.TypeOf((*MockTigerService)(nil).ListTigersNear), ctx, point, radiusMeters, seenSince, limit)
+}
+
+// SetProfilePhoto mocks base method.
+func (m *MockTigerService) SetProfilePhoto(ctx context.Context, tigerID, imageID string) (*model.Tiger, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetProfilePhoto", ctx, tigerID, imageID)
+	ret0, _ := ret[0].(*model.Tiger)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetProfilePhoto indicates an expected call of SetProfilePhoto.
+func (mr *MockTigerServiceMockRecorder) SetProfilePhoto(ctx, tigerID, imageID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetProfilePhoto", reflect.TypeOf((*MockTigerService)(nil).SetProfilePhoto), ctx, tigerID, imageID)
+}
+
+// UnfollowTiger mocks base method.
+func (m *MockTigerService) UnfollowTiger(ctx context.Context, userID, tigerID string) (*model.Tiger, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnfollowTiger", ctx, userID, tigerID)
+	ret0, _ := ret[0].(*model.Tiger)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UnfollowTiger indicates an expected call of UnfollowTiger.
+func (mr *MockTigerServiceMockRecorder) UnfollowTiger(ctx, userID, tigerID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnfollowTiger", reflect.TypeOf((*MockTigerService)(nil).UnfollowTiger), ctx, userID, tigerID)
+}
+
 // MockSightingService is a mock of SightingService interface.
 type MockSightingService struct {
 	ctrl     *gomock.Controller
@@ -216,6 +354,36 @@ func (m *MockSightingService) EXPECT() *MockSightingServiceMockRecorder {
 	return m.recorder
 }
 
+// AssignSighting mocks base method.
+func (m *MockSightingService) AssignSighting(ctx context.Context, sightingID, tigerID string) (*model.Sighting, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AssignSighting", ctx, sightingID, tigerID)
+	ret0, _ := ret[0].(*model.Sighting)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AssignSighting indicates an expected call of AssignSighting.
+func (mr *MockSightingServiceMockRecorder) AssignSighting(ctx, sightingID, tigerID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AssignSighting", reflect.TypeOf((*MockSightingService)(nil).AssignSighting), ctx, sightingID, tigerID)
+}
+
+// CoverImageURL mocks base method.
+func (m *MockSightingService) CoverImageURL(ctx context.Context, sighting *model.Sighting, size model.ImageSize) (*string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CoverImageURL", ctx, sighting, size)
+	ret0, _ := ret[0].(*string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CoverImageURL indicates an expected call of CoverImageURL.
+func (mr *MockSightingServiceMockRecorder) CoverImageURL(ctx, sighting, size any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CoverImageURL", reflect.TypeOf((*MockSightingService)(nil).CoverImageURL), ctx, sighting, size)
+}
+
 // CreateSighting mocks base method.
 func (m *MockSightingService) CreateSighting(ctx context.Context, newSighting *model.SightingInput) (*model.Sighting, error) {
 	m.ctrl.T.Helper()
@@ -231,19 +399,63 @@ func (mr *MockSightingServiceMockRecorder) CreateSighting(ctx, newSighting any)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSighting", reflect.TypeOf((*MockSightingService)(nil).CreateSighting), ctx, newSighting)
 }
 
-// GetResizedImage mocks base method.
-func (m *MockSightingService) GetResizedImage(ctx context.Context, inputImage *graphql.Upload) (string, error) {
+// GetTigerTrack mocks base method.
+func (m *MockSightingService) GetTigerTrack(ctx context.Context, tigerID string, timeRange *model.TimeRangeInput) (*model.TigerTrack, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetResizedImage", ctx, inputImage)
-	ret0, _ := ret[0].(string)
+	ret := m.ctrl.Call(m, "GetTigerTrack", ctx, tigerID, timeRange)
+	ret0, _ := ret[0].(*model.TigerTrack)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetResizedImage indicates an expected call of GetResizedImage.
-func (mr *MockSightingServiceMockRecorder) GetResizedImage(ctx, inputImage any) *gomock.Call {
+// GetTigerTrack indicates an expected call of GetTigerTrack.
+func (mr *MockSightingServiceMockRecorder) GetTigerTrack(ctx, tigerID, timeRange any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetResizedImage", reflect.TypeOf((*MockSightingService)(nil).GetResizedImage), ctx, inputImage)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTigerTrack", reflect.TypeOf((*MockSightingService)(nil).GetTigerTrack), ctx, tigerID, timeRange)
+}
+
+// ImageStatus mocks base method.
+func (m *MockSightingService) ImageStatus(ctx context.Context, sighting *model.Sighting) (*model.ImageStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImageStatus", ctx, sighting)
+	ret0, _ := ret[0].(*model.ImageStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImageStatus indicates an expected call of ImageStatus.
+func (mr *MockSightingServiceMockRecorder) ImageStatus(ctx, sighting any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImageStatus", reflect.TypeOf((*MockSightingService)(nil).ImageStatus), ctx, sighting)
+}
+
+// ImageURL mocks base method.
+func (m *MockSightingService) ImageURL(key string, size model.ImageSize) *string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImageURL", key, size)
+	ret0, _ := ret[0].(*string)
+	return ret0
+}
+
+// ImageURL indicates an expected call of ImageURL.
+func (mr *MockSightingServiceMockRecorder) ImageURL(key, size any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImageURL", reflect.TypeOf((*MockSightingService)(nil).ImageURL), key, size)
+}
+
+// ListSightingImages mocks base method.
+func (m *MockSightingService) ListSightingImages(ctx context.Context, sighting *model.Sighting) ([]*model.SightingImage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSightingImages", ctx, sighting)
+	ret0, _ := ret[0].([]*model.SightingImage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSightingImages indicates an expected call of ListSightingImages.
+func (mr *MockSightingServiceMockRecorder) ListSightingImages(ctx, sighting any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSightingImages", reflect.TypeOf((*MockSightingService)(nil).ListSightingImages), ctx, sighting)
 }
 
 // ListSightings mocks base method.
@@ -260,3 +472,627 @@ func (mr *MockSightingServiceMockRecorder) ListSightings(ctx, tigerID, limit, of
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSightings", reflect.TypeOf((*MockSightingService)(nil).ListSightings), ctx, tigerID, limit, offset)
 }
+
+// ListSightingsInBox mocks base method.
+func (m *MockSightingService) ListSightingsInBox(ctx context.Context, box *model.BoundingBox, timeRange *model.TimeRangeInput, limit int) ([]*model.NearbySighting, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSightingsInBox", ctx, box, timeRange, limit)
+	ret0, _ := ret[0].([]*model.NearbySighting)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSightingsInBox indicates an expected call of ListSightingsInBox.
+func (mr *MockSightingServiceMockRecorder) ListSightingsInBox(ctx, box, timeRange, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSightingsInBox", reflect.TypeOf((*MockSightingService)(nil).ListSightingsInBox), ctx, box, timeRange, limit)
+}
+
+// ListSightingsNear mocks base method.
+func (m *MockSightingService) ListSightingsNear(ctx context.Context, point *model.LastSeenCoordinateInput, radiusMeters float64, timeRange *model.TimeRangeInput, limit int) ([]*model.NearbySighting, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSightingsNear", ctx, point, radiusMeters, timeRange, limit)
+	ret0, _ := ret[0].([]*model.NearbySighting)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSightingsNear indicates an expected call of ListSightingsNear.
+func (mr *MockSightingServiceMockRecorder) ListSightingsNear(ctx, point, radiusMeters, timeRange, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSightingsNear", reflect.TypeOf((*MockSightingService)(nil).ListSightingsNear), ctx, point, radiusMeters, timeRange, limit)
+}
+
+// ListTigerPhotos mocks base method.
+func (m *MockSightingService) ListTigerPhotos(ctx context.Context, tigerID string, first int, after *string) (*model.PhotoConnection, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTigerPhotos", ctx, tigerID, first, after)
+	ret0, _ := ret[0].(*model.PhotoConnection)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTigerPhotos indicates an expected call of ListTigerPhotos.
+func (mr *MockSightingServiceMockRecorder) ListTigerPhotos(ctx, tigerID, first, after any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTigerPhotos", reflect.TypeOf((*MockSightingService)(nil).ListTigerPhotos), ctx, tigerID, first, after)
+}
+
+// ListUnidentifiedSightings mocks base method.
+func (m *MockSightingService) ListUnidentifiedSightings(ctx context.Context, limit, offset int) ([]*model.Sighting, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUnidentifiedSightings", ctx, limit, offset)
+	ret0, _ := ret[0].([]*model.Sighting)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUnidentifiedSightings indicates an expected call of ListUnidentifiedSightings.
+func (mr *MockSightingServiceMockRecorder) ListUnidentifiedSightings(ctx, limit, offset any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUnidentifiedSightings", reflect.TypeOf((*MockSightingService)(nil).ListUnidentifiedSightings), ctx, limit, offset)
+}
+
+// SightingImageURL mocks base method.
+func (m *MockSightingService) SightingImageURL(image *model.SightingImage, size model.ImageSize) string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SightingImageURL", image, size)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// SightingImageURL indicates an expected call of SightingImageURL.
+func (mr *MockSightingServiceMockRecorder) SightingImageURL(image, size any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SightingImageURL", reflect.TypeOf((*MockSightingService)(nil).SightingImageURL), image, size)
+}
+
+// StoreImage mocks base method.
+func (m *MockSightingService) StoreImage(ctx context.Context, sightingID, imageID string, upload *imaging.Upload) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StoreImage", ctx, sightingID, imageID, upload)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StoreImage indicates an expected call of StoreImage.
+func (mr *MockSightingServiceMockRecorder) StoreImage(ctx, sightingID, imageID, upload any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreImage", reflect.TypeOf((*MockSightingService)(nil).StoreImage), ctx, sightingID, imageID, upload)
+}
+
+// SuggestTigers mocks base method.
+func (m *MockSightingService) SuggestTigers(ctx context.Context, upload graphql.Upload, near *model.LastSeenCoordinateInput, limit int) ([]*model.TigerSuggestion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SuggestTigers", ctx, upload, near, limit)
+	ret0, _ := ret[0].([]*model.TigerSuggestion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SuggestTigers indicates an expected call of SuggestTigers.
+func (mr *MockSightingServiceMockRecorder) SuggestTigers(ctx, upload, near, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SuggestTigers", reflect.TypeOf((*MockSightingService)(nil).SuggestTigers), ctx, upload, near, limit)
+}
+
+// MockImageProcessor is a mock of ImageProcessor interface.
+type MockImageProcessor struct {
+	ctrl     *gomock.Controller
+	recorder *MockImageProcessorMockRecorder
+}
+
+// MockImageProcessorMockRecorder is the mock recorder for MockImageProcessor.
+type MockImageProcessorMockRecorder struct {
+	mock *MockImageProcessor
+}
+
+// NewMockImageProcessor creates a new mock instance.
+func NewMockImageProcessor(ctrl *gomock.Controller) *MockImageProcessor {
+	mock := &MockImageProcessor{ctrl: ctrl}
+	mock.recorder = &MockImageProcessorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockImageProcessor) EXPECT() *MockImageProcessorMockRecorder {
+	return m.recorder
+}
+
+// Enqueue mocks base method.
+func (m *MockImageProcessor) Enqueue(imageID string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Enqueue", imageID)
+}
+
+// Enqueue indicates an expected call of Enqueue.
+func (mr *MockImageProcessorMockRecorder) Enqueue(imageID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Enqueue", reflect.TypeOf((*MockImageProcessor)(nil).Enqueue), imageID)
+}
+
+// ProcessImage mocks base method.
+func (m *MockImageProcessor) ProcessImage(ctx context.Context, imageID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProcessImage", ctx, imageID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ProcessImage indicates an expected call of ProcessImage.
+func (mr *MockImageProcessorMockRecorder) ProcessImage(ctx, imageID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProcessImage", reflect.TypeOf((*MockImageProcessor)(nil).ProcessImage), ctx, imageID)
+}
+
+// Start mocks base method.
+func (m *MockImageProcessor) Start(ctx context.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Start", ctx)
+}
+
+// Start indicates an expected call of Start.
+func (mr *MockImageProcessorMockRecorder) Start(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Start", reflect.TypeOf((*MockImageProcessor)(nil).Start), ctx)
+}
+
+// MockNotificationService is a mock of NotificationService interface.
+type MockNotificationService struct {
+	ctrl     *gomock.Controller
+	recorder *MockNotificationServiceMockRecorder
+}
+
+// MockNotificationServiceMockRecorder is the mock recorder for MockNotificationService.
+type MockNotificationServiceMockRecorder struct {
+	mock *MockNotificationService
+}
+
+// NewMockNotificationService creates a new mock instance.
+func NewMockNotificationService(ctrl *gomock.Controller) *MockNotificationService {
+	mock := &MockNotificationService{ctrl: ctrl}
+	mock.recorder = &MockNotificationServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockNotificationService) EXPECT() *MockNotificationServiceMockRecorder {
+	return m.recorder
+}
+
+// DispatchDue mocks base method.
+func (m *MockNotificationService) DispatchDue(ctx context.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DispatchDue", ctx)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DispatchDue indicates an expected call of DispatchDue.
+func (mr *MockNotificationServiceMockRecorder) DispatchDue(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DispatchDue", reflect.TypeOf((*MockNotificationService)(nil).DispatchDue), ctx)
+}
+
+// ListNotificationJobs mocks base method.
+func (m *MockNotificationService) ListNotificationJobs(ctx context.Context, status *model.NotificationStatus, limit, offset int) ([]*model.NotificationJob, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListNotificationJobs", ctx, status, limit, offset)
+	ret0, _ := ret[0].([]*model.NotificationJob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListNotificationJobs indicates an expected call of ListNotificationJobs.
+func (mr *MockNotificationServiceMockRecorder) ListNotificationJobs(ctx, status, limit, offset any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListNotificationJobs", reflect.TypeOf((*MockNotificationService)(nil).ListNotificationJobs), ctx, status, limit, offset)
+}
+
+// ListNotifications mocks base method.
+func (m *MockNotificationService) ListNotifications(ctx context.Context, userID string, unreadOnly bool, first int, after *string) (*model.NotificationConnection, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListNotifications", ctx, userID, unreadOnly, first, after)
+	ret0, _ := ret[0].(*model.NotificationConnection)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListNotifications indicates an expected call of ListNotifications.
+func (mr *MockNotificationServiceMockRecorder) ListNotifications(ctx, userID, unreadOnly, first, after any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListNotifications", reflect.TypeOf((*MockNotificationService)(nil).ListNotifications), ctx, userID, unreadOnly, first, after)
+}
+
+// MarkAllNotificationsRead mocks base method.
+func (m *MockNotificationService) MarkAllNotificationsRead(ctx context.Context, userID string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkAllNotificationsRead", ctx, userID)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkAllNotificationsRead indicates an expected call of MarkAllNotificationsRead.
+func (mr *MockNotificationServiceMockRecorder) MarkAllNotificationsRead(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkAllNotificationsRead", reflect.TypeOf((*MockNotificationService)(nil).MarkAllNotificationsRead), ctx, userID)
+}
+
+// MarkNotificationRead mocks base method.
+func (m *MockNotificationService) MarkNotificationRead(ctx context.Context, userID, id string) (*model.Notification, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkNotificationRead", ctx, userID, id)
+	ret0, _ := ret[0].(*model.Notification)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkNotificationRead indicates an expected call of MarkNotificationRead.
+func (mr *MockNotificationServiceMockRecorder) MarkNotificationRead(ctx, userID, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkNotificationRead", reflect.TypeOf((*MockNotificationService)(nil).MarkNotificationRead), ctx, userID, id)
+}
+
+// ReplayNotificationJob mocks base method.
+func (m *MockNotificationService) ReplayNotificationJob(ctx context.Context, id string) (*model.NotificationJob, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplayNotificationJob", ctx, id)
+	ret0, _ := ret[0].(*model.NotificationJob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReplayNotificationJob indicates an expected call of ReplayNotificationJob.
+func (mr *MockNotificationServiceMockRecorder) ReplayNotificationJob(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplayNotificationJob", reflect.TypeOf((*MockNotificationService)(nil).ReplayNotificationJob), ctx, id)
+}
+
+// SendDueDigests mocks base method.
+func (m *MockNotificationService) SendDueDigests(ctx context.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendDueDigests", ctx)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SendDueDigests indicates an expected call of SendDueDigests.
+func (mr *MockNotificationServiceMockRecorder) SendDueDigests(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendDueDigests", reflect.TypeOf((*MockNotificationService)(nil).SendDueDigests), ctx)
+}
+
+// Start mocks base method.
+func (m *MockNotificationService) Start(ctx context.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Start", ctx)
+}
+
+// Start indicates an expected call of Start.
+func (mr *MockNotificationServiceMockRecorder) Start(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Start", reflect.TypeOf((*MockNotificationService)(nil).Start), ctx)
+}
+
+// UnreadNotificationCount mocks base method.
+func (m *MockNotificationService) UnreadNotificationCount(ctx context.Context, userID string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnreadNotificationCount", ctx, userID)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UnreadNotificationCount indicates an expected call of UnreadNotificationCount.
+func (mr *MockNotificationServiceMockRecorder) UnreadNotificationCount(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnreadNotificationCount", reflect.TypeOf((*MockNotificationService)(nil).UnreadNotificationCount), ctx, userID)
+}
+
+// MockWatchZoneService is a mock of WatchZoneService interface.
+type MockWatchZoneService struct {
+	ctrl     *gomock.Controller
+	recorder *MockWatchZoneServiceMockRecorder
+}
+
+// MockWatchZoneServiceMockRecorder is the mock recorder for MockWatchZoneService.
+type MockWatchZoneServiceMockRecorder struct {
+	mock *MockWatchZoneService
+}
+
+// NewMockWatchZoneService creates a new mock instance.
+func NewMockWatchZoneService(ctrl *gomock.Controller) *MockWatchZoneService {
+	mock := &MockWatchZoneService{ctrl: ctrl}
+	mock.recorder = &MockWatchZoneServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWatchZoneService) EXPECT() *MockWatchZoneServiceMockRecorder {
+	return m.recorder
+}
+
+// CreateWatchZone mocks base method.
+func (m *MockWatchZoneService) CreateWatchZone(ctx context.Context, userID string, input *model.WatchZoneInput) (*model.WatchZone, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWatchZone", ctx, userID, input)
+	ret0, _ := ret[0].(*model.WatchZone)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWatchZone indicates an expected call of CreateWatchZone.
+func (mr *MockWatchZoneServiceMockRecorder) CreateWatchZone(ctx, userID, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWatchZone", reflect.TypeOf((*MockWatchZoneService)(nil).CreateWatchZone), ctx, userID, input)
+}
+
+// ListWatchZones mocks base method.
+func (m *MockWatchZoneService) ListWatchZones(ctx context.Context, userID string) ([]*model.WatchZone, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWatchZones", ctx, userID)
+	ret0, _ := ret[0].([]*model.WatchZone)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWatchZones indicates an expected call of ListWatchZones.
+func (mr *MockWatchZoneServiceMockRecorder) ListWatchZones(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWatchZones", reflect.TypeOf((*MockWatchZoneService)(nil).ListWatchZones), ctx, userID)
+}
+
+// MockEventBroker is a mock of EventBroker interface.
+type MockEventBroker struct {
+	ctrl     *gomock.Controller
+	recorder *MockEventBrokerMockRecorder
+}
+
+// MockEventBrokerMockRecorder is the mock recorder for MockEventBroker.
+type MockEventBrokerMockRecorder struct {
+	mock *MockEventBroker
+}
+
+// NewMockEventBroker creates a new mock instance.
+func NewMockEventBroker(ctrl *gomock.Controller) *MockEventBroker {
+	mock := &MockEventBroker{ctrl: ctrl}
+	mock.recorder = &MockEventBrokerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEventBroker) EXPECT() *MockEventBrokerMockRecorder {
+	return m.recorder
+}
+
+// PublishSighting mocks base method.
+func (m *MockEventBroker) PublishSighting(ctx context.Context, sighting *model.Sighting) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "PublishSighting", ctx, sighting)
+}
+
+// PublishSighting indicates an expected call of PublishSighting.
+func (mr *MockEventBrokerMockRecorder) PublishSighting(ctx, sighting any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishSighting", reflect.TypeOf((*MockEventBroker)(nil).PublishSighting), ctx, sighting)
+}
+
+// PublishSightingNotifications mocks base method.
+func (m *MockEventBroker) PublishSightingNotifications(ctx context.Context, sightingID string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "PublishSightingNotifications", ctx, sightingID)
+}
+
+// PublishSightingNotifications indicates an expected call of PublishSightingNotifications.
+func (mr *MockEventBrokerMockRecorder) PublishSightingNotifications(ctx, sightingID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishSightingNotifications", reflect.TypeOf((*MockEventBroker)(nil).PublishSightingNotifications), ctx, sightingID)
+}
+
+// SubscribeNotifications mocks base method.
+func (m *MockEventBroker) SubscribeNotifications(ctx context.Context, userID string) <-chan *model.Notification {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SubscribeNotifications", ctx, userID)
+	ret0, _ := ret[0].(<-chan *model.Notification)
+	return ret0
+}
+
+// SubscribeNotifications indicates an expected call of SubscribeNotifications.
+func (mr *MockEventBrokerMockRecorder) SubscribeNotifications(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubscribeNotifications", reflect.TypeOf((*MockEventBroker)(nil).SubscribeNotifications), ctx, userID)
+}
+
+// SubscribeSightings mocks base method.
+func (m *MockEventBroker) SubscribeSightings(ctx context.Context, tigerID *string, area *model.BoundingBox) (<-chan *model.Sighting, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SubscribeSightings", ctx, tigerID, area)
+	ret0, _ := ret[0].(<-chan *model.Sighting)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SubscribeSightings indicates an expected call of SubscribeSightings.
+func (mr *MockEventBrokerMockRecorder) SubscribeSightings(ctx, tigerID, area any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubscribeSightings", reflect.TypeOf((*MockEventBroker)(nil).SubscribeSightings), ctx, tigerID, area)
+}
+
+// MockExportService is a mock of ExportService interface.
+type MockExportService struct {
+	ctrl     *gomock.Controller
+	recorder *MockExportServiceMockRecorder
+}
+
+// MockExportServiceMockRecorder is the mock recorder for MockExportService.
+type MockExportServiceMockRecorder struct {
+	mock *MockExportService
+}
+
+// NewMockExportService creates a new mock instance.
+func NewMockExportService(ctrl *gomock.Controller) *MockExportService {
+	mock := &MockExportService{ctrl: ctrl}
+	mock.recorder = &MockExportServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockExportService) EXPECT() *MockExportServiceMockRecorder {
+	return m.recorder
+}
+
+// ExportDarwinCore mocks base method.
+func (m *MockExportService) ExportDarwinCore(ctx context.Context, w io.Writer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportDarwinCore", ctx, w)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExportDarwinCore indicates an expected call of ExportDarwinCore.
+func (mr *MockExportServiceMockRecorder) ExportDarwinCore(ctx, w any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportDarwinCore", reflect.TypeOf((*MockExportService)(nil).ExportDarwinCore), ctx, w)
+}
+
+// ExportSightings mocks base method.
+func (m *MockExportService) ExportSightings(ctx context.Context, w io.Writer, format export.BulkFormat, tigerID string, timeRange *model.TimeRangeInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportSightings", ctx, w, format, tigerID, timeRange)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExportSightings indicates an expected call of ExportSightings.
+func (mr *MockExportServiceMockRecorder) ExportSightings(ctx, w, format, tigerID, timeRange any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportSightings", reflect.TypeOf((*MockExportService)(nil).ExportSightings), ctx, w, format, tigerID, timeRange)
+}
+
+// ExportTigers mocks base method.
+func (m *MockExportService) ExportTigers(ctx context.Context, w io.Writer, format export.BulkFormat) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportTigers", ctx, w, format)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExportTigers indicates an expected call of ExportTigers.
+func (mr *MockExportServiceMockRecorder) ExportTigers(ctx, w, format any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportTigers", reflect.TypeOf((*MockExportService)(nil).ExportTigers), ctx, w, format)
+}
+
+// MockWebhookService is a mock of WebhookService interface.
+type MockWebhookService struct {
+	ctrl     *gomock.Controller
+	recorder *MockWebhookServiceMockRecorder
+}
+
+// MockWebhookServiceMockRecorder is the mock recorder for MockWebhookService.
+type MockWebhookServiceMockRecorder struct {
+	mock *MockWebhookService
+}
+
+// NewMockWebhookService creates a new mock instance.
+func NewMockWebhookService(ctrl *gomock.Controller) *MockWebhookService {
+	mock := &MockWebhookService{ctrl: ctrl}
+	mock.recorder = &MockWebhookServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWebhookService) EXPECT() *MockWebhookServiceMockRecorder {
+	return m.recorder
+}
+
+// CreateWebhookSubscription mocks base method.
+func (m *MockWebhookService) CreateWebhookSubscription(ctx context.Context, userID string, input *model.WebhookSubscriptionInput) (*model.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebhookSubscription", ctx, userID, input)
+	ret0, _ := ret[0].(*model.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWebhookSubscription indicates an expected call of CreateWebhookSubscription.
+func (mr *MockWebhookServiceMockRecorder) CreateWebhookSubscription(ctx, userID, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhookSubscription", reflect.TypeOf((*MockWebhookService)(nil).CreateWebhookSubscription), ctx, userID, input)
+}
+
+// DeleteWebhookSubscription mocks base method.
+func (m *MockWebhookService) DeleteWebhookSubscription(ctx context.Context, id string) (*model.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebhookSubscription", ctx, id)
+	ret0, _ := ret[0].(*model.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteWebhookSubscription indicates an expected call of DeleteWebhookSubscription.
+func (mr *MockWebhookServiceMockRecorder) DeleteWebhookSubscription(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebhookSubscription", reflect.TypeOf((*MockWebhookService)(nil).DeleteWebhookSubscription), ctx, id)
+}
+
+// DispatchDue mocks base method.
+func (m *MockWebhookService) DispatchDue(ctx context.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DispatchDue", ctx)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DispatchDue indicates an expected call of DispatchDue.
+func (mr *MockWebhookServiceMockRecorder) DispatchDue(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DispatchDue", reflect.TypeOf((*MockWebhookService)(nil).DispatchDue), ctx)
+}
+
+// ListWebhookDeliveries mocks base method.
+func (m *MockWebhookService) ListWebhookDeliveries(ctx context.Context, subscriptionID *string, status *model.WebhookDeliveryStatus, limit, offset int) ([]*model.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebhookDeliveries", ctx, subscriptionID, status, limit, offset)
+	ret0, _ := ret[0].([]*model.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebhookDeliveries indicates an expected call of ListWebhookDeliveries.
+func (mr *MockWebhookServiceMockRecorder) ListWebhookDeliveries(ctx, subscriptionID, status, limit, offset any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhookDeliveries", reflect.TypeOf((*MockWebhookService)(nil).ListWebhookDeliveries), ctx, subscriptionID, status, limit, offset)
+}
+
+// ListWebhookSubscriptions mocks base method.
+func (m *MockWebhookService) ListWebhookSubscriptions(ctx context.Context) ([]*model.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebhookSubscriptions", ctx)
+	ret0, _ := ret[0].([]*model.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebhookSubscriptions indicates an expected call of ListWebhookSubscriptions.
+func (mr *MockWebhookServiceMockRecorder) ListWebhookSubscriptions(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhookSubscriptions", reflect.TypeOf((*MockWebhookService)(nil).ListWebhookSubscriptions), ctx)
+}
+
+// Start mocks base method.
+func (m *MockWebhookService) Start(ctx context.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Start", ctx)
+}
+
+// Start indicates an expected call of Start.
+func (mr *MockWebhookServiceMockRecorder) Start(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Start", reflect.TypeOf((*MockWebhookService)(nil).Start), ctx)
+}
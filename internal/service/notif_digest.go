@@ -0,0 +1,280 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nurcholisnanda/tigerhall-kittens/internal/api/graph/model"
+	"github.com/nurcholisnanda/tigerhall-kittens/internal/email"
+	"github.com/nurcholisnanda/tigerhall-kittens/pkg/logger"
+	"github.com/nurcholisnanda/tigerhall-kittens/pkg/notifier"
+	"gorm.io/gorm"
+)
+
+// digest is the summary of the batched notifications of a recipient
+type digest struct {
+	Tigers []*email.Tiger
+	// Sightings counts the sightings over all tigers
+	Sightings int
+}
+
+// runDigests sends the digests that are due every digestPollInterval until
+// ctx is done
+func (s *notificationService) runDigests(ctx context.Context) {
+	ticker := time.NewTicker(digestPollInterval)
+	defer ticker.Stop()
+	for {
+		for {
+			recipients, err := s.SendDueDigests(ctx)
+			if err != nil {
+				logger.Logger(ctx).Error("Failed to send digests:", err)
+			}
+			if err != nil || recipients < digestBatchSize {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SendDueDigests sends one summary to every recipient whose digest is due:
+// hourly digests at the start of every hour, daily ones after midnight in
+// the recipient's timezone. It returns how many recipients were handled.
+func (s *notificationService) SendDueDigests(ctx context.Context) (int, error) {
+	now := time.Now().UTC()
+	hourlyBefore := now.Truncate(time.Hour)
+	timezones, err := s.notificationRepo.ListDigestTimezones(ctx, now)
+	if err != nil {
+		return 0, fmt.Errorf("error listing digest timezones: %w", err)
+	}
+	dailyBefore := dailyDigestCutoffs(ctx, now, timezones)
+	recipients, err := s.notificationRepo.ListDigestRecipients(ctx, now, hourlyBefore, dailyBefore, digestBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("error listing digest recipients: %w", err)
+	}
+	for _, userID := range recipients {
+		if err := s.deliverDigest(ctx, userID, now); err != nil {
+			logger.Logger(ctx).Error("Failed to update digest jobs of user:", userID, err)
+		}
+	}
+	return len(recipients), nil
+}
+
+// dailyDigestCutoffs returns the last midnight before now in every timezone.
+// A zone that cannot be loaded anymore falls back to midnight UTC, so one bad
+// preference does not hold back anyone's digest.
+func dailyDigestCutoffs(ctx context.Context, now time.Time, timezones []string) map[string]time.Time {
+	cutoffs := make(map[string]time.Time, len(timezones))
+	for _, timezone := range timezones {
+		location, err := time.LoadLocation(timezone)
+		if err != nil {
+			logger.Logger(ctx).Error("Failed to load digest timezone:", timezone, err)
+			location = time.UTC
+		}
+		local := now.In(location)
+		cutoffs[timezone] = time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, location)
+	}
+	return cutoffs
+}
+
+// deliverDigest claims the batched jobs of a user and sends them as one
+// email. Like deliver, the returned error is about recording the outcome.
+func (s *notificationService) deliverDigest(ctx context.Context, userID string, now time.Time) error {
+	jobs, err := s.notificationRepo.ClaimDigestJobs(ctx, userID, now, notificationLease)
+	if err != nil {
+		return fmt.Errorf("error claiming digest jobs: %w", err)
+	}
+	if len(jobs) == 0 {
+		return nil
+	}
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		permanent := errors.Is(err, gorm.ErrRecordNotFound)
+		return s.recordDigestFailure(ctx, jobs, fmt.Errorf("error getting user: %w", err), permanent)
+	}
+	preferences, err := s.userRepo.GetNotificationPreferences(ctx, userID)
+	if err != nil {
+		return s.recordDigestFailure(ctx, jobs, fmt.Errorf("error getting notification preferences: %w", err), false)
+	}
+
+	// preferences may have changed since the jobs were batched
+	pending := make([]*model.NotificationJob, 0, len(jobs))
+	for _, job := range jobs {
+		if !preferences.EmailEnabled || (job.TigerID != nil && preferences.Mutes(*job.TigerID)) {
+			if err := s.notificationRepo.MarkNotificationJobSkipped(ctx, job.ID); err != nil {
+				return err
+			}
+			continue
+		}
+		pending = append(pending, job)
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	summary, err := s.composeDigest(ctx, user.ID, pending)
+	if err != nil {
+		return s.recordDigestFailure(ctx, pending, err, false)
+	}
+	ids := make([]string, 0, len(pending))
+	for _, job := range pending {
+		ids = append(ids, job.ID)
+	}
+	// every sighting was deleted in the meantime
+	if summary.Sightings == 0 {
+		for _, id := range ids {
+			if err := s.notificationRepo.MarkNotificationJobSkipped(ctx, id); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := s.sendDigest(ctx, *user, preferences, summary); err != nil {
+		return s.recordDigestFailure(ctx, pending, err, false)
+	}
+	return s.notificationRepo.MarkNotificationJobsDelivered(ctx, ids, time.Now())
+}
+
+// recordDigestFailure records a failed digest with every job in it
+func (s *notificationService) recordDigestFailure(ctx context.Context, jobs []*model.NotificationJob, cause error,
+	permanent bool) error {
+	var errs []error
+	for _, job := range jobs {
+		errs = append(errs, s.recordFailure(ctx, job, cause, permanent))
+	}
+	return errors.Join(errs...)
+}
+
+// composeDigest groups the sightings of the jobs by tiger, in the order
+// they were seen. Sightings that were deleted are left out. A single
+// notification is composed as a digest of one job.
+func (s *notificationService) composeDigest(ctx context.Context, userID string, jobs []*model.NotificationJob) (*digest, error) {
+	sightingIDs := make([]string, 0, len(jobs))
+	seen := make(map[string]bool, len(jobs))
+	for _, job := range jobs {
+		if !seen[job.SightingID] {
+			seen[job.SightingID] = true
+			sightingIDs = append(sightingIDs, job.SightingID)
+		}
+	}
+	sightings, err := s.sightingRepo.ListSightingsByIDs(ctx, sightingIDs)
+	if err != nil {
+		return nil, fmt.Errorf("error getting sightings: %w", err)
+	}
+
+	var tigerIDs []string
+	names := make(map[string]string)
+	for _, sighting := range sightings {
+		if sighting.TigerID != nil {
+			if _, ok := names[*sighting.TigerID]; !ok {
+				names[*sighting.TigerID] = ""
+				tigerIDs = append(tigerIDs, *sighting.TigerID)
+			}
+		}
+	}
+	if len(tigerIDs) > 0 {
+		tigers, err := s.tigerRepo.ListTigersByIDs(ctx, tigerIDs)
+		if err != nil {
+			return nil, fmt.Errorf("error getting tigers: %w", err)
+		}
+		for _, tiger := range tigers {
+			names[tiger.ID] = tiger.Name
+		}
+	}
+
+	summary := &digest{Sightings: len(sightings)}
+	groups := make(map[string]*email.Tiger)
+	var unidentified *email.Tiger
+	for _, sighting := range sightings {
+		item, err := s.digestSighting(ctx, sighting)
+		if err != nil {
+			return nil, err
+		}
+		if sighting.TigerID == nil {
+			if unidentified == nil {
+				unidentified = &email.Tiger{}
+			}
+			unidentified.Sightings = append(unidentified.Sightings, item)
+			continue
+		}
+		tigerID := *sighting.TigerID
+		group, ok := groups[tigerID]
+		if !ok {
+			name := names[tigerID]
+			if name == "" {
+				name = tigerID
+			}
+			group = &email.Tiger{
+				ID:       tigerID,
+				Name:     name,
+				TrackURL: s.urlSigner.URL("/tigers/" + tigerID + "/track"),
+				MuteURL:  s.urlSigner.SignedURL(UnsubscribePath(userID, tigerID), unsubscribeURLTTL),
+			}
+			groups[tigerID] = group
+			summary.Tigers = append(summary.Tigers, group)
+		}
+		group.Sightings = append(group.Sightings, item)
+	}
+	if unidentified != nil {
+		summary.Tigers = append(summary.Tigers, unidentified)
+	}
+	return summary, nil
+}
+
+// digestSighting links the cover photo of a sighting, as a thumbnail once
+// its renditions are ready
+func (s *notificationService) digestSighting(ctx context.Context, sighting *model.Sighting) (*email.Sighting, error) {
+	item := &email.Sighting{
+		SeenAt:    sighting.LastSeenTime,
+		Latitude:  sighting.LastSeenCoordinate.Latitude,
+		Longitude: sighting.LastSeenCoordinate.Longitude,
+	}
+	if sighting.ImageKey == "" {
+		return item, nil
+	}
+	images, err := s.sightingImageRepo.ListSightingImages(ctx, sighting.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting sighting images: %w", err)
+	}
+	key, thumbnail := sighting.ImageKey, model.ImageSizeOriginal
+	// sightings stored before they could have several images only have a key
+	if len(images) > 0 {
+		key = images[0].Key
+		if images[0].Status == model.ImageStatusReady {
+			thumbnail = model.ImageSizeThumbnail
+		}
+	}
+	item.PhotoURL = signedImageURL(s.urlSigner, key, model.ImageSizeOriginal, digestImageURLTTL)
+	item.ThumbnailURL = signedImageURL(s.urlSigner, key, thumbnail, digestImageURLTTL)
+	return item, nil
+}
+
+// sendDigest emails the summary to the user
+func (s *notificationService) sendDigest(ctx context.Context, user model.User, preferences *model.NotificationPreferences,
+	summary *digest) error {
+	unsubscribeURL := s.urlSigner.SignedURL(UnsubscribePath(user.ID, ""), unsubscribeURLTTL)
+	rendered, err := email.RenderDigest(recipient(user, preferences), preferences.DigestFrequency, summary.Tigers, unsubscribeURL)
+	if err != nil {
+		return err
+	}
+	msg := &notifier.Message{
+		ToName:         user.Name,
+		ToAddress:      user.Email,
+		Subject:        rendered.Subject,
+		Body:           rendered.Text,
+		HTMLBody:       rendered.HTML,
+		UnsubscribeURL: unsubscribeURL,
+	}
+	if err := s.send(ctx, msg); err != nil {
+		logger.Logger(ctx).Error("Failed to send digest to user:", user.ID, err)
+		return fmt.Errorf("error sending email: %w", err)
+	}
+	logger.Logger(ctx).Info(ctx, "Sent digest to user:", user.ID)
+	return nil
+}
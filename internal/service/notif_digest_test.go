@@ -0,0 +1,336 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nurcholisnanda/tigerhall-kittens/internal/api/graph/model"
+	"github.com/nurcholisnanda/tigerhall-kittens/internal/email"
+	"github.com/nurcholisnanda/tigerhall-kittens/internal/repository/mock"
+	"github.com/nurcholisnanda/tigerhall-kittens/pkg/notifier"
+	"github.com/nurcholisnanda/tigerhall-kittens/pkg/urlsign"
+	"go.uber.org/mock/gomock"
+	"gorm.io/gorm"
+)
+
+func Test_notificationService_SendDueDigests(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	notificationRepo := mock.NewMockNotificationRepository(ctrl)
+	userRepo := mock.NewMockUserRepository(ctrl)
+	sightingRepo := mock.NewMockSightingRepository(ctrl)
+	tigerRepo := mock.NewMockTigerRepository(ctrl)
+	sightingImageRepo := mock.NewMockSightingImageRepository(ctrl)
+	user := &model.User{ID: uuid.NewString(), Name: "test", Email: "test@example.com"}
+	tigerID := uuid.NewString()
+	job := func(sightingID string) *model.NotificationJob {
+		return &model.NotificationJob{
+			ID:         uuid.NewString(),
+			UserID:     user.ID,
+			TigerID:    &tigerID,
+			SightingID: sightingID,
+			Status:     model.NotificationStatusBatched,
+		}
+	}
+	sighting := &model.Sighting{
+		ID:                 "s1",
+		TigerID:            &tigerID,
+		LastSeenCoordinate: &model.LastSeenCoordinate{Latitude: 1, Longitude: 2},
+	}
+	hourly := model.DefaultNotificationPreferences(user.ID)
+	hourly.DigestFrequency = model.DigestFrequencyHourly
+	tigerMuted := model.DefaultNotificationPreferences(user.ID)
+	tigerMuted.DigestFrequency = model.DigestFrequencyHourly
+	tigerMuted.MutedTigerIDs = []string{tigerID}
+	tests := []struct {
+		name     string
+		sendErr  error
+		want     int
+		wantSent int
+		wantErr  bool
+		mocks    []*gomock.Call
+	}{
+		{
+			name:    "should return error if digest timezones cannot be listed",
+			wantErr: true,
+			mocks: []*gomock.Call{
+				notificationRepo.EXPECT().ListDigestTimezones(gomock.Any(), gomock.Any()).Return(nil, errors.New("any error")),
+			},
+		},
+		{
+			name: "should still list the other recipients if a timezone cannot be loaded",
+			want: 1,
+			mocks: []*gomock.Call{
+				notificationRepo.EXPECT().ListDigestTimezones(gomock.Any(), gomock.Any()).
+					Return([]string{"Asia/Jakarta", "Mars/Olympus_Mons"}, nil),
+				notificationRepo.EXPECT().ListDigestRecipients(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Cond(func(x any) bool {
+					cutoffs := x.(map[string]time.Time)
+					jakarta, mars := cutoffs["Asia/Jakarta"], cutoffs["Mars/Olympus_Mons"]
+					return len(cutoffs) == 2 && jakarta.Hour() == 0 && jakarta.Location().String() == "Asia/Jakarta" &&
+						mars.Equal(time.Now().UTC().Truncate(24*time.Hour))
+				}), digestBatchSize).Return([]string{user.ID}, nil),
+				notificationRepo.EXPECT().ClaimDigestJobs(gomock.Any(), user.ID, gomock.Any(), notificationLease).Return(nil, nil),
+			},
+		},
+		{
+			name:    "should return error if recipients cannot be listed",
+			wantErr: true,
+			mocks: []*gomock.Call{
+				notificationRepo.EXPECT().ListDigestTimezones(gomock.Any(), gomock.Any()).Return(nil, nil),
+				notificationRepo.EXPECT().ListDigestRecipients(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), digestBatchSize).
+					Return(nil, errors.New("any error")),
+			},
+		},
+		{
+			name: "should do nothing if the jobs were claimed by someone else",
+			want: 1,
+			mocks: []*gomock.Call{
+				notificationRepo.EXPECT().ListDigestTimezones(gomock.Any(), gomock.Any()).Return(nil, nil),
+				notificationRepo.EXPECT().ListDigestRecipients(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), digestBatchSize).
+					Return([]string{user.ID}, nil),
+				notificationRepo.EXPECT().ClaimDigestJobs(gomock.Any(), user.ID, gomock.Any(), notificationLease).Return(nil, nil),
+			},
+		},
+		{
+			name: "should mark the jobs dead if the user does not exist anymore",
+			want: 1,
+			mocks: []*gomock.Call{
+				notificationRepo.EXPECT().ListDigestTimezones(gomock.Any(), gomock.Any()).Return(nil, nil),
+				notificationRepo.EXPECT().ListDigestRecipients(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), digestBatchSize).
+					Return([]string{user.ID}, nil),
+				notificationRepo.EXPECT().ClaimDigestJobs(gomock.Any(), user.ID, gomock.Any(), notificationLease).
+					Return([]*model.NotificationJob{job("s1"), job("s2")}, nil),
+				userRepo.EXPECT().GetUserByID(gomock.Any(), user.ID).Return(nil, gorm.ErrRecordNotFound),
+				notificationRepo.EXPECT().RecordNotificationJobFailure(gomock.Any(), failedJob(model.NotificationStatusDead, 1)).
+					Return(nil).Times(2),
+			},
+		},
+		{
+			name: "should skip the jobs of a muted tiger",
+			want: 1,
+			mocks: []*gomock.Call{
+				notificationRepo.EXPECT().ListDigestTimezones(gomock.Any(), gomock.Any()).Return(nil, nil),
+				notificationRepo.EXPECT().ListDigestRecipients(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), digestBatchSize).
+					Return([]string{user.ID}, nil),
+				notificationRepo.EXPECT().ClaimDigestJobs(gomock.Any(), user.ID, gomock.Any(), notificationLease).
+					Return([]*model.NotificationJob{job("s1")}, nil),
+				userRepo.EXPECT().GetUserByID(gomock.Any(), user.ID).Return(user, nil),
+				userRepo.EXPECT().GetNotificationPreferences(gomock.Any(), user.ID).Return(tigerMuted, nil),
+				notificationRepo.EXPECT().MarkNotificationJobSkipped(gomock.Any(), gomock.Any()).Return(nil),
+			},
+		},
+		{
+			name: "should skip the jobs if their sightings were deleted",
+			want: 1,
+			mocks: []*gomock.Call{
+				notificationRepo.EXPECT().ListDigestTimezones(gomock.Any(), gomock.Any()).Return(nil, nil),
+				notificationRepo.EXPECT().ListDigestRecipients(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), digestBatchSize).
+					Return([]string{user.ID}, nil),
+				notificationRepo.EXPECT().ClaimDigestJobs(gomock.Any(), user.ID, gomock.Any(), notificationLease).
+					Return([]*model.NotificationJob{job("s1")}, nil),
+				userRepo.EXPECT().GetUserByID(gomock.Any(), user.ID).Return(user, nil),
+				userRepo.EXPECT().GetNotificationPreferences(gomock.Any(), user.ID).Return(hourly, nil),
+				sightingRepo.EXPECT().ListSightingsByIDs(gomock.Any(), []string{"s1"}).Return(nil, nil),
+				notificationRepo.EXPECT().MarkNotificationJobSkipped(gomock.Any(), gomock.Any()).Return(nil),
+			},
+		},
+		{
+			name:    "should keep the jobs batched for a retry if sending fails",
+			sendErr: errors.New("any error"),
+			want:    1,
+			mocks: []*gomock.Call{
+				notificationRepo.EXPECT().ListDigestTimezones(gomock.Any(), gomock.Any()).Return(nil, nil),
+				notificationRepo.EXPECT().ListDigestRecipients(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), digestBatchSize).
+					Return([]string{user.ID}, nil),
+				notificationRepo.EXPECT().ClaimDigestJobs(gomock.Any(), user.ID, gomock.Any(), notificationLease).
+					Return([]*model.NotificationJob{job("s1")}, nil),
+				userRepo.EXPECT().GetUserByID(gomock.Any(), user.ID).Return(user, nil),
+				userRepo.EXPECT().GetNotificationPreferences(gomock.Any(), user.ID).Return(hourly, nil),
+				sightingRepo.EXPECT().ListSightingsByIDs(gomock.Any(), []string{"s1"}).Return([]*model.Sighting{sighting}, nil),
+				tigerRepo.EXPECT().ListTigersByIDs(gomock.Any(), []string{tigerID}).Return([]*model.Tiger{{ID: tigerID, Name: "Stripes"}}, nil),
+				notificationRepo.EXPECT().RecordNotificationJobFailure(gomock.Any(), failedJob(model.NotificationStatusBatched, 1)).Return(nil),
+			},
+		},
+		{
+			name:     "success sends one summary for all jobs",
+			want:     1,
+			wantSent: 1,
+			mocks: []*gomock.Call{
+				notificationRepo.EXPECT().ListDigestTimezones(gomock.Any(), gomock.Any()).Return(nil, nil),
+				notificationRepo.EXPECT().ListDigestRecipients(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), digestBatchSize).
+					Return([]string{user.ID}, nil),
+				notificationRepo.EXPECT().ClaimDigestJobs(gomock.Any(), user.ID, gomock.Any(), notificationLease).
+					Return([]*model.NotificationJob{job("s1"), job("s1")}, nil),
+				userRepo.EXPECT().GetUserByID(gomock.Any(), user.ID).Return(user, nil),
+				userRepo.EXPECT().GetNotificationPreferences(gomock.Any(), user.ID).Return(hourly, nil),
+				sightingRepo.EXPECT().ListSightingsByIDs(gomock.Any(), []string{"s1"}).Return([]*model.Sighting{sighting}, nil),
+				tigerRepo.EXPECT().ListTigersByIDs(gomock.Any(), []string{tigerID}).Return([]*model.Tiger{{ID: tigerID, Name: "Stripes"}}, nil),
+				notificationRepo.EXPECT().MarkNotificationJobsDelivered(gomock.Any(), gomock.Len(2), gomock.Any()).Return(nil),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			memoryNotifier := notifier.NewMemoryNotifier()
+			memoryNotifier.Err = tt.sendErr
+			s := NewNotificationService(notificationRepo, userRepo, sightingRepo, tigerRepo, sightingImageRepo, memoryNotifier,
+				urlsign.NewSigner("secret", "http://localhost:8080"))
+			got, err := s.SendDueDigests(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("notificationService.SendDueDigests() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("notificationService.SendDueDigests() = %v, want %v", got, tt.want)
+			}
+			if sent := len(memoryNotifier.Sent()); sent != tt.wantSent {
+				t.Errorf("notificationService.SendDueDigests() sent %d messages, want %d", sent, tt.wantSent)
+			}
+		})
+	}
+}
+
+func Test_notificationService_composeDigest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	sightingRepo := mock.NewMockSightingRepository(ctrl)
+	tigerRepo := mock.NewMockTigerRepository(ctrl)
+	sightingImageRepo := mock.NewMockSightingImageRepository(ctrl)
+	seenAt := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	sightings := []*model.Sighting{
+		{ID: "s1", TigerID: ptr("t1"), LastSeenTime: seenAt, ImageKey: "a.jpg",
+			LastSeenCoordinate: &model.LastSeenCoordinate{Latitude: 1, Longitude: 2}},
+		{ID: "s2", LastSeenTime: seenAt.Add(time.Minute), ImageKey: "b.jpg",
+			LastSeenCoordinate: &model.LastSeenCoordinate{Latitude: 3, Longitude: 4}},
+		{ID: "s3", TigerID: ptr("t1"), LastSeenTime: seenAt.Add(2 * time.Minute),
+			LastSeenCoordinate: &model.LastSeenCoordinate{Latitude: 5, Longitude: 6}},
+	}
+	jobs := []*model.NotificationJob{{SightingID: "s1"}, {SightingID: "s2"}, {SightingID: "s3"}}
+	sightingRepo.EXPECT().ListSightingsByIDs(gomock.Any(), []string{"s1", "s2", "s3"}).Return(sightings, nil)
+	tigerRepo.EXPECT().ListTigersByIDs(gomock.Any(), []string{"t1"}).Return([]*model.Tiger{{ID: "t1", Name: "Stripes"}}, nil)
+	sightingImageRepo.EXPECT().ListSightingImages(gomock.Any(), "s1").
+		Return([]*model.SightingImage{{Key: "a.jpg", Status: model.ImageStatusReady}}, nil)
+	sightingImageRepo.EXPECT().ListSightingImages(gomock.Any(), "s2").
+		Return([]*model.SightingImage{{Key: "b.jpg", Status: model.ImageStatusPending}}, nil)
+
+	urlSigner := urlsign.NewSigner("secret", "http://localhost:8080")
+	s := &notificationService{
+		sightingRepo:      sightingRepo,
+		tigerRepo:         tigerRepo,
+		sightingImageRepo: sightingImageRepo,
+		urlSigner:         urlSigner,
+	}
+	got, err := s.composeDigest(context.Background(), "u1", jobs)
+	if err != nil {
+		t.Fatalf("notificationService.composeDigest() error = %v", err)
+	}
+	if got.Sightings != 3 || len(got.Tigers) != 2 {
+		t.Fatalf("notificationService.composeDigest() = %d sightings of %d tigers, want 3 of 2", got.Sightings, len(got.Tigers))
+	}
+	stripes, unidentified := got.Tigers[0], got.Tigers[1]
+	if stripes.Name != "Stripes" || len(stripes.Sightings) != 2 {
+		t.Errorf("notificationService.composeDigest() first tiger = %s with %d sightings, want Stripes with 2",
+			stripes.Name, len(stripes.Sightings))
+	}
+	if stripes.TrackURL != "http://localhost:8080/tigers/t1/track" {
+		t.Errorf("notificationService.composeDigest() track URL = %v", stripes.TrackURL)
+	}
+	if !strings.Contains(stripes.MuteURL, UnsubscribePath("u1", "t1")) {
+		t.Errorf("notificationService.composeDigest() mute URL = %v", stripes.MuteURL)
+	}
+	if unidentified.ID != "" || unidentified.MuteURL != "" || len(unidentified.Sightings) != 1 {
+		t.Errorf("notificationService.composeDigest() unidentified group = %+v", unidentified)
+	}
+
+	// the thumbnail is used once the renditions are ready, the original before
+	thumbnail := stripes.Sightings[0].ThumbnailURL
+	if !strings.Contains(thumbnail, ImagePath(renditionKey("a.jpg", "thumbnail"))) {
+		t.Errorf("notificationService.composeDigest() thumbnail URL = %v", thumbnail)
+	}
+	if original := unidentified.Sightings[0].ThumbnailURL; !strings.Contains(original, ImagePath("b.jpg")) {
+		t.Errorf("notificationService.composeDigest() thumbnail URL = %v, want the original", original)
+	}
+	if stripes.Sightings[1].PhotoURL != "" {
+		t.Errorf("notificationService.composeDigest() linked a photo for a sighting without images")
+	}
+}
+
+func Test_notificationService_sendDigest(t *testing.T) {
+	user := model.User{ID: "u1", Name: "test", Email: "test@example.com"}
+	summary := &digest{
+		Sightings: 1,
+		Tigers: []*email.Tiger{{
+			ID:       "t1",
+			Name:     "Stripes",
+			TrackURL: "http://localhost:8080/tigers/t1/track",
+			MuteURL:  "http://localhost:8080/unsubscribe/u1/tigers/t1",
+			Sightings: []*email.Sighting{{
+				SeenAt:       time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC),
+				Latitude:     1.5,
+				Longitude:    2.5,
+				PhotoURL:     "http://localhost:8080/images/a.jpg",
+				ThumbnailURL: "http://localhost:8080/images/a_thumbnail.jpg",
+			}},
+		}},
+	}
+	english := model.DefaultNotificationPreferences(user.ID)
+	english.DigestFrequency = model.DigestFrequencyDaily
+	indonesian := model.DefaultNotificationPreferences(user.ID)
+	indonesian.DigestFrequency = model.DigestFrequencyHourly
+	indonesian.Locale = model.LocaleID
+	indonesian.Timezone = "Asia/Jakarta"
+	tests := []struct {
+		name        string
+		preferences *model.NotificationPreferences
+		wantSubject string
+		wantBody    []string
+	}{
+		{
+			name:        "in English and UTC by default",
+			preferences: english,
+			wantSubject: "Your daily tiger sightings summary",
+			wantBody: []string{
+				"Stripes (1)",
+				"Track: http://localhost:8080/tigers/t1/track",
+				"Friday, 1 March 2024 at 10:00 UTC, near 1.50°N, 2.50°E",
+				"Photo: http://localhost:8080/images/a.jpg",
+				"Stop notifications about Stripes: http://localhost:8080/unsubscribe/u1/tigers/t1",
+			},
+		},
+		{
+			name:        "in the user's locale and time zone",
+			preferences: indonesian,
+			wantSubject: "Ringkasan per jam penampakan harimau Anda",
+			wantBody: []string{
+				"Jumat, 1 Maret 2024 pukul 17.00 WIB, di sekitar 1,50° LU; 2,50° BT",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			memoryNotifier := notifier.NewMemoryNotifier()
+			s := &notificationService{notifier: memoryNotifier, urlSigner: urlsign.NewSigner("secret", "http://localhost:8080")}
+			if err := s.sendDigest(context.Background(), user, tt.preferences, summary); err != nil {
+				t.Fatalf("notificationService.sendDigest() error = %v", err)
+			}
+			sent := memoryNotifier.Sent()
+			if len(sent) != 1 {
+				t.Fatalf("notificationService.sendDigest() sent %d messages, want 1", len(sent))
+			}
+			msg := sent[0]
+			if msg.Subject != tt.wantSubject {
+				t.Errorf("notificationService.sendDigest() subject = %v, want %v", msg.Subject, tt.wantSubject)
+			}
+			for _, want := range append(tt.wantBody, msg.UnsubscribeURL) {
+				if !strings.Contains(msg.Body, want) {
+					t.Errorf("notificationService.sendDigest() body does not contain %q:\n%s", want, msg.Body)
+				}
+			}
+			if !strings.Contains(msg.HTMLBody, `src="http://localhost:8080/images/a_thumbnail.jpg"`) {
+				t.Errorf("notificationService.sendDigest() HTML body lacks the thumbnail:\n%s", msg.HTMLBody)
+			}
+		})
+	}
+}
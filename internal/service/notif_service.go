@@ -3,99 +3,377 @@ package service
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
-	"net/smtp"
-	"os"
 	"time"
 
 	"github.com/nurcholisnanda/tigerhall-kittens/internal/api/graph/model"
+	"github.com/nurcholisnanda/tigerhall-kittens/internal/email"
 	"github.com/nurcholisnanda/tigerhall-kittens/internal/repository"
 	"github.com/nurcholisnanda/tigerhall-kittens/pkg/helper"
 	"github.com/nurcholisnanda/tigerhall-kittens/pkg/logger"
-	"github.com/sendgrid/sendgrid-go/helpers/mail"
+	"github.com/nurcholisnanda/tigerhall-kittens/pkg/notifier"
+	"github.com/nurcholisnanda/tigerhall-kittens/pkg/urlsign"
+	"gorm.io/gorm"
 )
 
-// Channel for sending notifications
-var NotificationChan = make(chan model.Notification)
+const (
+	// notificationBatchSize is how many jobs a dispatch claims at once. They
+	// are sent one after the other, so a batch of timed out sends has to fit
+	// in notificationLease.
+	notificationBatchSize = 20
+	// notificationSendTimeout is how long handing one email to the mail
+	// server may take
+	notificationSendTimeout = 10 * time.Second
+	// notificationPollInterval is how often the outbox is checked for due jobs
+	notificationPollInterval = 5 * time.Second
+	// notificationLease is how long a claimed job is hidden from other
+	// dispatchers, a job whose dispatcher stopped is retried after it
+	notificationLease = 5 * time.Minute
+	// notificationBaseBackoff is the wait after the first failed attempt, it
+	// doubles with every further failure up to notificationMaxBackoff
+	notificationBaseBackoff = 30 * time.Second
+	notificationMaxBackoff  = 6 * time.Hour
+	// maxNotificationAttempts is how many failed attempts make a job dead
+	maxNotificationAttempts = 8
+	// unsubscribeURLTTL is how long the unsubscribe links in an email work
+	unsubscribeURLTTL = 365 * 24 * time.Hour
+	// digestPollInterval is how often due digests are looked for
+	digestPollInterval = time.Minute
+	// digestBatchSize is how many recipients a digest run handles at once
+	digestBatchSize = 50
+	// digestImageURLTTL is how long the photos in a digest can be opened,
+	// digests tend to be read later than single notifications
+	digestImageURLTTL = 7 * 24 * time.Hour
 
-// NotificationService handles notification logic
+	maxNotificationsPerPage = 100
+)
+
+// NotificationService delivers the notification jobs written to the outbox
 type notificationService struct {
-	sightingRepo repository.SightingRepository
-	userRepo     repository.UserRepository
+	notificationRepo  repository.NotificationRepository
+	userRepo          repository.UserRepository
+	sightingRepo      repository.SightingRepository
+	tigerRepo         repository.TigerRepository
+	sightingImageRepo repository.SightingImageRepository
+	notifier          notifier.Notifier
+	urlSigner         *urlsign.Signer
 }
 
 // NewNotificationService creates a new NotificationService
-func NewNotificationService(sr repository.SightingRepository, ur repository.UserRepository) *notificationService {
+func NewNotificationService(nr repository.NotificationRepository, ur repository.UserRepository,
+	sr repository.SightingRepository, tr repository.TigerRepository, sir repository.SightingImageRepository,
+	n notifier.Notifier, urlSigner *urlsign.Signer) *notificationService {
 	return &notificationService{
-		sightingRepo: sr,
-		userRepo:     ur,
+		notificationRepo:  nr,
+		userRepo:          ur,
+		sightingRepo:      sr,
+		tigerRepo:         tr,
+		sightingImageRepo: sir,
+		notifier:          n,
+		urlSigner:         urlSigner,
 	}
 }
 
-// StartNotificationConsumer starts the background goroutine to consume notifications
-func (s *notificationService) StartNotificationConsumer() {
-	ctx := context.Background()
+// Start polls the outbox in the background and delivers due jobs and
+// digests until ctx is done
+func (s *notificationService) Start(ctx context.Context) {
+	go s.runDigests(ctx)
 	go func() {
-		for notification := range NotificationChan {
-			// Fetch users who reported sightings of the same tiger
-			previousSighters, err := s.FetchPreviousSighters(ctx, notification.TigerID)
-			if err != nil {
-				logger.Logger(ctx).Error("Error fetching previous sighters:", err)
-				continue // Skip this notification if there's an error
+		ticker := time.NewTicker(notificationPollInterval)
+		defer ticker.Stop()
+		for {
+			// keep going while full batches come back, there may be more
+			for {
+				claimed, err := s.DispatchDue(ctx)
+				if err != nil {
+					logger.Logger(ctx).Error("Failed to dispatch notifications:", err)
+				}
+				if err != nil || claimed < notificationBatchSize {
+					break
+				}
 			}
-
-			// Send email notifications
-			for _, sighter := range previousSighters {
-				s.sendEmail(ctx, *sighter, notification)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
 			}
 		}
 	}()
 }
 
-// fetchPreviousSighters (Placeholder - you need to implement this)
-func (s *notificationService) FetchPreviousSighters(ctx context.Context, tigerID string) ([]*model.User, error) {
-	userIDs, err := s.sightingRepo.ListUserCreatedSightingByTigerID(ctx, tigerID)
+// DispatchDue claims the jobs that are due and attempts to deliver them. It
+// returns how many jobs were claimed.
+func (s *notificationService) DispatchDue(ctx context.Context) (int, error) {
+	jobs, err := s.notificationRepo.ClaimDueNotificationJobs(ctx, time.Now(), notificationLease, notificationBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("error claiming notification jobs: %w", err)
+	}
+	for _, job := range jobs {
+		if err := s.deliver(ctx, job); err != nil {
+			logger.Logger(ctx).Error("Failed to update notification job:", job.ID, err)
+		}
+	}
+	return len(jobs), nil
+}
+
+// deliver attempts a job once and records the outcome. The returned error
+// is about recording it, delivery failures are stored with the job.
+func (s *notificationService) deliver(ctx context.Context, job *model.NotificationJob) error {
+	user, err := s.userRepo.GetUserByID(ctx, job.UserID)
+	if err != nil {
+		// nobody left to retry for
+		permanent := errors.Is(err, gorm.ErrRecordNotFound)
+		return s.recordFailure(ctx, job, fmt.Errorf("error getting user: %w", err), permanent)
+	}
+	preferences, err := s.userRepo.GetNotificationPreferences(ctx, job.UserID)
+	if err != nil {
+		return s.recordFailure(ctx, job, fmt.Errorf("error getting notification preferences: %w", err), false)
+	}
+	if !preferences.EmailEnabled || (job.TigerID != nil && preferences.Mutes(*job.TigerID)) {
+		return s.notificationRepo.MarkNotificationJobSkipped(ctx, job.ID)
+	}
+	if preferences.DigestFrequency != model.DigestFrequencyImmediate {
+		return s.notificationRepo.MarkNotificationJobBatched(ctx, job.ID, time.Now())
+	}
+	summary, err := s.composeDigest(ctx, user.ID, []*model.NotificationJob{job})
+	if err != nil {
+		return s.recordFailure(ctx, job, err, false)
+	}
+	// the sighting was deleted in the meantime
+	if summary.Sightings == 0 {
+		return s.notificationRepo.MarkNotificationJobSkipped(ctx, job.ID)
+	}
+	if err := s.sendEmail(ctx, *user, preferences, job, summary.Tigers[0]); err != nil {
+		return s.recordFailure(ctx, job, err, false)
+	}
+	return s.notificationRepo.MarkNotificationJobDelivered(ctx, job.ID, time.Now())
+}
+
+// recordFailure schedules the next attempt of a job with exponential
+// backoff, or marks it dead once it ran out of attempts. A job waiting for a
+// digest stays batched.
+func (s *notificationService) recordFailure(ctx context.Context, job *model.NotificationJob, cause error, permanent bool) error {
+	message := cause.Error()
+	job.Attempts++
+	job.LastError = &message
+	if permanent || job.Attempts >= maxNotificationAttempts {
+		job.Status = model.NotificationStatusDead
+		logger.Logger(ctx).Error("Giving up on notification job:", job.ID, cause)
+	} else {
+		if job.Status != model.NotificationStatusBatched {
+			job.Status = model.NotificationStatusPending
+		}
+		job.NextAttemptAt = time.Now().Add(notificationBackoff(job.Attempts))
+	}
+	return s.notificationRepo.RecordNotificationJobFailure(ctx, job)
+}
+
+// notificationBackoff returns how long to wait after the given number of
+// failed attempts
+func notificationBackoff(attempts int) time.Duration {
+	return exponentialBackoff(attempts, notificationBaseBackoff, notificationMaxBackoff)
+}
+
+// exponentialBackoff doubles base with every failed attempt after the first,
+// up to maxBackoff
+func exponentialBackoff(attempts int, base time.Duration, maxBackoff time.Duration) time.Duration {
+	backoff := base
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+		if backoff >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return backoff
+}
+
+// ListNotificationJobs returns the outbox, optionally only the jobs with
+// the given status
+func (s *notificationService) ListNotificationJobs(ctx context.Context, status *model.NotificationStatus,
+	limit int, offset int) ([]*model.NotificationJob, error) {
+	jobs, err := s.notificationRepo.ListNotificationJobs(ctx, status, limit, offset)
+	if err != nil {
+		logger.Logger(ctx).Error("Failed to list notification jobs:", err)
+		return nil, helper.NewCustomError("Failed to list notification jobs", http.StatusInternalServerError)
+	}
+	return jobs, nil
+}
+
+// ReplayNotificationJob queues a dead job again with a fresh set of attempts
+func (s *notificationService) ReplayNotificationJob(ctx context.Context, id string) (*model.NotificationJob, error) {
+	job, err := s.notificationRepo.GetNotificationJobByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &helper.NotificationJobNotFoundError{Message: "Notification job not found"}
+		}
+		logger.Logger(ctx).Error("Unexpected error getting notification job by ID: ", err)
+		return nil, helper.NewCustomError("Failed to retrieve notification job", http.StatusInternalServerError)
+	}
+	if job.Status != model.NotificationStatusDead {
+		return nil, &helper.NotificationJobNotDeadError{Message: "only dead notification jobs can be replayed"}
+	}
+
+	now := time.Now()
+	if err := s.notificationRepo.ReplayNotificationJob(ctx, id, now); err != nil {
+		// replayed by someone else in the meantime
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &helper.NotificationJobNotDeadError{Message: "only dead notification jobs can be replayed"}
+		}
+		logger.Logger(ctx).Error("Unexpected error replaying notification job: ", err)
+		return nil, helper.NewCustomError("Failed to replay notification job", http.StatusInternalServerError)
+	}
+	job.Status = model.NotificationStatusPending
+	job.Attempts = 0
+	job.NextAttemptAt = now
+	return job, nil
+}
+
+// ListNotifications returns a page of a user's inbox, newest first
+func (s *notificationService) ListNotifications(ctx context.Context, userID string, unreadOnly bool, first int,
+	after *string) (*model.NotificationConnection, error) {
+	if first <= 0 || first > maxNotificationsPerPage {
+		return nil, &helper.InvalidPaginationError{
+			Message: fmt.Sprintf("first must be between 1 and %d", maxNotificationsPerPage),
+		}
+	}
+	var cursor *model.NotificationCursor
+	if after != nil {
+		var err error
+		if cursor, err = decodeNotificationCursor(*after); err != nil {
+			return nil, &helper.InvalidPaginationError{Message: "invalid cursor"}
+		}
+	}
+
+	// one extra notification tells whether there is a next page
+	notifications, err := s.notificationRepo.ListNotifications(ctx, userID, unreadOnly, cursor, first+1)
 	if err != nil {
-		logger.Logger(ctx).Error("Failed to fetch sightings by tiger ID: ", err)
-		return nil, helper.NewCustomError("Failed to fetch previous sighters", http.StatusInternalServerError)
+		logger.Logger(ctx).Error("Failed to list notifications:", err)
+		return nil, helper.NewCustomError("Failed to list notifications", http.StatusInternalServerError)
+	}
+
+	connection := &model.NotificationConnection{
+		Edges:    make([]*model.NotificationEdge, 0, first),
+		PageInfo: &model.PageInfo{HasNextPage: len(notifications) > first},
+	}
+	if len(notifications) > first {
+		notifications = notifications[:first]
+	}
+	for _, notification := range notifications {
+		connection.Edges = append(connection.Edges, &model.NotificationEdge{
+			Cursor: encodeNotificationCursor(notification),
+			Node:   notification,
+		})
 	}
+	if len(connection.Edges) > 0 {
+		connection.PageInfo.EndCursor = &connection.Edges[len(connection.Edges)-1].Cursor
+	}
+	return connection, nil
+}
 
-	// Deduplicate user IDs (in case a user reported multiple sightings)
-	uniqueUserIDs := make(map[string]bool)
-	for _, userID := range userIDs {
-		uniqueUserIDs[userID] = true
+func (s *notificationService) UnreadNotificationCount(ctx context.Context, userID string) (int, error) {
+	count, err := s.notificationRepo.CountUnreadNotifications(ctx, userID)
+	if err != nil {
+		logger.Logger(ctx).Error("Failed to count unread notifications:", err)
+		return 0, helper.NewCustomError("Failed to count unread notifications", http.StatusInternalServerError)
 	}
+	return count, nil
+}
 
-	var previousSighters []*model.User
-	for userID := range uniqueUserIDs {
-		user, err := s.userRepo.GetUserByID(ctx, userID) // Replace with your user repository function
-		if err != nil {
-			logger.Logger(ctx).Error("Failed to fetch user by ID: ", err)
-			continue // Skip this user if there's an error
+// MarkNotificationRead marks a notification in the user's inbox as read.
+// Marking it again keeps the time it was first read.
+func (s *notificationService) MarkNotificationRead(ctx context.Context, userID string, id string) (*model.Notification, error) {
+	notification, err := s.notificationRepo.GetNotification(ctx, userID, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &helper.NotificationNotFoundError{Message: "Notification not found"}
 		}
-		previousSighters = append(previousSighters, user)
+		logger.Logger(ctx).Error("Unexpected error getting notification by ID: ", err)
+		return nil, helper.NewCustomError("Failed to retrieve notification", http.StatusInternalServerError)
+	}
+	if notification.Read() {
+		return notification, nil
+	}
+
+	now := time.Now()
+	if err := s.notificationRepo.MarkNotificationRead(ctx, userID, id, now); err != nil {
+		logger.Logger(ctx).Error("Failed to mark notification read:", err)
+		return nil, helper.NewCustomError("Failed to mark notification read", http.StatusInternalServerError)
+	}
+	notification.ReadAt = &now
+	return notification, nil
+}
+
+// MarkAllNotificationsRead empties the unread part of the user's inbox and
+// returns how many notifications it marked
+func (s *notificationService) MarkAllNotificationsRead(ctx context.Context, userID string) (int, error) {
+	count, err := s.notificationRepo.MarkAllNotificationsRead(ctx, userID, time.Now())
+	if err != nil {
+		logger.Logger(ctx).Error("Failed to mark notifications read:", err)
+		return 0, helper.NewCustomError("Failed to mark notifications read", http.StatusInternalServerError)
 	}
+	return count, nil
+}
+
+func encodeNotificationCursor(notification *model.Notification) string {
+	data, _ := json.Marshal(model.NotificationCursor{
+		CreatedAt: notification.CreatedAt,
+		ID:        notification.ID,
+	})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
 
-	return previousSighters, nil
+func decodeNotificationCursor(cursor string) (*model.NotificationCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, err
+	}
+	var decoded model.NotificationCursor
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, err
+	}
+	return &decoded, nil
 }
 
-// sendEmail (Placeholder - you need to implement this)
-func (s *notificationService) sendEmail(ctx context.Context, user model.User, notification model.Notification) error {
-	// Set up Mailtrap SMTP configuration
-	from := mail.NewEmail("TigerHall Kittens", "allendragneel@gmail.com")
-	to := mail.NewEmail(user.Name, user.Email)
-	plainTextContent := fmt.Sprintf(
-		"Hello %s,\n\nA new sighting of tiger %s has been reported on %s.\n\nBest regards,\nTigerHall Kittens",
-		user.Name, notification.TigerID, notification.Timestamp.Format(time.RFC822),
-	)
+// UnsubscribePath returns the route of a one-click unsubscribe link. Without
+// a tiger the link turns notification emails off, with one it mutes the
+// tiger.
+func UnsubscribePath(userID string, tigerID string) string {
+	if tigerID == "" {
+		return "/unsubscribe/" + userID
+	}
+	return "/unsubscribe/" + userID + "/tigers/" + tigerID
+}
 
-	// Set up authentication (replace with your Mailtrap credentials)
-	auth := smtp.PlainAuth("", os.Getenv("MAILTRAP_USERNAME"), os.Getenv("MAILTRAP_PASSWORD"), "sandbox.smtp.mailtrap.io")
+// send hands an email to the notifier and gives up after
+// notificationSendTimeout
+func (s *notificationService) send(ctx context.Context, msg *notifier.Message) error {
+	ctx, cancel := context.WithTimeout(ctx, notificationSendTimeout)
+	defer cancel()
+	return s.notifier.Send(ctx, msg)
+}
 
-	// Send the email
-	err := smtp.SendMail("sandbox.smtp.mailtrap.io:2525", auth, from.Address, []string{to.Address}, []byte(plainTextContent))
+// sendEmail tells the user about the sighting of tiger the job is about
+func (s *notificationService) sendEmail(ctx context.Context, user model.User, preferences *model.NotificationPreferences,
+	job *model.NotificationJob, tiger *email.Tiger) error {
+	unsubscribeURL := s.urlSigner.SignedURL(UnsubscribePath(user.ID, ""), unsubscribeURLTTL)
+	rendered, err := email.RenderNotification(recipient(user, preferences), job.Type, tiger, unsubscribeURL)
 	if err != nil {
+		return err
+	}
+	msg := &notifier.Message{
+		ToName:         user.Name,
+		ToAddress:      user.Email,
+		Subject:        rendered.Subject,
+		Body:           rendered.Text,
+		HTMLBody:       rendered.HTML,
+		UnsubscribeURL: unsubscribeURL,
+	}
+	if err := s.send(ctx, msg); err != nil {
+		logger.Logger(ctx).Error("Failed to send notification to user:", user.ID, err)
 		return fmt.Errorf("error sending email: %w", err)
 	}
 
@@ -103,5 +381,18 @@ func (s *notificationService) sendEmail(ctx context.Context, user model.User, no
 	logger.Logger(ctx).Info(ctx, "Sent email notification to user:", user.ID)
 
 	return nil
+}
 
+// recipient returns who emails to the user are written for. A time zone
+// that cannot be loaded falls back to UTC.
+func recipient(user model.User, preferences *model.NotificationPreferences) email.Recipient {
+	location, err := time.LoadLocation(preferences.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+	return email.Recipient{
+		Name:     user.Name,
+		Locale:   preferences.Locale,
+		Location: location,
+	}
 }
@@ -4,23 +4,41 @@ package service
 import (
 	"context"
 	"errors"
+	"net/url"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/nurcholisnanda/tigerhall-kittens/internal/api/graph/model"
+	"github.com/nurcholisnanda/tigerhall-kittens/internal/email"
 	"github.com/nurcholisnanda/tigerhall-kittens/internal/repository"
 	"github.com/nurcholisnanda/tigerhall-kittens/internal/repository/mock"
+	"github.com/nurcholisnanda/tigerhall-kittens/pkg/helper"
+	"github.com/nurcholisnanda/tigerhall-kittens/pkg/notifier"
+	"github.com/nurcholisnanda/tigerhall-kittens/pkg/urlsign"
 	"go.uber.org/mock/gomock"
+	"gorm.io/gorm"
 )
 
 func TestNewNotificationService(t *testing.T) {
 	ctrl := gomock.NewController(t)
-	sightingRepo := mock.NewMockSightingRepository(ctrl)
+	notificationRepo := mock.NewMockNotificationRepository(ctrl)
 	userRepo := mock.NewMockUserRepository(ctrl)
+	sightingRepo := mock.NewMockSightingRepository(ctrl)
+	tigerRepo := mock.NewMockTigerRepository(ctrl)
+	sightingImageRepo := mock.NewMockSightingImageRepository(ctrl)
+	memoryNotifier := notifier.NewMemoryNotifier()
+	urlSigner := urlsign.NewSigner("secret", "http://localhost:8080")
 	type args struct {
-		sr repository.SightingRepository
-		ur repository.UserRepository
+		nr        repository.NotificationRepository
+		ur        repository.UserRepository
+		sr        repository.SightingRepository
+		tr        repository.TigerRepository
+		sir       repository.SightingImageRepository
+		n         notifier.Notifier
+		urlSigner *urlsign.Signer
 	}
 	tests := []struct {
 		name string
@@ -30,170 +48,700 @@ func TestNewNotificationService(t *testing.T) {
 		{
 			name: "success",
 			args: args{
-				sr: sightingRepo,
-				ur: userRepo,
+				nr:        notificationRepo,
+				ur:        userRepo,
+				sr:        sightingRepo,
+				tr:        tigerRepo,
+				sir:       sightingImageRepo,
+				n:         memoryNotifier,
+				urlSigner: urlSigner,
 			},
-			want: NewNotificationService(sightingRepo, userRepo),
+			want: NewNotificationService(notificationRepo, userRepo, sightingRepo, tigerRepo, sightingImageRepo, memoryNotifier, urlSigner),
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := NewNotificationService(tt.args.sr, tt.args.ur); !reflect.DeepEqual(got, tt.want) {
+			if got := NewNotificationService(tt.args.nr, tt.args.ur, tt.args.sr, tt.args.tr, tt.args.sir, tt.args.n,
+				tt.args.urlSigner); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("NewNotificationService() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
 
-func Test_notificationService_StartNotificationConsumer(t *testing.T) {
-	type fields struct {
-		sightingRepo repository.SightingRepository
-		userRepo     repository.UserRepository
+// failedJob matches a job recorded as failed with the given status and
+// attempt count
+func failedJob(status model.NotificationStatus, attempts int) gomock.Matcher {
+	return gomock.Cond(func(x any) bool {
+		job := x.(*model.NotificationJob)
+		return job.Status == status && job.Attempts == attempts && job.LastError != nil
+	})
+}
+
+func Test_notificationService_DispatchDue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	notificationRepo := mock.NewMockNotificationRepository(ctrl)
+	userRepo := mock.NewMockUserRepository(ctrl)
+	sightingRepo := mock.NewMockSightingRepository(ctrl)
+	tigerRepo := mock.NewMockTigerRepository(ctrl)
+	user := &model.User{ID: uuid.NewString(), Name: "test", Email: "test@example.com"}
+	tigerID := uuid.NewString()
+	job := func(attempts int) *model.NotificationJob {
+		return &model.NotificationJob{
+			ID:         uuid.NewString(),
+			UserID:     user.ID,
+			TigerID:    &tigerID,
+			SightingID: "s1",
+			Status:     model.NotificationStatusPending,
+			Attempts:   attempts,
+		}
 	}
+	sightings := []*model.Sighting{{
+		ID:                 "s1",
+		TigerID:            &tigerID,
+		LastSeenCoordinate: &model.LastSeenCoordinate{Latitude: 1, Longitude: 2},
+	}}
+	tigers := []*model.Tiger{{ID: tigerID, Name: "Stripes"}}
+	defaults := model.DefaultNotificationPreferences(user.ID)
+	emailDisabled := model.DefaultNotificationPreferences(user.ID)
+	emailDisabled.EmailEnabled = false
+	tigerMuted := model.DefaultNotificationPreferences(user.ID)
+	tigerMuted.MutedTigerIDs = []string{tigerID}
+	hourly := model.DefaultNotificationPreferences(user.ID)
+	hourly.DigestFrequency = model.DigestFrequencyHourly
 	tests := []struct {
-		name   string
-		fields fields
-	}{}
+		name        string
+		sendErr     error
+		wantClaimed int
+		wantSent    int
+		wantErr     bool
+		mocks       []*gomock.Call
+	}{
+		{
+			name:    "should return error if jobs cannot be claimed",
+			wantErr: true,
+			mocks: []*gomock.Call{
+				notificationRepo.EXPECT().ClaimDueNotificationJobs(gomock.Any(), gomock.Any(), notificationLease, notificationBatchSize).
+					Return(nil, errors.New("any error")),
+			},
+		},
+		{
+			name:        "should mark the job dead if the user does not exist anymore",
+			wantClaimed: 1,
+			mocks: []*gomock.Call{
+				notificationRepo.EXPECT().ClaimDueNotificationJobs(gomock.Any(), gomock.Any(), notificationLease, notificationBatchSize).
+					Return([]*model.NotificationJob{job(0)}, nil),
+				userRepo.EXPECT().GetUserByID(gomock.Any(), user.ID).Return(nil, gorm.ErrRecordNotFound),
+				notificationRepo.EXPECT().RecordNotificationJobFailure(gomock.Any(), failedJob(model.NotificationStatusDead, 1)).Return(nil),
+			},
+		},
+		{
+			name:        "should schedule a retry if the user cannot be fetched",
+			wantClaimed: 1,
+			mocks: []*gomock.Call{
+				notificationRepo.EXPECT().ClaimDueNotificationJobs(gomock.Any(), gomock.Any(), notificationLease, notificationBatchSize).
+					Return([]*model.NotificationJob{job(0)}, nil),
+				userRepo.EXPECT().GetUserByID(gomock.Any(), user.ID).Return(nil, errors.New("any error")),
+				notificationRepo.EXPECT().RecordNotificationJobFailure(gomock.Any(), failedJob(model.NotificationStatusPending, 1)).Return(nil),
+			},
+		},
+		{
+			name:        "should schedule a retry if the preferences cannot be fetched",
+			wantClaimed: 1,
+			mocks: []*gomock.Call{
+				notificationRepo.EXPECT().ClaimDueNotificationJobs(gomock.Any(), gomock.Any(), notificationLease, notificationBatchSize).
+					Return([]*model.NotificationJob{job(0)}, nil),
+				userRepo.EXPECT().GetUserByID(gomock.Any(), user.ID).Return(user, nil),
+				userRepo.EXPECT().GetNotificationPreferences(gomock.Any(), user.ID).Return(nil, errors.New("any error")),
+				notificationRepo.EXPECT().RecordNotificationJobFailure(gomock.Any(), failedJob(model.NotificationStatusPending, 1)).Return(nil),
+			},
+		},
+		{
+			name:        "should skip the job if the user turned emails off",
+			wantClaimed: 1,
+			mocks: []*gomock.Call{
+				notificationRepo.EXPECT().ClaimDueNotificationJobs(gomock.Any(), gomock.Any(), notificationLease, notificationBatchSize).
+					Return([]*model.NotificationJob{job(0)}, nil),
+				userRepo.EXPECT().GetUserByID(gomock.Any(), user.ID).Return(user, nil),
+				userRepo.EXPECT().GetNotificationPreferences(gomock.Any(), user.ID).Return(emailDisabled, nil),
+				notificationRepo.EXPECT().MarkNotificationJobSkipped(gomock.Any(), gomock.Any()).Return(nil),
+			},
+		},
+		{
+			name:        "should skip the job if the user muted the tiger",
+			wantClaimed: 1,
+			mocks: []*gomock.Call{
+				notificationRepo.EXPECT().ClaimDueNotificationJobs(gomock.Any(), gomock.Any(), notificationLease, notificationBatchSize).
+					Return([]*model.NotificationJob{job(0)}, nil),
+				userRepo.EXPECT().GetUserByID(gomock.Any(), user.ID).Return(user, nil),
+				userRepo.EXPECT().GetNotificationPreferences(gomock.Any(), user.ID).Return(tigerMuted, nil),
+				notificationRepo.EXPECT().MarkNotificationJobSkipped(gomock.Any(), gomock.Any()).Return(nil),
+			},
+		},
+		{
+			name:        "should batch the job if the user wants digests",
+			wantClaimed: 1,
+			mocks: []*gomock.Call{
+				notificationRepo.EXPECT().ClaimDueNotificationJobs(gomock.Any(), gomock.Any(), notificationLease, notificationBatchSize).
+					Return([]*model.NotificationJob{job(0)}, nil),
+				userRepo.EXPECT().GetUserByID(gomock.Any(), user.ID).Return(user, nil),
+				userRepo.EXPECT().GetNotificationPreferences(gomock.Any(), user.ID).Return(hourly, nil),
+				notificationRepo.EXPECT().MarkNotificationJobBatched(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil),
+			},
+		},
+		{
+			name:        "should schedule a retry if the sighting cannot be fetched",
+			wantClaimed: 1,
+			mocks: []*gomock.Call{
+				notificationRepo.EXPECT().ClaimDueNotificationJobs(gomock.Any(), gomock.Any(), notificationLease, notificationBatchSize).
+					Return([]*model.NotificationJob{job(0)}, nil),
+				userRepo.EXPECT().GetUserByID(gomock.Any(), user.ID).Return(user, nil),
+				userRepo.EXPECT().GetNotificationPreferences(gomock.Any(), user.ID).Return(defaults, nil),
+				sightingRepo.EXPECT().ListSightingsByIDs(gomock.Any(), []string{"s1"}).Return(nil, errors.New("any error")),
+				notificationRepo.EXPECT().RecordNotificationJobFailure(gomock.Any(), failedJob(model.NotificationStatusPending, 1)).Return(nil),
+			},
+		},
+		{
+			name:        "should skip the job if the sighting was deleted",
+			wantClaimed: 1,
+			mocks: []*gomock.Call{
+				notificationRepo.EXPECT().ClaimDueNotificationJobs(gomock.Any(), gomock.Any(), notificationLease, notificationBatchSize).
+					Return([]*model.NotificationJob{job(0)}, nil),
+				userRepo.EXPECT().GetUserByID(gomock.Any(), user.ID).Return(user, nil),
+				userRepo.EXPECT().GetNotificationPreferences(gomock.Any(), user.ID).Return(defaults, nil),
+				sightingRepo.EXPECT().ListSightingsByIDs(gomock.Any(), []string{"s1"}).Return(nil, nil),
+				notificationRepo.EXPECT().MarkNotificationJobSkipped(gomock.Any(), gomock.Any()).Return(nil),
+			},
+		},
+		{
+			name:        "should schedule a retry if sending fails",
+			sendErr:     errors.New("any error"),
+			wantClaimed: 1,
+			mocks: []*gomock.Call{
+				notificationRepo.EXPECT().ClaimDueNotificationJobs(gomock.Any(), gomock.Any(), notificationLease, notificationBatchSize).
+					Return([]*model.NotificationJob{job(2)}, nil),
+				userRepo.EXPECT().GetUserByID(gomock.Any(), user.ID).Return(user, nil),
+				userRepo.EXPECT().GetNotificationPreferences(gomock.Any(), user.ID).Return(defaults, nil),
+				sightingRepo.EXPECT().ListSightingsByIDs(gomock.Any(), []string{"s1"}).Return(sightings, nil),
+				tigerRepo.EXPECT().ListTigersByIDs(gomock.Any(), []string{tigerID}).Return(tigers, nil),
+				notificationRepo.EXPECT().RecordNotificationJobFailure(gomock.Any(), failedJob(model.NotificationStatusPending, 3)).Return(nil),
+			},
+		},
+		{
+			name:        "should mark the job dead after the last attempt fails",
+			sendErr:     errors.New("any error"),
+			wantClaimed: 1,
+			mocks: []*gomock.Call{
+				notificationRepo.EXPECT().ClaimDueNotificationJobs(gomock.Any(), gomock.Any(), notificationLease, notificationBatchSize).
+					Return([]*model.NotificationJob{job(maxNotificationAttempts - 1)}, nil),
+				userRepo.EXPECT().GetUserByID(gomock.Any(), user.ID).Return(user, nil),
+				userRepo.EXPECT().GetNotificationPreferences(gomock.Any(), user.ID).Return(defaults, nil),
+				sightingRepo.EXPECT().ListSightingsByIDs(gomock.Any(), []string{"s1"}).Return(sightings, nil),
+				tigerRepo.EXPECT().ListTigersByIDs(gomock.Any(), []string{tigerID}).Return(tigers, nil),
+				notificationRepo.EXPECT().RecordNotificationJobFailure(gomock.Any(),
+					failedJob(model.NotificationStatusDead, maxNotificationAttempts)).Return(nil),
+			},
+		},
+		{
+			name:        "should keep dispatching when a job cannot be updated",
+			wantClaimed: 2,
+			wantSent:    2,
+			mocks: []*gomock.Call{
+				notificationRepo.EXPECT().ClaimDueNotificationJobs(gomock.Any(), gomock.Any(), notificationLease, notificationBatchSize).
+					Return([]*model.NotificationJob{job(0), job(0)}, nil),
+				userRepo.EXPECT().GetUserByID(gomock.Any(), user.ID).Return(user, nil).Times(2),
+				userRepo.EXPECT().GetNotificationPreferences(gomock.Any(), user.ID).Return(defaults, nil).Times(2),
+				sightingRepo.EXPECT().ListSightingsByIDs(gomock.Any(), []string{"s1"}).Return(sightings, nil).Times(2),
+				tigerRepo.EXPECT().ListTigersByIDs(gomock.Any(), []string{tigerID}).Return(tigers, nil).Times(2),
+				notificationRepo.EXPECT().MarkNotificationJobDelivered(gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("any error")),
+				notificationRepo.EXPECT().MarkNotificationJobDelivered(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil),
+			},
+		},
+	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			s := &notificationService{
-				sightingRepo: tt.fields.sightingRepo,
-				userRepo:     tt.fields.userRepo,
+			memoryNotifier := notifier.NewMemoryNotifier()
+			memoryNotifier.Err = tt.sendErr
+			s := NewNotificationService(notificationRepo, userRepo, sightingRepo, tigerRepo, nil, memoryNotifier,
+				urlsign.NewSigner("secret", "http://localhost:8080"))
+			got, err := s.DispatchDue(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("notificationService.DispatchDue() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.wantClaimed {
+				t.Errorf("notificationService.DispatchDue() = %v, want %v", got, tt.wantClaimed)
+			}
+			if sent := len(memoryNotifier.Sent()); sent != tt.wantSent {
+				t.Errorf("notificationService.DispatchDue() sent %d messages, want %d", sent, tt.wantSent)
 			}
-			s.StartNotificationConsumer()
 		})
 	}
 }
 
-func Test_notificationService_FetchPreviousSighters(t *testing.T) {
+func Test_notificationService_recordFailure(t *testing.T) {
 	ctrl := gomock.NewController(t)
-	sightingRepo := mock.NewMockSightingRepository(ctrl)
-	userRepo := mock.NewMockUserRepository(ctrl)
-	appendedID := uuid.NewString()
-	type fields struct {
-		sightingRepo repository.SightingRepository
-		userRepo     repository.UserRepository
+	notificationRepo := mock.NewMockNotificationRepository(ctrl)
+	notificationRepo.EXPECT().RecordNotificationJobFailure(gomock.Any(), gomock.Any()).Return(nil)
+
+	s := &notificationService{notificationRepo: notificationRepo}
+	job := &model.NotificationJob{ID: uuid.NewString(), Attempts: 1}
+	before := time.Now()
+	if err := s.recordFailure(context.Background(), job, errors.New("connection refused"), false); err != nil {
+		t.Fatalf("notificationService.recordFailure() error = %v", err)
 	}
-	type args struct {
-		ctx     context.Context
-		tigerID string
+	if job.LastError == nil || *job.LastError != "connection refused" {
+		t.Errorf("notificationService.recordFailure() LastError = %v, want connection refused", job.LastError)
+	}
+	if wait := job.NextAttemptAt.Sub(before); wait < 2*notificationBaseBackoff || wait > 2*notificationBaseBackoff+time.Second {
+		t.Errorf("notificationService.recordFailure() scheduled the next attempt in %v, want %v", wait, 2*notificationBaseBackoff)
+	}
+}
+
+func Test_notificationBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: notificationBaseBackoff},
+		{attempts: 2, want: 2 * notificationBaseBackoff},
+		{attempts: 4, want: 8 * notificationBaseBackoff},
+		{attempts: 30, want: notificationMaxBackoff},
+	}
+	for _, tt := range tests {
+		if got := notificationBackoff(tt.attempts); got != tt.want {
+			t.Errorf("notificationBackoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
 	}
+}
+
+func Test_notificationService_ListNotificationJobs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	notificationRepo := mock.NewMockNotificationRepository(ctrl)
+	dead := model.NotificationStatusDead
+	jobs := []*model.NotificationJob{{ID: uuid.NewString(), Status: dead}}
 	tests := []struct {
 		name    string
-		fields  fields
-		args    args
-		want    []*model.User
+		want    []*model.NotificationJob
 		wantErr bool
 		mocks   []*gomock.Call
 	}{
 		{
-			name: "should return error when error fetching prev sighters",
-			fields: fields{
-				sightingRepo: sightingRepo,
-				userRepo:     userRepo,
-			},
-			args: args{
-				ctx:     context.Background(),
-				tigerID: uuid.NewString(),
-			},
-			want:    nil,
+			name:    "should return error if jobs cannot be listed",
 			wantErr: true,
 			mocks: []*gomock.Call{
-				sightingRepo.EXPECT().ListUserCreatedSightingByTigerID(gomock.Any(), gomock.Any()).Return(nil, errors.New("any error")),
+				notificationRepo.EXPECT().ListNotificationJobs(gomock.Any(), &dead, 10, 0).Return(nil, errors.New("any error")),
 			},
 		},
 		{
-			name: "should return error when error fetching prev sighters",
-			fields: fields{
-				sightingRepo: sightingRepo,
-				userRepo:     userRepo,
+			name: "success",
+			want: jobs,
+			mocks: []*gomock.Call{
+				notificationRepo.EXPECT().ListNotificationJobs(gomock.Any(), &dead, 10, 0).Return(jobs, nil),
 			},
-			args: args{
-				ctx:     context.Background(),
-				tigerID: uuid.NewString(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &notificationService{notificationRepo: notificationRepo}
+			got, err := s.ListNotificationJobs(context.Background(), &dead, 10, 0)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("notificationService.ListNotificationJobs() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("notificationService.ListNotificationJobs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_notificationService_ReplayNotificationJob(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	notificationRepo := mock.NewMockNotificationRepository(ctrl)
+	id := uuid.NewString()
+	lastError := "any error"
+	dead := func() *model.NotificationJob {
+		return &model.NotificationJob{ID: id, Status: model.NotificationStatusDead, Attempts: maxNotificationAttempts, LastError: &lastError}
+	}
+	tests := []struct {
+		name    string
+		wantErr error
+		mocks   []*gomock.Call
+	}{
+		{
+			name:    "should return not found error if the job does not exist",
+			wantErr: &helper.NotificationJobNotFoundError{},
+			mocks: []*gomock.Call{
+				notificationRepo.EXPECT().GetNotificationJobByID(gomock.Any(), id).Return(nil, gorm.ErrRecordNotFound),
 			},
-			want:    nil,
-			wantErr: false,
+		},
+		{
+			name:    "should return error if the job cannot be fetched",
+			wantErr: &helper.CustomError{},
 			mocks: []*gomock.Call{
-				sightingRepo.EXPECT().ListUserCreatedSightingByTigerID(gomock.Any(), gomock.Any()).Return([]string{uuid.NewString()}, nil),
-				userRepo.EXPECT().GetUserByID(gomock.Any(), gomock.Any()).Return(nil, errors.New("any error")),
+				notificationRepo.EXPECT().GetNotificationJobByID(gomock.Any(), id).Return(nil, errors.New("any error")),
 			},
 		},
 		{
-			name: "success",
-			fields: fields{
-				sightingRepo: sightingRepo,
-				userRepo:     userRepo,
+			name:    "should refuse to replay a job that is not dead",
+			wantErr: &helper.NotificationJobNotDeadError{},
+			mocks: []*gomock.Call{
+				notificationRepo.EXPECT().GetNotificationJobByID(gomock.Any(), id).
+					Return(&model.NotificationJob{ID: id, Status: model.NotificationStatusDelivered}, nil),
 			},
-			args: args{
-				ctx:     context.Background(),
-				tigerID: uuid.NewString(),
+		},
+		{
+			name:    "should refuse to replay a job replayed in the meantime",
+			wantErr: &helper.NotificationJobNotDeadError{},
+			mocks: []*gomock.Call{
+				notificationRepo.EXPECT().GetNotificationJobByID(gomock.Any(), id).Return(dead(), nil),
+				notificationRepo.EXPECT().ReplayNotificationJob(gomock.Any(), id, gomock.Any()).Return(gorm.ErrRecordNotFound),
 			},
-			want:    []*model.User{{ID: appendedID}},
-			wantErr: false,
+		},
+		{
+			name:    "should return error if the job cannot be replayed",
+			wantErr: &helper.CustomError{},
 			mocks: []*gomock.Call{
-				sightingRepo.EXPECT().ListUserCreatedSightingByTigerID(gomock.Any(), gomock.Any()).Return([]string{uuid.NewString(), appendedID}, nil),
-				userRepo.EXPECT().GetUserByID(gomock.Any(), gomock.Any()).Return(nil, errors.New("any error")),
-				userRepo.EXPECT().GetUserByID(gomock.Any(), gomock.Any()).Return(&model.User{ID: appendedID}, nil),
+				notificationRepo.EXPECT().GetNotificationJobByID(gomock.Any(), id).Return(dead(), nil),
+				notificationRepo.EXPECT().ReplayNotificationJob(gomock.Any(), id, gomock.Any()).Return(errors.New("any error")),
+			},
+		},
+		{
+			name: "success",
+			mocks: []*gomock.Call{
+				notificationRepo.EXPECT().GetNotificationJobByID(gomock.Any(), id).Return(dead(), nil),
+				notificationRepo.EXPECT().ReplayNotificationJob(gomock.Any(), id, gomock.Any()).Return(nil),
 			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			s := &notificationService{
-				sightingRepo: tt.fields.sightingRepo,
-				userRepo:     tt.fields.userRepo,
-			}
-			got, err := s.FetchPreviousSighters(tt.args.ctx, tt.args.tigerID)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("notificationService.FetchPreviousSighters() error = %v, wantErr %v", err, tt.wantErr)
+			s := &notificationService{notificationRepo: notificationRepo}
+			got, err := s.ReplayNotificationJob(context.Background(), id)
+			if tt.wantErr != nil {
+				if reflect.TypeOf(err) != reflect.TypeOf(tt.wantErr) {
+					t.Errorf("notificationService.ReplayNotificationJob() error = %T, want %T", err, tt.wantErr)
+				}
 				return
 			}
-			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("notificationService.FetchPreviousSighters() = %v, want %v", got, tt.want)
+			if err != nil {
+				t.Fatalf("notificationService.ReplayNotificationJob() error = %v", err)
+			}
+			if got.Status != model.NotificationStatusPending || got.Attempts != 0 {
+				t.Errorf("notificationService.ReplayNotificationJob() = %+v, want a pending job without attempts", got)
 			}
 		})
 	}
 }
 
+// deadlineNotifier records the deadline of the context it is sent with
+type deadlineNotifier struct {
+	deadline    time.Time
+	hasDeadline bool
+}
+
+func (n *deadlineNotifier) Send(ctx context.Context, msg *notifier.Message) error {
+	n.deadline, n.hasDeadline = ctx.Deadline()
+	return nil
+}
+
+func Test_notificationService_send(t *testing.T) {
+	recorder := &deadlineNotifier{}
+	s := &notificationService{notifier: recorder}
+	start := time.Now()
+	if err := s.send(context.Background(), &notifier.Message{}); err != nil {
+		t.Fatalf("notificationService.send() error = %v", err)
+	}
+	if !recorder.hasDeadline || recorder.deadline.After(start.Add(notificationSendTimeout+time.Second)) {
+		t.Errorf("notificationService.send() deadline = %v, want one within %v", recorder.deadline, notificationSendTimeout)
+	}
+	// a batch of sends that all time out is still inside the lease
+	if notificationBatchSize*notificationSendTimeout >= notificationLease {
+		t.Errorf("notificationBatchSize * notificationSendTimeout = %v, want less than the %v lease",
+			notificationBatchSize*notificationSendTimeout, notificationLease)
+	}
+}
+
 func Test_notificationService_sendEmail(t *testing.T) {
-	type fields struct {
-		sightingRepo repository.SightingRepository
-		userRepo     repository.UserRepository
+	user := model.User{
+		ID:    uuid.NewString(),
+		Name:  "test",
+		Email: "test@example.com",
 	}
-	type args struct {
-		ctx          context.Context
-		user         model.User
-		notification model.Notification
+	job := &model.NotificationJob{Type: model.NotificationTypeSighting, TigerID: ptr("tiger-1")}
+	urlSigner := urlsign.NewSigner("secret", "http://localhost:8080")
+	tiger := &email.Tiger{
+		ID:       "tiger-1",
+		Name:     "Stripes",
+		TrackURL: urlSigner.URL("/tigers/tiger-1/track"),
+		MuteURL:  urlSigner.SignedURL(UnsubscribePath(user.ID, "tiger-1"), unsubscribeURLTTL),
+		Sightings: []*email.Sighting{{
+			SeenAt:       time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC),
+			Latitude:     -1.23456,
+			Longitude:    101.45678,
+			PhotoURL:     "http://localhost:8080/images/a.jpg",
+			ThumbnailURL: "http://localhost:8080/images/a_thumbnail.jpg",
+		}},
 	}
 	tests := []struct {
-		name    string
-		fields  fields
-		args    args
-		wantErr bool
+		name     string
+		sendErr  error
+		wantSent int
+		wantErr  bool
 	}{
 		{
-			name: "tested",
-			args: args{
-				ctx: context.Background(),
-				user: model.User{
-					Name:     "test",
-					Email:    "test@example.com",
-					Password: "123456",
-				},
-			},
+			name:    "should return error when the notifier fails",
+			sendErr: errors.New("any error"),
 			wantErr: true,
 		},
+		{
+			name:     "success",
+			wantSent: 1,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			s := &notificationService{
-				sightingRepo: tt.fields.sightingRepo,
-				userRepo:     tt.fields.userRepo,
-			}
-			if err := s.sendEmail(tt.args.ctx, tt.args.user, tt.args.notification); (err != nil) != tt.wantErr {
+			memoryNotifier := notifier.NewMemoryNotifier()
+			memoryNotifier.Err = tt.sendErr
+			s := &notificationService{notifier: memoryNotifier, urlSigner: urlSigner}
+			preferences := model.DefaultNotificationPreferences(user.ID)
+			if err := s.sendEmail(context.Background(), user, preferences, job, tiger); (err != nil) != tt.wantErr {
 				t.Errorf("notificationService.sendEmail() error = %v, wantErr %v", err, tt.wantErr)
 			}
+			sent := memoryNotifier.Sent()
+			if len(sent) != tt.wantSent {
+				t.Fatalf("notificationService.sendEmail() sent %d messages, want %d", len(sent), tt.wantSent)
+			}
+			if tt.wantSent > 0 && (sent[0].ToAddress != user.Email || sent[0].ToName != user.Name) {
+				t.Errorf("notificationService.sendEmail() sent to %s <%s>, want %s <%s>",
+					sent[0].ToName, sent[0].ToAddress, user.Name, user.Email)
+			}
+			if tt.wantSent > 0 {
+				unsubscribe, err := url.Parse(sent[0].UnsubscribeURL)
+				if err != nil {
+					t.Fatalf("notificationService.sendEmail() UnsubscribeURL = %q: %v", sent[0].UnsubscribeURL, err)
+				}
+				query := unsubscribe.Query()
+				if _, err := urlSigner.Verify(UnsubscribePath(user.ID, ""), query.Get("expires"), query.Get("signature")); err != nil {
+					t.Errorf("notificationService.sendEmail() UnsubscribeURL does not verify: %v", err)
+				}
+				if !strings.Contains(sent[0].Body, sent[0].UnsubscribeURL) || !strings.Contains(sent[0].Body, UnsubscribePath(user.ID, *job.TigerID)) {
+					t.Errorf("notificationService.sendEmail() body %q lacks the unsubscribe links", sent[0].Body)
+				}
+				// the tiger is named and its location is approximate
+				if sent[0].Subject != "New sighting of Stripes" || !strings.Contains(sent[0].Body, "near 1.23°S, 101.46°E") ||
+					strings.Contains(sent[0].Body, "tiger-1 ") {
+					t.Errorf("notificationService.sendEmail() = %q\n%s", sent[0].Subject, sent[0].Body)
+				}
+				if !strings.Contains(sent[0].HTMLBody, tiger.Sightings[0].ThumbnailURL) {
+					t.Errorf("notificationService.sendEmail() HTML body lacks the thumbnail:\n%s", sent[0].HTMLBody)
+				}
+			}
+		})
+	}
+}
+
+func Test_notificationService_sendEmail_watchZone(t *testing.T) {
+	user := model.User{
+		ID:    uuid.NewString(),
+		Name:  "test",
+		Email: "test@example.com",
+	}
+	urlSigner := urlsign.NewSigner("secret", "http://localhost:8080")
+	memoryNotifier := notifier.NewMemoryNotifier()
+	s := &notificationService{notifier: memoryNotifier, urlSigner: urlSigner}
+
+	// an unidentified sighting has no tiger to mute
+	job := &model.NotificationJob{Type: model.NotificationTypeWatchZone, WatchZoneID: ptr("zone-1")}
+	tiger := &email.Tiger{Sightings: []*email.Sighting{{SeenAt: time.Now(), Latitude: 1, Longitude: 2}}}
+	preferences := model.DefaultNotificationPreferences(user.ID)
+	preferences.Locale = model.LocaleID
+	if err := s.sendEmail(context.Background(), user, preferences, job, tiger); err != nil {
+		t.Fatalf("notificationService.sendEmail() error = %v", err)
+	}
+	sent := memoryNotifier.Sent()
+	if len(sent) != 1 {
+		t.Fatalf("notificationService.sendEmail() sent %d messages, want 1", len(sent))
+	}
+	if sent[0].Subject != "Seekor harimau terlihat di zona pantauan Anda" {
+		t.Errorf("notificationService.sendEmail() subject = %q, want a watch zone alert in Indonesian", sent[0].Subject)
+	}
+	if strings.Contains(sent[0].Body, "/tigers/") || !strings.Contains(sent[0].Body, sent[0].UnsubscribeURL) {
+		t.Errorf("notificationService.sendEmail() body %q, want only the unsubscribe link", sent[0].Body)
+	}
+}
+
+func Test_notificationService_ListNotifications(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	notificationRepo := mock.NewMockNotificationRepository(ctrl)
+	userID := uuid.NewString()
+	// cursors round-trip through JSON, which keeps neither the monotonic
+	// clock reading nor the local time zone
+	now := time.Now().UTC().Round(0)
+	inbox := []*model.Notification{
+		{ID: "n1", UserID: userID, CreatedAt: now},
+		{ID: "n2", UserID: userID, CreatedAt: now.Add(-time.Minute)},
+		{ID: "n3", UserID: userID, CreatedAt: now.Add(-2 * time.Minute)},
+	}
+	after := encodeNotificationCursor(inbox[0])
+	invalid := "not a cursor"
+	tests := []struct {
+		name        string
+		first       int
+		after       *string
+		wantIDs     []string
+		wantHasNext bool
+		wantErr     error
+		mocks       []*gomock.Call
+	}{
+		{
+			name:    "should return pagination error if first is out of range",
+			first:   maxNotificationsPerPage + 1,
+			wantErr: &helper.InvalidPaginationError{},
+		},
+		{
+			name:    "should return pagination error if the cursor is invalid",
+			first:   2,
+			after:   &invalid,
+			wantErr: &helper.InvalidPaginationError{},
+		},
+		{
+			name:    "should return error if notifications cannot be listed",
+			first:   2,
+			wantErr: &helper.CustomError{},
+			mocks: []*gomock.Call{
+				notificationRepo.EXPECT().ListNotifications(gomock.Any(), userID, true, nil, 3).Return(nil, errors.New("any error")),
+			},
+		},
+		{
+			name:        "success with a next page",
+			first:       2,
+			wantIDs:     []string{"n1", "n2"},
+			wantHasNext: true,
+			mocks: []*gomock.Call{
+				notificationRepo.EXPECT().ListNotifications(gomock.Any(), userID, true, nil, 3).Return(inbox, nil),
+			},
+		},
+		{
+			name:    "success after a cursor",
+			first:   2,
+			after:   &after,
+			wantIDs: []string{"n2", "n3"},
+			mocks: []*gomock.Call{
+				notificationRepo.EXPECT().ListNotifications(gomock.Any(), userID, true,
+					&model.NotificationCursor{CreatedAt: inbox[0].CreatedAt, ID: "n1"}, 3).Return(inbox[1:], nil),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &notificationService{notificationRepo: notificationRepo}
+			got, err := s.ListNotifications(context.Background(), userID, true, tt.first, tt.after)
+			if tt.wantErr != nil {
+				if reflect.TypeOf(err) != reflect.TypeOf(tt.wantErr) {
+					t.Errorf("notificationService.ListNotifications() error = %T, want %T", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("notificationService.ListNotifications() error = %v", err)
+			}
+			var ids []string
+			for _, edge := range got.Edges {
+				ids = append(ids, edge.Node.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.wantIDs) || got.PageInfo.HasNextPage != tt.wantHasNext {
+				t.Errorf("notificationService.ListNotifications() = %v, hasNextPage %v, want %v, %v",
+					ids, got.PageInfo.HasNextPage, tt.wantIDs, tt.wantHasNext)
+			}
+			if *got.PageInfo.EndCursor != got.Edges[len(got.Edges)-1].Cursor {
+				t.Errorf("notificationService.ListNotifications() endCursor is not the cursor of the last edge")
+			}
+		})
+	}
+}
+
+func Test_notificationService_MarkNotificationRead(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	notificationRepo := mock.NewMockNotificationRepository(ctrl)
+	userID := uuid.NewString()
+	readAt := time.Now().Add(-time.Hour)
+	tests := []struct {
+		name       string
+		wantErr    error
+		wantReadAt *time.Time
+		mocks      []*gomock.Call
+	}{
+		{
+			name:    "should return not found error for a notification of another user",
+			wantErr: &helper.NotificationNotFoundError{},
+			mocks: []*gomock.Call{
+				notificationRepo.EXPECT().GetNotification(gomock.Any(), userID, "n1").Return(nil, gorm.ErrRecordNotFound),
+			},
+		},
+		{
+			name:    "should return error if the notification cannot be fetched",
+			wantErr: &helper.CustomError{},
+			mocks: []*gomock.Call{
+				notificationRepo.EXPECT().GetNotification(gomock.Any(), userID, "n1").Return(nil, errors.New("any error")),
+			},
+		},
+		{
+			name:       "should keep the time a read notification was first read",
+			wantReadAt: &readAt,
+			mocks: []*gomock.Call{
+				notificationRepo.EXPECT().GetNotification(gomock.Any(), userID, "n1").Return(&model.Notification{ID: "n1", ReadAt: &readAt}, nil),
+			},
+		},
+		{
+			name:    "should return error if the notification cannot be marked",
+			wantErr: &helper.CustomError{},
+			mocks: []*gomock.Call{
+				notificationRepo.EXPECT().GetNotification(gomock.Any(), userID, "n1").Return(&model.Notification{ID: "n1"}, nil),
+				notificationRepo.EXPECT().MarkNotificationRead(gomock.Any(), userID, "n1", gomock.Any()).Return(errors.New("any error")),
+			},
+		},
+		{
+			name: "success",
+			mocks: []*gomock.Call{
+				notificationRepo.EXPECT().GetNotification(gomock.Any(), userID, "n1").Return(&model.Notification{ID: "n1"}, nil),
+				notificationRepo.EXPECT().MarkNotificationRead(gomock.Any(), userID, "n1", gomock.Any()).Return(nil),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &notificationService{notificationRepo: notificationRepo}
+			got, err := s.MarkNotificationRead(context.Background(), userID, "n1")
+			if tt.wantErr != nil {
+				if reflect.TypeOf(err) != reflect.TypeOf(tt.wantErr) {
+					t.Errorf("notificationService.MarkNotificationRead() error = %T, want %T", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("notificationService.MarkNotificationRead() error = %v", err)
+			}
+			if !got.Read() || (tt.wantReadAt != nil && !got.ReadAt.Equal(*tt.wantReadAt)) {
+				t.Errorf("notificationService.MarkNotificationRead() readAt = %v, want %v", got.ReadAt, tt.wantReadAt)
+			}
 		})
 	}
 }
+
+func Test_notificationService_MarkAllNotificationsRead(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	notificationRepo := mock.NewMockNotificationRepository(ctrl)
+	userID := uuid.NewString()
+	notificationRepo.EXPECT().MarkAllNotificationsRead(gomock.Any(), userID, gomock.Any()).Return(0, errors.New("any error"))
+	notificationRepo.EXPECT().MarkAllNotificationsRead(gomock.Any(), userID, gomock.Any()).Return(3, nil)
+
+	s := &notificationService{notificationRepo: notificationRepo}
+	if _, err := s.MarkAllNotificationsRead(context.Background(), userID); err == nil {
+		t.Errorf("notificationService.MarkAllNotificationsRead() error = nil, want error")
+	}
+	if got, err := s.MarkAllNotificationsRead(context.Background(), userID); err != nil || got != 3 {
+		t.Errorf("notificationService.MarkAllNotificationsRead() = %v, %v, want 3", got, err)
+	}
+}
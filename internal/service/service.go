@@ -2,10 +2,14 @@ package service
 
 import (
 	"context"
+	"io"
+	"time"
 
 	"github.com/99designs/gqlgen/graphql"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/nurcholisnanda/tigerhall-kittens/internal/api/graph/model"
+	"github.com/nurcholisnanda/tigerhall-kittens/internal/export"
+	"github.com/nurcholisnanda/tigerhall-kittens/pkg/imaging"
 )
 
 //go:generate mockgen -source=service.go -destination=mock/service.go -package=mock
@@ -19,17 +23,89 @@ type UserService interface {
 	Register(ctx context.Context, input *model.NewUser) (interface{}, error)
 	Login(ctx context.Context, email string, password string) (interface{}, error)
 	GetUserByID(ctx context.Context, id string) (*model.User, error)
+	GetNotificationPreferences(ctx context.Context, userID string) (*model.NotificationPreferences, error)
+	UpdateNotificationPreferences(ctx context.Context, userID string, input *model.NotificationPreferencesInput) (*model.NotificationPreferences, error)
+	Unsubscribe(ctx context.Context, userID string, tigerID string) error
 }
 
 //go:generate mockgen -source=repository.go -destination=mock/repository.go -package=mock
 type TigerService interface {
 	CreateTiger(ctx context.Context, input *model.TigerInput) (*model.Tiger, error)
 	ListTigers(ctx context.Context, limit int, offset int) ([]*model.Tiger, error)
+	ListTigersNear(ctx context.Context, point *model.LastSeenCoordinateInput, radiusMeters float64, seenSince *time.Time, limit int) ([]*model.NearbyTiger, error)
+	SetProfilePhoto(ctx context.Context, tigerID string, imageID string) (*model.Tiger, error)
+	GetProfilePhoto(ctx context.Context, tiger *model.Tiger) (*model.SightingImage, error)
+	FollowTiger(ctx context.Context, userID string, tigerID string) (*model.Tiger, error)
+	UnfollowTiger(ctx context.Context, userID string, tigerID string) (*model.Tiger, error)
+	ListFollowedTigers(ctx context.Context, userID string) ([]*model.Tiger, error)
 }
 
 //go:generate mockgen -source=repository.go -destination=mock/repository.go -package=mock
 type SightingService interface {
 	CreateSighting(ctx context.Context, newSighting *model.SightingInput) (*model.Sighting, error)
 	ListSightings(ctx context.Context, tigerID string, limit int, offset int) ([]*model.Sighting, error)
-	GetResizedImage(ctx context.Context, inputImage *graphql.Upload) (string, error)
+	ListUnidentifiedSightings(ctx context.Context, limit int, offset int) ([]*model.Sighting, error)
+	AssignSighting(ctx context.Context, sightingID string, tigerID string) (*model.Sighting, error)
+	StoreImage(ctx context.Context, sightingID string, imageID string, upload *imaging.Upload) (string, error)
+	ImageURL(key string, size model.ImageSize) *string
+	SightingImageURL(image *model.SightingImage, size model.ImageSize) string
+	CoverImageURL(ctx context.Context, sighting *model.Sighting, size model.ImageSize) (*string, error)
+	ImageStatus(ctx context.Context, sighting *model.Sighting) (*model.ImageStatus, error)
+	ListSightingImages(ctx context.Context, sighting *model.Sighting) ([]*model.SightingImage, error)
+	ListTigerPhotos(ctx context.Context, tigerID string, first int, after *string) (*model.PhotoConnection, error)
+	SuggestTigers(ctx context.Context, upload graphql.Upload, near *model.LastSeenCoordinateInput, limit int) ([]*model.TigerSuggestion, error)
+	ListSightingsNear(ctx context.Context, point *model.LastSeenCoordinateInput, radiusMeters float64, timeRange *model.TimeRangeInput, limit int) ([]*model.NearbySighting, error)
+	ListSightingsInBox(ctx context.Context, box *model.BoundingBox, timeRange *model.TimeRangeInput, limit int) ([]*model.NearbySighting, error)
+	GetTigerTrack(ctx context.Context, tigerID string, timeRange *model.TimeRangeInput) (*model.TigerTrack, error)
+}
+
+//go:generate mockgen -source=repository.go -destination=mock/repository.go -package=mock
+type ImageProcessor interface {
+	Start(ctx context.Context)
+	Enqueue(imageID string)
+	ProcessImage(ctx context.Context, imageID string) error
+}
+
+//go:generate mockgen -source=repository.go -destination=mock/repository.go -package=mock
+type NotificationService interface {
+	Start(ctx context.Context)
+	DispatchDue(ctx context.Context) (int, error)
+	SendDueDigests(ctx context.Context) (int, error)
+	ListNotificationJobs(ctx context.Context, status *model.NotificationStatus, limit int, offset int) ([]*model.NotificationJob, error)
+	ReplayNotificationJob(ctx context.Context, id string) (*model.NotificationJob, error)
+	ListNotifications(ctx context.Context, userID string, unreadOnly bool, first int, after *string) (*model.NotificationConnection, error)
+	UnreadNotificationCount(ctx context.Context, userID string) (int, error)
+	MarkNotificationRead(ctx context.Context, userID string, id string) (*model.Notification, error)
+	MarkAllNotificationsRead(ctx context.Context, userID string) (int, error)
+}
+
+//go:generate mockgen -source=repository.go -destination=mock/repository.go -package=mock
+type WatchZoneService interface {
+	CreateWatchZone(ctx context.Context, userID string, input *model.WatchZoneInput) (*model.WatchZone, error)
+	ListWatchZones(ctx context.Context, userID string) ([]*model.WatchZone, error)
+}
+
+//go:generate mockgen -source=repository.go -destination=mock/repository.go -package=mock
+type EventBroker interface {
+	SubscribeSightings(ctx context.Context, tigerID *string, area *model.BoundingBox) (<-chan *model.Sighting, error)
+	SubscribeNotifications(ctx context.Context, userID string) <-chan *model.Notification
+	PublishSighting(ctx context.Context, sighting *model.Sighting)
+	PublishSightingNotifications(ctx context.Context, sightingID string)
+}
+
+//go:generate mockgen -source=repository.go -destination=mock/repository.go -package=mock
+type ExportService interface {
+	ExportTigers(ctx context.Context, w io.Writer, format export.BulkFormat) error
+	ExportSightings(ctx context.Context, w io.Writer, format export.BulkFormat, tigerID string, timeRange *model.TimeRangeInput) error
+	ExportDarwinCore(ctx context.Context, w io.Writer) error
+}
+
+//go:generate mockgen -source=repository.go -destination=mock/repository.go -package=mock
+type WebhookService interface {
+	Start(ctx context.Context)
+	DispatchDue(ctx context.Context) (int, error)
+	CreateWebhookSubscription(ctx context.Context, userID string, input *model.WebhookSubscriptionInput) (*model.WebhookSubscription, error)
+	ListWebhookSubscriptions(ctx context.Context) ([]*model.WebhookSubscription, error)
+	DeleteWebhookSubscription(ctx context.Context, id string) (*model.WebhookSubscription, error)
+	ListWebhookDeliveries(ctx context.Context, subscriptionID *string, status *model.WebhookDeliveryStatus, limit int, offset int) ([]*model.WebhookDelivery, error)
 }
@@ -4,35 +4,87 @@ import (
 	"bytes"
 	"context"
 	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"image"
-	"image/jpeg"
-	"io"
-	"log"
 	"math"
 	"net/http"
+	"path"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/99designs/gqlgen/graphql"
 	"github.com/google/uuid"
-	"github.com/nfnt/resize"
 	"github.com/nurcholisnanda/tigerhall-kittens/internal/api/graph/model"
+	"github.com/nurcholisnanda/tigerhall-kittens/internal/export"
 	"github.com/nurcholisnanda/tigerhall-kittens/internal/repository"
 	"github.com/nurcholisnanda/tigerhall-kittens/pkg/helper"
+	"github.com/nurcholisnanda/tigerhall-kittens/pkg/imaging"
 	"github.com/nurcholisnanda/tigerhall-kittens/pkg/logger"
+	"github.com/nurcholisnanda/tigerhall-kittens/pkg/storage"
+	"github.com/nurcholisnanda/tigerhall-kittens/pkg/urlsign"
 	"gorm.io/gorm"
 )
 
 type sightingService struct {
-	sightingRepo repository.SightingRepository
-	tigerRepo    repository.TigerRepository
+	sightingRepo      repository.SightingRepository
+	tigerRepo         repository.TigerRepository
+	sightingImageRepo repository.SightingImageRepository
+	blobStore         storage.BlobStore
+	urlSigner         *urlsign.Signer
+	imageURLTTL       time.Duration
+	uploadLimits      imaging.Limits
+	imageProcessor    ImageProcessor
+	watchZoneRepo     repository.WatchZoneRepository
+	events            EventBroker
 }
 
-func NewSightingService(sightingRepo repository.SightingRepository, tigerRepo repository.TigerRepository) SightingService {
+const (
+	// renditionQuality is the JPEG quality renditions are encoded with
+	renditionQuality = 85
+
+	maxImagesPerSighting = 10
+	maxPhotosPerPage     = 100
+
+	// exifDistanceTolerance is how far, in meters, the reported location may
+	// be from the photo's GPS position before the sighting is flagged
+	exifDistanceTolerance = 1000.0
+	// exifTimeTolerance is how far apart the reported time and the capture
+	// time may be. A capture time without a time zone is local to the camera
+	// and can be off by up to maxTimeZoneOffset, which widens the tolerance.
+	exifTimeTolerance = time.Hour
+	maxTimeZoneOffset = 14 * time.Hour
+
+	maxTigerSuggestions = 20
+	// suggestionSimilarityWeight is the share of visual similarity in the
+	// score of a suggestion when a location is given, the rest comes from how
+	// close the tiger was last seen. Proximity halves every
+	// suggestionProximityHalving meters.
+	suggestionSimilarityWeight = 0.7
+	suggestionProximityHalving = 20000.0
+	// Suggestions compare the upload with the suggestionPhotosPerTiger newest
+	// photos of a tiger and with maxSuggestionCandidates photos in all. With
+	// a location only tigers last seen within maxSearchRadius are compared.
+	suggestionPhotosPerTiger = 5
+	maxSuggestionCandidates  = 5000
+)
+
+func NewSightingService(sightingRepo repository.SightingRepository, tigerRepo repository.TigerRepository,
+	sightingImageRepo repository.SightingImageRepository, blobStore storage.BlobStore, urlSigner *urlsign.Signer, imageURLTTL time.Duration,
+	uploadLimits imaging.Limits, imageProcessor ImageProcessor, watchZoneRepo repository.WatchZoneRepository,
+	events EventBroker) SightingService {
 	return &sightingService{
-		sightingRepo: sightingRepo,
-		tigerRepo:    tigerRepo,
+		sightingRepo:      sightingRepo,
+		tigerRepo:         tigerRepo,
+		sightingImageRepo: sightingImageRepo,
+		blobStore:         blobStore,
+		urlSigner:         urlSigner,
+		imageURLTTL:       imageURLTTL,
+		uploadLimits:      uploadLimits,
+		imageProcessor:    imageProcessor,
+		watchZoneRepo:     watchZoneRepo,
+		events:            events,
 	}
 }
 
@@ -51,27 +103,52 @@ func (s *sightingService) ListSightings(ctx context.Context, tigerID string, lim
 }
 
 func (s *sightingService) CreateSighting(ctx context.Context, input *model.SightingInput) (*model.Sighting, error) {
-	// Check if Tiger exists
-	tiger, err := s.tigerRepo.GetTigerByID(ctx, input.TigerID)
-	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return nil, &helper.TigerNotFound{Message: "Tiger not found"}
+	// Check if Tiger exists, a sighting without one is stored unidentified
+	var tiger *model.Tiger
+	if input.TigerID != nil {
+		var err error
+		if tiger, err = s.getTiger(ctx, *input.TigerID); err != nil {
+			return nil, err
 		}
-		logger.Logger(ctx).Error("Unexpected error getting tiger by ID: ", err)
-		return nil, helper.NewCustomError("Failed to retrieve tiger by ID", http.StatusInternalServerError)
 	}
 
-	if !isValidLatitude(input.LastSeenCoordinate.Latitude) || !isValidLongitude(input.LastSeenCoordinate.Longitude) {
+	if len(input.Images) > maxImagesPerSighting {
+		return nil, &helper.InvalidImageError{
+			Message: fmt.Sprintf("a sighting can have at most %d images", maxImagesPerSighting),
+		}
+	}
+	if len(input.Captions) > len(input.Images) {
+		return nil, &helper.InvalidImageError{Message: "there are more captions than images"}
+	}
+
+	// Photos usually carry when and where they were taken, which fills in
+	// what the reporter left out and is checked against what they entered
+	uploads := make([]*imaging.Upload, 0, len(input.Images))
+	var flagReasons []string
+	for _, image := range input.Images {
+		upload, err := imaging.ReadUpload(image.File, s.uploadLimits)
+		if err != nil {
+			return nil, imageFailure(ctx, err)
+		}
+		exif := imaging.ReadExif(upload.Data)
+		flagReasons = append(flagReasons, applyExif(input, exif)...)
+		uploads = append(uploads, upload)
+	}
+
+	if input.LastSeenCoordinate == nil {
 		return nil, &helper.InvalidCoordinatesError{
-			Message: "latitude must be between -90 and 90, longitude between -180 and 180",
+			Message: "last seen coordinate is required when the image carries no GPS position",
+		}
+	}
+	if input.LastSeenTime == nil {
+		return nil, &helper.InvalidLastSeenTimeError{
+			Message: "last seen time is required when the image carries no capture time",
 		}
 	}
 
-	sighting, err := s.sightingRepo.GetLatestSightingByTigerID(ctx, input.TigerID)
-	if err != nil {
-		if err != gorm.ErrRecordNotFound {
-			logger.Logger(ctx).Error("Unexpected error getting tiger by ID: ", err)
-			return nil, helper.NewCustomError("Failed to retrieve tiger by ID", http.StatusInternalServerError)
+	if !isValidLatitude(input.LastSeenCoordinate.Latitude) || !isValidLongitude(input.LastSeenCoordinate.Longitude) {
+		return nil, &helper.InvalidCoordinatesError{
+			Message: "latitude must be between -90 and 90, longitude between -180 and 180",
 		}
 	}
 
@@ -81,6 +158,222 @@ func (s *sightingService) CreateSighting(ctx context.Context, input *model.Sight
 		}
 	}
 
+	if tiger != nil {
+		if err := s.checkAgainstLastSeen(ctx, tiger, *input.LastSeenTime, (*model.LastSeenCoordinate)(input.LastSeenCoordinate)); err != nil {
+			return nil, err
+		}
+	}
+
+	newSighting := &model.Sighting{
+		ID:                 uuid.NewString(),
+		TigerID:            input.TigerID,
+		LastSeenTime:       *input.LastSeenTime,
+		LastSeenCoordinate: (*model.LastSeenCoordinate)(input.LastSeenCoordinate),
+		Flagged:            len(flagReasons) > 0,
+	}
+	if newSighting.Flagged {
+		reason := strings.Join(flagReasons, "; ")
+		newSighting.FlagReason = &reason
+	}
+
+	for i, upload := range uploads {
+		image := &model.SightingImage{
+			ID:         uuid.NewString(),
+			SightingID: newSighting.ID,
+			TigerID:    newSighting.TigerID,
+			Position:   i,
+			Caption:    caption(input.Captions, i),
+			TakenAt:    newSighting.LastSeenTime,
+			Status:     model.ImageStatusPending,
+		}
+		var err error
+		if image.Key, err = s.StoreImage(ctx, newSighting.ID, image.ID, upload); err != nil {
+			s.deleteStoredImages(ctx, newSighting.Images)
+			return nil, imageFailure(ctx, err)
+		}
+		newSighting.Images = append(newSighting.Images, image)
+	}
+	if len(newSighting.Images) > 0 {
+		newSighting.ImageKey = newSighting.Images[0].Key
+	}
+
+	watchZones, err := s.matchWatchZones(ctx, newSighting.LastSeenCoordinate)
+	if err != nil {
+		s.deleteStoredImages(ctx, newSighting.Images)
+		return nil, err
+	}
+	webhooks, err := sightingWebhooks(newSighting, tiger)
+	if err == nil {
+		err = s.sightingRepo.CreateSighting(ctx, newSighting, watchZones, webhooks)
+	}
+	if err != nil {
+		logger.Logger(ctx).Error("Unexpected error creating sighting: ", err)
+		s.deleteStoredImages(ctx, newSighting.Images)
+		return nil, helper.NewCustomError("Failed to create sighting", http.StatusInternalServerError)
+	}
+	for _, image := range newSighting.Images {
+		s.imageProcessor.Enqueue(image.ID)
+	}
+	s.events.PublishSighting(ctx, publicSighting(newSighting, tiger))
+	s.events.PublishSightingNotifications(ctx, newSighting.ID)
+
+	return newSighting, nil
+}
+
+// publicSighting returns the sighting as subscribers see it, a sighting of a
+// sensitive tiger only carries its coarsened coordinate
+func publicSighting(sighting *model.Sighting, tiger *model.Tiger) *model.Sighting {
+	if tiger == nil || !tiger.Sensitive {
+		return sighting
+	}
+	public := *sighting
+	public.LastSeenCoordinate = export.CoarsenCoordinate(sighting.LastSeenCoordinate)
+	return &public
+}
+
+// publicTiger returns the tiger as users see it, a sensitive tiger only
+// carries its coarsened last seen coordinate
+func publicTiger(tiger *model.Tiger) *model.Tiger {
+	if tiger == nil || !tiger.Sensitive {
+		return tiger
+	}
+	public := *tiger
+	public.LastSeenCoordinate = export.CoarsenCoordinate(tiger.LastSeenCoordinate)
+	return &public
+}
+
+// publicTigers returns the tigers as users see them
+func publicTigers(tigers []*model.Tiger) []*model.Tiger {
+	public := make([]*model.Tiger, 0, len(tigers))
+	for _, tiger := range tigers {
+		public = append(public, publicTiger(tiger))
+	}
+	return public
+}
+
+// matchWatchZones returns the watch zones a point is inside of. The index
+// on the zones' bounding boxes narrows them down to a few candidates, only
+// those are tested against their exact shape.
+func (s *sightingService) matchWatchZones(ctx context.Context, point *model.LastSeenCoordinate) ([]*model.WatchZone, error) {
+	candidates, err := s.watchZoneRepo.ListWatchZonesAround(ctx, point)
+	if err != nil {
+		logger.Logger(ctx).Error("Unexpected error listing watch zones: ", err)
+		return nil, helper.NewCustomError("Failed to create sighting", http.StatusInternalServerError)
+	}
+	var zones []*model.WatchZone
+	for _, zone := range candidates {
+		if watchZoneContains(zone, point) {
+			zones = append(zones, zone)
+		}
+	}
+	return zones, nil
+}
+
+// ListUnidentifiedSightings returns the sightings still waiting to be
+// assigned to a tiger, oldest first
+func (s *sightingService) ListUnidentifiedSightings(ctx context.Context, limit int, offset int) ([]*model.Sighting, error) {
+	sightings, err := s.sightingRepo.ListUnidentifiedSightings(ctx, limit, offset)
+	if err != nil {
+		logger.Logger(ctx).Error("Failed to list unidentified sightings:", err)
+		return nil, helper.NewCustomError("Failed to list sightings", http.StatusInternalServerError)
+	}
+	return sightings, nil
+}
+
+// AssignSighting identifies the tiger of an unidentified sighting. The
+// sighting has to fit the tiger's history the same way a new sighting does,
+// and previous sighters are notified as if it had just been reported.
+func (s *sightingService) AssignSighting(ctx context.Context, sightingID string, tigerID string) (*model.Sighting, error) {
+	sighting, err := s.sightingRepo.GetSightingByID(ctx, sightingID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &helper.SightingNotFoundError{Message: "Sighting not found"}
+		}
+		logger.Logger(ctx).Error("Unexpected error getting sighting by ID: ", err)
+		return nil, helper.NewCustomError("Failed to retrieve sighting by ID", http.StatusInternalServerError)
+	}
+	if sighting.Identified() {
+		return nil, &helper.SightingAlreadyIdentifiedError{Message: "sighting is already assigned to a tiger"}
+	}
+
+	tiger, err := s.getTiger(ctx, tigerID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkAgainstLastSeen(ctx, tiger, sighting.LastSeenTime, sighting.LastSeenCoordinate); err != nil {
+		return nil, err
+	}
+
+	webhook, err := tigerMessage(model.WebhookEventTigerUpdated, tigerSeenAt(tiger, sighting))
+	if err != nil {
+		logger.Logger(ctx).Error("Unexpected error encoding webhook: ", err)
+		return nil, helper.NewCustomError("Failed to assign sighting", http.StatusInternalServerError)
+	}
+	if err := s.sightingRepo.AssignSighting(ctx, sighting, tigerID, webhook); err != nil {
+		// another curator assigned it in the meantime
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &helper.SightingAlreadyIdentifiedError{Message: "sighting is already assigned to a tiger"}
+		}
+		logger.Logger(ctx).Error("Unexpected error assigning sighting: ", err)
+		return nil, helper.NewCustomError("Failed to assign sighting", http.StatusInternalServerError)
+	}
+	sighting.TigerID = &tigerID
+	s.events.PublishSightingNotifications(ctx, sighting.ID)
+
+	return sighting, nil
+}
+
+// sightingWebhooks encodes the webhook messages about a new sighting, and
+// about its tiger being last seen at it when it is identified
+func sightingWebhooks(sighting *model.Sighting, tiger *model.Tiger) ([]*model.WebhookMessage, error) {
+	created, err := sightingCreatedMessage(sighting, tiger)
+	if err != nil {
+		return nil, err
+	}
+	if tiger == nil {
+		return []*model.WebhookMessage{created}, nil
+	}
+	seen, err := tigerMessage(model.WebhookEventTigerUpdated, tigerSeenAt(tiger, sighting))
+	if err != nil {
+		return nil, err
+	}
+	return []*model.WebhookMessage{created, seen}, nil
+}
+
+// tigerSeenAt returns the tiger last seen at the sighting, as the repository
+// stores it with the sighting
+func tigerSeenAt(tiger *model.Tiger, sighting *model.Sighting) *model.Tiger {
+	seen := *tiger
+	seen.LastSeenTime = sighting.LastSeenTime
+	seen.LastSeenCoordinate = sighting.LastSeenCoordinate
+	return &seen
+}
+
+// getTiger looks up a tiger a sighting is reported for
+func (s *sightingService) getTiger(ctx context.Context, tigerID string) (*model.Tiger, error) {
+	tiger, err := s.tigerRepo.GetTigerByID(ctx, tigerID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, &helper.TigerNotFound{Message: "Tiger not found"}
+		}
+		logger.Logger(ctx).Error("Unexpected error getting tiger by ID: ", err)
+		return nil, helper.NewCustomError("Failed to retrieve tiger by ID", http.StatusInternalServerError)
+	}
+	return tiger, nil
+}
+
+// checkAgainstLastSeen checks that a sighting of tiger comes after its last
+// known sighting and far enough from it to be a new one
+func (s *sightingService) checkAgainstLastSeen(ctx context.Context, tiger *model.Tiger, seenAt time.Time,
+	coordinate *model.LastSeenCoordinate) error {
+	sighting, err := s.sightingRepo.GetLatestSightingByTigerID(ctx, tiger.ID)
+	if err != nil {
+		if err != gorm.ErrRecordNotFound {
+			logger.Logger(ctx).Error("Unexpected error getting tiger by ID: ", err)
+			return helper.NewCustomError("Failed to retrieve tiger by ID", http.StatusInternalServerError)
+		}
+	}
+
 	var lastSeenTime time.Time
 	var lastSeenCoordinate *model.LastSeenCoordinate
 	if sighting == nil {
@@ -91,81 +384,515 @@ func (s *sightingService) CreateSighting(ctx context.Context, input *model.Sight
 		lastSeenCoordinate = sighting.LastSeenCoordinate
 	}
 
-	if input.LastSeenTime.Before(lastSeenTime) || input.LastSeenTime.Equal(lastSeenTime) {
-		return nil, &helper.InvalidLastSeenTimeError{
+	if seenAt.Before(lastSeenTime) || seenAt.Equal(lastSeenTime) {
+		return &helper.InvalidLastSeenTimeError{
 			Message: "your time sighting could not before last time seen recorded",
 		}
 	}
 
-	distance := calculateDistance((*model.LastSeenCoordinate)(input.LastSeenCoordinate), lastSeenCoordinate)
+	distance := calculateDistance(coordinate, lastSeenCoordinate)
 	if distance < 5000 {
-		return nil, &helper.SightingTooCloseError{
+		return &helper.SightingTooCloseError{
 			Message: fmt.Sprintf("new sighting is too close to the last known location (%.2f meters)", distance),
 		}
 	}
+	return nil
+}
 
-	imagePath, err := s.GetResizedImage(ctx, input.Image)
+// StoreImage saves the original of a validated upload in the blob store.
+// Metadata is stripped from it, so the reporter's device details and exact
+// location are not kept. Renditions are rendered later by the
+// ImageProcessor. It returns the key of the original, which the sighting
+// image keeps instead of the image itself.
+func (s *sightingService) StoreImage(ctx context.Context, sightingID string, imageID string, upload *imaging.Upload) (string, error) {
+	original, err := imaging.StripMetadata(upload.Data, upload.Format)
 	if err != nil {
-		logger.Logger(ctx).Error(ctx, "Fail when resizing image", "error", err)
+		return "", &helper.InvalidImageError{Message: "image structure is corrupt"}
 	}
 
-	newSighting := &model.Sighting{
-		ID:                 uuid.NewString(),
-		TigerID:            input.TigerID,
-		LastSeenTime:       input.LastSeenTime,
-		Image:              &imagePath,
-		LastSeenCoordinate: (*model.LastSeenCoordinate)(input.LastSeenCoordinate),
+	key := fmt.Sprintf("sightings/%s/%s/original.%s", sightingID, imageID, upload.Format)
+	if err := s.blobStore.Put(ctx, key, bytes.NewReader(original), int64(len(original)), upload.ContentType); err != nil {
+		logger.Logger(ctx).Error(ctx, "Error storing image", "error", err)
+		return "", fmt.Errorf("error storing image: %v", err)
 	}
+	return key, nil
+}
 
-	if err := s.sightingRepo.CreateSighting(ctx, newSighting); err != nil {
-		logger.Logger(ctx).Error("Unexpected error creating sighting: ", err)
-		return nil, helper.NewCustomError("Failed to create sighting", http.StatusInternalServerError)
+// deleteStoredImages removes the originals of a sighting that was not
+// created, nothing would ever refer to them. A failed delete only leaves the
+// blob behind, so it is logged.
+func (s *sightingService) deleteStoredImages(ctx context.Context, images []*model.SightingImage) {
+	// the request may have failed for being cancelled
+	ctx = context.WithoutCancel(ctx)
+	for _, image := range images {
+		if err := s.blobStore.Delete(ctx, image.Key); err != nil {
+			logger.Logger(ctx).Error("Failed to delete image of unsaved sighting:", image.Key, err)
+		}
 	}
+}
 
-	// Create a notification message\
-	notification := model.Notification{
-		TigerID:    newSighting.TigerID,
-		SightingID: newSighting.ID,
-		Timestamp:  time.Now(),
+// ImageURL returns a signed, expiring address the image stored under key can
+// be downloaded from in the requested size, or nil when there is no image.
+func (s *sightingService) ImageURL(key string, size model.ImageSize) *string {
+	if key == "" {
+		return nil
 	}
+	url := signedImageURL(s.urlSigner, key, size, s.imageURLTTL)
+	return &url
+}
 
-	// Send the notification
-	NotificationChan <- notification
+// signedImageURL returns the address of an image in the requested size that
+// stays valid for ttl
+func signedImageURL(signer *urlsign.Signer, key string, size model.ImageSize, ttl time.Duration) string {
+	if size != model.ImageSizeOriginal {
+		key = renditionKey(key, strings.ToLower(string(size)))
+	}
+	return signer.SignedURL(ImagePath(key), ttl)
+}
 
-	return newSighting, nil
+// SightingImageURL returns the address of a sighting image in the requested
+// size. Until its renditions are ready the original is served instead.
+func (s *sightingService) SightingImageURL(image *model.SightingImage, size model.ImageSize) string {
+	if image.Status != model.ImageStatusReady {
+		size = model.ImageSizeOriginal
+	}
+	return *s.ImageURL(image.Key, size)
 }
 
-func (s *sightingService) GetResizedImage(ctx context.Context, inputImage *graphql.Upload) (string, error) {
-	imageData, readErr := io.ReadAll(inputImage.File)
-	if readErr != nil {
-		logger.Logger(ctx).Error(ctx, "readErr", readErr)
+// CoverImageURL returns the address of the first image of a sighting in the
+// requested size, or nil when the sighting has no image
+func (s *sightingService) CoverImageURL(ctx context.Context, sighting *model.Sighting, size model.ImageSize) (*string, error) {
+	if sighting.ImageKey == "" {
+		return nil, nil
+	}
+	images, err := s.ListSightingImages(ctx, sighting)
+	if err != nil {
+		return nil, err
+	}
+	// sightings stored before they could have several images only have a key
+	if len(images) == 0 {
+		return s.ImageURL(sighting.ImageKey, size), nil
+	}
+	url := s.SightingImageURL(images[0], size)
+	return &url, nil
+}
+
+// ImageStatus sums up the processing status of the images of a sighting. It
+// is processing while any image is, then pending while any image waits, then
+// failed if any image failed, and ready otherwise. Sightings without images
+// have no status.
+func (s *sightingService) ImageStatus(ctx context.Context, sighting *model.Sighting) (*model.ImageStatus, error) {
+	images, err := s.ListSightingImages(ctx, sighting)
+	if err != nil {
+		return nil, err
+	}
+	if len(images) == 0 {
+		return nil, nil
+	}
+	statuses := make(map[model.ImageStatus]bool, len(images))
+	for _, image := range images {
+		statuses[image.Status] = true
+	}
+	for _, status := range []model.ImageStatus{model.ImageStatusProcessing, model.ImageStatusPending, model.ImageStatusFailed} {
+		if statuses[status] {
+			return &status, nil
+		}
+	}
+	status := model.ImageStatusReady
+	return &status, nil
+}
+
+// ListSightingImages returns the images of a sighting in order
+func (s *sightingService) ListSightingImages(ctx context.Context, sighting *model.Sighting) ([]*model.SightingImage, error) {
+	// a sighting that was just created already carries its images
+	if sighting.Images != nil {
+		return sighting.Images, nil
+	}
+	images, err := s.sightingImageRepo.ListSightingImages(ctx, sighting.ID)
+	if err != nil {
+		logger.Logger(ctx).Error("Failed to list sighting images:", err)
+		return nil, helper.NewCustomError("Failed to list sighting images", http.StatusInternalServerError)
+	}
+	return images, nil
+}
+
+// ListTigerPhotos pages through the photos of all of a tiger's sightings,
+// newest sighting first. after is the cursor of the last photo of the
+// previous page.
+func (s *sightingService) ListTigerPhotos(ctx context.Context, tigerID string, first int, after *string) (*model.PhotoConnection, error) {
+	if first <= 0 || first > maxPhotosPerPage {
+		return nil, &helper.InvalidPaginationError{
+			Message: fmt.Sprintf("first must be between 1 and %d", maxPhotosPerPage),
+		}
+	}
+	var cursor *model.PhotoCursor
+	if after != nil {
+		var err error
+		if cursor, err = decodePhotoCursor(*after); err != nil {
+			return nil, &helper.InvalidPaginationError{Message: "invalid cursor"}
+		}
+	}
+
+	// one extra photo tells whether there is a next page
+	images, err := s.sightingImageRepo.ListTigerPhotos(ctx, tigerID, cursor, first+1)
+	if err != nil {
+		logger.Logger(ctx).Error("Failed to list tiger photos:", err)
+		return nil, helper.NewCustomError("Failed to list tiger photos", http.StatusInternalServerError)
+	}
+
+	connection := &model.PhotoConnection{
+		Edges:    make([]*model.PhotoEdge, 0, first),
+		PageInfo: &model.PageInfo{HasNextPage: len(images) > first},
+	}
+	if len(images) > first {
+		images = images[:first]
+	}
+	for _, image := range images {
+		connection.Edges = append(connection.Edges, &model.PhotoEdge{
+			Cursor: encodePhotoCursor(image),
+			Node:   image,
+		})
+	}
+	if len(connection.Edges) > 0 {
+		connection.PageInfo.EndCursor = &connection.Edges[len(connection.Edges)-1].Cursor
+	}
+	return connection, nil
+}
+
+// SuggestTigers ranks known tigers by how much the stripes on their photos
+// look like the uploaded one. When near is given, tigers last seen close to
+// it rank higher. Photos whose features are not extracted yet are ignored.
+func (s *sightingService) SuggestTigers(ctx context.Context, upload graphql.Upload, near *model.LastSeenCoordinateInput,
+	limit int) ([]*model.TigerSuggestion, error) {
+	if limit <= 0 || limit > maxTigerSuggestions {
+		return nil, &helper.InvalidPaginationError{
+			Message: fmt.Sprintf("limit must be between 1 and %d", maxTigerSuggestions),
+		}
+	}
+	if near != nil && (!isValidLatitude(near.Latitude) || !isValidLongitude(near.Longitude)) {
+		return nil, &helper.InvalidCoordinatesError{
+			Message: "latitude must be between -90 and 90, longitude between -180 and 180",
+		}
+	}
+	photo, err := imaging.ReadUpload(upload.File, s.uploadLimits)
+	if err != nil {
+		return nil, imageFailure(ctx, err)
+	}
+	img, err := photo.Decode()
+	if err != nil {
+		return nil, invalidImage(err)
+	}
+	features := imaging.StripeFeatures(imaging.Orient(img, imaging.ReadExif(photo.Data).Orientation))
+
+	var box *model.BoundingBox
+	if near != nil {
+		box = boundingBoxAround((*model.LastSeenCoordinate)(near), maxSearchRadius)
+	}
+	images, err := s.sightingImageRepo.ListImageFeatures(ctx, box, suggestionPhotosPerTiger, maxSuggestionCandidates)
+	if err != nil {
+		logger.Logger(ctx).Error("Failed to list image features:", err)
+		return nil, helper.NewCustomError("Failed to suggest tigers", http.StatusInternalServerError)
+	}
+	// every tiger is represented by its photo that looks the most alike
+	best := map[string]*model.TigerSuggestion{}
+	for _, image := range images {
+		stored, err := imaging.DecodeFeatures(image.Features)
+		if err != nil {
+			continue
+		}
+		similarity := imaging.Similarity(features, stored)
+		if current, ok := best[*image.TigerID]; !ok || similarity > current.Similarity {
+			image.Features = nil
+			best[*image.TigerID] = &model.TigerSuggestion{Similarity: similarity, MatchedImage: image}
+		}
+	}
+	if len(best) == 0 {
+		return []*model.TigerSuggestion{}, nil
+	}
+
+	ids := make([]string, 0, len(best))
+	for id := range best {
+		ids = append(ids, id)
+	}
+	tigers, err := s.tigerRepo.ListTigersByIDs(ctx, ids)
+	if err != nil {
+		logger.Logger(ctx).Error("Failed to list suggested tigers:", err)
+		return nil, helper.NewCustomError("Failed to suggest tigers", http.StatusInternalServerError)
+	}
+	suggestions := make([]*model.TigerSuggestion, 0, len(tigers))
+	for _, tiger := range tigers {
+		suggestion := best[tiger.ID]
+		suggestion.Tiger = tiger
+		suggestion.Score = suggestion.Similarity
+		if near != nil {
+			distance := calculateDistance((*model.LastSeenCoordinate)(near), tiger.LastSeenCoordinate)
+			proximity := math.Pow(0.5, distance/suggestionProximityHalving)
+			suggestion.Distance = &distance
+			suggestion.Score = suggestionSimilarityWeight*suggestion.Similarity + (1-suggestionSimilarityWeight)*proximity
+		}
+		suggestions = append(suggestions, suggestion)
+	}
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		if suggestions[i].Score != suggestions[j].Score {
+			return suggestions[i].Score > suggestions[j].Score
+		}
+		return suggestions[i].Tiger.ID < suggestions[j].Tiger.ID
+	})
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions, nil
+}
+
+func (s *sightingService) ListSightingsNear(ctx context.Context, point *model.LastSeenCoordinateInput, radiusMeters float64,
+	timeRange *model.TimeRangeInput, limit int) ([]*model.NearbySighting, error) {
+	if !isValidLatitude(point.Latitude) || !isValidLongitude(point.Longitude) {
+		return nil, &helper.InvalidCoordinatesError{
+			Message: "latitude must be between -90 and 90, longitude between -180 and 180",
+		}
+	}
+	if radiusMeters <= 0 || radiusMeters > maxSearchRadius {
+		return nil, &helper.InvalidRadiusError{
+			Message: fmt.Sprintf("radius must be greater than 0 and at most %.0f meters", maxSearchRadius),
+		}
+	}
+
+	center := (*model.LastSeenCoordinate)(point)
+	sightings, err := s.sightingRepo.ListSightingsInBox(ctx, boundingBoxAround(center, radiusMeters), center, timeRange, limit)
+	if err != nil {
+		logger.Logger(ctx).Error("Failed to list sightings near point:", err)
+		return nil, helper.NewCustomError("Failed to list sightings", http.StatusInternalServerError)
+	}
 
-		fmt.Printf("error from file %v", readErr)
+	nearby := make([]*model.NearbySighting, 0, len(sightings))
+	for _, sighting := range sightings {
+		distance := calculateDistance(center, sighting.LastSeenCoordinate)
+		if distance > radiusMeters {
+			continue
+		}
+		nearby = append(nearby, &model.NearbySighting{Sighting: sighting, Distance: distance})
+	}
+	nearby = sortNearbySightings(nearby, limit)
+	if err := s.coarsenNearbySightings(ctx, center, nearby); err != nil {
+		logger.Logger(ctx).Error("Failed to get tigers of sightings near point:", err)
+		return nil, helper.NewCustomError("Failed to list sightings", http.StatusInternalServerError)
+	}
+	return nearby, nil
+}
+
+func (s *sightingService) ListSightingsInBox(ctx context.Context, box *model.BoundingBox, timeRange *model.TimeRangeInput,
+	limit int) ([]*model.NearbySighting, error) {
+	if !isValidBoundingBox(box) {
+		return nil, &helper.InvalidCoordinatesError{
+			Message: "south-west and north-east corners must be valid coordinates with the south-west latitude not above the north-east latitude",
+		}
 	}
 
-	img, format, err := image.Decode(bytes.NewReader(imageData))
+	center := boxCenter(box)
+	sightings, err := s.sightingRepo.ListSightingsInBox(ctx, box, center, timeRange, limit)
 	if err != nil {
-		log.Printf("Error decoding image")
+		logger.Logger(ctx).Error("Failed to list sightings in box:", err)
+		return nil, helper.NewCustomError("Failed to list sightings", http.StatusInternalServerError)
+	}
+
+	nearby := make([]*model.NearbySighting, 0, len(sightings))
+	for _, sighting := range sightings {
+		nearby = append(nearby, &model.NearbySighting{
+			Sighting: sighting,
+			Distance: calculateDistance(center, sighting.LastSeenCoordinate),
+		})
+	}
+	nearby = sortNearbySightings(nearby, limit)
+	if err := s.coarsenNearbySightings(ctx, center, nearby); err != nil {
+		logger.Logger(ctx).Error("Failed to get tigers of sightings in box:", err)
+		return nil, helper.NewCustomError("Failed to list sightings", http.StatusInternalServerError)
+	}
+	return nearby, nil
+}
 
-		return "", fmt.Errorf("error decoding image: %v got format %v", err, format)
+func (s *sightingService) GetTigerTrack(ctx context.Context, tigerID string, timeRange *model.TimeRangeInput) (*model.TigerTrack, error) {
+	tiger, err := s.tigerRepo.GetTigerByID(ctx, tigerID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &helper.TigerNotFound{Message: "Tiger not found"}
+		}
+		logger.Logger(ctx).Error("Unexpected error getting tiger by ID: ", err)
+		return nil, helper.NewCustomError("Failed to retrieve tiger by ID", http.StatusInternalServerError)
 	}
-	resizedImage := resize.Resize(250, 200, img, resize.Lanczos3)
 
-	// Encode resized image to base64
-	buf := new(bytes.Buffer)
-	err = jpeg.Encode(buf, resizedImage, nil)
+	sightings, err := s.sightingRepo.ListSightingsForTrack(ctx, tigerID, timeRange)
 	if err != nil {
-		logger.Logger(ctx).Error(ctx, "Error encoding image", "error", err)
-		return "", fmt.Errorf("error encoding image: %v", err)
+		logger.Logger(ctx).Error("Failed to list sightings for track:", err)
+		return nil, helper.NewCustomError("Failed to list sightings", http.StatusInternalServerError)
 	}
 
-	// Return the base64 encoded string
-	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+	// the distance is summed over the exact positions before a sensitive
+	// tiger's are coarsened
+	var totalDistance float64
+	for i := 1; i < len(sightings); i++ {
+		totalDistance += calculateDistance(sightings[i-1].LastSeenCoordinate, sightings[i].LastSeenCoordinate)
+	}
+	public := make([]*model.Sighting, 0, len(sightings))
+	for _, sighting := range sightings {
+		public = append(public, publicSighting(sighting, tiger))
+	}
+
+	return &model.TigerTrack{
+		Tiger:         publicTiger(tiger),
+		Sightings:     public,
+		TotalDistance: totalDistance,
+	}, nil
+}
+
+// ImagePath returns the route a stored image is served from
+func ImagePath(key string) string {
+	return "/images/" + key
+}
+
+// invalidImage turns an upload validation failure into the error reported to
+// clients, other errors are passed through
+func invalidImage(err error) error {
+	var validationErr *imaging.ValidationError
+	if errors.As(err, &validationErr) {
+		return &helper.InvalidImageError{Message: validationErr.Message}
+	}
+	return err
+}
+
+// imageFailure returns the error CreateSighting reports when an image cannot
+// be read or stored. Rejected uploads keep their reason.
+func imageFailure(ctx context.Context, err error) error {
+	if invalid, ok := invalidImage(err).(*helper.InvalidImageError); ok {
+		return invalid
+	}
+	logger.Logger(ctx).Error(ctx, "Fail when storing image", "error", err)
+	return helper.NewCustomError("Failed to store image", http.StatusInternalServerError)
+}
+
+// applyExif fills in the time and location the reporter left out from the
+// photo's EXIF data. When the reporter entered them and they disagree with
+// the photo beyond the tolerances, it returns why the sighting is flagged.
+func applyExif(input *model.SightingInput, exif *imaging.Exif) []string {
+	var reasons []string
+	if exif.HasGPS && isValidLatitude(exif.Latitude) && isValidLongitude(exif.Longitude) {
+		photo := &model.LastSeenCoordinate{Latitude: exif.Latitude, Longitude: exif.Longitude}
+		if input.LastSeenCoordinate == nil {
+			input.LastSeenCoordinate = (*model.LastSeenCoordinateInput)(photo)
+		} else if distance := calculateDistance((*model.LastSeenCoordinate)(input.LastSeenCoordinate), photo); distance > exifDistanceTolerance {
+			reasons = append(reasons, fmt.Sprintf("reported location is %.0f meters from where the photo was taken", distance))
+		}
+	}
+	if exif.DateTimeOriginal != nil {
+		if input.LastSeenTime == nil {
+			input.LastSeenTime = exif.DateTimeOriginal
+		} else {
+			tolerance := exifTimeTolerance
+			if !exif.TimeZoneKnown {
+				tolerance += maxTimeZoneOffset
+			}
+			if diff := input.LastSeenTime.Sub(*exif.DateTimeOriginal).Abs(); diff > tolerance {
+				reasons = append(reasons, fmt.Sprintf("reported time is %s away from when the photo was taken", diff.Round(time.Minute)))
+			}
+		}
+	}
+	return reasons
+}
+
+// caption returns the caption given for the image at position i, if any
+func caption(captions []*string, i int) *string {
+	if i >= len(captions) || captions[i] == nil || strings.TrimSpace(*captions[i]) == "" {
+		return nil
+	}
+	return captions[i]
+}
+
+func encodePhotoCursor(image *model.SightingImage) string {
+	data, _ := json.Marshal(model.PhotoCursor{
+		TakenAt:    image.TakenAt,
+		SightingID: image.SightingID,
+		Position:   image.Position,
+	})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodePhotoCursor(cursor string) (*model.PhotoCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, err
+	}
+	var decoded model.PhotoCursor
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, err
+	}
+	return &decoded, nil
+}
+
+// renditionKey returns the key of a rendition stored next to the original.
+// Images stored before renditions existed have a single key for every size.
+func renditionKey(originalKey string, name string) string {
+	dir, file := path.Split(originalKey)
+	if !strings.HasPrefix(file, "original.") {
+		return originalKey
+	}
+	return dir + name + ".jpg"
+}
+
+// coarsenNearbySightings replaces the sightings of sensitive tigers with their
+// public copy once they were ranked by their exact distance. Their distance
+// is measured to the coarsened coordinate so it does not give the exact one
+// away.
+func (s *sightingService) coarsenNearbySightings(ctx context.Context, center *model.LastSeenCoordinate,
+	nearby []*model.NearbySighting) error {
+	var ids []string
+	seen := make(map[string]bool)
+	for _, n := range nearby {
+		if n.Sighting.TigerID != nil && !seen[*n.Sighting.TigerID] {
+			seen[*n.Sighting.TigerID] = true
+			ids = append(ids, *n.Sighting.TigerID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	tigers, err := s.tigerRepo.ListTigersByIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+	sensitive := make(map[string]*model.Tiger)
+	for _, tiger := range tigers {
+		if tiger.Sensitive {
+			sensitive[tiger.ID] = tiger
+		}
+	}
+	for _, n := range nearby {
+		if n.Sighting.TigerID == nil || sensitive[*n.Sighting.TigerID] == nil {
+			continue
+		}
+		n.Sighting = publicSighting(n.Sighting, sensitive[*n.Sighting.TigerID])
+		n.Distance = calculateDistance(center, n.Sighting.LastSeenCoordinate)
+	}
+	return nil
+}
+
+// sortNearbySightings orders sightings from the closest to the farthest and
+// keeps at most limit of them.
+func sortNearbySightings(nearby []*model.NearbySighting, limit int) []*model.NearbySighting {
+	return sortByDistance(nearby, func(n *model.NearbySighting) float64 { return n.Distance }, limit)
+}
+
+// sortByDistance orders results from the closest to the farthest and keeps at
+// most limit of them. A negative limit keeps every result.
+func sortByDistance[T any](nearby []T, distance func(T) float64, limit int) []T {
+	sort.SliceStable(nearby, func(i, j int) bool {
+		return distance(nearby[i]) < distance(nearby[j])
+	})
+	if limit >= 0 && len(nearby) > limit {
+		nearby = nearby[:limit]
+	}
+	return nearby
 }
 
 func calculateDistance(coord1, coord2 *model.LastSeenCoordinate) float64 {
-	// Earth radius in meters
-	const EarthRadius = 6371000
 	// Convert latitude and longitude from degrees to radians
 	lat1 := degreesToRadians(coord1.Latitude)
 	lon1 := degreesToRadians(coord1.Longitude)
@@ -177,7 +904,7 @@ func calculateDistance(coord1, coord2 *model.LastSeenCoordinate) float64 {
 	dlat := lat2 - lat1
 	a := math.Pow(math.Sin(dlat/2), 2) + math.Cos(lat1)*math.Cos(lat2)*math.Pow(math.Sin(dlon/2), 2)
 	c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
-	distance := EarthRadius * c
+	distance := earthRadius * c
 	return distance
 }
 
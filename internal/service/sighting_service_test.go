@@ -1,9 +1,16 @@
 package service
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"net/url"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -12,6 +19,12 @@ import (
 	"github.com/nurcholisnanda/tigerhall-kittens/internal/api/graph/model"
 	"github.com/nurcholisnanda/tigerhall-kittens/internal/repository"
 	mockRepo "github.com/nurcholisnanda/tigerhall-kittens/internal/repository/mock"
+	mockService "github.com/nurcholisnanda/tigerhall-kittens/internal/service/mock"
+	"github.com/nurcholisnanda/tigerhall-kittens/pkg/helper"
+	"github.com/nurcholisnanda/tigerhall-kittens/pkg/imaging"
+	"github.com/nurcholisnanda/tigerhall-kittens/pkg/storage"
+	mockStorage "github.com/nurcholisnanda/tigerhall-kittens/pkg/storage/mock"
+	"github.com/nurcholisnanda/tigerhall-kittens/pkg/urlsign"
 	"go.uber.org/mock/gomock"
 	"gorm.io/gorm"
 )
@@ -20,9 +33,18 @@ func TestNewSightingService(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	sightingRepo := mockRepo.NewMockSightingRepository(ctrl)
 	tigerRepo := mockRepo.NewMockTigerRepository(ctrl)
+	sightingImageRepo := mockRepo.NewMockSightingImageRepository(ctrl)
+	blobStore := mockStorage.NewMockBlobStore(ctrl)
+	urlSigner := urlsign.NewSigner("secret", "http://localhost:8080")
+	imageProcessor := mockService.NewMockImageProcessor(ctrl)
+	watchZoneRepo := mockRepo.NewMockWatchZoneRepository(ctrl)
+	events := mockService.NewMockEventBroker(ctrl)
 	type args struct {
-		sightingRepo repository.SightingRepository
-		tigerRepo    repository.TigerRepository
+		sightingRepo      repository.SightingRepository
+		tigerRepo         repository.TigerRepository
+		sightingImageRepo repository.SightingImageRepository
+		blobStore         storage.BlobStore
+		urlSigner         *urlsign.Signer
 	}
 	tests := []struct {
 		name string
@@ -32,15 +54,18 @@ func TestNewSightingService(t *testing.T) {
 		{
 			name: "success",
 			args: args{
-				sightingRepo: sightingRepo,
-				tigerRepo:    tigerRepo,
+				sightingRepo:      sightingRepo,
+				tigerRepo:         tigerRepo,
+				sightingImageRepo: sightingImageRepo,
+				blobStore:         blobStore,
+				urlSigner:         urlSigner,
 			},
-			want: NewSightingService(sightingRepo, tigerRepo),
+			want: NewSightingService(sightingRepo, tigerRepo, sightingImageRepo, blobStore, urlSigner, time.Hour, testLimits, imageProcessor, watchZoneRepo, events),
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := NewSightingService(tt.args.sightingRepo, tt.args.tigerRepo); !reflect.DeepEqual(got, tt.want) {
+			if got := NewSightingService(tt.args.sightingRepo, tt.args.tigerRepo, tt.args.sightingImageRepo, tt.args.blobStore, tt.args.urlSigner, time.Hour, testLimits, imageProcessor, watchZoneRepo, events); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("NewSightingService() = %v, want %v", got, tt.want)
 			}
 		})
@@ -51,6 +76,25 @@ func Test_sightingService_CreateSighting(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	sightingRepo := mockRepo.NewMockSightingRepository(ctrl)
 	tigerRepo := mockRepo.NewMockTigerRepository(ctrl)
+	watchZoneRepo := mockRepo.NewMockWatchZoneRepository(ctrl)
+	events := mockService.NewMockEventBroker(ctrl)
+	blobStore := mockStorage.NewMockBlobStore(ctrl)
+	photo := func() *graphql.Upload {
+		return &graphql.Upload{File: bytes.NewReader(testPNG(t, 50, 40))}
+	}
+	// the keys the originals of a test were stored under
+	var stored []string
+	put := func(_ context.Context, key string, _ io.Reader, _ int64, _ string) error {
+		stored = append(stored, key)
+		return nil
+	}
+	deleted := func(_ context.Context, key string) error {
+		if len(stored) == 0 || stored[0] != key {
+			t.Errorf("sightingService.CreateSighting() deleted %v, want one of %v", key, stored)
+		}
+		stored = stored[1:]
+		return nil
+	}
 	type fields struct {
 		sightingRepo repository.SightingRepository
 		tigerRepo    repository.TigerRepository
@@ -76,7 +120,7 @@ func Test_sightingService_CreateSighting(t *testing.T) {
 			args: args{
 				ctx: context.Background(),
 				input: &model.SightingInput{
-					TigerID: uuid.NewString(),
+					TigerID: ptr(uuid.NewString()),
 				},
 			},
 			want:    nil,
@@ -94,7 +138,7 @@ func Test_sightingService_CreateSighting(t *testing.T) {
 			args: args{
 				ctx: context.Background(),
 				input: &model.SightingInput{
-					TigerID: uuid.NewString(),
+					TigerID: ptr(uuid.NewString()),
 				},
 			},
 			want:    nil,
@@ -112,7 +156,7 @@ func Test_sightingService_CreateSighting(t *testing.T) {
 			args: args{
 				ctx: context.Background(),
 				input: &model.SightingInput{
-					TigerID: uuid.NewString(),
+					TigerID: ptr(uuid.NewString()),
 					LastSeenCoordinate: &model.LastSeenCoordinateInput{
 						Latitude:  25,
 						Longitude: 200,
@@ -134,11 +178,12 @@ func Test_sightingService_CreateSighting(t *testing.T) {
 			args: args{
 				ctx: context.Background(),
 				input: &model.SightingInput{
-					TigerID: uuid.NewString(),
+					TigerID: ptr(uuid.NewString()),
 					LastSeenCoordinate: &model.LastSeenCoordinateInput{
 						Latitude:  25,
 						Longitude: 130,
 					},
+					LastSeenTime: ptr(time.Now().Add(-5 * time.Hour)),
 				},
 			},
 			want:    nil,
@@ -157,19 +202,18 @@ func Test_sightingService_CreateSighting(t *testing.T) {
 			args: args{
 				ctx: context.Background(),
 				input: &model.SightingInput{
-					TigerID: uuid.NewString(),
+					TigerID: ptr(uuid.NewString()),
 					LastSeenCoordinate: &model.LastSeenCoordinateInput{
 						Latitude:  25,
 						Longitude: 130,
 					},
-					LastSeenTime: time.Now().Add(1 * time.Hour),
+					LastSeenTime: ptr(time.Now().Add(1 * time.Hour)),
 				},
 			},
 			want:    nil,
 			wantErr: true,
 			mocks: []*gomock.Call{
 				tigerRepo.EXPECT().GetTigerByID(gomock.Any(), gomock.Any()).Return(&model.Tiger{}, nil),
-				sightingRepo.EXPECT().GetLatestSightingByTigerID(gomock.Any(), gomock.Any()).Return(nil, gorm.ErrRecordNotFound),
 			},
 		},
 		{
@@ -181,12 +225,12 @@ func Test_sightingService_CreateSighting(t *testing.T) {
 			args: args{
 				ctx: context.Background(),
 				input: &model.SightingInput{
-					TigerID: uuid.NewString(),
+					TigerID: ptr(uuid.NewString()),
 					LastSeenCoordinate: &model.LastSeenCoordinateInput{
 						Latitude:  25,
 						Longitude: 130,
 					},
-					LastSeenTime: time.Now().Add(-5 * time.Hour),
+					LastSeenTime: ptr(time.Now().Add(-5 * time.Hour)),
 				},
 			},
 			want:    nil,
@@ -205,90 +249,316 @@ func Test_sightingService_CreateSighting(t *testing.T) {
 			args: args{
 				ctx: context.Background(),
 				input: &model.SightingInput{
-					TigerID: uuid.NewString(),
+					TigerID: ptr(uuid.NewString()),
 					LastSeenCoordinate: &model.LastSeenCoordinateInput{
 						Latitude:  25,
 						Longitude: 130,
 					},
-					LastSeenTime: time.Now().Add(-5 * time.Hour),
+					LastSeenTime: ptr(time.Now().Add(-5 * time.Hour)),
+				},
+			},
+			want:    nil,
+			wantErr: true,
+			mocks: []*gomock.Call{
+				tigerRepo.EXPECT().GetTigerByID(gomock.Any(), gomock.Any()).Return(&model.Tiger{
+					LastSeenCoordinate: &model.LastSeenCoordinate{Latitude: 25, Longitude: 130},
+				}, nil),
+				sightingRepo.EXPECT().GetLatestSightingByTigerID(gomock.Any(), gomock.Any()).Return(nil, gorm.ErrRecordNotFound),
+			},
+		},
+		{
+			name: "should return error if coordinate is missing and there is no image",
+			fields: fields{
+				sightingRepo: sightingRepo,
+				tigerRepo:    tigerRepo,
+			},
+			args: args{
+				ctx: context.Background(),
+				input: &model.SightingInput{
+					TigerID:      ptr(uuid.NewString()),
+					LastSeenTime: ptr(time.Now().Add(-5 * time.Hour)),
+				},
+			},
+			want:    nil,
+			wantErr: true,
+			mocks: []*gomock.Call{
+				tigerRepo.EXPECT().GetTigerByID(gomock.Any(), gomock.Any()).Return(&model.Tiger{}, nil),
+			},
+		},
+		{
+			name: "should return error if there are too many images",
+			fields: fields{
+				sightingRepo: sightingRepo,
+				tigerRepo:    tigerRepo,
+			},
+			args: args{
+				ctx: context.Background(),
+				input: &model.SightingInput{
+					TigerID:      ptr(uuid.NewString()),
+					LastSeenTime: ptr(time.Now().Add(-5 * time.Hour)),
+					Images:       make([]*graphql.Upload, maxImagesPerSighting+1),
+				},
+			},
+			want:    nil,
+			wantErr: true,
+			mocks: []*gomock.Call{
+				tigerRepo.EXPECT().GetTigerByID(gomock.Any(), gomock.Any()).Return(&model.Tiger{}, nil),
+			},
+		},
+		{
+			name: "should return error if image is rejected",
+			fields: fields{
+				sightingRepo: sightingRepo,
+				tigerRepo:    tigerRepo,
+			},
+			args: args{
+				ctx: context.Background(),
+				input: &model.SightingInput{
+					TigerID: ptr(uuid.NewString()),
+					LastSeenCoordinate: &model.LastSeenCoordinateInput{
+						Latitude:  70,
+						Longitude: -140,
+					},
+					LastSeenTime: ptr(time.Now().Add(-5 * time.Hour)),
+					Images:       []*graphql.Upload{{File: strings.NewReader("GIF89a not allowed")}},
+				},
+			},
+			want:    nil,
+			wantErr: true,
+			mocks: []*gomock.Call{
+				tigerRepo.EXPECT().GetTigerByID(gomock.Any(), gomock.Any()).Return(&model.Tiger{
+					LastSeenCoordinate: &model.LastSeenCoordinate{Latitude: 25, Longitude: 130},
+				}, nil),
+			},
+		},
+		{
+			name: "success stores a sighting without a tiger as unidentified",
+			fields: fields{
+				sightingRepo: sightingRepo,
+				tigerRepo:    tigerRepo,
+			},
+			args: args{
+				ctx: context.Background(),
+				input: &model.SightingInput{
+					LastSeenCoordinate: &model.LastSeenCoordinateInput{
+						Latitude:  70,
+						Longitude: -140,
+					},
+					LastSeenTime: ptr(time.Now().Add(-5 * time.Hour)),
+				},
+			},
+			want: &model.Sighting{
+				LastSeenCoordinate: &model.LastSeenCoordinate{
+					Latitude:  70,
+					Longitude: -140,
+				},
+			},
+			mocks: []*gomock.Call{
+				watchZoneRepo.EXPECT().ListWatchZonesAround(gomock.Any(), gomock.Any()).Return(nil, nil),
+				sightingRepo.EXPECT().CreateSighting(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, sighting *model.Sighting, _ []*model.WatchZone, webhooks []*model.WebhookMessage) error {
+						if sighting.Identified() {
+							t.Errorf("sightingService.CreateSighting() stored tiger %v, want none", *sighting.TigerID)
+						}
+						if len(webhooks) != 1 || webhooks[0].Event != model.WebhookEventSightingCreated {
+							t.Errorf("sightingService.CreateSighting() queued webhooks %+v, want sighting.created", webhooks)
+						}
+						return nil
+					}),
+				events.EXPECT().PublishSighting(gomock.Any(), gomock.Any()),
+				events.EXPECT().PublishSightingNotifications(gomock.Any(), gomock.Any()),
+			},
+		},
+		{
+			name: "success publishes the coarsened coordinate of a sensitive tiger",
+			fields: fields{
+				sightingRepo: sightingRepo,
+				tigerRepo:    tigerRepo,
+			},
+			args: args{
+				ctx: context.Background(),
+				input: &model.SightingInput{
+					TigerID: ptr(uuid.NewString()),
+					LastSeenCoordinate: &model.LastSeenCoordinateInput{
+						Latitude:  70.1234,
+						Longitude: -140.5678,
+					},
+					LastSeenTime: ptr(time.Now().Add(-5 * time.Hour)),
+				},
+			},
+			want: &model.Sighting{
+				LastSeenCoordinate: &model.LastSeenCoordinate{
+					Latitude:  70.1234,
+					Longitude: -140.5678,
+				},
+			},
+			mocks: []*gomock.Call{
+				tigerRepo.EXPECT().GetTigerByID(gomock.Any(), gomock.Any()).Return(&model.Tiger{
+					LastSeenCoordinate: &model.LastSeenCoordinate{Latitude: 70, Longitude: -140},
+					Sensitive:          true,
+				}, nil),
+				sightingRepo.EXPECT().GetLatestSightingByTigerID(gomock.Any(), gomock.Any()).Return(nil, gorm.ErrRecordNotFound),
+				watchZoneRepo.EXPECT().ListWatchZonesAround(gomock.Any(), gomock.Any()).Return(nil, nil),
+				sightingRepo.EXPECT().CreateSighting(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, _ *model.Sighting, _ []*model.WatchZone, webhooks []*model.WebhookMessage) error {
+						want := map[string]any{"latitude": 70.1, "longitude": -140.6}
+						for _, webhook := range webhooks {
+							if got := webhookData(t, webhook)["lastSeenCoordinate"]; !reflect.DeepEqual(got, want) {
+								t.Errorf("sightingService.CreateSighting() queued %s at %v, want %v", webhook.Event.Name(), got, want)
+							}
+						}
+						return nil
+					}),
+				events.EXPECT().PublishSighting(gomock.Any(), gomock.Any()).Do(
+					func(_ context.Context, sighting *model.Sighting) {
+						want := &model.LastSeenCoordinate{Latitude: 70.1, Longitude: -140.6}
+						if !reflect.DeepEqual(sighting.LastSeenCoordinate, want) {
+							t.Errorf("sightingService.CreateSighting() published sighting at %v, want %v", sighting.LastSeenCoordinate, want)
+						}
+					}),
+				events.EXPECT().PublishSightingNotifications(gomock.Any(), gomock.Any()),
+			},
+		},
+		{
+			name: "should return error if fail on creating New Sighting",
+			fields: fields{
+				sightingRepo: sightingRepo,
+				tigerRepo:    tigerRepo,
+			},
+			args: args{
+				ctx: context.Background(),
+				input: &model.SightingInput{
+					TigerID: ptr(uuid.NewString()),
+					LastSeenCoordinate: &model.LastSeenCoordinateInput{
+						Latitude:  70,
+						Longitude: -140,
+					},
+					LastSeenTime: ptr(time.Now().Add(-5 * time.Hour)),
+				},
+			},
+			want:    nil,
+			wantErr: true,
+			mocks: []*gomock.Call{
+				tigerRepo.EXPECT().GetTigerByID(gomock.Any(), gomock.Any()).Return(&model.Tiger{
+					LastSeenCoordinate: &model.LastSeenCoordinate{Latitude: 25, Longitude: 130},
+				}, nil),
+				sightingRepo.EXPECT().GetLatestSightingByTigerID(gomock.Any(), gomock.Any()).Return(nil, gorm.ErrRecordNotFound),
+				watchZoneRepo.EXPECT().ListWatchZonesAround(gomock.Any(), gomock.Any()).Return(nil, nil),
+				sightingRepo.EXPECT().CreateSighting(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("any error")),
+			},
+		},
+		{
+			name: "should delete the stored images if fail on creating New Sighting",
+			fields: fields{
+				sightingRepo: sightingRepo,
+				tigerRepo:    tigerRepo,
+			},
+			args: args{
+				ctx: context.Background(),
+				input: &model.SightingInput{
+					LastSeenCoordinate: &model.LastSeenCoordinateInput{
+						Latitude:  70,
+						Longitude: -140,
+					},
+					LastSeenTime: ptr(time.Now().Add(-5 * time.Hour)),
+					Images:       []*graphql.Upload{photo(), photo()},
+				},
+			},
+			want:    nil,
+			wantErr: true,
+			mocks: []*gomock.Call{
+				blobStore.EXPECT().Put(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), "image/png").DoAndReturn(put).Times(2),
+				watchZoneRepo.EXPECT().ListWatchZonesAround(gomock.Any(), gomock.Any()).Return(nil, nil),
+				sightingRepo.EXPECT().CreateSighting(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("any error")),
+				blobStore.EXPECT().Delete(gomock.Any(), gomock.Any()).DoAndReturn(deleted).Times(2),
+			},
+		},
+		{
+			name: "should delete the stored images if fail on storing a later one",
+			fields: fields{
+				sightingRepo: sightingRepo,
+				tigerRepo:    tigerRepo,
+			},
+			args: args{
+				ctx: context.Background(),
+				input: &model.SightingInput{
+					LastSeenCoordinate: &model.LastSeenCoordinateInput{
+						Latitude:  70,
+						Longitude: -140,
+					},
+					LastSeenTime: ptr(time.Now().Add(-5 * time.Hour)),
+					Images:       []*graphql.Upload{photo(), photo()},
 				},
 			},
 			want:    nil,
 			wantErr: true,
+			mocks: []*gomock.Call{
+				blobStore.EXPECT().Put(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), "image/png").DoAndReturn(put),
+				blobStore.EXPECT().Put(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), "image/png").Return(errors.New("any error")),
+				blobStore.EXPECT().Delete(gomock.Any(), gomock.Any()).DoAndReturn(deleted),
+			},
+		},
+		{
+			name: "success",
+			fields: fields{
+				sightingRepo: sightingRepo,
+				tigerRepo:    tigerRepo,
+			},
+			args: args{
+				ctx: context.Background(),
+				input: &model.SightingInput{
+					TigerID: ptr(uuid.NewString()),
+					LastSeenCoordinate: &model.LastSeenCoordinateInput{
+						Latitude:  70,
+						Longitude: -140,
+					},
+					LastSeenTime: ptr(time.Now().Add(-5 * time.Hour)),
+				},
+			},
+			want: &model.Sighting{
+				LastSeenCoordinate: &model.LastSeenCoordinate{
+					Latitude:  70,
+					Longitude: -140,
+				},
+			},
+			wantErr: false,
 			mocks: []*gomock.Call{
 				tigerRepo.EXPECT().GetTigerByID(gomock.Any(), gomock.Any()).Return(&model.Tiger{
 					LastSeenCoordinate: &model.LastSeenCoordinate{Latitude: 25, Longitude: 130},
 				}, nil),
 				sightingRepo.EXPECT().GetLatestSightingByTigerID(gomock.Any(), gomock.Any()).Return(nil, gorm.ErrRecordNotFound),
+				watchZoneRepo.EXPECT().ListWatchZonesAround(gomock.Any(), gomock.Any()).Return(nil, nil),
+				sightingRepo.EXPECT().CreateSighting(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, _ *model.Sighting, _ []*model.WatchZone, webhooks []*model.WebhookMessage) error {
+						if len(webhooks) != 2 || webhooks[1].Event != model.WebhookEventTigerUpdated {
+							t.Fatalf("sightingService.CreateSighting() queued webhooks %+v, want sighting.created and tiger.updated", webhooks)
+						}
+						want := map[string]any{"latitude": 70.0, "longitude": -140.0}
+						if got := webhookData(t, webhooks[1])["lastSeenCoordinate"]; !reflect.DeepEqual(got, want) {
+							t.Errorf("sightingService.CreateSighting() queued tiger at %v, want the sighting", got)
+						}
+						return nil
+					}),
+				events.EXPECT().PublishSighting(gomock.Any(), gomock.Any()),
+				events.EXPECT().PublishSightingNotifications(gomock.Any(), gomock.Any()),
 			},
 		},
-		// {
-		// 	name: "should return error if fail on creating New Sighting",
-		// 	fields: fields{
-		// 		sightingRepo: sightingRepo,
-		// 		tigerRepo:    tigerRepo,
-		// 	},
-		// 	args: args{
-		// 		ctx: context.Background(),
-		// 		input: &model.SightingInput{
-		// 			TigerID: uuid.NewString(),
-		// 			LastSeenCoordinate: &model.LastSeenCoordinateInput{
-		// 				Latitude:  70,
-		// 				Longitude: -140,
-		// 			},
-		// 			LastSeenTime: time.Now().Add(-5 * time.Hour),
-		// 		},
-		// 	},
-		// 	want:    nil,
-		// 	wantErr: true,
-		// 	mocks: []*gomock.Call{
-		// 		tigerRepo.EXPECT().GetTigerByID(gomock.Any(), gomock.Any()).Return(&model.Tiger{
-		// 			LastSeenCoordinate: &model.LastSeenCoordinate{Latitude: 25, Longitude: 130},
-		// 		}, nil),
-		// 		sightingRepo.EXPECT().GetLatestSightingByTigerID(gomock.Any(), gomock.Any()).Return(nil, gorm.ErrRecordNotFound),
-		// 		sightingRepo.EXPECT().CreateSighting(gomock.Any(), gomock.Any()).Return(errors.New("any error")),
-		// 	},
-		// },
-		// {
-		// 	name: "success",
-		// 	fields: fields{
-		// 		sightingRepo: sightingRepo,
-		// 		tigerRepo:    tigerRepo,
-		// 	},
-		// 	args: args{
-		// 		ctx: context.Background(),
-		// 		input: &model.SightingInput{
-		// 			TigerID: uuid.NewString(),
-		// 			LastSeenCoordinate: &model.LastSeenCoordinateInput{
-		// 				Latitude:  70,
-		// 				Longitude: -140,
-		// 			},
-		// 			LastSeenTime: time.Now().Add(-5 * time.Hour),
-		// 		},
-		// 	},
-		// 	want: &model.Sighting{
-		// 		LastSeenCoordinate: &model.LastSeenCoordinate{
-		// 			Latitude:  70,
-		// 			Longitude: -140,
-		// 		},
-		// 	},
-		// 	wantErr: false,
-		// 	mocks: []*gomock.Call{
-		// 		tigerRepo.EXPECT().GetTigerByID(gomock.Any(), gomock.Any()).Return(&model.Tiger{
-		// 			LastSeenCoordinate: &model.LastSeenCoordinate{Latitude: 25, Longitude: 130},
-		// 		}, nil),
-		// 		sightingRepo.EXPECT().GetLatestSightingByTigerID(gomock.Any(), gomock.Any()).Return(nil, gorm.ErrRecordNotFound),
-		// 		sightingRepo.EXPECT().CreateSighting(gomock.Any(), gomock.Any()).Return(nil),
-		// 	},
-		// },
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			s := &sightingService{
-				sightingRepo: tt.fields.sightingRepo,
-				tigerRepo:    tt.fields.tigerRepo,
+				sightingRepo:  tt.fields.sightingRepo,
+				tigerRepo:     tt.fields.tigerRepo,
+				blobStore:     blobStore,
+				uploadLimits:  testLimits,
+				watchZoneRepo: watchZoneRepo,
+				events:        events,
 			}
 			got, err := s.CreateSighting(tt.args.ctx, tt.args.input)
+			if len(stored) > 0 && got == nil {
+				t.Errorf("sightingService.CreateSighting() left %v behind", stored)
+			}
+			stored = nil
 			if (err != nil) != tt.wantErr {
 				t.Errorf("sightingService.CreateSighting() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -302,55 +572,759 @@ func Test_sightingService_CreateSighting(t *testing.T) {
 	}
 }
 
-func Test_sightingService_GetResizedImage(t *testing.T) {
-	type fields struct {
-		sightingRepo repository.SightingRepository
-		tigerRepo    repository.TigerRepository
+func Test_sightingService_AssignSighting(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	sightingRepo := mockRepo.NewMockSightingRepository(ctrl)
+	tigerRepo := mockRepo.NewMockTigerRepository(ctrl)
+	events := mockService.NewMockEventBroker(ctrl)
+	seenAt := time.Now().Add(-5 * time.Hour)
+	unidentified := func() *model.Sighting {
+		return &model.Sighting{
+			ID:                 "s1",
+			LastSeenTime:       seenAt,
+			LastSeenCoordinate: &model.LastSeenCoordinate{Latitude: 25, Longitude: 130},
+		}
 	}
-	type args struct {
-		ctx       context.Context
-		imageData *graphql.Upload
+	farTiger := &model.Tiger{
+		ID:                 "t1",
+		LastSeenTime:       seenAt.Add(-time.Hour),
+		LastSeenCoordinate: &model.LastSeenCoordinate{Latitude: 26, Longitude: 130},
 	}
 	tests := []struct {
 		name    string
-		fields  fields
-		args    args
-		want    string
-		wantErr bool
+		wantErr error
+		mocks   []*gomock.Call
 	}{
-		// TODO: Add test cases.
+		{
+			name:    "should return error if the sighting does not exist",
+			wantErr: &helper.SightingNotFoundError{},
+			mocks: []*gomock.Call{
+				sightingRepo.EXPECT().GetSightingByID(gomock.Any(), "s1").Return(nil, gorm.ErrRecordNotFound),
+			},
+		},
+		{
+			name:    "should return error if getting the sighting fails",
+			wantErr: &helper.CustomError{},
+			mocks: []*gomock.Call{
+				sightingRepo.EXPECT().GetSightingByID(gomock.Any(), "s1").Return(nil, errors.New("any error")),
+			},
+		},
+		{
+			name:    "should return error if the sighting is already identified",
+			wantErr: &helper.SightingAlreadyIdentifiedError{},
+			mocks: []*gomock.Call{
+				sightingRepo.EXPECT().GetSightingByID(gomock.Any(), "s1").Return(&model.Sighting{ID: "s1", TigerID: ptr("t2")}, nil),
+			},
+		},
+		{
+			name:    "should return error if the tiger does not exist",
+			wantErr: &helper.TigerNotFound{},
+			mocks: []*gomock.Call{
+				sightingRepo.EXPECT().GetSightingByID(gomock.Any(), "s1").Return(unidentified(), nil),
+				tigerRepo.EXPECT().GetTigerByID(gomock.Any(), "t1").Return(nil, gorm.ErrRecordNotFound),
+			},
+		},
+		{
+			name:    "should return error if the sighting is older than the tiger's last sighting",
+			wantErr: &helper.InvalidLastSeenTimeError{},
+			mocks: []*gomock.Call{
+				sightingRepo.EXPECT().GetSightingByID(gomock.Any(), "s1").Return(unidentified(), nil),
+				tigerRepo.EXPECT().GetTigerByID(gomock.Any(), "t1").Return(farTiger, nil),
+				sightingRepo.EXPECT().GetLatestSightingByTigerID(gomock.Any(), "t1").Return(&model.Sighting{
+					LastSeenTime:       seenAt.Add(time.Hour),
+					LastSeenCoordinate: farTiger.LastSeenCoordinate,
+				}, nil),
+			},
+		},
+		{
+			name:    "should return error if the sighting is too close to the tiger's last sighting",
+			wantErr: &helper.SightingTooCloseError{},
+			mocks: []*gomock.Call{
+				sightingRepo.EXPECT().GetSightingByID(gomock.Any(), "s1").Return(unidentified(), nil),
+				tigerRepo.EXPECT().GetTigerByID(gomock.Any(), "t1").Return(&model.Tiger{
+					ID:                 "t1",
+					LastSeenTime:       seenAt.Add(-time.Hour),
+					LastSeenCoordinate: &model.LastSeenCoordinate{Latitude: 25, Longitude: 130.01},
+				}, nil),
+				sightingRepo.EXPECT().GetLatestSightingByTigerID(gomock.Any(), "t1").Return(nil, gorm.ErrRecordNotFound),
+			},
+		},
+		{
+			name:    "should return error if another curator assigned it first",
+			wantErr: &helper.SightingAlreadyIdentifiedError{},
+			mocks: []*gomock.Call{
+				sightingRepo.EXPECT().GetSightingByID(gomock.Any(), "s1").Return(unidentified(), nil),
+				tigerRepo.EXPECT().GetTigerByID(gomock.Any(), "t1").Return(farTiger, nil),
+				sightingRepo.EXPECT().GetLatestSightingByTigerID(gomock.Any(), "t1").Return(nil, gorm.ErrRecordNotFound),
+				sightingRepo.EXPECT().AssignSighting(gomock.Any(), gomock.Any(), "t1", gomock.Any()).Return(gorm.ErrRecordNotFound),
+			},
+		},
+		{
+			name:    "should return error if assigning fails",
+			wantErr: &helper.CustomError{},
+			mocks: []*gomock.Call{
+				sightingRepo.EXPECT().GetSightingByID(gomock.Any(), "s1").Return(unidentified(), nil),
+				tigerRepo.EXPECT().GetTigerByID(gomock.Any(), "t1").Return(farTiger, nil),
+				sightingRepo.EXPECT().GetLatestSightingByTigerID(gomock.Any(), "t1").Return(nil, gorm.ErrRecordNotFound),
+				sightingRepo.EXPECT().AssignSighting(gomock.Any(), gomock.Any(), "t1", gomock.Any()).Return(errors.New("any error")),
+			},
+		},
+		{
+			name: "success",
+			mocks: []*gomock.Call{
+				sightingRepo.EXPECT().GetSightingByID(gomock.Any(), "s1").Return(unidentified(), nil),
+				tigerRepo.EXPECT().GetTigerByID(gomock.Any(), "t1").Return(farTiger, nil),
+				sightingRepo.EXPECT().GetLatestSightingByTigerID(gomock.Any(), "t1").Return(nil, gorm.ErrRecordNotFound),
+				sightingRepo.EXPECT().AssignSighting(gomock.Any(), gomock.Any(), "t1", gomock.Any()).DoAndReturn(
+					func(_ context.Context, _ *model.Sighting, _ string, webhook *model.WebhookMessage) error {
+						data := webhookData(t, webhook)
+						lastSeenTime, _ := time.Parse(time.RFC3339Nano, data["lastSeenTime"].(string))
+						coordinate := data["lastSeenCoordinate"].(map[string]any)
+						if webhook.Event != model.WebhookEventTigerUpdated || !lastSeenTime.Equal(seenAt) || coordinate["latitude"] != 25.0 {
+							t.Errorf("sightingService.AssignSighting() queued %s of tiger last seen %v at %v, want the sighting",
+								webhook.Event.Name(), lastSeenTime, coordinate)
+						}
+						return nil
+					}),
+				events.EXPECT().PublishSightingNotifications(gomock.Any(), "s1"),
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			s := &sightingService{
-				sightingRepo: tt.fields.sightingRepo,
-				tigerRepo:    tt.fields.tigerRepo,
-			}
-			got, err := s.GetResizedImage(tt.args.ctx, tt.args.imageData)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("sightingService.GetResizedImage() error = %v, wantErr %v", err, tt.wantErr)
-				return
+				sightingRepo: sightingRepo,
+				tigerRepo:    tigerRepo,
+				events:       events,
 			}
-			if got != tt.want {
-				t.Errorf("sightingService.GetResizedImage() = %v, want %v", got, tt.want)
+			_, err := s.AssignSighting(context.Background(), "s1", "t1")
+			if reflect.TypeOf(err) != reflect.TypeOf(tt.wantErr) {
+				t.Errorf("sightingService.AssignSighting() error = %v, want %T", err, tt.wantErr)
 			}
 		})
 	}
 }
 
-func Test_calculateDistance(t *testing.T) {
+func ptr[T any](v T) *T {
+	return &v
+}
+
+var testLimits = imaging.Limits{MaxBytes: 1 << 20, MaxWidth: 1000, MaxHeight: 1000}
+
+func Test_sightingService_StoreImage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	blobStore := mockStorage.NewMockBlobStore(ctrl)
+	sightingID := uuid.NewString()
+	imageID := uuid.NewString()
+	prefix := "sightings/" + sightingID + "/" + imageID + "/"
 	type args struct {
-		coord1 *model.LastSeenCoordinate
-		coord2 *model.LastSeenCoordinate
+		ctx    context.Context
+		upload *imaging.Upload
 	}
 	tests := []struct {
-		name string
-		args args
-		want float64
+		name    string
+		args    args
+		want    string
+		wantErr bool
+		mocks   []*gomock.Call
 	}{
-		// TODO: Add test cases.
-	}
-	for _, tt := range tests {
+		{
+			name: "should return error if image structure is corrupt",
+			args: args{
+				ctx:    context.Background(),
+				upload: &imaging.Upload{Data: []byte("not an image"), ContentType: "image/png", Format: "png"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "should return error if storing the original fails",
+			args: args{
+				ctx:    context.Background(),
+				upload: testUpload(t, testPNG(t, 500, 400)),
+			},
+			wantErr: true,
+			mocks: []*gomock.Call{
+				blobStore.EXPECT().Put(gomock.Any(), prefix+"original.png", gomock.Any(), gomock.Any(), "image/png").Return(errors.New("any error")),
+			},
+		},
+		{
+			name: "success stores only the original",
+			args: args{
+				ctx:    context.Background(),
+				upload: testUpload(t, testPNG(t, 500, 400)),
+			},
+			want: prefix + "original.png",
+			mocks: []*gomock.Call{
+				blobStore.EXPECT().Put(gomock.Any(), prefix+"original.png", gomock.Any(), gomock.Any(), "image/png").Return(nil),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &sightingService{
+				blobStore:    blobStore,
+				uploadLimits: testLimits,
+			}
+			got, err := s.StoreImage(tt.args.ctx, sightingID, imageID, tt.args.upload)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("sightingService.StoreImage() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("sightingService.StoreImage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_sightingService_SuggestTigers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	tigerRepo := mockRepo.NewMockTigerRepository(ctrl)
+	sightingImageRepo := mockRepo.NewMockSightingImageRepository(ctrl)
+	photo := testPNG(t, 300, 200)
+	img, _ := testUpload(t, photo).Decode()
+	features := imaging.EncodeFeatures(imaging.StripeFeatures(img))
+	stored := func() []*model.SightingImage {
+		return []*model.SightingImage{
+			{ID: "i1", TigerID: ptr("far"), Features: features},
+			{ID: "i2", TigerID: ptr("near"), Features: features},
+			{ID: "i3", TigerID: ptr("near"), Features: []byte("not extracted properly")},
+		}
+	}
+	tigers := []*model.Tiger{
+		{ID: "far", LastSeenCoordinate: &model.LastSeenCoordinate{Latitude: 10, Longitude: 100}},
+		{ID: "near", LastSeenCoordinate: &model.LastSeenCoordinate{Latitude: -2.5, Longitude: 101.26}},
+	}
+	upload := func(data []byte) graphql.Upload {
+		return graphql.Upload{File: bytes.NewReader(data)}
+	}
+	tests := []struct {
+		name    string
+		upload  graphql.Upload
+		near    *model.LastSeenCoordinateInput
+		limit   int
+		want    []string
+		wantErr error
+		mocks   []*gomock.Call
+	}{
+		{
+			name:    "should return error if limit is out of range",
+			upload:  upload(photo),
+			limit:   0,
+			wantErr: &helper.InvalidPaginationError{},
+		},
+		{
+			name:    "should return error if the location is invalid",
+			upload:  upload(photo),
+			near:    &model.LastSeenCoordinateInput{Latitude: 91},
+			limit:   5,
+			wantErr: &helper.InvalidCoordinatesError{},
+		},
+		{
+			name:    "should return error if the image is rejected",
+			upload:  upload([]byte("not an image")),
+			limit:   5,
+			wantErr: &helper.InvalidImageError{},
+		},
+		{
+			name:    "should return error if listing features fails",
+			upload:  upload(photo),
+			limit:   5,
+			wantErr: &helper.CustomError{},
+			mocks: []*gomock.Call{
+				sightingImageRepo.EXPECT().ListImageFeatures(gomock.Any(), nil, suggestionPhotosPerTiger, maxSuggestionCandidates).Return(nil, errors.New("any error")),
+			},
+		},
+		{
+			name:   "should return no suggestions without extracted features",
+			upload: upload(photo),
+			limit:  5,
+			want:   []string{},
+			mocks: []*gomock.Call{
+				sightingImageRepo.EXPECT().ListImageFeatures(gomock.Any(), nil, suggestionPhotosPerTiger, maxSuggestionCandidates).Return(nil, nil),
+			},
+		},
+		{
+			name:    "should return error if listing tigers fails",
+			upload:  upload(photo),
+			limit:   5,
+			wantErr: &helper.CustomError{},
+			mocks: []*gomock.Call{
+				sightingImageRepo.EXPECT().ListImageFeatures(gomock.Any(), nil, suggestionPhotosPerTiger, maxSuggestionCandidates).Return(stored(), nil),
+				tigerRepo.EXPECT().ListTigersByIDs(gomock.Any(), gomock.Any()).Return(nil, errors.New("any error")),
+			},
+		},
+		{
+			name:   "should rank by similarity alone without a location",
+			upload: upload(photo),
+			limit:  5,
+			want:   []string{"far", "near"},
+			mocks: []*gomock.Call{
+				sightingImageRepo.EXPECT().ListImageFeatures(gomock.Any(), nil, suggestionPhotosPerTiger, maxSuggestionCandidates).Return(stored(), nil),
+				tigerRepo.EXPECT().ListTigersByIDs(gomock.Any(), gomock.Any()).Return(tigers, nil),
+			},
+		},
+		{
+			name:   "should rank tigers last seen nearby higher and keep the limit",
+			upload: upload(photo),
+			near:   &model.LastSeenCoordinateInput{Latitude: -2.5, Longitude: 101.27},
+			limit:  1,
+			want:   []string{"near"},
+			mocks: []*gomock.Call{
+				sightingImageRepo.EXPECT().ListImageFeatures(gomock.Any(), gomock.Not(gomock.Nil()), suggestionPhotosPerTiger, maxSuggestionCandidates).Return(stored(), nil),
+				tigerRepo.EXPECT().ListTigersByIDs(gomock.Any(), gomock.Any()).Return(tigers, nil),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &sightingService{
+				tigerRepo:         tigerRepo,
+				sightingImageRepo: sightingImageRepo,
+				uploadLimits:      testLimits,
+			}
+			got, err := s.SuggestTigers(context.Background(), tt.upload, tt.near, tt.limit)
+			if tt.wantErr != nil {
+				if reflect.TypeOf(err) != reflect.TypeOf(tt.wantErr) {
+					t.Errorf("sightingService.SuggestTigers() error = %T, want %T", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("sightingService.SuggestTigers() error = %v", err)
+			}
+			ids := make([]string, 0, len(got))
+			for _, suggestion := range got {
+				ids = append(ids, suggestion.Tiger.ID)
+				if suggestion.Similarity < 0.99 || suggestion.MatchedImage == nil {
+					t.Errorf("sightingService.SuggestTigers() suggestion = %+v, want the identical photo matched", suggestion)
+				}
+				if (suggestion.Distance != nil) != (tt.near != nil) {
+					t.Errorf("sightingService.SuggestTigers() distance = %v, want one only with a location", suggestion.Distance)
+				}
+			}
+			if !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("sightingService.SuggestTigers() = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
+
+// testUpload validates data the way CreateSighting does
+func testUpload(t *testing.T, data []byte) *imaging.Upload {
+	t.Helper()
+	upload, err := imaging.ReadUpload(bytes.NewReader(data), testLimits)
+	if err != nil {
+		t.Fatalf("imaging.ReadUpload() error = %v", err)
+	}
+	return upload
+}
+
+func Test_applyExif(t *testing.T) {
+	taken := time.Date(2024, 3, 14, 23, 30, 0, 0, time.UTC)
+	photo := &imaging.Exif{
+		DateTimeOriginal: &taken,
+		TimeZoneKnown:    true,
+		HasGPS:           true,
+		Latitude:         -2.5,
+		Longitude:        101.26,
+	}
+	tests := []struct {
+		name           string
+		input          *model.SightingInput
+		exif           *imaging.Exif
+		wantTime       time.Time
+		wantCoordinate *model.LastSeenCoordinateInput
+		wantFlagged    bool
+	}{
+		{
+			name:           "should fill in what the reporter left out",
+			input:          &model.SightingInput{},
+			exif:           photo,
+			wantTime:       taken,
+			wantCoordinate: &model.LastSeenCoordinateInput{Latitude: -2.5, Longitude: 101.26},
+		},
+		{
+			name: "should accept values within the tolerance",
+			input: &model.SightingInput{
+				LastSeenTime:       ptr(taken.Add(30 * time.Minute)),
+				LastSeenCoordinate: &model.LastSeenCoordinateInput{Latitude: -2.505, Longitude: 101.26},
+			},
+			exif:           photo,
+			wantTime:       taken.Add(30 * time.Minute),
+			wantCoordinate: &model.LastSeenCoordinateInput{Latitude: -2.505, Longitude: 101.26},
+		},
+		{
+			name: "should flag a location far from the photo",
+			input: &model.SightingInput{
+				LastSeenTime:       ptr(taken),
+				LastSeenCoordinate: &model.LastSeenCoordinateInput{Latitude: -2.6, Longitude: 101.26},
+			},
+			exif:           photo,
+			wantTime:       taken,
+			wantCoordinate: &model.LastSeenCoordinateInput{Latitude: -2.6, Longitude: 101.26},
+			wantFlagged:    true,
+		},
+		{
+			name: "should flag a time far from the photo",
+			input: &model.SightingInput{
+				LastSeenTime:       ptr(taken.Add(3 * time.Hour)),
+				LastSeenCoordinate: &model.LastSeenCoordinateInput{Latitude: -2.5, Longitude: 101.26},
+			},
+			exif:           photo,
+			wantTime:       taken.Add(3 * time.Hour),
+			wantCoordinate: &model.LastSeenCoordinateInput{Latitude: -2.5, Longitude: 101.26},
+			wantFlagged:    true,
+		},
+		{
+			name: "should allow for the camera time zone when it is unknown",
+			input: &model.SightingInput{
+				LastSeenTime: ptr(taken.Add(7 * time.Hour)),
+			},
+			exif:     &imaging.Exif{DateTimeOriginal: &taken},
+			wantTime: taken.Add(7 * time.Hour),
+		},
+		{
+			name: "should leave the input alone without exif data",
+			input: &model.SightingInput{
+				LastSeenTime: ptr(taken),
+			},
+			exif:     &imaging.Exif{Orientation: 1},
+			wantTime: taken,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reasons := applyExif(tt.input, tt.exif)
+			if (len(reasons) > 0) != tt.wantFlagged {
+				t.Errorf("applyExif() reasons = %v, want flagged %v", reasons, tt.wantFlagged)
+			}
+			if tt.input.LastSeenTime == nil || !tt.input.LastSeenTime.Equal(tt.wantTime) {
+				t.Errorf("applyExif() time = %v, want %v", tt.input.LastSeenTime, tt.wantTime)
+			}
+			if !reflect.DeepEqual(tt.input.LastSeenCoordinate, tt.wantCoordinate) {
+				t.Errorf("applyExif() coordinate = %v, want %v", tt.input.LastSeenCoordinate, tt.wantCoordinate)
+			}
+		})
+	}
+}
+
+func Test_sightingService_ImageURL(t *testing.T) {
+	urlSigner := urlsign.NewSigner("secret", "http://localhost:8080")
+	tests := []struct {
+		name     string
+		key      string
+		size     model.ImageSize
+		wantPath string
+	}{
+		{
+			name: "should return nil if there is no image",
+			key:  "",
+			size: model.ImageSizeMedium,
+		},
+		{
+			name:     "should return the requested rendition",
+			key:      "sightings/a/original.png",
+			size:     model.ImageSizeThumbnail,
+			wantPath: "/images/sightings/a/thumbnail.jpg",
+		},
+		{
+			name:     "should return the original",
+			key:      "sightings/a/original.png",
+			size:     model.ImageSizeOriginal,
+			wantPath: "/images/sightings/a/original.png",
+		},
+		{
+			name:     "should return the single image stored before renditions existed",
+			key:      "sightings/a.jpg",
+			size:     model.ImageSizeLarge,
+			wantPath: "/images/sightings/a.jpg",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &sightingService{
+				urlSigner:   urlSigner,
+				imageURLTTL: time.Hour,
+			}
+			got := s.ImageURL(tt.key, tt.size)
+			if tt.wantPath == "" {
+				if got != nil {
+					t.Errorf("sightingService.ImageURL() = %v, want nil", *got)
+				}
+				return
+			}
+			signed, err := url.Parse(*got)
+			if err != nil || signed.Path != tt.wantPath {
+				t.Fatalf("sightingService.ImageURL() = %v, want a URL for %v", *got, tt.wantPath)
+			}
+			if _, err := urlSigner.Verify(signed.Path, signed.Query().Get("expires"), signed.Query().Get("signature")); err != nil {
+				t.Errorf("sightingService.ImageURL() returned a URL that does not verify: %v", err)
+			}
+		})
+	}
+}
+
+func Test_sightingService_SightingImageURL(t *testing.T) {
+	urlSigner := urlsign.NewSigner("secret", "http://localhost:8080")
+	tests := []struct {
+		name     string
+		status   model.ImageStatus
+		size     model.ImageSize
+		wantPath string
+	}{
+		{
+			name:     "should return the requested rendition once it is ready",
+			status:   model.ImageStatusReady,
+			size:     model.ImageSizeThumbnail,
+			wantPath: "/images/sightings/a/b/thumbnail.jpg",
+		},
+		{
+			name:     "should return the original while renditions are pending",
+			status:   model.ImageStatusPending,
+			size:     model.ImageSizeThumbnail,
+			wantPath: "/images/sightings/a/b/original.png",
+		},
+		{
+			name:     "should return the original if processing failed",
+			status:   model.ImageStatusFailed,
+			size:     model.ImageSizeMedium,
+			wantPath: "/images/sightings/a/b/original.png",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &sightingService{
+				urlSigner:   urlSigner,
+				imageURLTTL: time.Hour,
+			}
+			got := s.SightingImageURL(&model.SightingImage{Key: "sightings/a/b/original.png", Status: tt.status}, tt.size)
+			signed, err := url.Parse(got)
+			if err != nil || signed.Path != tt.wantPath {
+				t.Errorf("sightingService.SightingImageURL() = %v, want a URL for %v", got, tt.wantPath)
+			}
+		})
+	}
+}
+
+func Test_sightingService_ImageStatus(t *testing.T) {
+	images := func(statuses ...model.ImageStatus) []*model.SightingImage {
+		images := make([]*model.SightingImage, 0, len(statuses))
+		for _, status := range statuses {
+			images = append(images, &model.SightingImage{Status: status})
+		}
+		return images
+	}
+	tests := []struct {
+		name   string
+		images []*model.SightingImage
+		want   *model.ImageStatus
+	}{
+		{
+			name:   "should return nil for a sighting without images",
+			images: images(),
+		},
+		{
+			name:   "should be processing while any image is",
+			images: images(model.ImageStatusReady, model.ImageStatusPending, model.ImageStatusProcessing),
+			want:   ptr(model.ImageStatusProcessing),
+		},
+		{
+			name:   "should be pending while any image waits",
+			images: images(model.ImageStatusFailed, model.ImageStatusPending),
+			want:   ptr(model.ImageStatusPending),
+		},
+		{
+			name:   "should be failed if any image failed",
+			images: images(model.ImageStatusReady, model.ImageStatusFailed),
+			want:   ptr(model.ImageStatusFailed),
+		},
+		{
+			name:   "should be ready once every image is",
+			images: images(model.ImageStatusReady, model.ImageStatusReady),
+			want:   ptr(model.ImageStatusReady),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &sightingService{}
+			got, err := s.ImageStatus(context.Background(), &model.Sighting{ID: "s1", Images: tt.images})
+			if err != nil {
+				t.Fatalf("sightingService.ImageStatus() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("sightingService.ImageStatus() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_sightingService_ListSightingImages(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	sightingImageRepo := mockRepo.NewMockSightingImageRepository(ctrl)
+	images := []*model.SightingImage{{ID: "a", Position: 0}, {ID: "b", Position: 1}}
+	tests := []struct {
+		name     string
+		sighting *model.Sighting
+		want     []*model.SightingImage
+		wantErr  bool
+		mocks    []*gomock.Call
+	}{
+		{
+			name:     "should return the images of a sighting that was just created",
+			sighting: &model.Sighting{ID: "s1", Images: images},
+			want:     images,
+		},
+		{
+			name:     "should return error if listing fails",
+			sighting: &model.Sighting{ID: "s1"},
+			wantErr:  true,
+			mocks: []*gomock.Call{
+				sightingImageRepo.EXPECT().ListSightingImages(gomock.Any(), "s1").Return(nil, errors.New("any error")),
+			},
+		},
+		{
+			name:     "success",
+			sighting: &model.Sighting{ID: "s1"},
+			want:     images,
+			mocks: []*gomock.Call{
+				sightingImageRepo.EXPECT().ListSightingImages(gomock.Any(), "s1").Return(images, nil),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &sightingService{
+				sightingImageRepo: sightingImageRepo,
+			}
+			got, err := s.ListSightingImages(context.Background(), tt.sighting)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("sightingService.ListSightingImages() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("sightingService.ListSightingImages() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_sightingService_ListTigerPhotos(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	sightingImageRepo := mockRepo.NewMockSightingImageRepository(ctrl)
+	taken := time.Date(2024, 3, 15, 6, 30, 0, 0, time.UTC)
+	photos := []*model.SightingImage{
+		{ID: "a", SightingID: "s2", Position: 0, TakenAt: taken},
+		{ID: "b", SightingID: "s2", Position: 1, TakenAt: taken},
+		{ID: "c", SightingID: "s1", Position: 0, TakenAt: taken.Add(-time.Hour)},
+	}
+	after := encodePhotoCursor(photos[1])
+	tests := []struct {
+		name        string
+		first       int
+		after       *string
+		wantIDs     []string
+		wantNext    bool
+		wantErrType error
+		mocks       []*gomock.Call
+	}{
+		{
+			name:        "should return error if page size is out of range",
+			first:       0,
+			wantErrType: &helper.InvalidPaginationError{},
+		},
+		{
+			name:        "should return error if cursor is invalid",
+			first:       2,
+			after:       ptr("not a cursor"),
+			wantErrType: &helper.InvalidPaginationError{},
+		},
+		{
+			name:        "should return error if listing fails",
+			first:       2,
+			wantErrType: &helper.CustomError{},
+			mocks: []*gomock.Call{
+				sightingImageRepo.EXPECT().ListTigerPhotos(gomock.Any(), "tiger-1", nil, 3).Return(nil, errors.New("any error")),
+			},
+		},
+		{
+			name:     "should report a next page when more photos exist",
+			first:    2,
+			wantIDs:  []string{"a", "b"},
+			wantNext: true,
+			mocks: []*gomock.Call{
+				sightingImageRepo.EXPECT().ListTigerPhotos(gomock.Any(), "tiger-1", nil, 3).Return(photos, nil),
+			},
+		},
+		{
+			name:    "should continue after the cursor",
+			first:   2,
+			after:   &after,
+			wantIDs: []string{"c"},
+			mocks: []*gomock.Call{
+				sightingImageRepo.EXPECT().ListTigerPhotos(gomock.Any(), "tiger-1",
+					&model.PhotoCursor{TakenAt: taken, SightingID: "s2", Position: 1}, 3).Return(photos[2:], nil),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &sightingService{
+				sightingImageRepo: sightingImageRepo,
+			}
+			got, err := s.ListTigerPhotos(context.Background(), "tiger-1", tt.first, tt.after)
+			if tt.wantErrType != nil {
+				if reflect.TypeOf(err) != reflect.TypeOf(tt.wantErrType) {
+					t.Errorf("sightingService.ListTigerPhotos() error = %T, want %T", err, tt.wantErrType)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("sightingService.ListTigerPhotos() error = %v", err)
+			}
+			var ids []string
+			for _, edge := range got.Edges {
+				ids = append(ids, edge.Node.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.wantIDs) || got.PageInfo.HasNextPage != tt.wantNext {
+				t.Errorf("sightingService.ListTigerPhotos() = %v (next %v), want %v (next %v)", ids, got.PageInfo.HasNextPage, tt.wantIDs, tt.wantNext)
+			}
+			if got.PageInfo.EndCursor == nil || *got.PageInfo.EndCursor != got.Edges[len(got.Edges)-1].Cursor {
+				t.Errorf("sightingService.ListTigerPhotos() end cursor = %v, want the last edge cursor", got.PageInfo.EndCursor)
+			}
+		})
+	}
+}
+
+// testPNG encodes a gradient PNG of the given size
+func testPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("png.Encode() error = %v", err)
+	}
+	return buf.Bytes()
+}
+
+func Test_calculateDistance(t *testing.T) {
+	type args struct {
+		coord1 *model.LastSeenCoordinate
+		coord2 *model.LastSeenCoordinate
+	}
+	tests := []struct {
+		name string
+		args args
+		want float64
+	}{
+		// TODO: Add test cases.
+	}
+	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			if got := calculateDistance(tt.args.coord1, tt.args.coord2); got != tt.want {
 				t.Errorf("calculateDistance() = %v, want %v", got, tt.want)
@@ -383,7 +1357,7 @@ func Test_sightingService_ListSightings(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	sightingRepo := mockRepo.NewMockSightingRepository(ctrl)
 	tigerRepo := mockRepo.NewMockTigerRepository(ctrl)
-	sightings := []*model.Sighting{{ID: uuid.NewString(), TigerID: uuid.NewString()}}
+	sightings := []*model.Sighting{{ID: uuid.NewString(), TigerID: ptr(uuid.NewString())}}
 	type fields struct {
 		sightingRepo repository.SightingRepository
 		tigerRepo    repository.TigerRepository
@@ -477,3 +1451,417 @@ func Test_sightingService_ListSightings(t *testing.T) {
 		})
 	}
 }
+
+func Test_sightingService_ListSightingsNear(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	sightingRepo := mockRepo.NewMockSightingRepository(ctrl)
+	tigerRepo := mockRepo.NewMockTigerRepository(ctrl)
+	near := &model.Sighting{ID: uuid.NewString(), LastSeenCoordinate: &model.LastSeenCoordinate{Latitude: 1.01, Longitude: 103}}
+	nearest := &model.Sighting{ID: uuid.NewString(), LastSeenCoordinate: &model.LastSeenCoordinate{Latitude: 1.001, Longitude: 103}}
+	outside := &model.Sighting{ID: uuid.NewString(), LastSeenCoordinate: &model.LastSeenCoordinate{Latitude: 1.04, Longitude: 103.04}}
+	sensitive := &model.Tiger{ID: uuid.NewString(), Sensitive: true}
+	// farther than near, but coarsened onto the searched point
+	hidden := &model.Sighting{
+		ID:                 uuid.NewString(),
+		TigerID:            &sensitive.ID,
+		LastSeenCoordinate: &model.LastSeenCoordinate{Latitude: 1.012, Longitude: 103.001},
+	}
+	type args struct {
+		ctx          context.Context
+		point        *model.LastSeenCoordinateInput
+		radiusMeters float64
+		limit        int
+	}
+	tests := []struct {
+		name            string
+		args            args
+		want            []string
+		wantCoordinates []*model.LastSeenCoordinate
+		wantErr         bool
+		mocks           []*gomock.Call
+	}{
+		{
+			name: "should return invalid input if point is not a valid coordinate",
+			args: args{
+				ctx:          context.Background(),
+				point:        &model.LastSeenCoordinateInput{Latitude: 95, Longitude: 103},
+				radiusMeters: 5000,
+				limit:        10,
+			},
+			wantErr: true,
+		},
+		{
+			name: "should return invalid input if radius is not positive",
+			args: args{
+				ctx:          context.Background(),
+				point:        &model.LastSeenCoordinateInput{Latitude: 1, Longitude: 103},
+				radiusMeters: 0,
+				limit:        10,
+			},
+			wantErr: true,
+		},
+		{
+			name: "should return error if database returning error",
+			args: args{
+				ctx:          context.Background(),
+				point:        &model.LastSeenCoordinateInput{Latitude: 1, Longitude: 103},
+				radiusMeters: 5000,
+				limit:        10,
+			},
+			wantErr: true,
+			mocks: []*gomock.Call{
+				sightingRepo.EXPECT().ListSightingsInBox(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New("any error")),
+			},
+		},
+		{
+			name: "success dropping sightings outside the radius and sorting by distance",
+			args: args{
+				ctx:          context.Background(),
+				point:        &model.LastSeenCoordinateInput{Latitude: 1, Longitude: 103},
+				radiusMeters: 5000,
+				limit:        10,
+			},
+			want: []string{nearest.ID, near.ID},
+			mocks: []*gomock.Call{
+				sightingRepo.EXPECT().ListSightingsInBox(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return([]*model.Sighting{near, outside, nearest}, nil),
+			},
+		},
+		{
+			name: "should return error if the tigers of the sightings cannot be listed",
+			args: args{
+				ctx:          context.Background(),
+				point:        &model.LastSeenCoordinateInput{Latitude: 1, Longitude: 103},
+				radiusMeters: 5000,
+				limit:        10,
+			},
+			wantErr: true,
+			mocks: []*gomock.Call{
+				sightingRepo.EXPECT().ListSightingsInBox(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return([]*model.Sighting{hidden}, nil),
+				tigerRepo.EXPECT().ListTigersByIDs(gomock.Any(), []string{sensitive.ID}).Return(nil, errors.New("any error")),
+			},
+		},
+		{
+			name: "success coarsening sightings of sensitive tigers after sorting by the exact distance",
+			args: args{
+				ctx:          context.Background(),
+				point:        &model.LastSeenCoordinateInput{Latitude: 1, Longitude: 103},
+				radiusMeters: 5000,
+				limit:        10,
+			},
+			want:            []string{near.ID, hidden.ID},
+			wantCoordinates: []*model.LastSeenCoordinate{near.LastSeenCoordinate, {Latitude: 1, Longitude: 103}},
+			mocks: []*gomock.Call{
+				sightingRepo.EXPECT().ListSightingsInBox(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return([]*model.Sighting{hidden, near}, nil),
+				tigerRepo.EXPECT().ListTigersByIDs(gomock.Any(), []string{sensitive.ID}).Return([]*model.Tiger{sensitive}, nil),
+			},
+		},
+		{
+			name: "success applying limit",
+			args: args{
+				ctx:          context.Background(),
+				point:        &model.LastSeenCoordinateInput{Latitude: 1, Longitude: 103},
+				radiusMeters: 5000,
+				limit:        1,
+			},
+			want: []string{nearest.ID},
+			mocks: []*gomock.Call{
+				sightingRepo.EXPECT().ListSightingsInBox(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), 1).
+					Return([]*model.Sighting{near, nearest}, nil),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &sightingService{
+				sightingRepo: sightingRepo,
+				tigerRepo:    tigerRepo,
+			}
+			got, err := s.ListSightingsNear(tt.args.ctx, tt.args.point, tt.args.radiusMeters, nil, tt.args.limit)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("sightingService.ListSightingsNear() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			var gotIDs []string
+			var gotCoordinates []*model.LastSeenCoordinate
+			for _, nearby := range got {
+				gotIDs = append(gotIDs, nearby.Sighting.ID)
+				gotCoordinates = append(gotCoordinates, nearby.Sighting.LastSeenCoordinate)
+			}
+			if !reflect.DeepEqual(gotIDs, tt.want) {
+				t.Errorf("sightingService.ListSightingsNear() = %v, want %v", gotIDs, tt.want)
+			}
+			if tt.wantCoordinates != nil && !reflect.DeepEqual(gotCoordinates, tt.wantCoordinates) {
+				t.Errorf("sightingService.ListSightingsNear() coordinates = %v, want %v", gotCoordinates, tt.wantCoordinates)
+			}
+		})
+	}
+}
+
+func Test_sightingService_ListSightingsInBox(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	sightingRepo := mockRepo.NewMockSightingRepository(ctrl)
+	tigerRepo := mockRepo.NewMockTigerRepository(ctrl)
+	center := &model.Sighting{ID: uuid.NewString(), LastSeenCoordinate: &model.LastSeenCoordinate{Latitude: 0.5, Longitude: 103.5}}
+	corner := &model.Sighting{ID: uuid.NewString(), LastSeenCoordinate: &model.LastSeenCoordinate{Latitude: 0.1, Longitude: 103.1}}
+	sensitive := &model.Tiger{ID: uuid.NewString(), Sensitive: true}
+	hidden := &model.Sighting{
+		ID:                 uuid.NewString(),
+		TigerID:            &sensitive.ID,
+		LastSeenCoordinate: &model.LastSeenCoordinate{Latitude: 0.3234, Longitude: 103.2481},
+	}
+	type args struct {
+		ctx context.Context
+		box *model.BoundingBox
+	}
+	tests := []struct {
+		name            string
+		args            args
+		want            []string
+		wantCoordinates []*model.LastSeenCoordinate
+		wantErr         bool
+		mocks           []*gomock.Call
+	}{
+		{
+			name: "should return invalid input if south-west is above north-east",
+			args: args{
+				ctx: context.Background(),
+				box: &model.BoundingBox{
+					SouthWest: &model.LastSeenCoordinateInput{Latitude: 1, Longitude: 103},
+					NorthEast: &model.LastSeenCoordinateInput{Latitude: 0, Longitude: 104},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "should return error if database returning error",
+			args: args{
+				ctx: context.Background(),
+				box: &model.BoundingBox{
+					SouthWest: &model.LastSeenCoordinateInput{Latitude: 0, Longitude: 103},
+					NorthEast: &model.LastSeenCoordinateInput{Latitude: 1, Longitude: 104},
+				},
+			},
+			wantErr: true,
+			mocks: []*gomock.Call{
+				sightingRepo.EXPECT().ListSightingsInBox(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New("any error")),
+			},
+		},
+		{
+			name: "success sorting by distance from the box center",
+			args: args{
+				ctx: context.Background(),
+				box: &model.BoundingBox{
+					SouthWest: &model.LastSeenCoordinateInput{Latitude: 0, Longitude: 103},
+					NorthEast: &model.LastSeenCoordinateInput{Latitude: 1, Longitude: 104},
+				},
+			},
+			want: []string{center.ID, corner.ID},
+			mocks: []*gomock.Call{
+				sightingRepo.EXPECT().ListSightingsInBox(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return([]*model.Sighting{corner, center}, nil),
+			},
+		},
+		{
+			name: "success coarsening sightings of sensitive tigers",
+			args: args{
+				ctx: context.Background(),
+				box: &model.BoundingBox{
+					SouthWest: &model.LastSeenCoordinateInput{Latitude: 0, Longitude: 103},
+					NorthEast: &model.LastSeenCoordinateInput{Latitude: 1, Longitude: 104},
+				},
+			},
+			want:            []string{center.ID, hidden.ID},
+			wantCoordinates: []*model.LastSeenCoordinate{center.LastSeenCoordinate, {Latitude: 0.3, Longitude: 103.2}},
+			mocks: []*gomock.Call{
+				sightingRepo.EXPECT().ListSightingsInBox(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return([]*model.Sighting{hidden, center}, nil),
+				tigerRepo.EXPECT().ListTigersByIDs(gomock.Any(), []string{sensitive.ID}).Return([]*model.Tiger{sensitive}, nil),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &sightingService{
+				sightingRepo: sightingRepo,
+				tigerRepo:    tigerRepo,
+			}
+			got, err := s.ListSightingsInBox(tt.args.ctx, tt.args.box, nil, 10)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("sightingService.ListSightingsInBox() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			var gotIDs []string
+			var gotCoordinates []*model.LastSeenCoordinate
+			for _, nearby := range got {
+				gotIDs = append(gotIDs, nearby.Sighting.ID)
+				gotCoordinates = append(gotCoordinates, nearby.Sighting.LastSeenCoordinate)
+			}
+			if !reflect.DeepEqual(gotIDs, tt.want) {
+				t.Errorf("sightingService.ListSightingsInBox() = %v, want %v", gotIDs, tt.want)
+			}
+			if tt.wantCoordinates != nil && !reflect.DeepEqual(gotCoordinates, tt.wantCoordinates) {
+				t.Errorf("sightingService.ListSightingsInBox() coordinates = %v, want %v", gotCoordinates, tt.wantCoordinates)
+			}
+		})
+	}
+}
+
+func Test_sightingService_GetTigerTrack(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	sightingRepo := mockRepo.NewMockSightingRepository(ctrl)
+	tigerRepo := mockRepo.NewMockTigerRepository(ctrl)
+	tiger := &model.Tiger{ID: uuid.NewString()}
+	sightings := []*model.Sighting{
+		{ID: uuid.NewString(), LastSeenCoordinate: &model.LastSeenCoordinate{Latitude: 0, Longitude: 0}},
+		{ID: uuid.NewString(), LastSeenCoordinate: &model.LastSeenCoordinate{Latitude: 0, Longitude: 1}},
+		{ID: uuid.NewString(), LastSeenCoordinate: &model.LastSeenCoordinate{Latitude: 1, Longitude: 1}},
+	}
+	sensitive := &model.Tiger{
+		ID:                 uuid.NewString(),
+		Sensitive:          true,
+		LastSeenCoordinate: &model.LastSeenCoordinate{Latitude: 1.6234, Longitude: 103.7481},
+	}
+	// 0.03 degrees apart, both coarsen to the same point
+	exact := []*model.Sighting{
+		{ID: uuid.NewString(), LastSeenCoordinate: &model.LastSeenCoordinate{Latitude: 0.01, Longitude: 0.01}},
+		{ID: uuid.NewString(), LastSeenCoordinate: &model.LastSeenCoordinate{Latitude: 0.01, Longitude: 0.04}},
+	}
+	tests := []struct {
+		name         string
+		want         *model.TigerTrack
+		wantDistance float64
+		wantErr      bool
+		mocks        []*gomock.Call
+	}{
+		{
+			name:    "should return tiger not found error if tiger is not exist",
+			wantErr: true,
+			mocks: []*gomock.Call{
+				tigerRepo.EXPECT().GetTigerByID(gomock.Any(), gomock.Any()).Return(nil, gorm.ErrRecordNotFound),
+			},
+		},
+		{
+			name:    "should return error if database returning error when get sighting data",
+			wantErr: true,
+			mocks: []*gomock.Call{
+				tigerRepo.EXPECT().GetTigerByID(gomock.Any(), gomock.Any()).Return(tiger, nil),
+				sightingRepo.EXPECT().ListSightingsForTrack(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New("any error")),
+			},
+		},
+		{
+			name: "success coarsens a sensitive tiger after summing the exact distance",
+			want: &model.TigerTrack{
+				Tiger: &model.Tiger{
+					ID:                 sensitive.ID,
+					Sensitive:          true,
+					LastSeenCoordinate: &model.LastSeenCoordinate{Latitude: 1.6, Longitude: 103.7},
+				},
+				Sightings: []*model.Sighting{
+					{ID: exact[0].ID, LastSeenCoordinate: &model.LastSeenCoordinate{Latitude: 0, Longitude: 0}},
+					{ID: exact[1].ID, LastSeenCoordinate: &model.LastSeenCoordinate{Latitude: 0, Longitude: 0}},
+				},
+			},
+			wantDistance: 3336,
+			mocks: []*gomock.Call{
+				tigerRepo.EXPECT().GetTigerByID(gomock.Any(), gomock.Any()).Return(sensitive, nil),
+				sightingRepo.EXPECT().ListSightingsForTrack(gomock.Any(), gomock.Any(), gomock.Any()).Return(exact, nil),
+			},
+		},
+		{
+			name: "success summing the distance between consecutive sightings",
+			want: &model.TigerTrack{
+				Tiger:     tiger,
+				Sightings: sightings,
+			},
+			wantDistance: 2 * 111195,
+			mocks: []*gomock.Call{
+				tigerRepo.EXPECT().GetTigerByID(gomock.Any(), gomock.Any()).Return(tiger, nil),
+				sightingRepo.EXPECT().ListSightingsForTrack(gomock.Any(), gomock.Any(), gomock.Any()).Return(sightings, nil),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &sightingService{
+				sightingRepo: sightingRepo,
+				tigerRepo:    tigerRepo,
+			}
+			got, err := s.GetTigerTrack(context.Background(), tiger.ID, nil)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("sightingService.GetTigerTrack() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got == nil {
+				return
+			}
+			if !reflect.DeepEqual(got.Tiger, tt.want.Tiger) || !reflect.DeepEqual(got.Sightings, tt.want.Sightings) {
+				t.Errorf("sightingService.GetTigerTrack() = %v, want %v", got, tt.want)
+			}
+			if got.TotalDistance < tt.wantDistance-100 || got.TotalDistance > tt.wantDistance+100 {
+				t.Errorf("sightingService.GetTigerTrack() total distance = %v, want %v", got.TotalDistance, tt.wantDistance)
+			}
+		})
+	}
+}
+
+func Test_sightingService_matchWatchZones(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	watchZoneRepo := mockRepo.NewMockWatchZoneRepository(ctrl)
+	point := &model.LastSeenCoordinate{Latitude: 1, Longitude: 101}
+	inside := &model.WatchZone{
+		ID:    "inside",
+		Shape: model.WatchZoneShapePolygon,
+		Polygon: []*model.LastSeenCoordinate{
+			{Latitude: 0, Longitude: 100}, {Latitude: 0, Longitude: 102}, {Latitude: 2, Longitude: 101},
+		},
+	}
+	// the bounding box holds the point but the triangle does not
+	outside := &model.WatchZone{
+		ID:    "outside",
+		Shape: model.WatchZoneShapePolygon,
+		Polygon: []*model.LastSeenCoordinate{
+			{Latitude: 0, Longitude: 100}, {Latitude: 0, Longitude: 100.5}, {Latitude: 2, Longitude: 100},
+		},
+	}
+	tests := []struct {
+		name    string
+		want    []*model.WatchZone
+		wantErr error
+		mocks   []*gomock.Call
+	}{
+		{
+			name:    "should return error if listing zones fails",
+			wantErr: &helper.CustomError{},
+			mocks: []*gomock.Call{
+				watchZoneRepo.EXPECT().ListWatchZonesAround(gomock.Any(), point).Return(nil, errors.New("any error")),
+			},
+		},
+		{
+			name: "success keeps the zones holding the point",
+			want: []*model.WatchZone{inside},
+			mocks: []*gomock.Call{
+				watchZoneRepo.EXPECT().ListWatchZonesAround(gomock.Any(), point).Return([]*model.WatchZone{outside, inside}, nil),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &sightingService{
+				watchZoneRepo: watchZoneRepo,
+			}
+			got, err := s.matchWatchZones(context.Background(), point)
+			if tt.wantErr != nil {
+				if reflect.TypeOf(err) != reflect.TypeOf(tt.wantErr) {
+					t.Errorf("sightingService.matchWatchZones() error = %v, want %T", err, tt.wantErr)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("sightingService.matchWatchZones() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,8 +2,10 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math"
+	"net/http"
 	"strings"
 	"time"
 
@@ -12,15 +14,18 @@ import (
 	"github.com/nurcholisnanda/tigerhall-kittens/internal/repository"
 	"github.com/nurcholisnanda/tigerhall-kittens/pkg/helper"
 	"github.com/nurcholisnanda/tigerhall-kittens/pkg/logger"
+	"gorm.io/gorm"
 )
 
 type tigerService struct {
-	tigerRepo repository.TigerRepository
+	tigerRepo         repository.TigerRepository
+	sightingImageRepo repository.SightingImageRepository
 }
 
-func NewTigerService(tigerRepo repository.TigerRepository) TigerService {
+func NewTigerService(tigerRepo repository.TigerRepository, sightingImageRepo repository.SightingImageRepository) TigerService {
 	return &tigerService{
-		tigerRepo: tigerRepo,
+		tigerRepo:         tigerRepo,
+		sightingImageRepo: sightingImageRepo,
 	}
 }
 
@@ -52,10 +57,17 @@ func (s *tigerService) CreateTiger(ctx context.Context, input *model.TigerInput)
 		DateOfBirth:        input.DateOfBirth,
 		LastSeenTime:       input.LastSeenTime,
 		LastSeenCoordinate: (*model.LastSeenCoordinate)(input.LastSeenCoordinate),
+		Sensitive:          input.Sensitive != nil && *input.Sensitive,
+	}
+
+	webhook, err := tigerMessage(model.WebhookEventTigerCreated, tiger)
+	if err != nil {
+		logger.Logger(ctx).Error("unexpected error encoding webhook", err)
+		return nil, helper.ErrInternalServer
 	}
 
 	// Database Interaction
-	if err := s.tigerRepo.Create(ctx, tiger); err != nil {
+	if err := s.tigerRepo.Create(ctx, tiger, webhook); err != nil {
 		if strings.Contains(err.Error(), "unique constraint") {
 			return nil, &helper.TigerCreationError{
 				Field:   "name",
@@ -75,7 +87,53 @@ func (s *tigerService) ListTigers(ctx context.Context, limit int, offset int) ([
 		logger.Logger(ctx).Error("unexpected error creating tiger", err)
 		return nil, fmt.Errorf("unexpected error listing tigers : %v", err.Error())
 	}
-	return tigers, nil
+	return publicTigers(tigers), nil
+}
+
+func (s *tigerService) ListTigersNear(ctx context.Context, point *model.LastSeenCoordinateInput, radiusMeters float64,
+	seenSince *time.Time, limit int) ([]*model.NearbyTiger, error) {
+	if !isValidLatitude(point.Latitude) || !isValidLongitude(point.Longitude) {
+		return nil, &helper.InvalidCoordinatesError{
+			Message: "latitude must be between -90 and 90, longitude between -180 and 180",
+		}
+	}
+	if radiusMeters <= 0 || radiusMeters > maxSearchRadius {
+		return nil, &helper.InvalidRadiusError{
+			Message: fmt.Sprintf("radius must be greater than 0 and at most %.0f meters", maxSearchRadius),
+		}
+	}
+
+	center := (*model.LastSeenCoordinate)(point)
+	tigers, err := s.tigerRepo.ListTigersInBox(ctx, boundingBoxAround(center, radiusMeters), seenSince)
+	if err != nil {
+		logger.Logger(ctx).Error("unexpected error listing tigers near point", err)
+		return nil, helper.NewCustomError("Failed to list tigers", http.StatusInternalServerError)
+	}
+
+	now := time.Now()
+	nearby := make([]*model.NearbyTiger, 0, len(tigers))
+	for _, tiger := range tigers {
+		distance := calculateDistance(center, tiger.LastSeenCoordinate)
+		if distance > radiusMeters {
+			continue
+		}
+		nearby = append(nearby, &model.NearbyTiger{
+			Tiger:       tiger,
+			Distance:    distance,
+			LastSeenAge: int(now.Sub(tiger.LastSeenTime).Seconds()),
+		})
+	}
+
+	// sensitive tigers are ranked by their exact distance, but their distance
+	// is measured to the coarsened coordinate so it does not give it away
+	nearby = sortByDistance(nearby, func(n *model.NearbyTiger) float64 { return n.Distance }, limit)
+	for _, n := range nearby {
+		if n.Tiger.Sensitive {
+			n.Tiger = publicTiger(n.Tiger)
+			n.Distance = calculateDistance(center, n.Tiger.LastSeenCoordinate)
+		}
+	}
+	return nearby, nil
 }
 
 // Helper Validation Functions
@@ -86,3 +144,101 @@ func isValidLatitude(latitude float64) bool {
 func isValidLongitude(longitude float64) bool {
 	return math.Abs(longitude) <= 180
 }
+
+// SetProfilePhoto makes a photo from one of the tiger's sightings its profile
+// picture
+func (s *tigerService) SetProfilePhoto(ctx context.Context, tigerID string, imageID string) (*model.Tiger, error) {
+	tiger, err := s.tigerRepo.GetTigerByID(ctx, tigerID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &helper.TigerNotFound{Message: "Tiger not found"}
+		}
+		logger.Logger(ctx).Error("Unexpected error getting tiger by ID: ", err)
+		return nil, helper.NewCustomError("Failed to retrieve tiger by ID", http.StatusInternalServerError)
+	}
+
+	image, err := s.sightingImageRepo.GetSightingImageByID(ctx, imageID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &helper.ImageNotFoundError{Message: "Image not found"}
+		}
+		logger.Logger(ctx).Error("Unexpected error getting sighting image by ID: ", err)
+		return nil, helper.NewCustomError("Failed to retrieve image by ID", http.StatusInternalServerError)
+	}
+	if image.TigerID == nil || *image.TigerID != tiger.ID {
+		return nil, &helper.ImageNotFoundError{Message: "Image does not belong to one of the tiger's sightings"}
+	}
+
+	tiger.ProfileImageID = &image.ID
+	webhook, err := tigerMessage(model.WebhookEventTigerUpdated, tiger)
+	if err == nil {
+		err = s.tigerRepo.SetProfileImage(ctx, tiger.ID, image.ID, webhook)
+	}
+	if err != nil {
+		logger.Logger(ctx).Error("Failed to set tiger profile image: ", err)
+		return nil, helper.NewCustomError("Failed to set profile photo", http.StatusInternalServerError)
+	}
+	return publicTiger(tiger), nil
+}
+
+// FollowTiger notifies the user about sightings of the tiger whether or not
+// they ever sighted it
+func (s *tigerService) FollowTiger(ctx context.Context, userID string, tigerID string) (*model.Tiger, error) {
+	tiger, err := s.tigerRepo.GetTigerByID(ctx, tigerID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &helper.TigerNotFound{Message: "Tiger not found"}
+		}
+		logger.Logger(ctx).Error("Unexpected error getting tiger by ID: ", err)
+		return nil, helper.NewCustomError("Failed to retrieve tiger by ID", http.StatusInternalServerError)
+	}
+	if err := s.tigerRepo.FollowTiger(ctx, userID, tiger.ID); err != nil {
+		logger.Logger(ctx).Error("Failed to follow tiger: ", err)
+		return nil, helper.NewCustomError("Failed to follow tiger", http.StatusInternalServerError)
+	}
+	return publicTiger(tiger), nil
+}
+
+// UnfollowTiger stops notifications the user gets for following the tiger,
+// they are still notified when they sighted it themselves
+func (s *tigerService) UnfollowTiger(ctx context.Context, userID string, tigerID string) (*model.Tiger, error) {
+	tiger, err := s.tigerRepo.GetTigerByID(ctx, tigerID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &helper.TigerNotFound{Message: "Tiger not found"}
+		}
+		logger.Logger(ctx).Error("Unexpected error getting tiger by ID: ", err)
+		return nil, helper.NewCustomError("Failed to retrieve tiger by ID", http.StatusInternalServerError)
+	}
+	if err := s.tigerRepo.UnfollowTiger(ctx, userID, tiger.ID); err != nil {
+		logger.Logger(ctx).Error("Failed to unfollow tiger: ", err)
+		return nil, helper.NewCustomError("Failed to unfollow tiger", http.StatusInternalServerError)
+	}
+	return publicTiger(tiger), nil
+}
+
+func (s *tigerService) ListFollowedTigers(ctx context.Context, userID string) ([]*model.Tiger, error) {
+	tigers, err := s.tigerRepo.ListFollowedTigers(ctx, userID)
+	if err != nil {
+		logger.Logger(ctx).Error("Failed to list followed tigers: ", err)
+		return nil, helper.NewCustomError("Failed to list followed tigers", http.StatusInternalServerError)
+	}
+	return publicTigers(tigers), nil
+}
+
+// GetProfilePhoto returns the tiger's profile picture, or nil when it has none
+func (s *tigerService) GetProfilePhoto(ctx context.Context, tiger *model.Tiger) (*model.SightingImage, error) {
+	if tiger.ProfileImageID == nil {
+		return nil, nil
+	}
+	image, err := s.sightingImageRepo.GetSightingImageByID(ctx, *tiger.ProfileImageID)
+	if err != nil {
+		// the photo may have been removed since it was picked
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		logger.Logger(ctx).Error("Unexpected error getting sighting image by ID: ", err)
+		return nil, helper.NewCustomError("Failed to retrieve profile photo", http.StatusInternalServerError)
+	}
+	return image, nil
+}
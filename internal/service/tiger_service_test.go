@@ -11,14 +11,18 @@ import (
 	"github.com/nurcholisnanda/tigerhall-kittens/internal/api/graph/model"
 	"github.com/nurcholisnanda/tigerhall-kittens/internal/repository"
 	mockRepo "github.com/nurcholisnanda/tigerhall-kittens/internal/repository/mock"
+	"github.com/nurcholisnanda/tigerhall-kittens/pkg/helper"
 	"go.uber.org/mock/gomock"
+	"gorm.io/gorm"
 )
 
 func TestNewTigerService(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	tigerRepo := mockRepo.NewMockTigerRepository(ctrl)
+	sightingImageRepo := mockRepo.NewMockSightingImageRepository(ctrl)
 	type args struct {
-		tigerRepo repository.TigerRepository
+		tigerRepo         repository.TigerRepository
+		sightingImageRepo repository.SightingImageRepository
 	}
 	tests := []struct {
 		name string
@@ -28,14 +32,15 @@ func TestNewTigerService(t *testing.T) {
 		{
 			name: "success",
 			args: args{
-				tigerRepo: tigerRepo,
+				tigerRepo:         tigerRepo,
+				sightingImageRepo: sightingImageRepo,
 			},
-			want: NewTigerService(tigerRepo),
+			want: NewTigerService(tigerRepo, sightingImageRepo),
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := NewTigerService(tt.args.tigerRepo); !reflect.DeepEqual(got, tt.want) {
+			if got := NewTigerService(tt.args.tigerRepo, tt.args.sightingImageRepo); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("NewTigerService() = %v, want %v", got, tt.want)
 			}
 		})
@@ -83,7 +88,7 @@ func Test_tigerService_CreateTiger(t *testing.T) {
 		args    args
 		want    *model.Tiger
 		wantErr bool
-		mocks   *gomock.Call
+		mocks   []*gomock.Call
 	}{
 		{
 			name: "should return error if inserting invalid coordinate",
@@ -142,7 +147,9 @@ func Test_tigerService_CreateTiger(t *testing.T) {
 			},
 			want:    nil,
 			wantErr: true,
-			mocks:   tigerRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(errors.New("any error")),
+			mocks: []*gomock.Call{
+				tigerRepo.EXPECT().Create(gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("any error")),
+			},
 		},
 		{
 			name: "should return error if input the same name",
@@ -155,7 +162,9 @@ func Test_tigerService_CreateTiger(t *testing.T) {
 			},
 			want:    nil,
 			wantErr: true,
-			mocks:   tigerRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(errors.New("unique constraint")),
+			mocks: []*gomock.Call{
+				tigerRepo.EXPECT().Create(gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("unique constraint")),
+			},
 		},
 		{
 			name: "success create new tiger",
@@ -168,7 +177,15 @@ func Test_tigerService_CreateTiger(t *testing.T) {
 			},
 			want:    tiger,
 			wantErr: false,
-			mocks:   tigerRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil),
+			mocks: []*gomock.Call{
+				tigerRepo.EXPECT().Create(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, tiger *model.Tiger, webhook *model.WebhookMessage) error {
+						if webhook.Event != model.WebhookEventTigerCreated || webhookData(t, webhook)["id"] != tiger.ID {
+							t.Errorf("tigerService.CreateTiger() queued %s %s, want tiger.created", webhook.Event.Name(), webhook.Payload)
+						}
+						return nil
+					}),
+			},
 		},
 	}
 	for _, tt := range tests {
@@ -199,6 +216,12 @@ func Test_tigerService_ListTigers(t *testing.T) {
 			Name: "tiger a",
 		},
 	}
+	sensitive := &model.Tiger{
+		ID:                 uuid.NewString(),
+		Name:               "tiger b",
+		Sensitive:          true,
+		LastSeenCoordinate: &model.LastSeenCoordinate{Latitude: 1.6234, Longitude: 103.7481},
+	}
 	type fields struct {
 		tigerRepo repository.TigerRepository
 	}
@@ -243,6 +266,25 @@ func Test_tigerService_ListTigers(t *testing.T) {
 			wantErr: false,
 			mocks:   tigerRepo.EXPECT().ListTigers(gomock.Any(), gomock.Any(), gomock.Any()).Return(tigers, nil),
 		},
+		{
+			name: "success coarsening sensitive tigers",
+			fields: fields{
+				tigerRepo: tigerRepo,
+			},
+			args: args{
+				ctx:    context.Background(),
+				limit:  1,
+				offset: 0,
+			},
+			want: []*model.Tiger{{
+				ID:                 sensitive.ID,
+				Name:               "tiger b",
+				Sensitive:          true,
+				LastSeenCoordinate: &model.LastSeenCoordinate{Latitude: 1.6, Longitude: 103.7},
+			}},
+			wantErr: false,
+			mocks:   tigerRepo.EXPECT().ListTigers(gomock.Any(), gomock.Any(), gomock.Any()).Return([]*model.Tiger{sensitive}, nil),
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -260,3 +302,422 @@ func Test_tigerService_ListTigers(t *testing.T) {
 		})
 	}
 }
+
+func Test_tigerService_ListTigersNear(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	tigerRepo := mockRepo.NewMockTigerRepository(ctrl)
+	lastSeen := time.Now().Add(-2 * time.Hour)
+	near := &model.Tiger{
+		ID:                 uuid.NewString(),
+		LastSeenTime:       lastSeen,
+		LastSeenCoordinate: &model.LastSeenCoordinate{Latitude: 1.01, Longitude: 103},
+	}
+	outside := &model.Tiger{
+		ID:                 uuid.NewString(),
+		LastSeenTime:       lastSeen,
+		LastSeenCoordinate: &model.LastSeenCoordinate{Latitude: 1.04, Longitude: 103.04},
+	}
+	// farther than near, but coarsened onto the searched point
+	sensitive := &model.Tiger{
+		ID:                 uuid.NewString(),
+		LastSeenTime:       lastSeen,
+		Sensitive:          true,
+		LastSeenCoordinate: &model.LastSeenCoordinate{Latitude: 1.012, Longitude: 103.001},
+	}
+	type args struct {
+		ctx          context.Context
+		point        *model.LastSeenCoordinateInput
+		radiusMeters float64
+	}
+	tests := []struct {
+		name            string
+		args            args
+		want            []string
+		wantCoordinates []*model.LastSeenCoordinate
+		wantErr         bool
+		mocks           []*gomock.Call
+	}{
+		{
+			name: "should return invalid input if point is not a valid coordinate",
+			args: args{
+				ctx:          context.Background(),
+				point:        &model.LastSeenCoordinateInput{Latitude: 1, Longitude: 190},
+				radiusMeters: 5000,
+			},
+			wantErr: true,
+		},
+		{
+			name: "should return invalid input if radius is too large",
+			args: args{
+				ctx:          context.Background(),
+				point:        &model.LastSeenCoordinateInput{Latitude: 1, Longitude: 103},
+				radiusMeters: maxSearchRadius + 1,
+			},
+			wantErr: true,
+		},
+		{
+			name: "should return error if database returning error",
+			args: args{
+				ctx:          context.Background(),
+				point:        &model.LastSeenCoordinateInput{Latitude: 1, Longitude: 103},
+				radiusMeters: 5000,
+			},
+			wantErr: true,
+			mocks: []*gomock.Call{
+				tigerRepo.EXPECT().ListTigersInBox(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New("any error")),
+			},
+		},
+		{
+			name: "success dropping tigers outside the radius",
+			args: args{
+				ctx:          context.Background(),
+				point:        &model.LastSeenCoordinateInput{Latitude: 1, Longitude: 103},
+				radiusMeters: 5000,
+			},
+			want: []string{near.ID},
+			mocks: []*gomock.Call{
+				tigerRepo.EXPECT().ListTigersInBox(gomock.Any(), gomock.Any(), gomock.Any()).Return([]*model.Tiger{outside, near}, nil),
+			},
+		},
+		{
+			name: "success coarsening sensitive tigers after sorting by the exact distance",
+			args: args{
+				ctx:          context.Background(),
+				point:        &model.LastSeenCoordinateInput{Latitude: 1, Longitude: 103},
+				radiusMeters: 5000,
+			},
+			want:            []string{near.ID, sensitive.ID},
+			wantCoordinates: []*model.LastSeenCoordinate{near.LastSeenCoordinate, {Latitude: 1, Longitude: 103}},
+			mocks: []*gomock.Call{
+				tigerRepo.EXPECT().ListTigersInBox(gomock.Any(), gomock.Any(), gomock.Any()).Return([]*model.Tiger{sensitive, near}, nil),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &tigerService{
+				tigerRepo: tigerRepo,
+			}
+			got, err := s.ListTigersNear(tt.args.ctx, tt.args.point, tt.args.radiusMeters, nil, 10)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("tigerService.ListTigersNear() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			var gotIDs []string
+			var gotCoordinates []*model.LastSeenCoordinate
+			for _, nearby := range got {
+				gotIDs = append(gotIDs, nearby.Tiger.ID)
+				gotCoordinates = append(gotCoordinates, nearby.Tiger.LastSeenCoordinate)
+				if nearby.LastSeenAge < int((2 * time.Hour).Seconds()) {
+					t.Errorf("tigerService.ListTigersNear() last seen age = %v, want at least two hours", nearby.LastSeenAge)
+				}
+			}
+			if !reflect.DeepEqual(gotIDs, tt.want) {
+				t.Errorf("tigerService.ListTigersNear() = %v, want %v", gotIDs, tt.want)
+			}
+			if tt.wantCoordinates != nil && !reflect.DeepEqual(gotCoordinates, tt.wantCoordinates) {
+				t.Errorf("tigerService.ListTigersNear() coordinates = %v, want %v", gotCoordinates, tt.wantCoordinates)
+			}
+		})
+	}
+}
+
+func Test_tigerService_SetProfilePhoto(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	tigerRepo := mockRepo.NewMockTigerRepository(ctrl)
+	sightingImageRepo := mockRepo.NewMockSightingImageRepository(ctrl)
+	tiger := &model.Tiger{ID: "tiger-1"}
+	tests := []struct {
+		name        string
+		imageID     string
+		wantErrType error
+		mocks       []*gomock.Call
+	}{
+		{
+			name:        "should return tiger not found",
+			imageID:     "image-1",
+			wantErrType: &helper.TigerNotFound{},
+			mocks: []*gomock.Call{
+				tigerRepo.EXPECT().GetTigerByID(gomock.Any(), "tiger-1").Return(nil, gorm.ErrRecordNotFound),
+			},
+		},
+		{
+			name:        "should return image not found",
+			imageID:     "image-1",
+			wantErrType: &helper.ImageNotFoundError{},
+			mocks: []*gomock.Call{
+				tigerRepo.EXPECT().GetTigerByID(gomock.Any(), "tiger-1").Return(tiger, nil),
+				sightingImageRepo.EXPECT().GetSightingImageByID(gomock.Any(), "image-1").Return(nil, gorm.ErrRecordNotFound),
+			},
+		},
+		{
+			name:        "should reject a photo of another tiger",
+			imageID:     "image-2",
+			wantErrType: &helper.ImageNotFoundError{},
+			mocks: []*gomock.Call{
+				tigerRepo.EXPECT().GetTigerByID(gomock.Any(), "tiger-1").Return(tiger, nil),
+				sightingImageRepo.EXPECT().GetSightingImageByID(gomock.Any(), "image-2").Return(&model.SightingImage{ID: "image-2", TigerID: ptr("tiger-2")}, nil),
+			},
+		},
+		{
+			name:        "should return error if update fails",
+			imageID:     "image-1",
+			wantErrType: &helper.CustomError{},
+			mocks: []*gomock.Call{
+				tigerRepo.EXPECT().GetTigerByID(gomock.Any(), "tiger-1").Return(tiger, nil),
+				sightingImageRepo.EXPECT().GetSightingImageByID(gomock.Any(), "image-1").Return(&model.SightingImage{ID: "image-1", TigerID: ptr("tiger-1")}, nil),
+				tigerRepo.EXPECT().SetProfileImage(gomock.Any(), "tiger-1", "image-1", gomock.Any()).Return(errors.New("any error")),
+			},
+		},
+		{
+			name:    "success",
+			imageID: "image-1",
+			mocks: []*gomock.Call{
+				tigerRepo.EXPECT().GetTigerByID(gomock.Any(), "tiger-1").Return(tiger, nil),
+				sightingImageRepo.EXPECT().GetSightingImageByID(gomock.Any(), "image-1").Return(&model.SightingImage{ID: "image-1", TigerID: ptr("tiger-1")}, nil),
+				tigerRepo.EXPECT().SetProfileImage(gomock.Any(), "tiger-1", "image-1", gomock.Any()).DoAndReturn(
+					func(_ context.Context, _ string, _ string, webhook *model.WebhookMessage) error {
+						if webhook.Event != model.WebhookEventTigerUpdated || webhookData(t, webhook)["profileImageID"] != "image-1" {
+							t.Errorf("tigerService.SetProfilePhoto() queued %s %s, want tiger.updated", webhook.Event.Name(), webhook.Payload)
+						}
+						return nil
+					}),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &tigerService{
+				tigerRepo:         tigerRepo,
+				sightingImageRepo: sightingImageRepo,
+			}
+			got, err := s.SetProfilePhoto(context.Background(), "tiger-1", tt.imageID)
+			if tt.wantErrType != nil {
+				if reflect.TypeOf(err) != reflect.TypeOf(tt.wantErrType) {
+					t.Errorf("tigerService.SetProfilePhoto() error = %T, want %T", err, tt.wantErrType)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("tigerService.SetProfilePhoto() error = %v", err)
+			}
+			if got.ProfileImageID == nil || *got.ProfileImageID != tt.imageID {
+				t.Errorf("tigerService.SetProfilePhoto() profile image = %v, want %v", got.ProfileImageID, tt.imageID)
+			}
+		})
+	}
+}
+
+func Test_tigerService_GetProfilePhoto(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	sightingImageRepo := mockRepo.NewMockSightingImageRepository(ctrl)
+	imageID := "image-1"
+	image := &model.SightingImage{ID: imageID}
+	tests := []struct {
+		name    string
+		tiger   *model.Tiger
+		want    *model.SightingImage
+		wantErr bool
+		mocks   []*gomock.Call
+	}{
+		{
+			name:  "should return nil if the tiger has no profile photo",
+			tiger: &model.Tiger{},
+		},
+		{
+			name:  "should return nil if the photo was removed",
+			tiger: &model.Tiger{ProfileImageID: &imageID},
+			mocks: []*gomock.Call{
+				sightingImageRepo.EXPECT().GetSightingImageByID(gomock.Any(), imageID).Return(nil, gorm.ErrRecordNotFound),
+			},
+		},
+		{
+			name:    "should return error if lookup fails",
+			tiger:   &model.Tiger{ProfileImageID: &imageID},
+			wantErr: true,
+			mocks: []*gomock.Call{
+				sightingImageRepo.EXPECT().GetSightingImageByID(gomock.Any(), imageID).Return(nil, errors.New("any error")),
+			},
+		},
+		{
+			name:  "success",
+			tiger: &model.Tiger{ProfileImageID: &imageID},
+			want:  image,
+			mocks: []*gomock.Call{
+				sightingImageRepo.EXPECT().GetSightingImageByID(gomock.Any(), imageID).Return(image, nil),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &tigerService{
+				sightingImageRepo: sightingImageRepo,
+			}
+			got, err := s.GetProfilePhoto(context.Background(), tt.tiger)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("tigerService.GetProfilePhoto() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("tigerService.GetProfilePhoto() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_tigerService_FollowTiger(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	tigerRepo := mockRepo.NewMockTigerRepository(ctrl)
+	tiger := &model.Tiger{ID: "tiger-1"}
+	tests := []struct {
+		name        string
+		wantErrType error
+		mocks       []*gomock.Call
+	}{
+		{
+			name:        "should return tiger not found",
+			wantErrType: &helper.TigerNotFound{},
+			mocks: []*gomock.Call{
+				tigerRepo.EXPECT().GetTigerByID(gomock.Any(), "tiger-1").Return(nil, gorm.ErrRecordNotFound),
+			},
+		},
+		{
+			name:        "should return error if getting the tiger fails",
+			wantErrType: &helper.CustomError{},
+			mocks: []*gomock.Call{
+				tigerRepo.EXPECT().GetTigerByID(gomock.Any(), "tiger-1").Return(nil, errors.New("any error")),
+			},
+		},
+		{
+			name:        "should return error if following fails",
+			wantErrType: &helper.CustomError{},
+			mocks: []*gomock.Call{
+				tigerRepo.EXPECT().GetTigerByID(gomock.Any(), "tiger-1").Return(tiger, nil),
+				tigerRepo.EXPECT().FollowTiger(gomock.Any(), "user-1", "tiger-1").Return(errors.New("any error")),
+			},
+		},
+		{
+			name: "success",
+			mocks: []*gomock.Call{
+				tigerRepo.EXPECT().GetTigerByID(gomock.Any(), "tiger-1").Return(tiger, nil),
+				tigerRepo.EXPECT().FollowTiger(gomock.Any(), "user-1", "tiger-1").Return(nil),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &tigerService{
+				tigerRepo: tigerRepo,
+			}
+			got, err := s.FollowTiger(context.Background(), "user-1", "tiger-1")
+			if tt.wantErrType != nil {
+				if reflect.TypeOf(err) != reflect.TypeOf(tt.wantErrType) {
+					t.Errorf("tigerService.FollowTiger() error = %T, want %T", err, tt.wantErrType)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("tigerService.FollowTiger() error = %v", err)
+			}
+			if got != tiger {
+				t.Errorf("tigerService.FollowTiger() = %v, want %v", got, tiger)
+			}
+		})
+	}
+}
+
+func Test_tigerService_UnfollowTiger(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	tigerRepo := mockRepo.NewMockTigerRepository(ctrl)
+	tiger := &model.Tiger{ID: "tiger-1"}
+	tests := []struct {
+		name        string
+		wantErrType error
+		mocks       []*gomock.Call
+	}{
+		{
+			name:        "should return tiger not found",
+			wantErrType: &helper.TigerNotFound{},
+			mocks: []*gomock.Call{
+				tigerRepo.EXPECT().GetTigerByID(gomock.Any(), "tiger-1").Return(nil, gorm.ErrRecordNotFound),
+			},
+		},
+		{
+			name:        "should return error if unfollowing fails",
+			wantErrType: &helper.CustomError{},
+			mocks: []*gomock.Call{
+				tigerRepo.EXPECT().GetTigerByID(gomock.Any(), "tiger-1").Return(tiger, nil),
+				tigerRepo.EXPECT().UnfollowTiger(gomock.Any(), "user-1", "tiger-1").Return(errors.New("any error")),
+			},
+		},
+		{
+			name: "success",
+			mocks: []*gomock.Call{
+				tigerRepo.EXPECT().GetTigerByID(gomock.Any(), "tiger-1").Return(tiger, nil),
+				tigerRepo.EXPECT().UnfollowTiger(gomock.Any(), "user-1", "tiger-1").Return(nil),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &tigerService{
+				tigerRepo: tigerRepo,
+			}
+			got, err := s.UnfollowTiger(context.Background(), "user-1", "tiger-1")
+			if tt.wantErrType != nil {
+				if reflect.TypeOf(err) != reflect.TypeOf(tt.wantErrType) {
+					t.Errorf("tigerService.UnfollowTiger() error = %T, want %T", err, tt.wantErrType)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("tigerService.UnfollowTiger() error = %v", err)
+			}
+			if got != tiger {
+				t.Errorf("tigerService.UnfollowTiger() = %v, want %v", got, tiger)
+			}
+		})
+	}
+}
+
+func Test_tigerService_ListFollowedTigers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	tigerRepo := mockRepo.NewMockTigerRepository(ctrl)
+	tigers := []*model.Tiger{{ID: "tiger-1"}, {ID: "tiger-2"}}
+	tests := []struct {
+		name    string
+		want    []*model.Tiger
+		wantErr bool
+		mocks   []*gomock.Call
+	}{
+		{
+			name:    "should return error if listing fails",
+			wantErr: true,
+			mocks: []*gomock.Call{
+				tigerRepo.EXPECT().ListFollowedTigers(gomock.Any(), "user-1").Return(nil, errors.New("any error")),
+			},
+		},
+		{
+			name: "success",
+			want: tigers,
+			mocks: []*gomock.Call{
+				tigerRepo.EXPECT().ListFollowedTigers(gomock.Any(), "user-1").Return(tigers, nil),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &tigerService{
+				tigerRepo: tigerRepo,
+			}
+			got, err := s.ListFollowedTigers(context.Background(), "user-1")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("tigerService.ListFollowedTigers() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("tigerService.ListFollowedTigers() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
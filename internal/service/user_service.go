@@ -3,11 +3,16 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net/http"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/nurcholisnanda/tigerhall-kittens/internal/api/graph/model"
 	"github.com/nurcholisnanda/tigerhall-kittens/internal/repository"
 	"github.com/nurcholisnanda/tigerhall-kittens/pkg/bcrypt"
+	"github.com/nurcholisnanda/tigerhall-kittens/pkg/helper"
+	"github.com/nurcholisnanda/tigerhall-kittens/pkg/logger"
 	"gorm.io/gorm"
 )
 
@@ -46,6 +51,7 @@ func (s *userService) Register(ctx context.Context, input *model.NewUser) (inter
 		Name:     input.Name,
 		Email:    input.Email,
 		Password: string(hashedPassword),
+		Role:     model.RoleUser,
 	}
 
 	if err := s.userRepo.CreateUser(ctx, user); err != nil {
@@ -82,3 +88,78 @@ func (s *userService) Login(ctx context.Context, email string, password string)
 func (s *userService) GetUserByID(ctx context.Context, id string) (*model.User, error) {
 	return s.userRepo.GetUserByID(ctx, id)
 }
+
+func (s *userService) GetNotificationPreferences(ctx context.Context, userID string) (*model.NotificationPreferences, error) {
+	preferences, err := s.userRepo.GetNotificationPreferences(ctx, userID)
+	if err != nil {
+		logger.Logger(ctx).Error("Failed to get notification preferences:", err)
+		return nil, helper.NewCustomError("Failed to get notification preferences", http.StatusInternalServerError)
+	}
+	return preferences, nil
+}
+
+// UpdateNotificationPreferences changes the preferences given in input and
+// keeps the others
+func (s *userService) UpdateNotificationPreferences(ctx context.Context, userID string,
+	input *model.NotificationPreferencesInput) (*model.NotificationPreferences, error) {
+	preferences, err := s.GetNotificationPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if input.EmailEnabled != nil {
+		preferences.EmailEnabled = *input.EmailEnabled
+	}
+	if input.DigestFrequency != nil {
+		preferences.DigestFrequency = *input.DigestFrequency
+	}
+	if input.Locale != nil {
+		preferences.Locale = *input.Locale
+	}
+	if input.Timezone != nil {
+		// LoadLocation takes "" for UTC and "Local" for the server's zone
+		if _, err := time.LoadLocation(*input.Timezone); err != nil || *input.Timezone == "" || *input.Timezone == "Local" {
+			return nil, &helper.InvalidTimezoneError{Message: fmt.Sprintf("unknown time zone %q", *input.Timezone)}
+		}
+		preferences.Timezone = *input.Timezone
+	}
+	preferences.MutedTigerIDs = updateMutedTigers(preferences.MutedTigerIDs, input.MuteTigerIDs, input.UnmuteTigerIDs)
+
+	if err := s.userRepo.SaveNotificationPreferences(ctx, preferences); err != nil {
+		logger.Logger(ctx).Error("Failed to save notification preferences:", err)
+		return nil, helper.NewCustomError("Failed to save notification preferences", http.StatusInternalServerError)
+	}
+	return preferences, nil
+}
+
+// Unsubscribe handles a one-click unsubscribe link. Without a tiger it turns
+// notification emails off, with one it mutes that tiger.
+func (s *userService) Unsubscribe(ctx context.Context, userID string, tigerID string) error {
+	input := &model.NotificationPreferencesInput{}
+	if tigerID == "" {
+		emailEnabled := false
+		input.EmailEnabled = &emailEnabled
+	} else {
+		input.MuteTigerIDs = []string{tigerID}
+	}
+	_, err := s.UpdateNotificationPreferences(ctx, userID, input)
+	return err
+}
+
+// updateMutedTigers adds mute to the muted tigers and removes unmute, keeping
+// the order tigers were muted in
+func updateMutedTigers(muted []string, mute []string, unmute []string) []string {
+	removed := make(map[string]bool, len(unmute))
+	for _, id := range unmute {
+		removed[id] = true
+	}
+	seen := make(map[string]bool, len(muted)+len(mute))
+	result := []string{}
+	for _, id := range append(append([]string{}, muted...), mute...) {
+		if removed[id] || seen[id] {
+			continue
+		}
+		seen[id] = true
+		result = append(result, id)
+	}
+	return result
+}
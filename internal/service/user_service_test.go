@@ -358,3 +358,140 @@ func Test_userService_GetUserByID(t *testing.T) {
 		})
 	}
 }
+
+func Test_userService_UpdateNotificationPreferences(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	userRepo := mockRepo.NewMockUserRepository(ctrl)
+	userID := uuid.NewString()
+	stored := func() *model.NotificationPreferences {
+		preferences := model.DefaultNotificationPreferences(userID)
+		preferences.MutedTigerIDs = []string{"t1", "t2"}
+		return preferences
+	}
+	daily := model.DigestFrequencyDaily
+	indonesian := model.LocaleID
+	jakarta := "Asia/Jakarta"
+	tests := []struct {
+		name    string
+		input   *model.NotificationPreferencesInput
+		want    *model.NotificationPreferences
+		wantErr bool
+		mocks   []*gomock.Call
+	}{
+		{
+			name:    "should return error if preferences cannot be fetched",
+			input:   &model.NotificationPreferencesInput{},
+			wantErr: true,
+			mocks: []*gomock.Call{
+				userRepo.EXPECT().GetNotificationPreferences(gomock.Any(), userID).Return(nil, errors.New("any error")),
+			},
+		},
+		{
+			name:    "should return error if preferences cannot be saved",
+			input:   &model.NotificationPreferencesInput{},
+			wantErr: true,
+			mocks: []*gomock.Call{
+				userRepo.EXPECT().GetNotificationPreferences(gomock.Any(), userID).Return(stored(), nil),
+				userRepo.EXPECT().SaveNotificationPreferences(gomock.Any(), gomock.Any()).Return(errors.New("any error")),
+			},
+		},
+		{
+			name: "success changes only the given preferences",
+			input: &model.NotificationPreferencesInput{
+				DigestFrequency: &daily,
+				MuteTigerIDs:    []string{"t3", "t1"},
+				UnmuteTigerIDs:  []string{"t2"},
+			},
+			want: &model.NotificationPreferences{
+				UserID:          userID,
+				EmailEnabled:    true,
+				DigestFrequency: model.DigestFrequencyDaily,
+				Locale:          model.LocaleEn,
+				Timezone:        "UTC",
+				MutedTigerIDs:   []string{"t1", "t3"},
+			},
+			mocks: []*gomock.Call{
+				userRepo.EXPECT().GetNotificationPreferences(gomock.Any(), userID).Return(stored(), nil),
+				userRepo.EXPECT().SaveNotificationPreferences(gomock.Any(), gomock.Any()).Return(nil),
+			},
+		},
+		{
+			name:    "should reject an unknown time zone",
+			input:   &model.NotificationPreferencesInput{Timezone: ptr("Asia/Atlantis")},
+			wantErr: true,
+			mocks: []*gomock.Call{
+				userRepo.EXPECT().GetNotificationPreferences(gomock.Any(), userID).Return(stored(), nil),
+			},
+		},
+		{
+			name:    "should reject the server's local time zone",
+			input:   &model.NotificationPreferencesInput{Timezone: ptr("Local")},
+			wantErr: true,
+			mocks: []*gomock.Call{
+				userRepo.EXPECT().GetNotificationPreferences(gomock.Any(), userID).Return(stored(), nil),
+			},
+		},
+		{
+			name:  "success changes the locale and time zone",
+			input: &model.NotificationPreferencesInput{Locale: &indonesian, Timezone: &jakarta},
+			want: &model.NotificationPreferences{
+				UserID:          userID,
+				EmailEnabled:    true,
+				DigestFrequency: model.DigestFrequencyImmediate,
+				Locale:          model.LocaleID,
+				Timezone:        "Asia/Jakarta",
+				MutedTigerIDs:   []string{"t1", "t2"},
+			},
+			mocks: []*gomock.Call{
+				userRepo.EXPECT().GetNotificationPreferences(gomock.Any(), userID).Return(stored(), nil),
+				userRepo.EXPECT().SaveNotificationPreferences(gomock.Any(), gomock.Any()).Return(nil),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &userService{userRepo: userRepo}
+			got, err := s.UpdateNotificationPreferences(context.Background(), userID, tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("userService.UpdateNotificationPreferences() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("userService.UpdateNotificationPreferences() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_userService_Unsubscribe(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	userRepo := mockRepo.NewMockUserRepository(ctrl)
+	userID := uuid.NewString()
+	tests := []struct {
+		name    string
+		tigerID string
+		check   func(preferences *model.NotificationPreferences) bool
+	}{
+		{
+			name:  "should turn emails off without a tiger",
+			check: func(p *model.NotificationPreferences) bool { return !p.EmailEnabled && len(p.MutedTigerIDs) == 0 },
+		},
+		{
+			name:    "should mute the tiger and keep emails on",
+			tigerID: "t1",
+			check:   func(p *model.NotificationPreferences) bool { return p.EmailEnabled && p.Mutes("t1") },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userRepo.EXPECT().GetNotificationPreferences(gomock.Any(), userID).Return(model.DefaultNotificationPreferences(userID), nil)
+			userRepo.EXPECT().SaveNotificationPreferences(gomock.Any(), gomock.Cond(func(x any) bool {
+				return tt.check(x.(*model.NotificationPreferences))
+			})).Return(nil)
+			s := &userService{userRepo: userRepo}
+			if err := s.Unsubscribe(context.Background(), userID, tt.tigerID); err != nil {
+				t.Errorf("userService.Unsubscribe() error = %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/nurcholisnanda/tigerhall-kittens/internal/api/graph/model"
+	"github.com/nurcholisnanda/tigerhall-kittens/internal/repository"
+	"github.com/nurcholisnanda/tigerhall-kittens/pkg/helper"
+	"github.com/nurcholisnanda/tigerhall-kittens/pkg/logger"
+)
+
+const (
+	maxWatchZonesPerUser   = 100
+	maxWatchZoneNameLength = 100
+	minPolygonVertices     = 3
+	maxPolygonVertices     = 100
+)
+
+type watchZoneService struct {
+	watchZoneRepo repository.WatchZoneRepository
+}
+
+func NewWatchZoneService(watchZoneRepo repository.WatchZoneRepository) WatchZoneService {
+	return &watchZoneService{
+		watchZoneRepo: watchZoneRepo,
+	}
+}
+
+// CreateWatchZone adds a zone the user is alerted about whenever a tiger is
+// sighted inside it
+func (s *watchZoneService) CreateWatchZone(ctx context.Context, userID string, input *model.WatchZoneInput) (*model.WatchZone, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" || len(name) > maxWatchZoneNameLength {
+		return nil, &helper.InvalidWatchZoneError{
+			Message: fmt.Sprintf("name must be between 1 and %d characters", maxWatchZoneNameLength),
+		}
+	}
+	zone := &model.WatchZone{
+		ID:     uuid.NewString(),
+		UserID: userID,
+		Name:   name,
+	}
+	var err error
+	switch {
+	case input.Polygon != nil && input.Center == nil && input.RadiusMeters == nil:
+		err = setPolygon(zone, input.Polygon)
+	case input.Polygon == nil && input.Center != nil && input.RadiusMeters != nil:
+		err = setCircle(zone, input.Center, *input.RadiusMeters)
+	default:
+		err = &helper.InvalidWatchZoneError{Message: "a watch zone needs either a polygon or a center and a radius"}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := s.watchZoneRepo.CreateWatchZone(ctx, zone, maxWatchZonesPerUser)
+	if err != nil {
+		logger.Logger(ctx).Error("Failed to create watch zone:", err)
+		return nil, helper.NewCustomError("Failed to create watch zone", http.StatusInternalServerError)
+	}
+	if !created {
+		return nil, &helper.InvalidWatchZoneError{
+			Message: fmt.Sprintf("a user can have at most %d watch zones", maxWatchZonesPerUser),
+		}
+	}
+	return zone, nil
+}
+
+func (s *watchZoneService) ListWatchZones(ctx context.Context, userID string) ([]*model.WatchZone, error) {
+	zones, err := s.watchZoneRepo.ListWatchZones(ctx, userID)
+	if err != nil {
+		logger.Logger(ctx).Error("Failed to list watch zones:", err)
+		return nil, helper.NewCustomError("Failed to list watch zones", http.StatusInternalServerError)
+	}
+	return zones, nil
+}
+
+// setPolygon makes zone a polygon zone. Longitudes spanning 180 degrees or
+// more are taken as a polygon crossing the antimeridian, which is not
+// supported.
+func setPolygon(zone *model.WatchZone, vertices []*model.LastSeenCoordinateInput) error {
+	if len(vertices) < minPolygonVertices || len(vertices) > maxPolygonVertices {
+		return &helper.InvalidWatchZoneError{
+			Message: fmt.Sprintf("a polygon must have between %d and %d vertices", minPolygonVertices, maxPolygonVertices),
+		}
+	}
+	zone.Shape = model.WatchZoneShapePolygon
+	zone.Polygon = make([]*model.LastSeenCoordinate, 0, len(vertices))
+	zone.MinLatitude, zone.MaxLatitude = math.Inf(1), math.Inf(-1)
+	zone.MinLongitude, zone.MaxLongitude = math.Inf(1), math.Inf(-1)
+	for _, vertex := range vertices {
+		if !isValidLatitude(vertex.Latitude) || !isValidLongitude(vertex.Longitude) {
+			return &helper.InvalidCoordinatesError{
+				Message: "latitude must be between -90 and 90, longitude between -180 and 180",
+			}
+		}
+		zone.Polygon = append(zone.Polygon, (*model.LastSeenCoordinate)(vertex))
+		zone.MinLatitude = math.Min(zone.MinLatitude, vertex.Latitude)
+		zone.MaxLatitude = math.Max(zone.MaxLatitude, vertex.Latitude)
+		zone.MinLongitude = math.Min(zone.MinLongitude, vertex.Longitude)
+		zone.MaxLongitude = math.Max(zone.MaxLongitude, vertex.Longitude)
+	}
+	if zone.MaxLongitude-zone.MinLongitude >= 180 {
+		return &helper.InvalidWatchZoneError{Message: "a polygon may not cross the antimeridian"}
+	}
+	return nil
+}
+
+// setCircle makes zone a circle zone
+func setCircle(zone *model.WatchZone, center *model.LastSeenCoordinateInput, radiusMeters float64) error {
+	if !isValidLatitude(center.Latitude) || !isValidLongitude(center.Longitude) {
+		return &helper.InvalidCoordinatesError{
+			Message: "latitude must be between -90 and 90, longitude between -180 and 180",
+		}
+	}
+	if radiusMeters <= 0 || radiusMeters > maxSearchRadius {
+		return &helper.InvalidRadiusError{
+			Message: fmt.Sprintf("radius must be greater than 0 and at most %.0f meters", maxSearchRadius),
+		}
+	}
+	zone.Shape = model.WatchZoneShapeCircle
+	zone.CenterLatitude = &center.Latitude
+	zone.CenterLongitude = &center.Longitude
+	zone.RadiusMeters = &radiusMeters
+	box := boundingBoxAround((*model.LastSeenCoordinate)(center), radiusMeters)
+	zone.MinLatitude, zone.MaxLatitude = box.SouthWest.Latitude, box.NorthEast.Latitude
+	zone.MinLongitude, zone.MaxLongitude = box.SouthWest.Longitude, box.NorthEast.Longitude
+	return nil
+}
@@ -0,0 +1,203 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/nurcholisnanda/tigerhall-kittens/internal/api/graph/model"
+	mockRepo "github.com/nurcholisnanda/tigerhall-kittens/internal/repository/mock"
+	"github.com/nurcholisnanda/tigerhall-kittens/pkg/helper"
+	"go.uber.org/mock/gomock"
+)
+
+func Test_watchZoneService_CreateWatchZone(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	watchZoneRepo := mockRepo.NewMockWatchZoneRepository(ctrl)
+	triangle := []*model.LastSeenCoordinateInput{
+		{Latitude: 0, Longitude: 100},
+		{Latitude: 0, Longitude: 102},
+		{Latitude: 2, Longitude: 101},
+	}
+	center := &model.LastSeenCoordinateInput{Latitude: 0, Longitude: 179.9}
+	tests := []struct {
+		name        string
+		input       *model.WatchZoneInput
+		want        *model.WatchZone
+		wantErrType error
+		mocks       []*gomock.Call
+	}{
+		{
+			name:        "should reject a zone without a name",
+			input:       &model.WatchZoneInput{Name: " ", Polygon: triangle},
+			wantErrType: &helper.InvalidWatchZoneError{},
+		},
+		{
+			name:        "should reject a zone with both shapes",
+			input:       &model.WatchZoneInput{Name: "village", Polygon: triangle, Center: center, RadiusMeters: ptr(1000.0)},
+			wantErrType: &helper.InvalidWatchZoneError{},
+		},
+		{
+			name:        "should reject a circle without a radius",
+			input:       &model.WatchZoneInput{Name: "village", Center: center},
+			wantErrType: &helper.InvalidWatchZoneError{},
+		},
+		{
+			name:        "should reject a polygon with too few vertices",
+			input:       &model.WatchZoneInput{Name: "village", Polygon: triangle[:2]},
+			wantErrType: &helper.InvalidWatchZoneError{},
+		},
+		{
+			name: "should reject a polygon with invalid coordinates",
+			input: &model.WatchZoneInput{Name: "village", Polygon: []*model.LastSeenCoordinateInput{
+				{Latitude: 0, Longitude: 100}, {Latitude: 0, Longitude: 102}, {Latitude: 91, Longitude: 101},
+			}},
+			wantErrType: &helper.InvalidCoordinatesError{},
+		},
+		{
+			name: "should reject a polygon crossing the antimeridian",
+			input: &model.WatchZoneInput{Name: "village", Polygon: []*model.LastSeenCoordinateInput{
+				{Latitude: 0, Longitude: 179}, {Latitude: 0, Longitude: -179}, {Latitude: 2, Longitude: 180},
+			}},
+			wantErrType: &helper.InvalidWatchZoneError{},
+		},
+		{
+			name:        "should reject a radius that is too large",
+			input:       &model.WatchZoneInput{Name: "village", Center: center, RadiusMeters: ptr(maxSearchRadius + 1)},
+			wantErrType: &helper.InvalidRadiusError{},
+		},
+		{
+			name:        "should reject a zone above the limit",
+			input:       &model.WatchZoneInput{Name: "village", Polygon: triangle},
+			wantErrType: &helper.InvalidWatchZoneError{},
+			mocks: []*gomock.Call{
+				watchZoneRepo.EXPECT().CreateWatchZone(gomock.Any(), gomock.Any(), maxWatchZonesPerUser).Return(false, nil),
+			},
+		},
+		{
+			name:        "should return error if creating fails",
+			input:       &model.WatchZoneInput{Name: "village", Polygon: triangle},
+			wantErrType: &helper.CustomError{},
+			mocks: []*gomock.Call{
+				watchZoneRepo.EXPECT().CreateWatchZone(gomock.Any(), gomock.Any(), maxWatchZonesPerUser).Return(false, errors.New("any error")),
+			},
+		},
+		{
+			name:  "success creates a polygon zone",
+			input: &model.WatchZoneInput{Name: " village ", Polygon: triangle},
+			want: &model.WatchZone{
+				UserID:       "user-1",
+				Name:         "village",
+				Shape:        model.WatchZoneShapePolygon,
+				Polygon:      []*model.LastSeenCoordinate{{Latitude: 0, Longitude: 100}, {Latitude: 0, Longitude: 102}, {Latitude: 2, Longitude: 101}},
+				MinLatitude:  0,
+				MaxLatitude:  2,
+				MinLongitude: 100,
+				MaxLongitude: 102,
+			},
+			mocks: []*gomock.Call{
+				watchZoneRepo.EXPECT().CreateWatchZone(gomock.Any(), gomock.Any(), maxWatchZonesPerUser).Return(true, nil),
+			},
+		},
+		{
+			name:  "success creates a circle zone across the antimeridian",
+			input: &model.WatchZoneInput{Name: "village", Center: center, RadiusMeters: ptr(111195.0)},
+			want: &model.WatchZone{
+				UserID:          "user-1",
+				Name:            "village",
+				Shape:           model.WatchZoneShapeCircle,
+				CenterLatitude:  ptr(0.0),
+				CenterLongitude: ptr(179.9),
+				RadiusMeters:    ptr(111195.0),
+				MinLatitude:     -1,
+				MaxLatitude:     1,
+				MinLongitude:    178.9,
+				MaxLongitude:    -179.1,
+			},
+			mocks: []*gomock.Call{
+				watchZoneRepo.EXPECT().CreateWatchZone(gomock.Any(), gomock.Any(), maxWatchZonesPerUser).Return(true, nil),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &watchZoneService{
+				watchZoneRepo: watchZoneRepo,
+			}
+			got, err := s.CreateWatchZone(context.Background(), "user-1", tt.input)
+			if tt.wantErrType != nil {
+				if reflect.TypeOf(err) != reflect.TypeOf(tt.wantErrType) {
+					t.Errorf("watchZoneService.CreateWatchZone() error = %T, want %T", err, tt.wantErrType)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("watchZoneService.CreateWatchZone() error = %v", err)
+			}
+			if got.ID == "" {
+				t.Errorf("watchZoneService.CreateWatchZone() did not assign an ID")
+			}
+			bounds := &model.BoundingBox{
+				SouthWest: &model.LastSeenCoordinateInput{Latitude: got.MinLatitude, Longitude: got.MinLongitude},
+				NorthEast: &model.LastSeenCoordinateInput{Latitude: got.MaxLatitude, Longitude: got.MaxLongitude},
+			}
+			wantBounds := &model.BoundingBox{
+				SouthWest: &model.LastSeenCoordinateInput{Latitude: tt.want.MinLatitude, Longitude: tt.want.MinLongitude},
+				NorthEast: &model.LastSeenCoordinateInput{Latitude: tt.want.MaxLatitude, Longitude: tt.want.MaxLongitude},
+			}
+			if !closeCoordinate(bounds.SouthWest, wantBounds.SouthWest) || !closeCoordinate(bounds.NorthEast, wantBounds.NorthEast) {
+				t.Errorf("watchZoneService.CreateWatchZone() bounds = %v %v, want %v %v",
+					bounds.SouthWest, bounds.NorthEast, wantBounds.SouthWest, wantBounds.NorthEast)
+			}
+			got.ID = ""
+			got.MinLatitude, got.MaxLatitude, got.MinLongitude, got.MaxLongitude = 0, 0, 0, 0
+			tt.want.MinLatitude, tt.want.MaxLatitude, tt.want.MinLongitude, tt.want.MaxLongitude = 0, 0, 0, 0
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("watchZoneService.CreateWatchZone() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_watchZoneService_ListWatchZones(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	watchZoneRepo := mockRepo.NewMockWatchZoneRepository(ctrl)
+	zones := []*model.WatchZone{{ID: "zone-1"}, {ID: "zone-2"}}
+	tests := []struct {
+		name    string
+		want    []*model.WatchZone
+		wantErr bool
+		mocks   []*gomock.Call
+	}{
+		{
+			name:    "should return error if listing fails",
+			wantErr: true,
+			mocks: []*gomock.Call{
+				watchZoneRepo.EXPECT().ListWatchZones(gomock.Any(), "user-1").Return(nil, errors.New("any error")),
+			},
+		},
+		{
+			name: "success",
+			want: zones,
+			mocks: []*gomock.Call{
+				watchZoneRepo.EXPECT().ListWatchZones(gomock.Any(), "user-1").Return(zones, nil),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &watchZoneService{
+				watchZoneRepo: watchZoneRepo,
+			}
+			got, err := s.ListWatchZones(context.Background(), "user-1")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("watchZoneService.ListWatchZones() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("watchZoneService.ListWatchZones() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
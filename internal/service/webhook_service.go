@@ -0,0 +1,429 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nurcholisnanda/tigerhall-kittens/internal/api/graph/model"
+	"github.com/nurcholisnanda/tigerhall-kittens/internal/repository"
+	"github.com/nurcholisnanda/tigerhall-kittens/pkg/helper"
+	"github.com/nurcholisnanda/tigerhall-kittens/pkg/logger"
+	"gorm.io/gorm"
+)
+
+const (
+	// webhookBatchSize is how many deliveries a dispatch claims at once. They
+	// are sent one after the other, so a batch of timeouts has to fit in
+	// webhookLease.
+	webhookBatchSize = 20
+	// webhookTimeout is how long a receiver has to respond
+	webhookTimeout = 10 * time.Second
+	// webhookPollInterval is how often the delivery log is checked for due
+	// deliveries
+	webhookPollInterval = 5 * time.Second
+	// webhookLease is how long a claimed delivery is hidden from other
+	// dispatchers
+	webhookLease = 5 * time.Minute
+	// webhookBaseBackoff is the wait after the first failed attempt, it
+	// doubles with every further failure up to webhookMaxBackoff
+	webhookBaseBackoff = time.Minute
+	webhookMaxBackoff  = 12 * time.Hour
+	// maxWebhookAttempts is how many failed attempts make a delivery dead,
+	// about two days of retries
+	maxWebhookAttempts = 10
+	// minWebhookSecretLength keeps secrets from being guessable
+	minWebhookSecretLength = 16
+	// maxWebhookErrorBody is how much of an error response is kept in the log
+	maxWebhookErrorBody = 512
+)
+
+// Headers sent with every webhook call. The signature is the hex encoded
+// HMAC-SHA256 of the timestamp, a dot and the body, keyed with the
+// subscription's secret and prefixed with "sha256=".
+const (
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookIDHeader        = "X-Webhook-ID"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookSignatureHeader = "X-Webhook-Signature"
+)
+
+type webhookService struct {
+	webhookRepo repository.WebhookRepository
+	client      *http.Client
+}
+
+// NewWebhookService creates a new WebhookService sending webhooks with client
+func NewWebhookService(webhookRepo repository.WebhookRepository, client *http.Client) *webhookService {
+	return &webhookService{
+		webhookRepo: webhookRepo,
+		client:      client,
+	}
+}
+
+// NewWebhookClient creates the client webhooks are sent with: it gives up
+// after webhookTimeout, does not follow redirects and only connects to public
+// addresses
+func NewWebhookClient() *http.Client {
+	return newWebhookClient(publicAddressesOnly)
+}
+
+// newWebhookClient creates the client webhooks are sent with. control is
+// called with every address the client is about to connect to.
+func newWebhookClient(control func(network, address string, c syscall.RawConn) error) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: webhookTimeout,
+		Control: control,
+	}
+	return &http.Client{
+		Timeout: webhookTimeout,
+		// no proxy, the dialer has to see the receiver's address
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: webhookTimeout,
+		},
+		// a redirect is answered like any other non-2xx response
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// publicAddressesOnly refuses connections to loopback, private, link-local
+// (such as cloud metadata at 169.254.169.254) and other internal addresses.
+// It checks the address actually dialed, after DNS resolution, so a host
+// name resolving to an internal address later cannot get past it.
+func publicAddressesOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	if !isPublicAddress(ip) {
+		return fmt.Errorf("refusing to connect to internal address %s", ip)
+	}
+	return nil
+}
+
+// isPublicAddress reports whether ip is reachable on the internet rather
+// than on this host or its network
+func isPublicAddress(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsGlobalUnicast() && !ip.IsPrivate() && !sharedAddressSpace.Contains(ip)
+}
+
+// sharedAddressSpace is the carrier-grade NAT range, internal like the
+// private ranges
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// webhookPayload is the JSON body of a webhook call
+type webhookPayload struct {
+	ID        string    `json:"id"` // the event, shared by all its deliveries
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"createdAt"`
+	Data      any       `json:"data"`
+}
+
+type webhookCoordinate struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+type webhookSighting struct {
+	ID                 string             `json:"id"`
+	TigerID            *string            `json:"tigerID"`
+	LastSeenTime       time.Time          `json:"lastSeenTime"`
+	LastSeenCoordinate *webhookCoordinate `json:"lastSeenCoordinate"`
+	Flagged            bool               `json:"flagged"`
+	Images             int                `json:"images"`
+}
+
+type webhookTiger struct {
+	ID                 string             `json:"id"`
+	Name               string             `json:"name"`
+	DateOfBirth        time.Time          `json:"dateOfBirth"`
+	LastSeenTime       time.Time          `json:"lastSeenTime"`
+	LastSeenCoordinate *webhookCoordinate `json:"lastSeenCoordinate"`
+	Sensitive          bool               `json:"sensitive"`
+	ProfileImageID     *string            `json:"profileImageID"`
+}
+
+func toWebhookCoordinate(coordinate *model.LastSeenCoordinate) *webhookCoordinate {
+	if coordinate == nil {
+		return nil
+	}
+	return &webhookCoordinate{Latitude: coordinate.Latitude, Longitude: coordinate.Longitude}
+}
+
+// Start polls the delivery log in the background and sends due deliveries
+// until ctx is done
+func (s *webhookService) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(webhookPollInterval)
+		defer ticker.Stop()
+		for {
+			// keep going while full batches come back, there may be more
+			for {
+				claimed, err := s.DispatchDue(ctx)
+				if err != nil {
+					logger.Logger(ctx).Error("Failed to dispatch webhooks:", err)
+				}
+				if err != nil || claimed < webhookBatchSize {
+					break
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// sightingCreatedMessage encodes the sighting.created event of a new
+// sighting. A sighting of a sensitive tiger only carries its coarsened
+// coordinate.
+func sightingCreatedMessage(sighting *model.Sighting, tiger *model.Tiger) (*model.WebhookMessage, error) {
+	return newWebhookMessage(model.WebhookEventSightingCreated, &webhookSighting{
+		ID:                 sighting.ID,
+		TigerID:            sighting.TigerID,
+		LastSeenTime:       sighting.LastSeenTime,
+		LastSeenCoordinate: toWebhookCoordinate(publicSighting(sighting, tiger).LastSeenCoordinate),
+		Flagged:            sighting.Flagged,
+		Images:             len(sighting.Images),
+	})
+}
+
+// tigerMessage encodes a tiger.created or tiger.updated event, the last seen
+// coordinate of a sensitive tiger is coarsened
+func tigerMessage(event model.WebhookEvent, tiger *model.Tiger) (*model.WebhookMessage, error) {
+	coordinate := publicTiger(tiger).LastSeenCoordinate
+	return newWebhookMessage(event, &webhookTiger{
+		ID:                 tiger.ID,
+		Name:               tiger.Name,
+		DateOfBirth:        tiger.DateOfBirth,
+		LastSeenTime:       tiger.LastSeenTime,
+		LastSeenCoordinate: toWebhookCoordinate(coordinate),
+		Sensitive:          tiger.Sensitive,
+		ProfileImageID:     tiger.ProfileImageID,
+	})
+}
+
+// newWebhookMessage encodes the payload of a new event, the repositories
+// queue it for every subscription to the event with the change it is about
+func newWebhookMessage(event model.WebhookEvent, data any) (*model.WebhookMessage, error) {
+	now := time.Now()
+	payload := webhookPayload{
+		ID:        uuid.NewString(),
+		Type:      event.Name(),
+		CreatedAt: now.UTC(),
+		Data:      data,
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding webhook payload: %w", err)
+	}
+	return &model.WebhookMessage{
+		Event:     event,
+		EventID:   payload.ID,
+		Payload:   string(body),
+		CreatedAt: now,
+	}, nil
+}
+
+// DispatchDue claims the deliveries that are due and sends them. It returns
+// how many deliveries were claimed.
+func (s *webhookService) DispatchDue(ctx context.Context) (int, error) {
+	deliveries, err := s.webhookRepo.ClaimDueWebhookDeliveries(ctx, time.Now(), webhookLease, webhookBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("error claiming webhook deliveries: %w", err)
+	}
+	for _, delivery := range deliveries {
+		if err := s.deliver(ctx, delivery); err != nil {
+			logger.Logger(ctx).Error("Failed to update webhook delivery:", delivery.ID, err)
+		}
+	}
+	return len(deliveries), nil
+}
+
+// deliver sends a delivery once and records the outcome. The returned error
+// is about recording it, failed calls are stored with the delivery.
+func (s *webhookService) deliver(ctx context.Context, delivery *model.WebhookDelivery) error {
+	if delivery.Subscription == nil {
+		// deleted after the delivery was queued
+		return s.recordFailure(ctx, delivery, nil, errors.New("subscription deleted"), true)
+	}
+	status, err := s.send(ctx, delivery)
+	if err != nil {
+		return s.recordFailure(ctx, delivery, status, err, false)
+	}
+	return s.webhookRepo.MarkWebhookDeliveryDelivered(ctx, delivery.ID, *status, time.Now())
+}
+
+// send posts the payload of a delivery to its subscription. The status is
+// returned whenever the receiver responded, any status but 2xx is an error.
+func (s *webhookService) send(ctx context.Context, delivery *model.WebhookDelivery) (*int, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Tigerhall-Kittens-Webhooks/1.0")
+	req.Header.Set(WebhookEventHeader, delivery.Event.Name())
+	req.Header.Set(WebhookIDHeader, delivery.EventID)
+	req.Header.Set(WebhookDeliveryHeader, delivery.ID)
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	req.Header.Set(WebhookSignatureHeader, WebhookSignature(delivery.Subscription.Secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error sending webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	status := resp.StatusCode
+	if status >= 200 && status < 300 {
+		return &status, nil
+	}
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, maxWebhookErrorBody))
+	return &status, fmt.Errorf("receiver responded %s: %s", resp.Status, bytes.TrimSpace(snippet))
+}
+
+// WebhookSignature signs a webhook body the way receivers verify it
+func WebhookSignature(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// recordFailure schedules the next attempt of a delivery with exponential
+// backoff, or marks it dead once it ran out of attempts
+func (s *webhookService) recordFailure(ctx context.Context, delivery *model.WebhookDelivery, responseStatus *int,
+	cause error, permanent bool) error {
+	message := cause.Error()
+	delivery.Attempts++
+	delivery.ResponseStatus = responseStatus
+	delivery.LastError = &message
+	if permanent || delivery.Attempts >= maxWebhookAttempts {
+		delivery.Status = model.WebhookDeliveryStatusDead
+		logger.Logger(ctx).Error("Giving up on webhook delivery:", delivery.ID, cause)
+	} else {
+		delivery.Status = model.WebhookDeliveryStatusPending
+		delivery.NextAttemptAt = time.Now().Add(exponentialBackoff(delivery.Attempts, webhookBaseBackoff, webhookMaxBackoff))
+	}
+	return s.webhookRepo.RecordWebhookDeliveryFailure(ctx, delivery)
+}
+
+// isPublicHost reports whether the host of a webhook URL may be public. IP
+// addresses have to be public, of host names only localhost is refused.
+func isPublicHost(host string) bool {
+	if ip, err := netip.ParseAddr(host); err == nil {
+		return isPublicAddress(ip)
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	return host != "localhost" && !strings.HasSuffix(host, ".localhost")
+}
+
+// CreateWebhookSubscription registers a partner system for the given events
+func (s *webhookService) CreateWebhookSubscription(ctx context.Context, userID string,
+	input *model.WebhookSubscriptionInput) (*model.WebhookSubscription, error) {
+	target, err := url.Parse(input.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return nil, &helper.InvalidWebhookError{Message: "url must be an absolute http or https URL"}
+	}
+	// host names are checked again when a webhook is sent, they may resolve
+	// to a different address by then
+	if !isPublicHost(target.Hostname()) {
+		return nil, &helper.InvalidWebhookError{Message: "url must point to a public address"}
+	}
+	if len(input.Events) == 0 {
+		return nil, &helper.InvalidWebhookError{Message: "at least one event is required"}
+	}
+	if len(input.Secret) < minWebhookSecretLength {
+		return nil, &helper.InvalidWebhookError{
+			Message: fmt.Sprintf("secret must be at least %d characters", minWebhookSecretLength),
+		}
+	}
+
+	events := make([]model.WebhookEvent, 0, len(input.Events))
+	seen := make(map[model.WebhookEvent]bool, len(input.Events))
+	for _, event := range input.Events {
+		if !seen[event] {
+			seen[event] = true
+			events = append(events, event)
+		}
+	}
+	subscription := &model.WebhookSubscription{
+		ID:        uuid.NewString(),
+		URL:       target.String(),
+		Events:    events,
+		Secret:    input.Secret,
+		CreatedBy: userID,
+	}
+	if err := s.webhookRepo.CreateWebhookSubscription(ctx, subscription); err != nil {
+		logger.Logger(ctx).Error("Failed to create webhook subscription:", err)
+		return nil, helper.NewCustomError("Failed to create webhook subscription", http.StatusInternalServerError)
+	}
+	return subscription, nil
+}
+
+func (s *webhookService) ListWebhookSubscriptions(ctx context.Context) ([]*model.WebhookSubscription, error) {
+	subscriptions, err := s.webhookRepo.ListWebhookSubscriptions(ctx)
+	if err != nil {
+		logger.Logger(ctx).Error("Failed to list webhook subscriptions:", err)
+		return nil, helper.NewCustomError("Failed to list webhook subscriptions", http.StatusInternalServerError)
+	}
+	return subscriptions, nil
+}
+
+// DeleteWebhookSubscription stops sending events to a subscription and
+// returns it
+func (s *webhookService) DeleteWebhookSubscription(ctx context.Context, id string) (*model.WebhookSubscription, error) {
+	subscription, err := s.webhookRepo.GetWebhookSubscription(ctx, id)
+	if err == nil {
+		err = s.webhookRepo.DeleteWebhookSubscription(ctx, id)
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &helper.WebhookSubscriptionNotFoundError{Message: "Webhook subscription not found"}
+		}
+		logger.Logger(ctx).Error("Failed to delete webhook subscription:", err)
+		return nil, helper.NewCustomError("Failed to delete webhook subscription", http.StatusInternalServerError)
+	}
+	return subscription, nil
+}
+
+// ListWebhookDeliveries returns the delivery log, optionally only of one
+// subscription or with one status
+func (s *webhookService) ListWebhookDeliveries(ctx context.Context, subscriptionID *string,
+	status *model.WebhookDeliveryStatus, limit int, offset int) ([]*model.WebhookDelivery, error) {
+	deliveries, err := s.webhookRepo.ListWebhookDeliveries(ctx, subscriptionID, status, limit, offset)
+	if err != nil {
+		logger.Logger(ctx).Error("Failed to list webhook deliveries:", err)
+		return nil, helper.NewCustomError("Failed to list webhook deliveries", http.StatusInternalServerError)
+	}
+	return deliveries, nil
+}
@@ -0,0 +1,552 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nurcholisnanda/tigerhall-kittens/internal/api/graph/model"
+	"github.com/nurcholisnanda/tigerhall-kittens/internal/repository/mock"
+	"github.com/nurcholisnanda/tigerhall-kittens/pkg/helper"
+	"go.uber.org/mock/gomock"
+	"gorm.io/gorm"
+)
+
+const testWebhookSecret = "0123456789abcdef"
+
+// webhookReceiver is a partner system that answers every call with status
+// and keeps the requests it got
+type webhookReceiver struct {
+	*httptest.Server
+	status int
+
+	mu       sync.Mutex
+	requests []*receivedWebhook
+}
+
+type receivedWebhook struct {
+	header http.Header
+	body   []byte
+}
+
+func newWebhookReceiver(t *testing.T) *webhookReceiver {
+	receiver := &webhookReceiver{status: http.StatusOK}
+	receiver.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		receiver.mu.Lock()
+		receiver.requests = append(receiver.requests, &receivedWebhook{header: r.Header.Clone(), body: body})
+		status := receiver.status
+		receiver.mu.Unlock()
+		if status == http.StatusFound {
+			w.Header().Set("Location", "/elsewhere")
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte("receiver says no"))
+	}))
+	t.Cleanup(receiver.Close)
+	return receiver
+}
+
+func (r *webhookReceiver) received() []*receivedWebhook {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.requests
+}
+
+// failedDelivery matches a delivery recorded as failed with the given
+// status, attempt count and response status
+func failedDelivery(status model.WebhookDeliveryStatus, attempts int, responseStatus *int) gomock.Matcher {
+	return gomock.Cond(func(x any) bool {
+		delivery := x.(*model.WebhookDelivery)
+		return delivery.Status == status && delivery.Attempts == attempts && delivery.LastError != nil &&
+			reflect.DeepEqual(delivery.ResponseStatus, responseStatus)
+	})
+}
+
+func Test_webhookService_DispatchDue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	webhookRepo := mock.NewMockWebhookRepository(ctrl)
+	receiver := newWebhookReceiver(t)
+	subscription := &model.WebhookSubscription{
+		ID:     uuid.NewString(),
+		URL:    receiver.URL + "/hooks/tigers",
+		Events: []model.WebhookEvent{model.WebhookEventSightingCreated},
+		Secret: testWebhookSecret,
+	}
+	payload := `{"id":"e1","type":"sighting.created","createdAt":"2024-05-01T10:00:00Z","data":{"id":"s1"}}`
+	delivery := func(attempts int) *model.WebhookDelivery {
+		return &model.WebhookDelivery{
+			ID:             uuid.NewString(),
+			SubscriptionID: subscription.ID,
+			Subscription:   subscription,
+			Event:          model.WebhookEventSightingCreated,
+			EventID:        "e1",
+			Payload:        payload,
+			Status:         model.WebhookDeliveryStatusPending,
+			Attempts:       attempts,
+		}
+	}
+	deleted := delivery(0)
+	deleted.Subscription = nil
+
+	tests := []struct {
+		name        string
+		status      int
+		wantClaimed int
+		wantCalls   int
+		wantErr     bool
+		mocks       []*gomock.Call
+	}{
+		{
+			name:    "should return error if deliveries cannot be claimed",
+			wantErr: true,
+			mocks: []*gomock.Call{
+				webhookRepo.EXPECT().ClaimDueWebhookDeliveries(gomock.Any(), gomock.Any(), webhookLease, webhookBatchSize).
+					Return(nil, errors.New("any error")),
+			},
+		},
+		{
+			name:        "should mark the delivery delivered if the receiver accepts it",
+			status:      http.StatusNoContent,
+			wantClaimed: 1,
+			wantCalls:   1,
+			mocks: []*gomock.Call{
+				webhookRepo.EXPECT().ClaimDueWebhookDeliveries(gomock.Any(), gomock.Any(), webhookLease, webhookBatchSize).
+					Return([]*model.WebhookDelivery{delivery(0)}, nil),
+				webhookRepo.EXPECT().MarkWebhookDeliveryDelivered(gomock.Any(), gomock.Any(), http.StatusNoContent, gomock.Any()).Return(nil),
+			},
+		},
+		{
+			name:        "should schedule a retry if the receiver fails",
+			status:      http.StatusInternalServerError,
+			wantClaimed: 1,
+			wantCalls:   1,
+			mocks: []*gomock.Call{
+				webhookRepo.EXPECT().ClaimDueWebhookDeliveries(gomock.Any(), gomock.Any(), webhookLease, webhookBatchSize).
+					Return([]*model.WebhookDelivery{delivery(0)}, nil),
+				webhookRepo.EXPECT().RecordWebhookDeliveryFailure(gomock.Any(),
+					failedDelivery(model.WebhookDeliveryStatusPending, 1, ptr(http.StatusInternalServerError))).Return(nil),
+			},
+		},
+		{
+			name:        "should not follow redirects",
+			status:      http.StatusFound,
+			wantClaimed: 1,
+			wantCalls:   1,
+			mocks: []*gomock.Call{
+				webhookRepo.EXPECT().ClaimDueWebhookDeliveries(gomock.Any(), gomock.Any(), webhookLease, webhookBatchSize).
+					Return([]*model.WebhookDelivery{delivery(0)}, nil),
+				webhookRepo.EXPECT().RecordWebhookDeliveryFailure(gomock.Any(),
+					failedDelivery(model.WebhookDeliveryStatusPending, 1, ptr(http.StatusFound))).Return(nil),
+			},
+		},
+		{
+			name:        "should mark the delivery dead after the last attempt",
+			status:      http.StatusBadGateway,
+			wantClaimed: 1,
+			wantCalls:   1,
+			mocks: []*gomock.Call{
+				webhookRepo.EXPECT().ClaimDueWebhookDeliveries(gomock.Any(), gomock.Any(), webhookLease, webhookBatchSize).
+					Return([]*model.WebhookDelivery{delivery(maxWebhookAttempts - 1)}, nil),
+				webhookRepo.EXPECT().RecordWebhookDeliveryFailure(gomock.Any(),
+					failedDelivery(model.WebhookDeliveryStatusDead, maxWebhookAttempts, ptr(http.StatusBadGateway))).Return(nil),
+			},
+		},
+		{
+			name:        "should mark the delivery dead if the subscription was deleted",
+			wantClaimed: 1,
+			mocks: []*gomock.Call{
+				webhookRepo.EXPECT().ClaimDueWebhookDeliveries(gomock.Any(), gomock.Any(), webhookLease, webhookBatchSize).
+					Return([]*model.WebhookDelivery{deleted}, nil),
+				webhookRepo.EXPECT().RecordWebhookDeliveryFailure(gomock.Any(),
+					failedDelivery(model.WebhookDeliveryStatusDead, 1, nil)).Return(nil),
+			},
+		},
+		{
+			name:        "should keep going if an outcome cannot be recorded",
+			status:      http.StatusOK,
+			wantClaimed: 2,
+			wantCalls:   2,
+			mocks: []*gomock.Call{
+				webhookRepo.EXPECT().ClaimDueWebhookDeliveries(gomock.Any(), gomock.Any(), webhookLease, webhookBatchSize).
+					Return([]*model.WebhookDelivery{delivery(0), delivery(0)}, nil),
+				webhookRepo.EXPECT().MarkWebhookDeliveryDelivered(gomock.Any(), gomock.Any(), http.StatusOK, gomock.Any()).
+					Return(errors.New("any error")),
+				webhookRepo.EXPECT().MarkWebhookDeliveryDelivered(gomock.Any(), gomock.Any(), http.StatusOK, gomock.Any()).Return(nil),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			receiver.mu.Lock()
+			receiver.status, receiver.requests = tt.status, nil
+			receiver.mu.Unlock()
+
+			s := NewWebhookService(webhookRepo, newWebhookClient(nil))
+			got, err := s.DispatchDue(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("webhookService.DispatchDue() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.wantClaimed {
+				t.Errorf("webhookService.DispatchDue() = %v, want %v", got, tt.wantClaimed)
+			}
+			if calls := len(receiver.received()); calls != tt.wantCalls {
+				t.Errorf("webhookService.DispatchDue() called the receiver %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func Test_webhookService_DispatchDue_signature(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	webhookRepo := mock.NewMockWebhookRepository(ctrl)
+	receiver := newWebhookReceiver(t)
+	delivery := &model.WebhookDelivery{
+		ID:      uuid.NewString(),
+		Event:   model.WebhookEventTigerUpdated,
+		EventID: "e1",
+		Payload: `{"id":"e1","type":"tiger.updated","createdAt":"2024-05-01T10:00:00Z","data":{"id":"t1"}}`,
+		Subscription: &model.WebhookSubscription{
+			URL:    receiver.URL,
+			Secret: testWebhookSecret,
+		},
+	}
+	webhookRepo.EXPECT().ClaimDueWebhookDeliveries(gomock.Any(), gomock.Any(), webhookLease, webhookBatchSize).
+		Return([]*model.WebhookDelivery{delivery}, nil)
+	webhookRepo.EXPECT().MarkWebhookDeliveryDelivered(gomock.Any(), delivery.ID, http.StatusOK, gomock.Any()).Return(nil)
+
+	if _, err := NewWebhookService(webhookRepo, newWebhookClient(nil)).DispatchDue(context.Background()); err != nil {
+		t.Fatalf("webhookService.DispatchDue() error = %v", err)
+	}
+	requests := receiver.received()
+	if len(requests) != 1 {
+		t.Fatalf("webhookService.DispatchDue() called the receiver %d times, want 1", len(requests))
+	}
+	got := requests[0]
+	if string(got.body) != delivery.Payload {
+		t.Errorf("webhookService.DispatchDue() sent %s, want %s", got.body, delivery.Payload)
+	}
+	for header, want := range map[string]string{
+		"Content-Type":        "application/json",
+		WebhookEventHeader:    "tiger.updated",
+		WebhookIDHeader:       "e1",
+		WebhookDeliveryHeader: delivery.ID,
+	} {
+		if value := got.header.Get(header); value != want {
+			t.Errorf("webhookService.DispatchDue() sent %s %q, want %q", header, value, want)
+		}
+	}
+
+	// verified the way a receiver would, without the service's helper
+	timestamp := got.header.Get(WebhookTimestampHeader)
+	mac := hmac.New(sha256.New, []byte(testWebhookSecret))
+	mac.Write([]byte(timestamp + "." + string(got.body)))
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if signature := got.header.Get(WebhookSignatureHeader); !hmac.Equal([]byte(signature), []byte(want)) {
+		t.Errorf("webhookService.DispatchDue() signed %q, want %q", signature, want)
+	}
+	if signature := WebhookSignature("another secret", timestamp, got.body); signature == want {
+		t.Errorf("WebhookSignature() does not depend on the secret")
+	}
+}
+
+func Test_webhookService_recordFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	webhookRepo := mock.NewMockWebhookRepository(ctrl)
+	webhookRepo.EXPECT().RecordWebhookDeliveryFailure(gomock.Any(), gomock.Any()).Return(nil)
+
+	s := &webhookService{webhookRepo: webhookRepo}
+	delivery := &model.WebhookDelivery{ID: uuid.NewString(), Attempts: 2}
+	before := time.Now()
+	if err := s.recordFailure(context.Background(), delivery, nil, errors.New("connection refused"), false); err != nil {
+		t.Fatalf("webhookService.recordFailure() error = %v", err)
+	}
+	if delivery.LastError == nil || *delivery.LastError != "connection refused" {
+		t.Errorf("webhookService.recordFailure() LastError = %v, want connection refused", delivery.LastError)
+	}
+	if wait := delivery.NextAttemptAt.Sub(before); wait < 4*webhookBaseBackoff || wait > 4*webhookBaseBackoff+time.Second {
+		t.Errorf("webhookService.recordFailure() scheduled the next attempt in %v, want %v", wait, 4*webhookBaseBackoff)
+	}
+}
+
+// webhookData decodes the data of a webhook message
+func webhookData(t *testing.T, message *model.WebhookMessage) map[string]any {
+	t.Helper()
+	var payload struct {
+		ID   string         `json:"id"`
+		Type string         `json:"type"`
+		Data map[string]any `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(message.Payload), &payload); err != nil {
+		t.Fatalf("webhook message %s is invalid JSON: %v", message.Payload, err)
+	}
+	if payload.ID != message.EventID || payload.Type != message.Event.Name() {
+		t.Errorf("webhook message %s does not match event %s %s", message.Payload, message.Event.Name(), message.EventID)
+	}
+	return payload.Data
+}
+
+func Test_sightingCreatedMessage(t *testing.T) {
+	tigerID := "t1"
+	sighting := &model.Sighting{
+		ID:                 "s1",
+		TigerID:            &tigerID,
+		LastSeenTime:       time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+		LastSeenCoordinate: &model.LastSeenCoordinate{Latitude: -2.5432, Longitude: 112.9876},
+		CreatedBy:          "u1",
+	}
+	tests := []struct {
+		name           string
+		tiger          *model.Tiger
+		wantCoordinate map[string]any
+	}{
+		{
+			name:           "exact coordinate of a tiger",
+			tiger:          &model.Tiger{ID: tigerID},
+			wantCoordinate: map[string]any{"latitude": -2.5432, "longitude": 112.9876},
+		},
+		{
+			name:           "coarsened coordinate of a sensitive tiger",
+			tiger:          &model.Tiger{ID: tigerID, Sensitive: true},
+			wantCoordinate: map[string]any{"latitude": -2.5, "longitude": 113.0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := sightingCreatedMessage(sighting, tt.tiger)
+			if err != nil {
+				t.Fatalf("sightingCreatedMessage() error = %v", err)
+			}
+			data := webhookData(t, got)
+			if got.Event != model.WebhookEventSightingCreated || data["id"] != "s1" || data["tigerID"] != "t1" {
+				t.Errorf("sightingCreatedMessage() = %s %s", got.Event.Name(), got.Payload)
+			}
+			if !reflect.DeepEqual(data["lastSeenCoordinate"], tt.wantCoordinate) {
+				t.Errorf("sightingCreatedMessage() coordinate = %v, want %v", data["lastSeenCoordinate"], tt.wantCoordinate)
+			}
+			if _, ok := data["createdBy"]; ok {
+				t.Errorf("sightingCreatedMessage() shared who reported the sighting: %s", got.Payload)
+			}
+		})
+	}
+}
+
+func Test_tigerMessage(t *testing.T) {
+	tiger := &model.Tiger{
+		ID:                 "t1",
+		Name:               "Raja",
+		LastSeenCoordinate: &model.LastSeenCoordinate{Latitude: -2.5432, Longitude: 112.9876},
+		Sensitive:          true,
+	}
+	got, err := tigerMessage(model.WebhookEventTigerUpdated, tiger)
+	if err != nil {
+		t.Fatalf("tigerMessage() error = %v", err)
+	}
+	data := webhookData(t, got)
+	want := map[string]any{"latitude": -2.5, "longitude": 113.0}
+	if got.Event != model.WebhookEventTigerUpdated || data["id"] != "t1" || !reflect.DeepEqual(data["lastSeenCoordinate"], want) {
+		t.Errorf("tigerMessage() = %s %s, want tiger.updated at %v", got.Event.Name(), got.Payload, want)
+	}
+	if tiger.LastSeenCoordinate.Latitude != -2.5432 {
+		t.Errorf("tigerMessage() changed the tiger's coordinate to %v", tiger.LastSeenCoordinate)
+	}
+}
+
+func Test_publicAddressesOnly(t *testing.T) {
+	tests := []struct {
+		address string
+		wantErr bool
+	}{
+		{address: "93.184.216.34:443"},
+		{address: "[2606:2800:220:1:248:1893:25c8:1946]:443"},
+		{address: "127.0.0.1:80", wantErr: true},
+		{address: "10.0.0.5:80", wantErr: true},
+		{address: "172.16.0.1:80", wantErr: true},
+		{address: "192.168.1.1:80", wantErr: true},
+		{address: "169.254.169.254:80", wantErr: true},
+		{address: "100.64.0.1:80", wantErr: true},
+		{address: "0.0.0.0:80", wantErr: true},
+		{address: "[::1]:80", wantErr: true},
+		{address: "[fe80::1]:80", wantErr: true},
+		{address: "[fd00:ec2::254]:80", wantErr: true},
+		{address: "[::ffff:127.0.0.1]:80", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			if err := publicAddressesOnly("tcp", tt.address, nil); (err != nil) != tt.wantErr {
+				t.Errorf("publicAddressesOnly() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_webhookService_send_refusesInternalAddresses(t *testing.T) {
+	receiver := newWebhookReceiver(t)
+	s := NewWebhookService(nil, NewWebhookClient())
+	status, err := s.send(context.Background(), &model.WebhookDelivery{
+		ID:           "d1",
+		Event:        model.WebhookEventTigerCreated,
+		Payload:      "{}",
+		Subscription: &model.WebhookSubscription{URL: receiver.URL, Secret: testWebhookSecret},
+	})
+	if err == nil || status != nil {
+		t.Errorf("webhookService.send() = %v, %v, want an error before connecting", status, err)
+	}
+	if calls := len(receiver.received()); calls != 0 {
+		t.Errorf("webhookService.send() called a receiver on a loopback address %d times", calls)
+	}
+}
+
+func Test_webhookService_CreateWebhookSubscription(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	webhookRepo := mock.NewMockWebhookRepository(ctrl)
+	input := func(url string, secret string, events ...model.WebhookEvent) *model.WebhookSubscriptionInput {
+		return &model.WebhookSubscriptionInput{URL: url, Secret: secret, Events: events}
+	}
+	tests := []struct {
+		name       string
+		input      *model.WebhookSubscriptionInput
+		wantEvents []model.WebhookEvent
+		wantErr    error
+		mocks      []*gomock.Call
+	}{
+		{
+			name:    "should reject a relative url",
+			input:   input("/hooks", testWebhookSecret, model.WebhookEventTigerCreated),
+			wantErr: &helper.InvalidWebhookError{},
+		},
+		{
+			name:    "should reject a url that is not http",
+			input:   input("ftp://partner.example.org/hooks", testWebhookSecret, model.WebhookEventTigerCreated),
+			wantErr: &helper.InvalidWebhookError{},
+		},
+		{
+			name:    "should reject a loopback address",
+			input:   input("http://127.0.0.1:8080/hooks", testWebhookSecret, model.WebhookEventTigerCreated),
+			wantErr: &helper.InvalidWebhookError{},
+		},
+		{
+			name:    "should reject the cloud metadata address",
+			input:   input("http://169.254.169.254/latest/meta-data", testWebhookSecret, model.WebhookEventTigerCreated),
+			wantErr: &helper.InvalidWebhookError{},
+		},
+		{
+			name:    "should reject a private IPv6 address",
+			input:   input("http://[fd00::1]/hooks", testWebhookSecret, model.WebhookEventTigerCreated),
+			wantErr: &helper.InvalidWebhookError{},
+		},
+		{
+			name:    "should reject localhost",
+			input:   input("http://LOCALHOST./hooks", testWebhookSecret, model.WebhookEventTigerCreated),
+			wantErr: &helper.InvalidWebhookError{},
+		},
+		{
+			name:    "should reject a subscription without events",
+			input:   input("https://partner.example.org/hooks", testWebhookSecret),
+			wantErr: &helper.InvalidWebhookError{},
+		},
+		{
+			name:    "should reject a short secret",
+			input:   input("https://partner.example.org/hooks", "secret", model.WebhookEventTigerCreated),
+			wantErr: &helper.InvalidWebhookError{},
+		},
+		{
+			name:    "should return error if the subscription cannot be stored",
+			input:   input("https://partner.example.org/hooks", testWebhookSecret, model.WebhookEventTigerCreated),
+			wantErr: &helper.CustomError{},
+			mocks: []*gomock.Call{
+				webhookRepo.EXPECT().CreateWebhookSubscription(gomock.Any(), gomock.Any()).Return(errors.New("any error")),
+			},
+		},
+		{
+			name: "success drops repeated events",
+			input: input("https://partner.example.org/hooks", testWebhookSecret,
+				model.WebhookEventTigerCreated, model.WebhookEventSightingCreated, model.WebhookEventTigerCreated),
+			wantEvents: []model.WebhookEvent{model.WebhookEventTigerCreated, model.WebhookEventSightingCreated},
+			mocks: []*gomock.Call{
+				webhookRepo.EXPECT().CreateWebhookSubscription(gomock.Any(), gomock.Any()).Return(nil),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &webhookService{webhookRepo: webhookRepo}
+			got, err := s.CreateWebhookSubscription(context.Background(), "u1", tt.input)
+			if reflect.TypeOf(err) != reflect.TypeOf(tt.wantErr) {
+				t.Fatalf("webhookService.CreateWebhookSubscription() error = %v, want %T", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(got.Events, tt.wantEvents) || got.CreatedBy != "u1" || got.Secret != testWebhookSecret {
+				t.Errorf("webhookService.CreateWebhookSubscription() = %+v", got)
+			}
+		})
+	}
+}
+
+func Test_webhookService_DeleteWebhookSubscription(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	webhookRepo := mock.NewMockWebhookRepository(ctrl)
+	subscription := &model.WebhookSubscription{ID: "w1"}
+	tests := []struct {
+		name    string
+		wantErr error
+		mocks   []*gomock.Call
+	}{
+		{
+			name:    "should return not found error if the subscription does not exist",
+			wantErr: &helper.WebhookSubscriptionNotFoundError{},
+			mocks: []*gomock.Call{
+				webhookRepo.EXPECT().GetWebhookSubscription(gomock.Any(), "w1").Return(nil, gorm.ErrRecordNotFound),
+			},
+		},
+		{
+			name:    "should return not found error if the subscription was deleted in the meantime",
+			wantErr: &helper.WebhookSubscriptionNotFoundError{},
+			mocks: []*gomock.Call{
+				webhookRepo.EXPECT().GetWebhookSubscription(gomock.Any(), "w1").Return(subscription, nil),
+				webhookRepo.EXPECT().DeleteWebhookSubscription(gomock.Any(), "w1").Return(gorm.ErrRecordNotFound),
+			},
+		},
+		{
+			name:    "should return error if the subscription cannot be deleted",
+			wantErr: &helper.CustomError{},
+			mocks: []*gomock.Call{
+				webhookRepo.EXPECT().GetWebhookSubscription(gomock.Any(), "w1").Return(subscription, nil),
+				webhookRepo.EXPECT().DeleteWebhookSubscription(gomock.Any(), "w1").Return(errors.New("any error")),
+			},
+		},
+		{
+			name: "success",
+			mocks: []*gomock.Call{
+				webhookRepo.EXPECT().GetWebhookSubscription(gomock.Any(), "w1").Return(subscription, nil),
+				webhookRepo.EXPECT().DeleteWebhookSubscription(gomock.Any(), "w1").Return(nil),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &webhookService{webhookRepo: webhookRepo}
+			got, err := s.DeleteWebhookSubscription(context.Background(), "w1")
+			if reflect.TypeOf(err) != reflect.TypeOf(tt.wantErr) {
+				t.Errorf("webhookService.DeleteWebhookSubscription() error = %v, want %T", err, tt.wantErr)
+			}
+			if err == nil && got != subscription {
+				t.Errorf("webhookService.DeleteWebhookSubscription() = %v, want %v", got, subscription)
+			}
+		})
+	}
+}
@@ -46,3 +46,15 @@ func GetUserID(ctx context.Context) (string, error) {
 	}
 	return tokenData.ID, nil
 }
+
+func GetUserRole(ctx context.Context) (string, error) {
+	gc, err := RetrieveGinContext(ctx, "ContextKey")
+	if err != nil {
+		return "", err
+	}
+	role, ok := gc.Value("role").(string)
+	if !ok {
+		return "", errors.New("Access Denied")
+	}
+	return role, nil
+}
@@ -12,6 +12,8 @@ var (
 	INVALID_INPUT     ErrorCode = "INVALID_INPUT"
 	NOT_FOUND         ErrorCode = "NOT_FOUND"
 	CONFLICT          ErrorCode = "CONFLICT"
+	INVALID_IMAGE     ErrorCode = "INVALID_IMAGE"
+	FORBIDDEN         ErrorCode = "FORBIDDEN"
 )
 
 // Custom Errors
@@ -70,6 +72,89 @@ func (e *TigerNotFound) Error() string {
 	return e.Message
 }
 
+type InvalidImageError struct {
+	Message string `json:"message"`
+}
+
+func (e *InvalidImageError) Error() string {
+	return e.Message
+}
+
+type ImageNotFoundError struct {
+	Message string `json:"message"`
+}
+
+func (e *ImageNotFoundError) Error() string {
+	return e.Message
+}
+
+type InvalidPaginationError struct {
+	Message string `json:"message"`
+}
+
+func (e *InvalidPaginationError) Error() string {
+	return e.Message
+}
+
+type SightingNotFoundError struct {
+	Message string `json:"message"`
+}
+
+func (e *SightingNotFoundError) Error() string {
+	return e.Message
+}
+
+type SightingAlreadyIdentifiedError struct {
+	Message string `json:"message"`
+}
+
+func (e *SightingAlreadyIdentifiedError) Error() string {
+	return e.Message
+}
+
+type NotificationNotFoundError struct {
+	Message string `json:"message"`
+}
+
+func (e *NotificationNotFoundError) Error() string {
+	return e.Message
+}
+
+type NotificationJobNotFoundError struct {
+	Message string `json:"message"`
+}
+
+func (e *NotificationJobNotFoundError) Error() string {
+	return e.Message
+}
+
+type NotificationJobNotDeadError struct {
+	Message string `json:"message"`
+}
+
+func (e *NotificationJobNotDeadError) Error() string {
+	return e.Message
+}
+
+// InvalidWatchZoneError reports a watch zone that is neither a valid polygon
+// nor a valid circle
+type InvalidWatchZoneError struct {
+	Message string `json:"message"`
+}
+
+func (e *InvalidWatchZoneError) Error() string {
+	return e.Message
+}
+
+// InvalidTimezoneError reports a time zone that is not in the IANA database
+type InvalidTimezoneError struct {
+	Message string `json:"message"`
+}
+
+func (e *InvalidTimezoneError) Error() string {
+	return e.Message
+}
+
 type SightingTooCloseError struct {
 	Message string `json:"message"`
 }
@@ -78,6 +163,32 @@ func (e *SightingTooCloseError) Error() string {
 	return e.Message
 }
 
+type InvalidRadiusError struct {
+	Message string `json:"message"`
+}
+
+func (e *InvalidRadiusError) Error() string {
+	return e.Message
+}
+
+// InvalidWebhookError reports a webhook subscription without a usable URL,
+// events or secret
+type InvalidWebhookError struct {
+	Message string `json:"message"`
+}
+
+func (e *InvalidWebhookError) Error() string {
+	return e.Message
+}
+
+type WebhookSubscriptionNotFoundError struct {
+	Message string `json:"message"`
+}
+
+func (e *WebhookSubscriptionNotFoundError) Error() string {
+	return e.Message
+}
+
 func As(err error, target any) bool {
 	return errors.As(err, target)
 }
@@ -0,0 +1,283 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"time"
+)
+
+const (
+	tagOrientation        = 0x0112
+	tagExifIFD            = 0x8769
+	tagGPSIFD             = 0x8825
+	tagDateTimeOriginal   = 0x9003
+	tagOffsetTimeOriginal = 0x9011
+	tagGPSLatitudeRef     = 0x0001
+	tagGPSLatitude        = 0x0002
+	tagGPSLongitudeRef    = 0x0003
+	tagGPSLongitude       = 0x0004
+
+	typeASCII    = 2
+	typeShort    = 3
+	typeLong     = 4
+	typeRational = 5
+
+	exifTimeLayout = "2006:01:02 15:04:05"
+)
+
+var (
+	exifHeader = []byte("Exif\x00\x00")
+
+	errNoExif      = errors.New("no exif data")
+	errInvalidExif = errors.New("invalid exif data")
+)
+
+// Exif holds the metadata read from the EXIF block of a JPEG
+type Exif struct {
+	Orientation int
+
+	// DateTimeOriginal is when the photo was taken. Cameras record local
+	// time, so unless TimeZoneKnown is set the value is read as UTC.
+	DateTimeOriginal *time.Time
+	TimeZoneKnown    bool
+
+	// Latitude and Longitude are only meaningful when HasGPS is set
+	HasGPS    bool
+	Latitude  float64
+	Longitude float64
+}
+
+// ReadExif extracts the EXIF metadata of a JPEG. Images that are not JPEGs
+// or carry no EXIF block return an Exif with the default orientation.
+func ReadExif(data []byte) *Exif {
+	exif := &Exif{Orientation: 1}
+	tiff, err := exifSegment(data)
+	if err != nil {
+		return exif
+	}
+	r, err := newTiffReader(tiff)
+	if err != nil {
+		return exif
+	}
+	ifd0, _, err := r.readIFD(r.firstIFD)
+	if err != nil {
+		return exif
+	}
+	if e, ok := ifd0[tagOrientation]; ok {
+		if o := int(r.uint(e)); o >= 1 && o <= 8 {
+			exif.Orientation = o
+		}
+	}
+	if e, ok := ifd0[tagExifIFD]; ok {
+		if sub, _, err := r.readIFD(r.uint(e)); err == nil {
+			r.readDateTime(sub, exif)
+		}
+	}
+	if e, ok := ifd0[tagGPSIFD]; ok {
+		if sub, _, err := r.readIFD(r.uint(e)); err == nil {
+			r.readGPS(sub, exif)
+		}
+	}
+	return exif
+}
+
+func (r *tiffReader) readDateTime(ifd map[uint16]ifdEntry, exif *Exif) {
+	e, ok := ifd[tagDateTimeOriginal]
+	if !ok {
+		return
+	}
+	value := r.ascii(e)
+	location := time.UTC
+	if offset, ok := ifd[tagOffsetTimeOriginal]; ok {
+		if t, err := time.Parse("-07:00", r.ascii(offset)); err == nil {
+			_, seconds := t.Zone()
+			location = time.FixedZone("", seconds)
+			exif.TimeZoneKnown = true
+		}
+	}
+	t, err := time.ParseInLocation(exifTimeLayout, value, location)
+	if err != nil {
+		exif.TimeZoneKnown = false
+		return
+	}
+	exif.DateTimeOriginal = &t
+}
+
+func (r *tiffReader) readGPS(ifd map[uint16]ifdEntry, exif *Exif) {
+	latRef, okLatRef := ifd[tagGPSLatitudeRef]
+	lat, okLat := ifd[tagGPSLatitude]
+	lonRef, okLonRef := ifd[tagGPSLongitudeRef]
+	lon, okLon := ifd[tagGPSLongitude]
+	if !okLatRef || !okLat || !okLonRef || !okLon {
+		return
+	}
+	latitude, ok := r.degrees(lat)
+	if !ok {
+		return
+	}
+	longitude, ok := r.degrees(lon)
+	if !ok {
+		return
+	}
+	switch r.ascii(latRef) {
+	case "S":
+		latitude = -latitude
+	case "N":
+	default:
+		return
+	}
+	switch r.ascii(lonRef) {
+	case "W":
+		longitude = -longitude
+	case "E":
+	default:
+		return
+	}
+	exif.HasGPS = true
+	exif.Latitude = latitude
+	exif.Longitude = longitude
+}
+
+// exifSegment walks the JPEG markers and returns the TIFF structure stored in
+// the APP1 Exif segment
+func exifSegment(data []byte) ([]byte, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, errNoExif
+	}
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return nil, errInvalidExif
+		}
+		marker := data[i+1]
+		// start of scan, image data follows and no more metadata can appear
+		if marker == 0xDA || marker == 0xD9 {
+			return nil, errNoExif
+		}
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		if length < 2 || i+2+length > len(data) {
+			return nil, errInvalidExif
+		}
+		payload := data[i+4 : i+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(payload, exifHeader) {
+			return payload[len(exifHeader):], nil
+		}
+		i += 2 + length
+	}
+	return nil, errNoExif
+}
+
+// ifdEntry is a single tag of an image file directory
+type ifdEntry struct {
+	typ   uint16
+	count uint32
+	value []byte // the 4 byte value/offset field
+}
+
+type tiffReader struct {
+	data     []byte
+	order    binary.ByteOrder
+	firstIFD uint32
+}
+
+func newTiffReader(data []byte) (*tiffReader, error) {
+	if len(data) < 8 {
+		return nil, errInvalidExif
+	}
+	var order binary.ByteOrder
+	switch string(data[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return nil, errInvalidExif
+	}
+	if order.Uint16(data[2:]) != 42 {
+		return nil, errInvalidExif
+	}
+	return &tiffReader{data: data, order: order, firstIFD: order.Uint32(data[4:])}, nil
+}
+
+// readIFD returns the entries of the directory at offset keyed by tag, and
+// the offset of the next directory
+func (r *tiffReader) readIFD(offset uint32) (map[uint16]ifdEntry, uint32, error) {
+	if int(offset)+2 > len(r.data) {
+		return nil, 0, errInvalidExif
+	}
+	count := int(r.order.Uint16(r.data[offset:]))
+	start := int(offset) + 2
+	if start+count*12+4 > len(r.data) {
+		return nil, 0, errInvalidExif
+	}
+	entries := make(map[uint16]ifdEntry, count)
+	for i := 0; i < count; i++ {
+		e := r.data[start+i*12:]
+		entries[r.order.Uint16(e)] = ifdEntry{
+			typ:   r.order.Uint16(e[2:]),
+			count: r.order.Uint32(e[4:]),
+			value: e[8:12],
+		}
+	}
+	return entries, r.order.Uint32(r.data[start+count*12:]), nil
+}
+
+// bytes returns the raw value of an entry, which is stored inline when it
+// fits in 4 bytes and at an offset otherwise
+func (r *tiffReader) bytes(e ifdEntry, size int) ([]byte, bool) {
+	n := int(e.count) * size
+	if n <= 4 {
+		return e.value[:n], true
+	}
+	offset := int(r.order.Uint32(e.value))
+	if offset < 0 || offset+n > len(r.data) {
+		return nil, false
+	}
+	return r.data[offset : offset+n], true
+}
+
+// ascii returns the value of an ASCII entry without its NUL terminator
+func (r *tiffReader) ascii(e ifdEntry) string {
+	if e.typ != typeASCII {
+		return ""
+	}
+	b, ok := r.bytes(e, 1)
+	if !ok {
+		return ""
+	}
+	return strings.TrimRight(string(b), "\x00 ")
+}
+
+// degrees converts a degrees, minutes, seconds RATIONAL triplet to decimal
+// degrees
+func (r *tiffReader) degrees(e ifdEntry) (float64, bool) {
+	if e.typ != typeRational || e.count != 3 {
+		return 0, false
+	}
+	b, ok := r.bytes(e, 8)
+	if !ok {
+		return 0, false
+	}
+	var parts [3]float64
+	for i := range parts {
+		num := r.order.Uint32(b[i*8:])
+		den := r.order.Uint32(b[i*8+4:])
+		if den == 0 {
+			return 0, false
+		}
+		parts[i] = float64(num) / float64(den)
+	}
+	return parts[0] + parts[1]/60 + parts[2]/3600, true
+}
+
+// uint returns the first value of a SHORT or LONG entry
+func (r *tiffReader) uint(e ifdEntry) uint32 {
+	switch e.typ {
+	case typeShort:
+		return uint32(r.order.Uint16(e.value))
+	case typeLong:
+		return r.order.Uint32(e.value)
+	}
+	return 0
+}
@@ -0,0 +1,186 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"math"
+	"testing"
+	"time"
+)
+
+type testEntry struct {
+	tag   uint16
+	typ   uint16
+	count uint32
+	data  []byte
+}
+
+func asciiEntry(tag uint16, s string) testEntry {
+	return testEntry{tag: tag, typ: typeASCII, count: uint32(len(s) + 1), data: append([]byte(s), 0)}
+}
+
+func dmsEntry(order binary.ByteOrder, tag uint16, deg, min, sec100 uint32) testEntry {
+	data := make([]byte, 24)
+	for i, v := range [][2]uint32{{deg, 1}, {min, 1}, {sec100, 100}} {
+		order.PutUint32(data[i*8:], v[0])
+		order.PutUint32(data[i*8+4:], v[1])
+	}
+	return testEntry{tag: tag, typ: typeRational, count: 3, data: data}
+}
+
+// buildTiff lays out IFD0 followed by the optional Exif and GPS directories,
+// with values longer than 4 bytes stored after them
+func buildTiff(order binary.ByteOrder, orientation uint16, exifIFD, gpsIFD []testEntry) []byte {
+	ifd0 := []testEntry{{tag: tagOrientation, typ: typeShort, count: 1, data: make([]byte, 2)}}
+	order.PutUint16(ifd0[0].data, orientation)
+	if exifIFD != nil {
+		ifd0 = append(ifd0, testEntry{tag: tagExifIFD, typ: typeLong, count: 1})
+	}
+	if gpsIFD != nil {
+		ifd0 = append(ifd0, testEntry{tag: tagGPSIFD, typ: typeLong, count: 1})
+	}
+	ifds := [][]testEntry{ifd0, exifIFD, gpsIFD}
+
+	ifdSize := func(entries []testEntry) int { return 2 + 12*len(entries) + 4 }
+	offsets := []int{8, 8 + ifdSize(ifd0), 8 + ifdSize(ifd0) + ifdSize(exifIFD)}
+	dataOffset := offsets[2] + ifdSize(gpsIFD)
+	if gpsIFD == nil {
+		dataOffset = offsets[2]
+	}
+
+	var head, tail bytes.Buffer
+	if order == binary.LittleEndian {
+		head.WriteString("II")
+	} else {
+		head.WriteString("MM")
+	}
+	binary.Write(&head, order, uint16(42))
+	binary.Write(&head, order, uint32(8))
+	for _, entries := range ifds {
+		if entries == nil {
+			continue
+		}
+		binary.Write(&head, order, uint16(len(entries)))
+		for _, e := range entries {
+			binary.Write(&head, order, e.tag)
+			binary.Write(&head, order, e.typ)
+			binary.Write(&head, order, e.count)
+			value := make([]byte, 4)
+			switch {
+			case e.tag == tagExifIFD:
+				order.PutUint32(value, uint32(offsets[1]))
+			case e.tag == tagGPSIFD && e.typ == typeLong:
+				order.PutUint32(value, uint32(offsets[2]))
+			case len(e.data) <= 4:
+				copy(value, e.data)
+			default:
+				order.PutUint32(value, uint32(dataOffset+tail.Len()))
+				tail.Write(e.data)
+			}
+			head.Write(value)
+		}
+		binary.Write(&head, order, uint32(0))
+	}
+	return append(head.Bytes(), tail.Bytes()...)
+}
+
+// withTiff returns a JPEG carrying the TIFF structure as its EXIF block
+func withTiff(t *testing.T, tiff []byte) []byte {
+	t.Helper()
+	var img bytes.Buffer
+	if err := jpeg.Encode(&img, image.NewGray(image.Rect(0, 0, 4, 2)), nil); err != nil {
+		t.Fatalf("jpeg.Encode() error = %v", err)
+	}
+	payload := append(append([]byte{}, exifHeader...), tiff...)
+	var out bytes.Buffer
+	out.Write(img.Bytes()[:2])
+	out.Write([]byte{0xFF, 0xE1})
+	binary.Write(&out, binary.BigEndian, uint16(len(payload)+2))
+	out.Write(payload)
+	out.Write(img.Bytes()[2:])
+	return out.Bytes()
+}
+
+// withExif returns a JPEG whose EXIF block only holds an orientation
+func withExif(t *testing.T, order binary.ByteOrder, orientation uint16) []byte {
+	return withTiff(t, buildTiff(order, orientation, nil, nil))
+}
+
+func fieldPhoto(t *testing.T, order binary.ByteOrder) []byte {
+	return withTiff(t, buildTiff(order, 6,
+		[]testEntry{
+			asciiEntry(tagDateTimeOriginal, "2024:03:15 06:30:00"),
+			asciiEntry(tagOffsetTimeOriginal, "+07:00"),
+		},
+		[]testEntry{
+			asciiEntry(tagGPSLatitudeRef, "S"),
+			dmsEntry(order, tagGPSLatitude, 2, 30, 0),
+			asciiEntry(tagGPSLongitudeRef, "E"),
+			dmsEntry(order, tagGPSLongitude, 101, 15, 3600),
+		}))
+}
+
+func TestReadExif(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want int
+	}{
+		{
+			name: "little endian",
+			data: withExif(t, binary.LittleEndian, 6),
+			want: 6,
+		},
+		{
+			name: "big endian",
+			data: withExif(t, binary.BigEndian, 8),
+			want: 8,
+		},
+		{
+			name: "out of range orientation falls back to the default",
+			data: withExif(t, binary.BigEndian, 42),
+			want: 1,
+		},
+		{
+			name: "not a jpeg",
+			data: []byte("not an image"),
+			want: 1,
+		},
+		{
+			name: "truncated segment",
+			data: withExif(t, binary.LittleEndian, 6)[:12],
+			want: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ReadExif(tt.data).Orientation; got != tt.want {
+				t.Errorf("ReadExif().Orientation = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadExif_DateTimeAndGPS(t *testing.T) {
+	wantTime := time.Date(2024, 3, 14, 23, 30, 0, 0, time.UTC)
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		exif := ReadExif(fieldPhoto(t, order))
+		if exif.DateTimeOriginal == nil || !exif.DateTimeOriginal.Equal(wantTime) || !exif.TimeZoneKnown {
+			t.Errorf("%v: ReadExif().DateTimeOriginal = %v (zone known %v), want %v", order, exif.DateTimeOriginal, exif.TimeZoneKnown, wantTime)
+		}
+		if !exif.HasGPS || math.Abs(exif.Latitude+2.5) > 1e-9 || math.Abs(exif.Longitude-101.26) > 1e-9 {
+			t.Errorf("%v: ReadExif() GPS = %v %v,%v, want -2.5,101.26", order, exif.HasGPS, exif.Latitude, exif.Longitude)
+		}
+	}
+
+	noZone := ReadExif(withTiff(t, buildTiff(binary.BigEndian, 1,
+		[]testEntry{asciiEntry(tagDateTimeOriginal, "2024:03:15 06:30:00")}, nil)))
+	if noZone.DateTimeOriginal == nil || noZone.TimeZoneKnown || !noZone.DateTimeOriginal.Equal(time.Date(2024, 3, 15, 6, 30, 0, 0, time.UTC)) {
+		t.Errorf("ReadExif() without offset = %v (zone known %v), want UTC time", noZone.DateTimeOriginal, noZone.TimeZoneKnown)
+	}
+	if noZone.HasGPS {
+		t.Errorf("ReadExif() without GPS directory reported GPS")
+	}
+}
@@ -0,0 +1,118 @@
+package imaging
+
+import (
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/nfnt/resize"
+)
+
+const (
+	// featureSize is the side of the square gray image features are read from
+	featureSize = 96
+	// featureCells is how many cells each side of the image is split into
+	featureCells = 4
+	// orientationBins is how many gradient orientations, over half a turn,
+	// are told apart
+	orientationBins = 9
+
+	// FeatureLength is how many values a feature vector holds: a histogram
+	// per cell followed by one for the whole image
+	FeatureLength = (featureCells*featureCells + 1) * orientationBins
+)
+
+var errInvalidFeatures = errors.New("invalid feature vector")
+
+// StripeFeatures describes the stripe pattern of an image as histograms of
+// gradient orientations weighted by their strength, one per cell of a 4x4
+// grid and one for the whole image. Stripes produce strong gradients across
+// them, so two photos of the same flank give similar histograms. The vector
+// has unit length and needs no GPU or trained model.
+func StripeFeatures(img image.Image) []float32 {
+	small := resize.Resize(featureSize, featureSize, img, resize.Bilinear)
+	gray := make([][]float64, featureSize)
+	bounds := small.Bounds()
+	for y := range gray {
+		gray[y] = make([]float64, featureSize)
+		for x := range gray[y] {
+			gray[y][x] = float64(color.Gray16Model.Convert(small.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.Gray16).Y) / 0xffff
+		}
+	}
+
+	histograms := make([]float64, FeatureLength)
+	global := histograms[featureCells*featureCells*orientationBins:]
+	cellSize := featureSize / featureCells
+	for y := 1; y < featureSize-1; y++ {
+		for x := 1; x < featureSize-1; x++ {
+			dx := gray[y][x+1] - gray[y][x-1]
+			dy := gray[y+1][x] - gray[y-1][x]
+			magnitude := math.Hypot(dx, dy)
+			if magnitude == 0 {
+				continue
+			}
+			// the direction of a stripe edge does not depend on which side is darker
+			angle := math.Atan2(dy, dx)
+			if angle < 0 {
+				angle += math.Pi
+			}
+			bin := int(angle / math.Pi * orientationBins)
+			if bin == orientationBins {
+				bin = 0
+			}
+			cell := (y/cellSize)*featureCells + x/cellSize
+			histograms[cell*orientationBins+bin] += magnitude
+			global[bin] += magnitude
+		}
+	}
+
+	var norm float64
+	for _, v := range histograms {
+		norm += v * v
+	}
+	norm = math.Sqrt(norm)
+	features := make([]float32, FeatureLength)
+	if norm == 0 {
+		return features
+	}
+	for i, v := range histograms {
+		features[i] = float32(v / norm)
+	}
+	return features
+}
+
+// Similarity returns the cosine similarity of two feature vectors, from 0
+// for unrelated patterns to 1 for identical ones
+func Similarity(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+	}
+	return dot
+}
+
+// EncodeFeatures packs a feature vector for storage
+func EncodeFeatures(features []float32) []byte {
+	data := make([]byte, 4*len(features))
+	for i, v := range features {
+		binary.LittleEndian.PutUint32(data[4*i:], math.Float32bits(v))
+	}
+	return data
+}
+
+// DecodeFeatures unpacks a feature vector packed by EncodeFeatures
+func DecodeFeatures(data []byte) ([]float32, error) {
+	if len(data) != 4*FeatureLength {
+		return nil, errInvalidFeatures
+	}
+	features := make([]float32, FeatureLength)
+	for i := range features {
+		features[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[4*i:]))
+	}
+	return features, nil
+}
@@ -0,0 +1,64 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/nfnt/resize"
+)
+
+// stripes draws dark stripes at the given angle, in radians, over a light
+// background
+func stripes(width, height int, angle float64, period float64) image.Image {
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	sin, cos := math.Sincos(angle)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			v := uint8(220)
+			if math.Mod(math.Abs(float64(x)*cos+float64(y)*sin), period) < period/3 {
+				v = 30
+			}
+			img.SetGray(x, y, color.Gray{Y: v})
+		}
+	}
+	return img
+}
+
+func TestStripeFeatures(t *testing.T) {
+	reference := StripeFeatures(stripes(400, 300, math.Pi/6, 24))
+	if len(reference) != FeatureLength {
+		t.Fatalf("StripeFeatures() length = %d, want %d", len(reference), FeatureLength)
+	}
+	sameFlank := Similarity(reference, StripeFeatures(resize.Resize(200, 150, stripes(400, 300, math.Pi/6, 24), resize.Lanczos3)))
+	otherFlank := Similarity(reference, StripeFeatures(stripes(400, 300, 2*math.Pi/3, 24)))
+	if sameFlank < 0.9 {
+		t.Errorf("Similarity() of a rescaled copy = %.3f, want at least 0.9", sameFlank)
+	}
+	if otherFlank >= sameFlank {
+		t.Errorf("Similarity() of different stripes = %.3f, want below %.3f", otherFlank, sameFlank)
+	}
+}
+
+func TestStripeFeatures_blank(t *testing.T) {
+	features := StripeFeatures(image.NewGray(image.Rect(0, 0, 50, 50)))
+	if Similarity(features, features) != 0 {
+		t.Errorf("StripeFeatures() of a blank image = %v, want all zeros", features)
+	}
+}
+
+func TestEncodeFeatures(t *testing.T) {
+	features := StripeFeatures(stripes(100, 100, 0, 10))
+	got, err := DecodeFeatures(EncodeFeatures(features))
+	if err != nil {
+		t.Fatalf("DecodeFeatures() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, features) {
+		t.Errorf("DecodeFeatures() = %v, want %v", got, features)
+	}
+	if _, err := DecodeFeatures([]byte{1, 2, 3}); err == nil {
+		t.Errorf("DecodeFeatures() of a truncated vector error = nil, want error")
+	}
+}
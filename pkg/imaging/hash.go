@@ -0,0 +1,68 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"math/bits"
+
+	"github.com/nfnt/resize"
+)
+
+// DHash computes the difference hash of an image. The image is shrunk to 9x8
+// gray pixels and every bit tells whether a pixel is brighter than its right
+// neighbour, so re-encoding, rescaling or small edits barely change the hash
+// while different photos end up far apart.
+func DHash(img image.Image) uint64 {
+	small := resize.Resize(9, 8, img, resize.Bilinear)
+	var hash uint64
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			if luminance(small, x, y) > luminance(small, x+1, y) {
+				hash |= 1 << (y*8 + x)
+			}
+		}
+	}
+	return hash
+}
+
+// HashBlocks is how many blocks a hash is split into for multi-index
+// hashing. Two hashes at most d bits apart have a block that is at most
+// d/HashBlocks bits apart, so only hashes sharing such a block need to be
+// compared.
+const HashBlocks = 4
+
+// SplitHash returns the 16 bit blocks of a hash, lowest bits first
+func SplitHash(hash uint64) [HashBlocks]uint16 {
+	var blocks [HashBlocks]uint16
+	for i := range blocks {
+		blocks[i] = uint16(hash >> (16 * i))
+	}
+	return blocks
+}
+
+// BlocksWithin returns every block at most radius bits away from block,
+// block itself first
+func BlocksWithin(block uint16, radius int) []uint16 {
+	blocks := []uint16{block}
+	// flips every set of up to left bits from bit from on, each set once
+	var flip func(value uint16, from int, left int)
+	flip = func(value uint16, from int, left int) {
+		for bit := from; bit < 16 && left > 0; bit++ {
+			flipped := value ^ 1<<bit
+			blocks = append(blocks, flipped)
+			flip(flipped, bit+1, left-1)
+		}
+	}
+	flip(block, 0, radius)
+	return blocks
+}
+
+// HammingDistance returns how many bits differ between two hashes
+func HammingDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+func luminance(img image.Image, x, y int) uint16 {
+	bounds := img.Bounds()
+	return color.Gray16Model.Convert(img.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.Gray16).Y
+}
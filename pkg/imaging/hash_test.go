@@ -0,0 +1,104 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/nfnt/resize"
+)
+
+// testPattern draws soft diagonal stripes, which give a hash with plenty of
+// set and cleared bits
+func testPattern(width, height int, flip bool) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			px := x
+			if flip {
+				px = width - 1 - x
+			}
+			v := uint8((px*7/width*40 + y*255/height) % 256)
+			img.Set(x, y, color.RGBA{R: v, G: v, B: v, A: 255})
+		}
+	}
+	return img
+}
+
+func TestDHash(t *testing.T) {
+	original := DHash(testPattern(640, 480, false))
+	tests := []struct {
+		name     string
+		img      image.Image
+		wantNear bool
+	}{
+		{"identical image", testPattern(640, 480, false), true},
+		{"rescaled copy", resize.Resize(320, 240, testPattern(640, 480, false), resize.Lanczos3), true},
+		{"mirrored image", testPattern(640, 480, true), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			distance := HammingDistance(original, DHash(tt.img))
+			if tt.wantNear && distance > 4 {
+				t.Errorf("HammingDistance() = %d, want a near-identical hash", distance)
+			}
+			if !tt.wantNear && distance <= 10 {
+				t.Errorf("HammingDistance() = %d, want a different hash", distance)
+			}
+		})
+	}
+}
+
+func TestHammingDistance(t *testing.T) {
+	tests := []struct {
+		a, b uint64
+		want int
+	}{
+		{0, 0, 0},
+		{0b1011, 0b0001, 2},
+		{0, ^uint64(0), 64},
+	}
+	for _, tt := range tests {
+		if got := HammingDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("HammingDistance(%b, %b) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestSplitHash(t *testing.T) {
+	got := SplitHash(0x0004000300020001)
+	if got != [HashBlocks]uint16{1, 2, 3, 4} {
+		t.Errorf("SplitHash() = %v, want [1 2 3 4]", got)
+	}
+}
+
+func TestBlocksWithin(t *testing.T) {
+	tests := []struct {
+		name   string
+		radius int
+		want   int
+	}{
+		{name: "radius 0 is the block itself", radius: 0, want: 1},
+		{name: "radius 1 flips every bit", radius: 1, want: 1 + 16},
+		{name: "radius 2 flips every pair of bits", radius: 2, want: 1 + 16 + 120},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			block := uint16(0xa5c3)
+			got := BlocksWithin(block, tt.radius)
+			seen := map[uint16]bool{}
+			for _, b := range got {
+				if seen[b] {
+					t.Fatalf("BlocksWithin() returned %016b twice", b)
+				}
+				seen[b] = true
+				if d := HammingDistance(uint64(b), uint64(block)); d > tt.radius {
+					t.Errorf("BlocksWithin() returned %016b, %d bits away", b, d)
+				}
+			}
+			if len(got) != tt.want {
+				t.Errorf("BlocksWithin() returned %d blocks, want %d", len(got), tt.want)
+			}
+		})
+	}
+}
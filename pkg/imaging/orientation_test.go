@@ -0,0 +1,45 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestOrient(t *testing.T) {
+	// a 3x2 image whose pixels are numbered row by row:
+	//   0 1 2
+	//   3 4 5
+	src := image.NewGray(image.Rect(0, 0, 3, 2))
+	for i := range src.Pix {
+		src.Pix[i] = uint8(i)
+	}
+	tests := []struct {
+		orientation int
+		want        [][]uint8
+	}{
+		{1, [][]uint8{{0, 1, 2}, {3, 4, 5}}},
+		{2, [][]uint8{{2, 1, 0}, {5, 4, 3}}},
+		{3, [][]uint8{{5, 4, 3}, {2, 1, 0}}},
+		{4, [][]uint8{{3, 4, 5}, {0, 1, 2}}},
+		{5, [][]uint8{{0, 3}, {1, 4}, {2, 5}}},
+		{6, [][]uint8{{3, 0}, {4, 1}, {5, 2}}},
+		{7, [][]uint8{{5, 2}, {4, 1}, {3, 0}}},
+		{8, [][]uint8{{2, 5}, {1, 4}, {0, 3}}},
+	}
+	for _, tt := range tests {
+		got := Orient(src, tt.orientation)
+		size := got.Bounds().Size()
+		if size.Y != len(tt.want) || size.X != len(tt.want[0]) {
+			t.Errorf("Orient(%d) size = %v, want %dx%d", tt.orientation, size, len(tt.want[0]), len(tt.want))
+			continue
+		}
+		for y, row := range tt.want {
+			for x, want := range row {
+				if v := color.GrayModel.Convert(got.At(x, y)).(color.Gray).Y; v != want {
+					t.Errorf("Orient(%d) pixel (%d,%d) = %v, want %v", tt.orientation, x, y, v, want)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package imaging
+
+import (
+	"fmt"
+	"image"
+	"strconv"
+	"strings"
+
+	"github.com/nfnt/resize"
+)
+
+// Rendition is a named size an uploaded image is scaled down to. The image
+// fits within MaxWidth x MaxHeight with its aspect ratio kept.
+type Rendition struct {
+	Name      string
+	MaxWidth  int
+	MaxHeight int
+}
+
+// Fit scales img down so it fits within the rendition bounds, keeping its
+// aspect ratio. Images that already fit are returned untouched.
+func (r Rendition) Fit(img image.Image) image.Image {
+	return resize.Thumbnail(uint(r.MaxWidth), uint(r.MaxHeight), img, resize.Lanczos3)
+}
+
+// ParseBounds parses a "<width>x<height>" string such as "640x480"
+func ParseBounds(s string) (int, int, error) {
+	w, h, ok := strings.Cut(strings.ToLower(strings.TrimSpace(s)), "x")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid bounds %q, want <width>x<height>", s)
+	}
+	width, err := strconv.Atoi(w)
+	if err != nil || width <= 0 {
+		return 0, 0, fmt.Errorf("invalid width in bounds %q", s)
+	}
+	height, err := strconv.Atoi(h)
+	if err != nil || height <= 0 {
+		return 0, 0, fmt.Errorf("invalid height in bounds %q", s)
+	}
+	return width, height, nil
+}
@@ -0,0 +1,52 @@
+package imaging
+
+import (
+	"image"
+	"testing"
+)
+
+func TestRendition_Fit(t *testing.T) {
+	rendition := Rendition{Name: "medium", MaxWidth: 200, MaxHeight: 200}
+	tests := []struct {
+		name string
+		size image.Point
+		want image.Point
+	}{
+		{"landscape", image.Pt(800, 400), image.Pt(200, 100)},
+		{"portrait", image.Pt(300, 600), image.Pt(100, 200)},
+		{"smaller images are not upscaled", image.Pt(120, 80), image.Pt(120, 80)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := image.NewRGBA(image.Rectangle{Max: tt.size})
+			if got := rendition.Fit(img).Bounds().Size(); got != tt.want {
+				t.Errorf("Rendition.Fit() size = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseBounds(t *testing.T) {
+	tests := []struct {
+		in         string
+		wantWidth  int
+		wantHeight int
+		wantErr    bool
+	}{
+		{in: "640x480", wantWidth: 640, wantHeight: 480},
+		{in: " 1600X1200 ", wantWidth: 1600, wantHeight: 1200},
+		{in: "640", wantErr: true},
+		{in: "0x480", wantErr: true},
+		{in: "640x-1", wantErr: true},
+	}
+	for _, tt := range tests {
+		width, height, err := ParseBounds(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseBounds(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if width != tt.wantWidth || height != tt.wantHeight {
+			t.Errorf("ParseBounds(%q) = %v, %v, want %v, %v", tt.in, width, height, tt.wantWidth, tt.wantHeight)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+var (
+	pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+	errMalformed = errors.New("malformed image structure")
+)
+
+// pngMetadataChunks are the PNG chunks that can carry EXIF or free text
+var pngMetadataChunks = map[string]bool{
+	"eXIf": true,
+	"tEXt": true,
+	"iTXt": true,
+	"zTXt": true,
+	"tIME": true,
+}
+
+// StripMetadata removes EXIF, XMP and text metadata from an encoded image
+// without re-encoding it, so the device details and location a camera
+// records are not kept. The orientation of a JPEG is preserved by writing a
+// minimal EXIF block that only holds that tag.
+func StripMetadata(data []byte, format string) ([]byte, error) {
+	switch format {
+	case "jpeg":
+		return stripJPEG(data)
+	case "png":
+		return stripPNG(data)
+	case "webp":
+		return stripWebP(data)
+	}
+	return nil, errMalformed
+}
+
+func stripJPEG(data []byte) ([]byte, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, errMalformed
+	}
+	orientation := ReadExif(data).Orientation
+
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[:2])
+	wroteOrientation := orientation == 1
+	for i := 2; ; {
+		if i+4 > len(data) || data[i] != 0xFF {
+			return nil, errMalformed
+		}
+		marker := data[i+1]
+		// everything from the start of scan on is image data
+		if marker == 0xDA {
+			if !wroteOrientation {
+				out.Write(orientationSegment(orientation))
+			}
+			out.Write(data[i:])
+			return out.Bytes(), nil
+		}
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		if length < 2 || i+2+length > len(data) {
+			return nil, errMalformed
+		}
+		segment := data[i : i+2+length]
+		i += 2 + length
+
+		// the JFIF APP0 segment has to stay first, the orientation follows it
+		if marker != 0xE0 && !wroteOrientation {
+			out.Write(orientationSegment(orientation))
+			wroteOrientation = true
+		}
+		switch marker {
+		case 0xE1, 0xED, 0xFE: // Exif and XMP, Photoshop IPTC, comments
+			continue
+		}
+		out.Write(segment)
+	}
+}
+
+// orientationSegment builds an APP1 Exif segment holding only the
+// orientation tag
+func orientationSegment(orientation int) []byte {
+	var tiff bytes.Buffer
+	tiff.WriteString("MM")
+	binary.Write(&tiff, binary.BigEndian, uint16(42))
+	binary.Write(&tiff, binary.BigEndian, uint32(8))
+	binary.Write(&tiff, binary.BigEndian, uint16(1))
+	binary.Write(&tiff, binary.BigEndian, uint16(tagOrientation))
+	binary.Write(&tiff, binary.BigEndian, uint16(typeShort))
+	binary.Write(&tiff, binary.BigEndian, uint32(1))
+	binary.Write(&tiff, binary.BigEndian, uint16(orientation))
+	binary.Write(&tiff, binary.BigEndian, uint16(0))
+	binary.Write(&tiff, binary.BigEndian, uint32(0))
+
+	var segment bytes.Buffer
+	segment.Write([]byte{0xFF, 0xE1})
+	binary.Write(&segment, binary.BigEndian, uint16(2+len(exifHeader)+tiff.Len()))
+	segment.Write(exifHeader)
+	segment.Write(tiff.Bytes())
+	return segment.Bytes()
+}
+
+func stripPNG(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, errMalformed
+	}
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(pngSignature)
+	for i := len(pngSignature); i < len(data); {
+		if i+8 > len(data) {
+			return nil, errMalformed
+		}
+		length := int(binary.BigEndian.Uint32(data[i:]))
+		end := i + 12 + length // length, type, data and CRC
+		if length < 0 || end > len(data) {
+			return nil, errMalformed
+		}
+		if !pngMetadataChunks[string(data[i+4:i+8])] {
+			out.Write(data[i:end])
+		}
+		i = end
+	}
+	return out.Bytes(), nil
+}
+
+func stripWebP(data []byte) ([]byte, error) {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, errMalformed
+	}
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[:12])
+	for i := 12; i < len(data); {
+		if i+8 > len(data) {
+			return nil, errMalformed
+		}
+		size := int(binary.LittleEndian.Uint32(data[i+4:]))
+		end := i + 8 + size + size%2 // chunks are padded to an even size
+		if size < 0 || end > len(data) {
+			return nil, errMalformed
+		}
+		chunk := data[i:end]
+		i = end
+
+		switch string(chunk[:4]) {
+		case "EXIF", "XMP ":
+			continue
+		case "VP8X":
+			// clear the flags announcing EXIF and XMP chunks
+			chunk = append([]byte{}, chunk...)
+			if len(chunk) > 8 {
+				chunk[8] &^= 0x08 | 0x04
+			}
+		}
+		out.Write(chunk)
+	}
+	stripped := out.Bytes()
+	binary.LittleEndian.PutUint32(stripped[4:], uint32(len(stripped)-8))
+	return stripped, nil
+}
@@ -0,0 +1,90 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"testing"
+)
+
+func TestStripMetadata_JPEG(t *testing.T) {
+	stripped, err := StripMetadata(fieldPhoto(t, binary.LittleEndian), "jpeg")
+	if err != nil {
+		t.Fatalf("StripMetadata() error = %v", err)
+	}
+	exif := ReadExif(stripped)
+	if exif.HasGPS || exif.DateTimeOriginal != nil {
+		t.Errorf("StripMetadata() kept GPS or capture time: %+v", exif)
+	}
+	if exif.Orientation != 6 {
+		t.Errorf("StripMetadata() orientation = %v, want 6", exif.Orientation)
+	}
+	if _, _, err := image.Decode(bytes.NewReader(stripped)); err != nil {
+		t.Errorf("stripped JPEG does not decode: %v", err)
+	}
+}
+
+func TestStripMetadata_PNG(t *testing.T) {
+	data := encode(t, "png", 4, 4)
+	// insert a tEXt chunk right after IHDR
+	text := []byte("tEXtComment\x00taken at -2.5,101.26")
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(text)-4))
+	chunk = append(chunk, text...)
+	chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(text))
+	ihdrEnd := len(pngSignature) + 12 + 13
+	withText := append(append(append([]byte{}, data[:ihdrEnd]...), chunk...), data[ihdrEnd:]...)
+
+	stripped, err := StripMetadata(withText, "png")
+	if err != nil {
+		t.Fatalf("StripMetadata() error = %v", err)
+	}
+	if !bytes.Equal(stripped, data) {
+		t.Errorf("StripMetadata() did not remove the text chunk")
+	}
+}
+
+func TestStripMetadata_WebP(t *testing.T) {
+	riffChunk := func(fourCC string, payload []byte) []byte {
+		out := append([]byte(fourCC), binary.LittleEndian.AppendUint32(nil, uint32(len(payload)))...)
+		out = append(out, payload...)
+		if len(payload)%2 == 1 {
+			out = append(out, 0)
+		}
+		return out
+	}
+	vp8x := make([]byte, 10)
+	vp8x[0] = 0x08 // has EXIF
+	var body []byte
+	body = append(body, "WEBP"...)
+	body = append(body, riffChunk("VP8X", vp8x)...)
+	body = append(body, riffChunk("VP8L", webp1x1[20:33])...)
+	body = append(body, riffChunk("EXIF", []byte("MM\x00*secret"))...)
+	data := append([]byte("RIFF"), binary.LittleEndian.AppendUint32(nil, uint32(len(body)))...)
+	data = append(data, body...)
+
+	stripped, err := StripMetadata(data, "webp")
+	if err != nil {
+		t.Fatalf("StripMetadata() error = %v", err)
+	}
+	if bytes.Contains(stripped, []byte("EXIF")) || bytes.Contains(stripped, []byte("secret")) {
+		t.Errorf("StripMetadata() kept the EXIF chunk")
+	}
+	if got := binary.LittleEndian.Uint32(stripped[4:]); int(got) != len(stripped)-8 {
+		t.Errorf("StripMetadata() RIFF size = %v, want %v", got, len(stripped)-8)
+	}
+	if stripped[20]&0x08 != 0 {
+		t.Errorf("StripMetadata() kept the EXIF flag")
+	}
+	if _, _, err := image.Decode(bytes.NewReader(stripped)); err != nil {
+		t.Errorf("stripped WebP does not decode: %v", err)
+	}
+}
+
+func TestStripMetadata_Malformed(t *testing.T) {
+	for _, format := range []string{"jpeg", "png", "webp", "gif"} {
+		if _, err := StripMetadata([]byte("garbage"), format); err == nil {
+			t.Errorf("StripMetadata(%s) error = nil, want an error", format)
+		}
+	}
+}
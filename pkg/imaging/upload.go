@@ -0,0 +1,97 @@
+package imaging
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"io"
+	"net/http"
+
+	// decoders for the formats in allowedTypes
+	_ "image/jpeg"
+	_ "image/png"
+
+	_ "golang.org/x/image/webp"
+)
+
+// allowedTypes maps the content types accepted for uploads to the format name
+// image.Decode reports for them
+var allowedTypes = map[string]string{
+	"image/jpeg": "jpeg",
+	"image/png":  "png",
+	"image/webp": "webp",
+}
+
+// Limits bounds what an upload may be. They are checked before the image is
+// decoded, so a small file declaring huge dimensions is rejected early.
+type Limits struct {
+	MaxBytes  int64
+	MaxWidth  int
+	MaxHeight int
+}
+
+// ValidationError reports an upload that was rejected
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// Upload is an image that passed validation
+type Upload struct {
+	Data        []byte
+	ContentType string
+	Format      string
+	Width       int
+	Height      int
+}
+
+// ReadUpload reads an uploaded image while enforcing limits. The content type
+// is sniffed from the data rather than trusted from the client, and only
+// JPEG, PNG and WebP are accepted.
+func ReadUpload(r io.Reader, limits Limits) (*Upload, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limits.MaxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("error reading image: %w", err)
+	}
+	if int64(len(data)) > limits.MaxBytes {
+		return nil, &ValidationError{Message: fmt.Sprintf("image is larger than %d bytes", limits.MaxBytes)}
+	}
+
+	contentType := http.DetectContentType(data)
+	format, ok := allowedTypes[contentType]
+	if !ok {
+		return nil, &ValidationError{Message: fmt.Sprintf("unsupported image type %q, only JPEG, PNG and WebP are accepted", contentType)}
+	}
+
+	config, decodedFormat, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || decodedFormat != format {
+		return nil, &ValidationError{Message: "image data is corrupt or does not match its type"}
+	}
+	if config.Width <= 0 || config.Height <= 0 {
+		return nil, &ValidationError{Message: "image has no pixels"}
+	}
+	if config.Width > limits.MaxWidth || config.Height > limits.MaxHeight {
+		return nil, &ValidationError{Message: fmt.Sprintf("image is %dx%d, larger than the allowed %dx%d",
+			config.Width, config.Height, limits.MaxWidth, limits.MaxHeight)}
+	}
+
+	return &Upload{
+		Data:        data,
+		ContentType: contentType,
+		Format:      format,
+		Width:       config.Width,
+		Height:      config.Height,
+	}, nil
+}
+
+// Decode decodes the validated upload
+func (u *Upload) Decode() (image.Image, error) {
+	img, _, err := image.Decode(bytes.NewReader(u.Data))
+	if err != nil {
+		return nil, &ValidationError{Message: "image data is corrupt"}
+	}
+	return img, nil
+}
@@ -0,0 +1,72 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+// webp1x1 is a lossless 1x1 WebP
+var webp1x1 = []byte("RIFF\x1a\x00\x00\x00WEBPVP8L\x0d\x00\x00\x00\x2f\x00\x00\x00\x10\x07\x10\x11\x11\x88\x88\xfe\x07\x00")
+
+func encode(t *testing.T, format string, width, height int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	var buf bytes.Buffer
+	var err error
+	if format == "png" {
+		err = png.Encode(&buf, img)
+	} else {
+		err = jpeg.Encode(&buf, img, nil)
+	}
+	if err != nil {
+		t.Fatalf("encode %s error = %v", format, err)
+	}
+	return buf.Bytes()
+}
+
+func TestReadUpload(t *testing.T) {
+	limits := Limits{MaxBytes: 64 << 10, MaxWidth: 100, MaxHeight: 50}
+	pngData := encode(t, "png", 40, 30)
+	tests := []struct {
+		name            string
+		data            []byte
+		wantContentType string
+		wantWidth       int
+		wantInvalid     bool
+	}{
+		{name: "jpeg", data: encode(t, "jpeg", 100, 50), wantContentType: "image/jpeg", wantWidth: 100},
+		{name: "png", data: pngData, wantContentType: "image/png", wantWidth: 40},
+		{name: "webp", data: webp1x1, wantContentType: "image/webp", wantWidth: 1},
+		{name: "too many bytes", data: make([]byte, 64<<10+1), wantInvalid: true},
+		{name: "type not allowed", data: []byte("GIF89a\x01\x00\x01\x00"), wantInvalid: true},
+		{name: "plain text", data: []byte("hello"), wantInvalid: true},
+		{name: "too wide", data: encode(t, "png", 101, 10), wantInvalid: true},
+		{name: "too tall", data: encode(t, "jpeg", 10, 51), wantInvalid: true},
+		{name: "truncated header", data: pngData[:20], wantInvalid: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadUpload(bytes.NewReader(tt.data), limits)
+			var validationErr *ValidationError
+			if tt.wantInvalid {
+				if !errors.As(err, &validationErr) {
+					t.Fatalf("ReadUpload() error = %v, want a ValidationError", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadUpload() error = %v", err)
+			}
+			if got.ContentType != tt.wantContentType || got.Width != tt.wantWidth {
+				t.Errorf("ReadUpload() = %v %dpx wide, want %v %dpx wide", got.ContentType, got.Width, tt.wantContentType, tt.wantWidth)
+			}
+			if _, err := got.Decode(); err != nil {
+				t.Errorf("Upload.Decode() error = %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package notifier
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryNotifier keeps the notifications it is asked to send, for tests
+type MemoryNotifier struct {
+	mu   sync.Mutex
+	sent []*Message
+	// Err, when set, is returned by Send instead of keeping the message
+	Err error
+}
+
+// NewMemoryNotifier creates an empty MemoryNotifier
+func NewMemoryNotifier() *MemoryNotifier {
+	return &MemoryNotifier{}
+}
+
+func (n *MemoryNotifier) Send(ctx context.Context, msg *Message) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.Err != nil {
+		return n.Err
+	}
+	copied := *msg
+	n.sent = append(n.sent, &copied)
+	return nil
+}
+
+// Sent returns the messages sent so far, oldest first
+func (n *MemoryNotifier) Sent() []*Message {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]*Message(nil), n.sent...)
+}
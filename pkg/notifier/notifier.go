@@ -0,0 +1,24 @@
+package notifier
+
+import (
+	"context"
+)
+
+// Message is a notification for a single recipient. Body is plain text,
+// HTMLBody is optional and offered as an alternative to it.
+type Message struct {
+	ToName    string
+	ToAddress string
+	Subject   string
+	Body      string
+	HTMLBody  string
+	// UnsubscribeURL, when set, is offered to mail clients as a one-click
+	// unsubscribe link (RFC 8058)
+	UnsubscribeURL string
+}
+
+//go:generate mockgen -source=notifier.go -destination=mock/notifier.go -package=mock
+type Notifier interface {
+	// Send delivers msg to its recipient
+	Send(ctx context.Context, msg *Message) error
+}
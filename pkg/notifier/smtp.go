@@ -0,0 +1,202 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TLSMode is how the connection to the SMTP server is secured
+type TLSMode string
+
+const (
+	// TLSNone sends in plain text, only meant for local relays
+	TLSNone TLSMode = "none"
+	// TLSStartTLS upgrades a plain connection and refuses servers that
+	// cannot, usually on port 587
+	TLSStartTLS TLSMode = "starttls"
+	// TLSImplicit connects over TLS from the start, usually on port 465
+	TLSImplicit TLSMode = "tls"
+)
+
+const smtpDialTimeout = 10 * time.Second
+
+// SMTPConfig configures an SMTP notifier. Username and Password are optional,
+// authentication is skipped without them.
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	TLSMode  TLSMode
+	Username string
+	Password string
+	From     string
+	FromName string
+
+	// TLSConfig overrides the TLS settings, by default the server
+	// certificate is verified against Host
+	TLSConfig *tls.Config
+}
+
+type smtpNotifier struct {
+	config SMTPConfig
+	from   mail.Address
+}
+
+// NewSMTPNotifier sends notifications as emails through an SMTP server
+func NewSMTPNotifier(config SMTPConfig) (Notifier, error) {
+	if config.Host == "" {
+		return nil, fmt.Errorf("smtp host is required")
+	}
+	if config.Port <= 0 {
+		return nil, fmt.Errorf("invalid smtp port %d", config.Port)
+	}
+	switch config.TLSMode {
+	case TLSNone, TLSStartTLS, TLSImplicit:
+	default:
+		return nil, fmt.Errorf("unknown smtp tls mode %q", config.TLSMode)
+	}
+	from, err := mail.ParseAddress(config.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid from address %q: %w", config.From, err)
+	}
+	if config.FromName != "" {
+		from.Name = config.FromName
+	}
+	if config.TLSConfig == nil {
+		config.TLSConfig = &tls.Config{ServerName: config.Host}
+	}
+	return &smtpNotifier{config: config, from: *from}, nil
+}
+
+func (n *smtpNotifier) Send(ctx context.Context, msg *Message) error {
+	to, err := mail.ParseAddress(msg.ToAddress)
+	if err != nil {
+		return fmt.Errorf("invalid recipient address %q: %w", msg.ToAddress, err)
+	}
+	to.Name = msg.ToName
+
+	client, err := n.dial(ctx)
+	if err != nil {
+		return fmt.Errorf("error connecting to smtp server: %w", err)
+	}
+	defer client.Close()
+
+	if n.config.TLSMode == TLSStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("smtp server does not support STARTTLS")
+		}
+		if err := client.StartTLS(n.config.TLSConfig); err != nil {
+			return fmt.Errorf("error starting tls: %w", err)
+		}
+	}
+	if n.config.Username != "" {
+		auth := smtp.PlainAuth("", n.config.Username, n.config.Password, n.config.Host)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("error authenticating: %w", err)
+		}
+	}
+
+	if err := client.Mail(n.from.Address); err != nil {
+		return fmt.Errorf("error setting sender: %w", err)
+	}
+	if err := client.Rcpt(to.Address); err != nil {
+		return fmt.Errorf("error setting recipient: %w", err)
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("error starting message: %w", err)
+	}
+	if _, err := w.Write(n.compose(to, msg)); err != nil {
+		w.Close()
+		return fmt.Errorf("error writing message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("error sending message: %w", err)
+	}
+	return client.Quit()
+}
+
+func (n *smtpNotifier) dial(ctx context.Context) (*smtp.Client, error) {
+	address := net.JoinHostPort(n.config.Host, strconv.Itoa(n.config.Port))
+	dialer := &net.Dialer{Timeout: smtpDialTimeout}
+	var conn net.Conn
+	var err error
+	if n.config.TLSMode == TLSImplicit {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: n.config.TLSConfig}).DialContext(ctx, "tcp", address)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", address)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	client, err := smtp.NewClient(conn, n.config.Host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return client, nil
+}
+
+// compose builds the message headers and body. Names and the subject are
+// encoded so they can hold any character.
+func (n *smtpNotifier) compose(to *mail.Address, msg *Message) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", n.from.String())
+	fmt.Fprintf(&buf, "To: %s\r\n", to.String())
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: <%s@%s>\r\n", uuid.NewString(), n.config.Host)
+	if msg.UnsubscribeURL != "" {
+		fmt.Fprintf(&buf, "List-Unsubscribe: <%s>\r\n", msg.UnsubscribeURL)
+		buf.WriteString("List-Unsubscribe-Post: List-Unsubscribe=One-Click\r\n")
+	}
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	if msg.HTMLBody == "" {
+		buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+		buf.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+		buf.WriteString("\r\n")
+		writeText(&buf, msg.Body)
+		return buf.Bytes()
+	}
+
+	parts := multipart.NewWriter(&buf)
+	fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", parts.Boundary())
+	text, _ := parts.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/plain; charset=utf-8"},
+		"Content-Transfer-Encoding": {"8bit"},
+	})
+	writeText(text, msg.Body)
+	// quoted-printable keeps the long lines of generated HTML within the
+	// line length limit of SMTP
+	html, _ := parts.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/html; charset=utf-8"},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	qp := quotedprintable.NewWriter(html)
+	qp.Write([]byte(msg.HTMLBody))
+	qp.Close()
+	parts.Close()
+	return buf.Bytes()
+}
+
+// writeText writes a plain text body with CRLF line endings
+func writeText(w io.Writer, body string) {
+	w.Write(bytes.ReplaceAll([]byte(body), []byte("\n"), []byte("\r\n")))
+	w.Write([]byte("\r\n"))
+}
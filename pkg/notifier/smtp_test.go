@@ -0,0 +1,350 @@
+package notifier
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"io"
+	"math/big"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	testUsername = "kittens"
+	testPassword = "secret"
+)
+
+// fakeSMTP is a local stand-in for an SMTP server. It speaks enough of the
+// protocol for net/smtp, checks PLAIN credentials and keeps what it receives.
+type fakeSMTP struct {
+	listener    net.Listener
+	tlsConfig   *tls.Config
+	implicitTLS bool
+	startTLS    bool
+
+	mu       sync.Mutex
+	received []receivedMail
+}
+
+type receivedMail struct {
+	from, to string
+	data     string
+	tls      bool
+	authed   bool
+}
+
+func newFakeSMTP(t *testing.T, tlsConfig *tls.Config, implicitTLS bool, startTLS bool) *fakeSMTP {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	f := &fakeSMTP{listener: listener, tlsConfig: tlsConfig, implicitTLS: implicitTLS, startTLS: startTLS}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return f
+}
+
+func (f *fakeSMTP) port() int {
+	return f.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (f *fakeSMTP) mails() []receivedMail {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]receivedMail(nil), f.received...)
+}
+
+func (f *fakeSMTP) serve(conn net.Conn) {
+	defer func() { conn.Close() }()
+	secure := false
+	if f.implicitTLS {
+		conn = tls.Server(conn, f.tlsConfig)
+		secure = true
+	}
+	reader := bufio.NewReader(conn)
+	reply := func(s string) { conn.Write([]byte(s + "\r\n")) }
+	var current receivedMail
+	authed := false
+
+	reply("220 localhost ESMTP fake")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch verb {
+		case "EHLO", "HELO":
+			lines := []string{"localhost"}
+			if f.startTLS && !secure {
+				lines = append(lines, "STARTTLS")
+			}
+			lines = append(lines, "AUTH PLAIN", "8BITMIME")
+			for i, l := range lines {
+				sep := "-"
+				if i == len(lines)-1 {
+					sep = " "
+				}
+				reply("250" + sep + l)
+			}
+		case "STARTTLS":
+			reply("220 ready to start TLS")
+			conn = tls.Server(conn, f.tlsConfig)
+			reader = bufio.NewReader(conn)
+			secure = true
+		case "AUTH":
+			parts := strings.Fields(line)
+			decoded, _ := base64.StdEncoding.DecodeString(parts[len(parts)-1])
+			if string(decoded) != "\x00"+testUsername+"\x00"+testPassword {
+				reply("535 authentication failed")
+				continue
+			}
+			authed = true
+			reply("235 authenticated")
+		case "MAIL":
+			current = receivedMail{from: smtpPath(line[5:], "FROM:"), tls: secure, authed: authed}
+			reply("250 ok")
+		case "RCPT":
+			current.to = smtpPath(line[5:], "TO:")
+			reply("250 ok")
+		case "DATA":
+			reply("354 end with .")
+			var data strings.Builder
+			for {
+				l, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(l)
+			}
+			current.data = data.String()
+			f.mu.Lock()
+			f.received = append(f.received, current)
+			f.mu.Unlock()
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+// smtpPath extracts the address of a MAIL FROM or RCPT TO argument, dropping
+// parameters such as BODY=8BITMIME
+func smtpPath(arg, prefix string) string {
+	path := strings.Fields(strings.TrimPrefix(arg, prefix))[0]
+	return strings.Trim(path, "<>")
+}
+
+// testTLS returns a server config with a self-signed certificate for
+// 127.0.0.1 and a client config trusting it
+func testTLS(t *testing.T) (*tls.Config, *tls.Config) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("ecdsa.GenerateKey() error = %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("x509.CreateCertificate() error = %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	server := &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+	client := &tls.Config{ServerName: "127.0.0.1", RootCAs: pool}
+	return server, client
+}
+
+func TestNewSMTPNotifier(t *testing.T) {
+	valid := SMTPConfig{Host: "smtp.example.com", Port: 587, TLSMode: TLSStartTLS, From: "alerts@example.com"}
+	tests := []struct {
+		name    string
+		change  func(c *SMTPConfig)
+		wantErr bool
+	}{
+		{name: "valid", change: func(c *SMTPConfig) {}},
+		{name: "missing host", change: func(c *SMTPConfig) { c.Host = "" }, wantErr: true},
+		{name: "invalid port", change: func(c *SMTPConfig) { c.Port = 0 }, wantErr: true},
+		{name: "unknown tls mode", change: func(c *SMTPConfig) { c.TLSMode = "ssl3" }, wantErr: true},
+		{name: "invalid from address", change: func(c *SMTPConfig) { c.From = "not an address" }, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := valid
+			tt.change(&config)
+			if _, err := NewSMTPNotifier(config); (err != nil) != tt.wantErr {
+				t.Errorf("NewSMTPNotifier() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_smtpNotifier_Send(t *testing.T) {
+	serverTLS, clientTLS := testTLS(t)
+	msg := &Message{
+		ToName:    "Siti Rahma",
+		ToAddress: "siti@example.com",
+		Subject:   "New sighting of Harimau",
+		Body:      "Hello Siti,\nHarimau was seen again.",
+
+		UnsubscribeURL: "https://tigerhall.example/unsubscribe/u1?expires=1&signature=s",
+	}
+	tests := []struct {
+		name        string
+		implicitTLS bool
+		offerTLS    bool
+		mode        TLSMode
+		password    string
+		wantTLS     bool
+		wantErr     bool
+	}{
+		{name: "plain connection with auth", mode: TLSNone, password: testPassword},
+		{name: "starttls", offerTLS: true, mode: TLSStartTLS, password: testPassword, wantTLS: true},
+		{name: "implicit tls", implicitTLS: true, mode: TLSImplicit, password: testPassword, wantTLS: true},
+		{name: "starttls refused by the server", mode: TLSStartTLS, password: testPassword, wantErr: true},
+		{name: "wrong credentials", mode: TLSNone, password: "wrong", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeSMTP(t, serverTLS, tt.implicitTLS, tt.offerTLS)
+			n, err := NewSMTPNotifier(SMTPConfig{
+				Host:      "127.0.0.1",
+				Port:      server.port(),
+				TLSMode:   tt.mode,
+				Username:  testUsername,
+				Password:  tt.password,
+				From:      "alerts@tigerhall.example",
+				FromName:  "TigerHall Kittens",
+				TLSConfig: clientTLS,
+			})
+			if err != nil {
+				t.Fatalf("NewSMTPNotifier() error = %v", err)
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			err = n.Send(ctx, msg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("smtpNotifier.Send() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			mails := server.mails()
+			if len(mails) != 1 {
+				t.Fatalf("server received %d mails, want 1", len(mails))
+			}
+			got := mails[0]
+			if got.from != "alerts@tigerhall.example" || got.to != "siti@example.com" || !got.authed || got.tls != tt.wantTLS {
+				t.Errorf("server received %+v", got)
+			}
+			parsed, err := mail.ReadMessage(strings.NewReader(got.data))
+			if err != nil {
+				t.Fatalf("mail.ReadMessage() error = %v", err)
+			}
+			if to := parsed.Header.Get("To"); to != `"Siti Rahma" <siti@example.com>` {
+				t.Errorf("To header = %q", to)
+			}
+			if subject := parsed.Header.Get("Subject"); subject != msg.Subject {
+				t.Errorf("Subject header = %q, want %q", subject, msg.Subject)
+			}
+			if unsubscribe := parsed.Header.Get("List-Unsubscribe"); unsubscribe != "<"+msg.UnsubscribeURL+">" {
+				t.Errorf("List-Unsubscribe header = %q", unsubscribe)
+			}
+			if !strings.Contains(got.data, "Hello Siti,\r\nHarimau was seen again.") {
+				t.Errorf("body = %q", got.data)
+			}
+		})
+	}
+}
+
+func Test_smtpNotifier_compose_html(t *testing.T) {
+	n := &smtpNotifier{config: SMTPConfig{Host: "127.0.0.1"}, from: mail.Address{Address: "alerts@tigerhall.example"}}
+	msg := &Message{
+		ToAddress: "siti@example.com",
+		Subject:   "New sighting of Harimau",
+		Body:      "Harimau was seen again.",
+		HTMLBody:  "<p>Harimau was seen <b>again</b>.</p><img src=\"https://tigerhall.example/images/a.jpg?expires=1&signature=s\">",
+	}
+	parsed, err := mail.ReadMessage(bytes.NewReader(n.compose(&mail.Address{Address: msg.ToAddress}, msg)))
+	if err != nil {
+		t.Fatalf("mail.ReadMessage() error = %v", err)
+	}
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q", parsed.Header.Get("Content-Type"))
+	}
+	// multipart.Reader decodes quoted-printable parts
+	reader := multipart.NewReader(parsed.Body, params["boundary"])
+	var got []string
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("multipart.Reader.NextPart() error = %v", err)
+		}
+		body, _ := io.ReadAll(part)
+		got = append(got, part.Header.Get("Content-Type")+": "+strings.TrimSpace(string(body)))
+	}
+	want := []string{
+		"text/plain; charset=utf-8: " + msg.Body,
+		"text/html; charset=utf-8: " + msg.HTMLBody,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parts = %q, want %q", got, want)
+	}
+}
+
+func Test_smtpNotifier_Send_unreachable(t *testing.T) {
+	listener, _ := net.Listen("tcp", "127.0.0.1:0")
+	port, _ := strconv.Atoi(strings.Split(listener.Addr().String(), ":")[1])
+	listener.Close()
+
+	n, _ := NewSMTPNotifier(SMTPConfig{Host: "127.0.0.1", Port: port, TLSMode: TLSNone, From: "alerts@tigerhall.example"})
+	if err := n.Send(context.Background(), &Message{ToAddress: "siti@example.com"}); err == nil {
+		t.Errorf("smtpNotifier.Send() error = nil, want error")
+	}
+}
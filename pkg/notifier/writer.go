@@ -0,0 +1,36 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+type writerNotifier struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriterNotifier writes notifications to w instead of delivering them,
+// for development without a mail server. w is typically stdout or a file.
+func NewWriterNotifier(w io.Writer) Notifier {
+	return &writerNotifier{w: w}
+}
+
+func (n *writerNotifier) Send(ctx context.Context, msg *Message) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if _, err := fmt.Fprintf(n.w, "--- %s\nTo: %s <%s>\nSubject: %s\n",
+		time.Now().Format(time.RFC3339), msg.ToName, msg.ToAddress, msg.Subject); err != nil {
+		return err
+	}
+	if msg.UnsubscribeURL != "" {
+		if _, err := fmt.Fprintf(n.w, "List-Unsubscribe: <%s>\n", msg.UnsubscribeURL); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(n.w, "\n%s\n\n", msg.Body)
+	return err
+}
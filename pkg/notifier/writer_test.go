@@ -0,0 +1,43 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_writerNotifier_Send(t *testing.T) {
+	var buf bytes.Buffer
+	n := NewWriterNotifier(&buf)
+	if err := n.Send(context.Background(), &Message{ToName: "Siti", ToAddress: "siti@example.com", Subject: "Hi", Body: "Harimau was seen",
+		UnsubscribeURL: "https://tigerhall.example/unsubscribe/u1"}); err != nil {
+		t.Fatalf("writerNotifier.Send() error = %v", err)
+	}
+	for _, want := range []string{"To: Siti <siti@example.com>", "Subject: Hi", "List-Unsubscribe: <https://tigerhall.example/unsubscribe/u1>", "Harimau was seen"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("writerNotifier.Send() wrote %q, want it to contain %q", buf.String(), want)
+		}
+	}
+}
+
+func TestMemoryNotifier(t *testing.T) {
+	n := NewMemoryNotifier()
+	msg := &Message{ToAddress: "siti@example.com", Subject: "Hi"}
+	if err := n.Send(context.Background(), msg); err != nil {
+		t.Fatalf("MemoryNotifier.Send() error = %v", err)
+	}
+	if got := n.Sent(); !reflect.DeepEqual(got, []*Message{msg}) {
+		t.Errorf("MemoryNotifier.Sent() = %v, want %v", got, []*Message{msg})
+	}
+
+	n.Err = errors.New("any error")
+	if err := n.Send(context.Background(), msg); err == nil {
+		t.Errorf("MemoryNotifier.Send() error = nil, want error")
+	}
+	if len(n.Sent()) != 1 {
+		t.Errorf("MemoryNotifier.Sent() kept a message that failed")
+	}
+}
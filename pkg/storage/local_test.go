@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestLocalStore_PutGetDelete(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalStore() error = %v", err)
+	}
+	key := "sightings/abc/original.jpg"
+	data := []byte("jpeg bytes")
+
+	if err := store.Put(ctx, key, bytes.NewReader(data), int64(len(data)), "image/jpeg"); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	rc, info, err := store.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	got, _ := io.ReadAll(rc)
+	rc.Close()
+	if !bytes.Equal(got, data) || info.Size != int64(len(data)) || info.ContentType != "image/jpeg" {
+		t.Errorf("Get() = %q %+v, want %q", got, info, data)
+	}
+
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, _, err := store.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() after Delete() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestLocalStore_RejectsKeysOutsideRoot(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalStore() error = %v", err)
+	}
+	for _, key := range []string{"", "../escape.jpg", "a/../../escape.jpg", "/absolute.jpg"} {
+		if err := store.Put(context.Background(), key, strings.NewReader("x"), 1, "image/jpeg"); err == nil {
+			t.Errorf("Put(%q) error = nil, want invalid key error", key)
+		}
+	}
+}
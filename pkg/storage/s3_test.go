@@ -0,0 +1,172 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	testAccessKey = "AKIDEXAMPLE"
+	testSecretKey = "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"
+	testRegion    = "ap-southeast-1"
+	testBucket    = "sightings"
+)
+
+// fakeS3 is a local stand-in for an S3 compatible server. It keeps objects in
+// memory and rejects requests whose signature does not verify.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string]fakeObject
+}
+
+type fakeObject struct {
+	data        []byte
+	contentType string
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := verifySignature(r); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	prefix := "/" + testBucket + "/"
+	if !strings.HasPrefix(r.URL.Path, prefix) {
+		http.Error(w, "NoSuchBucket", http.StatusNotFound)
+		return
+	}
+	key := strings.TrimPrefix(r.URL.Path, prefix)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.Method {
+	case http.MethodPut:
+		data, _ := io.ReadAll(r.Body)
+		f.objects[key] = fakeObject{data: data, contentType: r.Header.Get("Content-Type")}
+	case http.MethodGet:
+		object, ok := f.objects[key]
+		if !ok {
+			http.Error(w, "NoSuchKey", http.StatusNotFound)
+			return
+		}
+		sum := sha256.Sum256(object.data)
+		w.Header().Set("Content-Type", object.contentType)
+		w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:8])+`"`)
+		w.Write(object.data)
+	case http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// verifySignature recomputes the Signature Version 4 signature from the
+// request as received by the server.
+func verifySignature(r *http.Request) error {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, s3Algorithm+" ") {
+		return errors.New("missing signature")
+	}
+	parts := map[string]string{}
+	for _, part := range strings.Split(strings.TrimPrefix(auth, s3Algorithm+" "), ", ") {
+		kv := strings.SplitN(part, "=", 2)
+		parts[kv[0]] = kv[1]
+	}
+	credential := strings.Split(parts["Credential"], "/")
+	if len(credential) != 5 || credential[0] != testAccessKey || credential[2] != testRegion {
+		return errors.New("invalid credential")
+	}
+
+	names := strings.Split(parts["SignedHeaders"], ";")
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		value := r.Header.Get(name)
+		if name == "host" {
+			value = r.Host
+		}
+		canonicalHeaders.WriteString(name + ":" + value + "\n")
+	}
+	canonicalRequest := strings.Join([]string{
+		r.Method, r.URL.EscapedPath(), r.URL.Query().Encode(), canonicalHeaders.String(),
+		parts["SignedHeaders"], r.Header.Get("X-Amz-Content-Sha256"),
+	}, "\n")
+	scope := strings.Join(credential[1:], "/")
+	stringToSign := strings.Join([]string{s3Algorithm, r.Header.Get("X-Amz-Date"), scope, hashHex([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+testSecretKey), credential[1])
+	for _, step := range credential[2:] {
+		key = hmacSHA256(key, step)
+	}
+	if hex.EncodeToString(hmacSHA256(key, stringToSign)) != parts["Signature"] {
+		return errors.New("signature does not match")
+	}
+	return nil
+}
+
+func newTestS3Store(t *testing.T, secretKey string) BlobStore {
+	server := httptest.NewServer(&fakeS3{objects: map[string]fakeObject{}})
+	t.Cleanup(server.Close)
+	store, err := NewS3Store(S3Config{
+		Endpoint:  server.URL,
+		Region:    testRegion,
+		Bucket:    testBucket,
+		AccessKey: testAccessKey,
+		SecretKey: secretKey,
+	}, server.Client())
+	if err != nil {
+		t.Fatalf("NewS3Store() error = %v", err)
+	}
+	store.(*s3Store).now = func() time.Time { return time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC) }
+	return store
+}
+
+func TestS3Store_PutGetDelete(t *testing.T) {
+	ctx := context.Background()
+	store := newTestS3Store(t, testSecretKey)
+	key := "sightings/abc/original.jpg"
+	data := []byte("jpeg bytes")
+
+	if err := store.Put(ctx, key, bytes.NewReader(data), int64(len(data)), "image/jpeg"); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	rc, info, err := store.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	got, _ := io.ReadAll(rc)
+	rc.Close()
+	if !bytes.Equal(got, data) || info.ContentType != "image/jpeg" || info.ETag == "" {
+		t.Errorf("Get() = %q %+v, want %q with image/jpeg and an etag", got, info, data)
+	}
+
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, _, err := store.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() after Delete() error = %v, want ErrNotFound", err)
+	}
+	if err := store.Delete(ctx, key); err != nil {
+		t.Errorf("Delete() of a missing key error = %v, want nil", err)
+	}
+}
+
+func TestS3Store_InvalidCredentials(t *testing.T) {
+	store := newTestS3Store(t, "wrong-secret")
+	err := store.Put(context.Background(), "key.jpg", strings.NewReader("x"), 1, "image/jpeg")
+	if err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("Put() error = %v, want a 403 error", err)
+	}
+}
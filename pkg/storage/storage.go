@@ -0,0 +1,29 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrNotFound is returned when no object is stored under a key
+var ErrNotFound = errors.New("object not found")
+
+// ObjectInfo describes a stored object
+type ObjectInfo struct {
+	Size        int64
+	ContentType string
+	ETag        string
+	ModTime     time.Time
+}
+
+//go:generate mockgen -source=storage.go -destination=mock/storage.go -package=mock
+type BlobStore interface {
+	// Put stores the content of r under key, replacing any existing object
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get opens the object stored under key. The caller must close the reader.
+	Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error)
+	// Delete removes the object stored under key. Deleting a missing key is not an error.
+	Delete(ctx context.Context, key string) error
+}
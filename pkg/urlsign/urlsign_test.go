@@ -0,0 +1,80 @@
+package urlsign
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestSigner_SignedURL(t *testing.T) {
+	now := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	signer := NewSigner("secret", "http://localhost:8080/")
+	signer.now = func() time.Time { return now }
+
+	signed, err := url.Parse(signer.SignedURL("/images/sightings/a.jpg", time.Hour))
+	if err != nil {
+		t.Fatalf("SignedURL() returned an invalid URL: %v", err)
+	}
+	if signed.Host != "localhost:8080" || signed.Path != "/images/sightings/a.jpg" {
+		t.Errorf("SignedURL() = %v", signed)
+	}
+	query := signed.Query()
+
+	tests := []struct {
+		name      string
+		path      string
+		expires   string
+		signature string
+		at        time.Time
+		wantErr   error
+	}{
+		{
+			name:      "valid url",
+			path:      "/images/sightings/a.jpg",
+			expires:   query.Get("expires"),
+			signature: query.Get("signature"),
+			at:        now.Add(59 * time.Minute),
+		},
+		{
+			name:      "expired url",
+			path:      "/images/sightings/a.jpg",
+			expires:   query.Get("expires"),
+			signature: query.Get("signature"),
+			at:        now.Add(time.Hour),
+			wantErr:   ErrExpired,
+		},
+		{
+			name:      "signature for another path",
+			path:      "/images/sightings/b.jpg",
+			expires:   query.Get("expires"),
+			signature: query.Get("signature"),
+			at:        now,
+			wantErr:   ErrInvalidSignature,
+		},
+		{
+			name:      "extended expiry",
+			path:      "/images/sightings/a.jpg",
+			expires:   "99999999999",
+			signature: query.Get("signature"),
+			at:        now,
+			wantErr:   ErrInvalidSignature,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer.now = func() time.Time { return tt.at }
+			_, err := signer.Verify(tt.path, tt.expires, tt.signature)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSigner_URL(t *testing.T) {
+	signer := NewSigner("secret", "http://localhost:8080/")
+	if got, want := signer.URL("/tigers/a b/track"), "http://localhost:8080/tigers/a%20b/track"; got != want {
+		t.Errorf("URL() = %v, want %v", got, want)
+	}
+}